@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"time"
@@ -10,6 +11,7 @@ import (
 	"github.com/zees-dev/zeth/pkg/app"
 	"github.com/zees-dev/zeth/pkg/datastore"
 	"github.com/zees-dev/zeth/pkg/httprest"
+	"github.com/zees-dev/zeth/pkg/managed"
 )
 
 // initializeGlobalLogger initializes the global zerolog logger.
@@ -51,9 +53,30 @@ func main() {
 		log.Err(err).Msg("failed to init app")
 		os.Exit(1)
 	}
+	defer func() {
+		// flush pending spans
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := app.Services.Tracing.Shutdown(ctx); err != nil {
+			log.Err(err).Msg("failed to shutdown tracing")
+		}
+	}()
+
+	// start background services
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	app.Start(ctx)
 
 	r := httprest.Routing(app)
-	if err := httprest.Start(app, r); err != nil {
+	err = httprest.Start(app, r)
+
+	// stop managed nodes gracefully before the datastore is closed
+	cancel()
+	stopCtx, stopCancel := context.WithTimeout(context.Background(), managed.DefaultStopTimeout)
+	defer stopCancel()
+	app.Stop(stopCtx)
+
+	if err != nil {
 		log.Err(err).Msg("failed to start http server")
 		os.Exit(1)
 	}
@@ -0,0 +1,185 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"text/template"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// RuleKind is the condition an alert rule evaluates.
+type RuleKind string
+
+const (
+	KindErrorRate       RuleKind = "error_rate"        // ratio of failed proxied requests in the window exceeds the threshold
+	KindLatencyP95      RuleKind = "p95_latency"       // p95 latency (ms) of proxied requests in the window exceeds the threshold
+	KindNodeUnreachable RuleKind = "node_unreachable"  // the node does not respond to eth_blockNumber
+	KindBlockLag        RuleKind = "block_lag"         // the node's head is more than threshold blocks behind the highest head on its chain
+	KindChainIDMismatch RuleKind = "chain_id_mismatch" // the node's eth_chainId differs from the expected chain ID
+)
+
+func (k RuleKind) IsValid() bool {
+	switch k {
+	case KindErrorRate, KindLatencyP95, KindNodeUnreachable, KindBlockLag, KindChainIDMismatch:
+		return true
+	}
+	return false
+}
+
+// isTrafficKind returns whether the rule is evaluated against proxied requests, rather than node probes.
+func (k RuleKind) isTrafficKind() bool {
+	return k == KindErrorRate || k == KindLatencyP95
+}
+
+// Defaults; used if not specified in a rule.
+const (
+	DefaultWindow      = 5 * time.Minute
+	DefaultMinRequests = 10
+	DefaultMaxRetries  = 3
+	maxWindow          = time.Hour
+)
+
+// Webhook is an HTTP endpoint notifications of a rule are POSTed to.
+type Webhook struct {
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// Template is a text/template of the request body executed with a Notification; the notification is sent as JSON if empty
+	Template   string `json:"template,omitempty"`
+	MaxRetries *int   `json:"maxRetries,omitempty"` // retries of failed deliveries; defaults to 3
+}
+
+// Rule is a user-defined alert rule.
+type Rule struct {
+	ID      uuid.UUID   `json:"id"`
+	Name    string      `json:"name"`
+	Enabled bool        `json:"enabled"`
+	Kind    RuleKind    `json:"kind"`
+	NodeIDs []uuid.UUID `json:"nodeIds"` // nodes the rule applies to; empty applies to all enabled nodes
+
+	WindowSeconds int64   `json:"windowSeconds,omitempty"` // error_rate, p95_latency
+	MinRequests   int     `json:"minRequests,omitempty"`   // error_rate, p95_latency; requests required in the window to evaluate
+	Threshold     float64 `json:"threshold"`               // error_rate: ratio in [0, 1]; p95_latency: milliseconds; block_lag: blocks
+	ChainID       uint64  `json:"chainId,omitempty"`       // chain_id_mismatch
+
+	Webhooks  []Webhook `json:"webhooks"`
+	DateAdded time.Time `json:"dateAdded"`
+}
+
+// Validate returns an error if the rule is invalid.
+func (r Rule) Validate() error {
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	if !r.Kind.IsValid() {
+		return fmt.Errorf("unknown rule kind %q", r.Kind)
+	}
+	if r.WindowSeconds < 0 || time.Duration(r.WindowSeconds)*time.Second > maxWindow {
+		return fmt.Errorf("window must be between 0 and %d seconds", int64(maxWindow.Seconds()))
+	}
+	if r.MinRequests < 0 {
+		return errors.New("min requests must not be negative")
+	}
+	switch r.Kind {
+	case KindErrorRate:
+		if r.Threshold < 0 || r.Threshold > 1 {
+			return errors.New("error rate threshold must be between 0 and 1")
+		}
+	case KindLatencyP95, KindBlockLag:
+		if r.Threshold < 0 {
+			return errors.New("threshold must not be negative")
+		}
+	case KindChainIDMismatch:
+		if r.ChainID == 0 {
+			return errors.New("chain id is required")
+		}
+	}
+	for i, w := range r.Webhooks {
+		u, err := url.Parse(w.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("webhook %d: invalid url", i)
+		}
+		if w.Template != "" {
+			if _, err := template.New("").Funcs(templateFuncs).Parse(w.Template); err != nil {
+				return fmt.Errorf("webhook %d: invalid template: %w", i, err)
+			}
+		}
+		if w.MaxRetries != nil && *w.MaxRetries < 0 {
+			return fmt.Errorf("webhook %d: max retries must not be negative", i)
+		}
+	}
+	return nil
+}
+
+func (r Rule) window() time.Duration {
+	if r.WindowSeconds == 0 {
+		return DefaultWindow
+	}
+	return time.Duration(r.WindowSeconds) * time.Second
+}
+
+func (r Rule) minRequests() int {
+	if r.MinRequests == 0 {
+		return DefaultMinRequests
+	}
+	return r.MinRequests
+}
+
+// appliesTo returns whether the rule applies to the node.
+func (r Rule) appliesTo(id uuid.UUID) bool {
+	if len(r.NodeIDs) == 0 {
+		return true
+	}
+	for _, nodeID := range r.NodeIDs {
+		if uuid.Equal(nodeID, id) {
+			return true
+		}
+	}
+	return false
+}
+
+// Status is the status of an alert.
+type Status string
+
+const (
+	StatusFiring   Status = "firing"
+	StatusResolved Status = "resolved"
+)
+
+// State is the state of a rule for a node.
+type State struct {
+	RuleID    uuid.UUID `json:"ruleId"`
+	NodeID    uuid.UUID `json:"nodeId"`
+	NodeName  string    `json:"nodeName"`
+	Status    Status    `json:"status"`
+	Value     float64   `json:"value"`
+	Message   string    `json:"message"`
+	Since     time.Time `json:"since"` // time of the last status change
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func (s State) key() string {
+	return stateKey(s.RuleID, s.NodeID)
+}
+
+func stateKey(ruleID, nodeID uuid.UUID) string {
+	return fmt.Sprintf("%s:%s", ruleID, nodeID)
+}
+
+// Notification is the payload sent to webhooks when an alert fires or resolves.
+type Notification struct {
+	Rule  Rule  `json:"rule"`
+	State State `json:"state"`
+}
+
+type RuleService interface {
+	Create(context.Context, Rule) (Rule, error)
+	Get(context.Context, uuid.UUID) (*Rule, error)
+	GetAll(context.Context) ([]Rule, error)
+	Update(context.Context, uuid.UUID, Rule) error
+	Delete(context.Context, uuid.UUID) error
+	States(context.Context) ([]State, error)
+}
@@ -0,0 +1,280 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/zees-dev/zeth/pkg/datastore/badgerdbtest"
+	"github.com/zees-dev/zeth/pkg/events"
+	"github.com/zees-dev/zeth/pkg/node"
+)
+
+func Test_RuleValidate(t *testing.T) {
+	retries := -1
+
+	tests := []struct {
+		name    string
+		rule    Rule
+		wantErr bool
+	}{
+		{"valid error rate", Rule{Name: "r", Kind: KindErrorRate, Threshold: 0.1}, false},
+		{"valid chain id", Rule{Name: "r", Kind: KindChainIDMismatch, ChainID: 1}, false},
+		{"missing name", Rule{Kind: KindErrorRate}, true},
+		{"unknown kind", Rule{Name: "r", Kind: "unknown"}, true},
+		{"error rate above 1", Rule{Name: "r", Kind: KindErrorRate, Threshold: 2}, true},
+		{"negative latency", Rule{Name: "r", Kind: KindLatencyP95, Threshold: -1}, true},
+		{"window too large", Rule{Name: "r", Kind: KindErrorRate, WindowSeconds: 7200}, true},
+		{"missing chain id", Rule{Name: "r", Kind: KindChainIDMismatch}, true},
+		{"invalid webhook url", Rule{Name: "r", Kind: KindNodeUnreachable, Webhooks: []Webhook{{URL: "localhost"}}}, true},
+		{"invalid webhook template", Rule{Name: "r", Kind: KindNodeUnreachable, Webhooks: []Webhook{{URL: "http://localhost", Template: "{{ .State"}}}, true},
+		{"negative webhook retries", Rule{Name: "r", Kind: KindNodeUnreachable, Webhooks: []Webhook{{URL: "http://localhost", MaxRetries: &retries}}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := assert.New(t)
+			err := tt.rule.Validate()
+			is.Equal(tt.wantErr, err != nil)
+		})
+	}
+}
+
+func Test_Percentile(t *testing.T) {
+	is := assert.New(t)
+
+	samples := []sample{}
+	for i := 100; i >= 1; i-- {
+		samples = append(samples, sample{duration: time.Duration(i) * time.Millisecond})
+	}
+	is.Equal(95*time.Millisecond, percentile(samples, 0.95))
+	is.Equal(100*time.Millisecond, percentile(samples, 1))
+	is.Equal(time.Millisecond, percentile(samples[99:], 0.95))
+}
+
+// newTestService returns an alert service with a single enabled node and a controllable clock.
+func newTestService(t *testing.T) (*Service, *events.Bus, node.ZethNode, *time.Time) {
+	store, cleanup := badgerdbtest.MustNewTestBadgerDB()
+	t.Cleanup(func() { cleanup() })
+
+	bus := events.NewBus(events.DefaultBacklog)
+	nodes := node.NewService(store, bus)
+	n, err := nodes.Create(context.Background(), node.ZethNode{Name: "test", Enabled: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewService(store, nodes, bus)
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	return s, bus, n, &now
+}
+
+func Test_ErrorRateRuleFiresAndResolves(t *testing.T) {
+	is := assert.New(t)
+	retryBackoff = time.Millisecond
+
+	// webhook fails the first delivery; succeeds on retry
+	var mu sync.Mutex
+	attempts := 0
+	received := make(chan Notification, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		attempts++
+		first := attempts == 1
+		mu.Unlock()
+		if first {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		is.Equal("secret", r.Header.Get("X-Token"))
+		b, _ := ioutil.ReadAll(r.Body)
+		var n Notification
+		is.NoError(json.Unmarshal(b, &n))
+		is.Nil(n.Rule.Webhooks)
+		received <- n
+	}))
+	defer srv.Close()
+
+	s, bus, n, now := newTestService(t)
+	ctx := context.Background()
+
+	c := make(chan events.Event, 10)
+	_, unsubscribe := bus.Subscribe(c, 0, []string{"alert.*"})
+	defer unsubscribe()
+
+	rule, err := s.Create(ctx, Rule{
+		Name:          "errors",
+		Enabled:       true,
+		Kind:          KindErrorRate,
+		WindowSeconds: 60,
+		MinRequests:   4,
+		Threshold:     0.5,
+		Webhooks:      []Webhook{{URL: srv.URL, Headers: map[string]string{"X-Token": "secret"}}},
+	})
+	is.NoError(err)
+
+	// too few requests to evaluate
+	s.ObserveRPC(n.ID, true, time.Millisecond)
+	is.NoError(s.Evaluate(ctx))
+	is.Len(c, 0)
+
+	for i := 0; i < 3; i++ {
+		s.ObserveRPC(n.ID, true, time.Millisecond)
+	}
+	is.NoError(s.Evaluate(ctx))
+	ev := <-c
+	is.Equal(events.AlertFiring, ev.Type)
+	is.Equal(rule.ID.String(), ev.Subject)
+
+	select {
+	case notification := <-received:
+		is.Equal(StatusFiring, notification.State.Status)
+		is.Equal(1.0, notification.State.Value)
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook not delivered")
+	}
+
+	// still firing; no repeated notifications
+	is.NoError(s.Evaluate(ctx))
+	is.Len(c, 0)
+
+	// failed requests fall out of the window
+	*now = now.Add(2 * time.Minute)
+	for i := 0; i < 4; i++ {
+		s.ObserveRPC(n.ID, false, time.Millisecond)
+	}
+	is.NoError(s.Evaluate(ctx))
+	ev = <-c
+	is.Equal(events.AlertResolved, ev.Type)
+
+	states, err := s.States(ctx)
+	is.NoError(err)
+	is.Len(states, 1)
+	is.Equal(StatusResolved, states[0].Status)
+	is.True(uuid.Equal(n.ID, states[0].NodeID))
+
+	is.NoError(s.Delete(ctx, rule.ID))
+	states, err = s.States(ctx)
+	is.NoError(err)
+	is.Len(states, 0)
+}
+
+func Test_InactiveNodesResolve(t *testing.T) {
+	is := assert.New(t)
+
+	s, bus, n, _ := newTestService(t)
+	ctx := context.Background()
+	s.prober = func(context.Context, node.ZethNode) probeResult {
+		return probeResult{err: errors.New("connection refused")}
+	}
+
+	c := make(chan events.Event, 10)
+	_, unsubscribe := bus.Subscribe(c, 0, []string{"alert.*"})
+	defer unsubscribe()
+
+	_, err := s.Create(ctx, Rule{Name: "unreachable", Enabled: true, Kind: KindNodeUnreachable})
+	is.NoError(err)
+	is.NoError(s.Evaluate(ctx))
+	is.Equal(events.AlertFiring, (<-c).Type)
+
+	// disabled nodes are no longer evaluated; the alert is resolved
+	n.Enabled = false
+	is.NoError(s.nodes.Update(ctx, n.ID, n))
+	is.NoError(s.Evaluate(ctx))
+	ev := <-c
+	is.Equal(events.AlertResolved, ev.Type)
+	is.Equal("node disabled", ev.Data.(State).Message)
+	states, err := s.States(ctx)
+	is.NoError(err)
+	is.Len(states, 1)
+	is.Equal(StatusResolved, states[0].Status)
+
+	n.Enabled = true
+	is.NoError(s.nodes.Update(ctx, n.ID, n))
+	is.NoError(s.Evaluate(ctx))
+	is.Equal(events.AlertFiring, (<-c).Type)
+
+	// the alert states of removed nodes are deleted
+	is.NoError(s.nodes.Delete(ctx, n.ID))
+	is.NoError(s.Evaluate(ctx))
+	ev = <-c
+	is.Equal(events.AlertResolved, ev.Type)
+	is.Equal("node removed", ev.Data.(State).Message)
+	states, err = s.States(ctx)
+	is.NoError(err)
+	is.Empty(states)
+	is.NoError(s.Evaluate(ctx))
+	is.Len(c, 0)
+}
+
+func Test_ProbeRules(t *testing.T) {
+	s, _, n, _ := newTestService(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		rule   Rule
+		probe  probeResult
+		firing bool
+	}{
+		{"reachable", Rule{Kind: KindNodeUnreachable}, probeResult{head: 10, chainID: 1}, false},
+		{"unreachable", Rule{Kind: KindNodeUnreachable}, probeResult{err: errors.New("connection refused")}, true},
+		{"chain id matches", Rule{Kind: KindChainIDMismatch, ChainID: 1}, probeResult{head: 10, chainID: 1}, false},
+		{"chain id mismatch", Rule{Kind: KindChainIDMismatch, ChainID: 5}, probeResult{head: 10, chainID: 1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := assert.New(t)
+
+			s.prober = func(context.Context, node.ZethNode) probeResult { return tt.probe }
+			probes := s.probeAll(ctx, []node.ZethNode{n})
+			_, firing, _, ok := s.evaluate(tt.rule, n, []node.ZethNode{n}, probes)
+			is.True(ok)
+			is.Equal(tt.firing, firing)
+		})
+	}
+}
+
+func Test_BlockLagRule(t *testing.T) {
+	is := assert.New(t)
+
+	s, _, n, _ := newTestService(t)
+	ahead := node.ZethNode{ID: uuid.NewV4(), Enabled: true}
+	otherChain := node.ZethNode{ID: uuid.NewV4(), Enabled: true}
+	nodes := []node.ZethNode{n, ahead, otherChain}
+
+	probes := map[uuid.UUID]probeResult{
+		n.ID:          {head: 100, chainID: 1},
+		ahead.ID:      {head: 120, chainID: 1},
+		otherChain.ID: {head: 1000, chainID: 5},
+	}
+
+	rule := Rule{Kind: KindBlockLag, Threshold: 10}
+	lag, firing, _, ok := s.evaluate(rule, n, nodes, probes)
+	is.True(ok)
+	is.True(firing)
+	is.Equal(20.0, lag)
+
+	rule.Threshold = 50
+	_, firing, _, _ = s.evaluate(rule, n, nodes, probes)
+	is.False(firing)
+}
+
+func Test_WebhookTemplatePayload(t *testing.T) {
+	is := assert.New(t)
+
+	w := Webhook{Template: `{"text":{{ json .State.Message }},"status":"{{ .State.Status }}"}`}
+	payload, err := w.Payload(Notification{State: State{Status: StatusFiring, Message: `node "a" unreachable`}})
+	is.NoError(err)
+	is.JSONEq(`{"text":"node \"a\" unreachable","status":"firing"}`, string(payload))
+}
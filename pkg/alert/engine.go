@@ -0,0 +1,387 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	uuid "github.com/satori/go.uuid"
+	"github.com/zees-dev/zeth/pkg/events"
+	"github.com/zees-dev/zeth/pkg/node"
+)
+
+// maxSamples is the maximum number of proxied requests retained per node.
+const maxSamples = 10000
+
+// probeTimeout is the timeout of probing a node.
+const probeTimeout = 10 * time.Second
+
+// sample is the outcome of a proxied request.
+type sample struct {
+	time     time.Time
+	failed   bool
+	duration time.Duration
+}
+
+// ObserveRPC records the outcome of a request proxied to the node.
+func (s *Service) ObserveRPC(nodeID uuid.UUID, failed bool, duration time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	samples := append(s.samples[nodeID], sample{time: s.now(), failed: failed, duration: duration})
+	if len(samples) > maxSamples {
+		samples = samples[len(samples)-maxSamples:]
+	}
+	s.samples[nodeID] = samples
+}
+
+// windowSamples returns the samples of the node within the window; pruning samples older than the max window.
+func (s *Service) windowSamples(nodeID uuid.UUID, window time.Duration) []sample {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	samples := s.samples[nodeID]
+	for len(samples) > 0 && now.Sub(samples[0].time) > maxWindow {
+		samples = samples[1:]
+	}
+	s.samples[nodeID] = samples
+
+	for i, smp := range samples {
+		if now.Sub(smp.time) <= window {
+			return append([]sample{}, samples[i:]...)
+		}
+	}
+	return nil
+}
+
+// probeResult is the result of probing a node.
+type probeResult struct {
+	head    uint64
+	chainID uint64
+	err     error
+}
+
+// probe queries the head block and chain ID of the node.
+func (s *Service) probe(ctx context.Context, n node.ZethNode) probeResult {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	transport, err := s.nodes.Transport(n)
+	if err != nil {
+		return probeResult{err: err}
+	}
+	client, err := n.DialWithTransport(transport)
+	if err != nil {
+		return probeResult{err: err}
+	}
+	defer client.Close()
+
+	head, err := client.BlockNumber(ctx)
+	if err != nil {
+		return probeResult{err: err}
+	}
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return probeResult{err: err}
+	}
+	return probeResult{head: head, chainID: chainID.Uint64()}
+}
+
+// Start evaluates the alert rules periodically until the context is cancelled.
+func (s *Service) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := s.Evaluate(ctx); err != nil {
+					log.Err(err).Msg("failed to evaluate alert rules")
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Evaluate evaluates all enabled rules against all nodes they apply to.
+func (s *Service) Evaluate(ctx context.Context) error {
+	if err := s.loadStates(ctx); err != nil {
+		return err
+	}
+
+	rules, err := s.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+	nodes, err := s.nodes.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.resolveInactive(rules, nodes)
+
+	// probe nodes once per evaluation; only if a rule requires it
+	var probes map[uuid.UUID]probeResult
+	for _, r := range rules {
+		if r.Enabled && !r.Kind.isTrafficKind() {
+			probes = s.probeAll(ctx, nodes)
+			break
+		}
+	}
+
+	for _, r := range rules {
+		if !r.Enabled {
+			continue
+		}
+		for _, n := range nodes {
+			if !n.Enabled || !r.appliesTo(n.ID) {
+				continue
+			}
+			value, firing, message, ok := s.evaluate(r, n, nodes, probes)
+			if !ok {
+				continue
+			}
+			s.transition(r, n, value, firing, message)
+		}
+	}
+	return nil
+}
+
+// resolveInactive resolves the firing alerts of removed and disabled nodes, which are no longer evaluated.
+// The alert states and samples of removed nodes are deleted.
+func (s *Service) resolveInactive(rules []Rule, nodes []node.ZethNode) {
+	enabled := make(map[uuid.UUID]bool, len(nodes))
+	for _, n := range nodes {
+		enabled[n.ID] = n.Enabled
+	}
+	now := s.now().UTC()
+
+	var resolved, removed []State
+	s.mu.Lock()
+	for k, st := range s.states {
+		nodeEnabled, exists := enabled[st.NodeID]
+		if nodeEnabled {
+			continue
+		}
+		if st.Status == StatusFiring {
+			st.Status = StatusResolved
+			st.Message = "node disabled"
+			if !exists {
+				st.Message = "node removed"
+			}
+			st.Since = now
+			st.UpdatedAt = now
+			s.states[k] = st
+			resolved = append(resolved, st)
+		}
+		if !exists {
+			delete(s.states, k)
+			removed = append(removed, st)
+		}
+	}
+	for id := range s.samples {
+		if _, exists := enabled[id]; !exists {
+			delete(s.samples, id)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, st := range removed {
+		if err := s.store.RemovePrefix(alertStateKey, []byte(st.key())); err != nil {
+			log.Err(err).Msgf("failed to remove alert state: %s", st.key())
+		}
+	}
+	for _, st := range resolved {
+		if _, exists := enabled[st.NodeID]; exists {
+			if err := s.saveState(st); err != nil {
+				log.Err(err).Msgf("failed to save alert state: %s", st.key())
+			}
+		}
+		r := Rule{ID: st.RuleID}
+		for _, rule := range rules {
+			if uuid.Equal(rule.ID, st.RuleID) {
+				r = rule
+				break
+			}
+		}
+		s.announce(r, st)
+	}
+}
+
+func (s *Service) probeAll(ctx context.Context, nodes []node.ZethNode) map[uuid.UUID]probeResult {
+	probes := make(map[uuid.UUID]probeResult, len(nodes))
+	for _, n := range nodes {
+		if n.Enabled {
+			probes[n.ID] = s.prober(ctx, n)
+		}
+	}
+	return probes
+}
+
+// evaluate returns the value of the rule's condition for the node and whether the alert fires.
+// Returns false if the rule cannot be evaluated; e.g. too few requests in the window.
+func (s *Service) evaluate(r Rule, n node.ZethNode, nodes []node.ZethNode, probes map[uuid.UUID]probeResult) (float64, bool, string, bool) {
+	switch r.Kind {
+	case KindErrorRate:
+		samples := s.windowSamples(n.ID, r.window())
+		if len(samples) < r.minRequests() {
+			return 0, false, "", false
+		}
+		failed := 0
+		for _, smp := range samples {
+			if smp.failed {
+				failed++
+			}
+		}
+		rate := float64(failed) / float64(len(samples))
+		return rate, rate > r.Threshold, fmt.Sprintf("error rate %.2f%% over %d requests", rate*100, len(samples)), true
+
+	case KindLatencyP95:
+		samples := s.windowSamples(n.ID, r.window())
+		if len(samples) < r.minRequests() {
+			return 0, false, "", false
+		}
+		p95 := float64(percentile(samples, 0.95).Milliseconds())
+		return p95, p95 > r.Threshold, fmt.Sprintf("p95 latency %.0fms over %d requests", p95, len(samples)), true
+
+	case KindNodeUnreachable:
+		p := probes[n.ID]
+		if p.err != nil {
+			return 1, true, fmt.Sprintf("node unreachable: %s", p.err), true
+		}
+		return 0, false, "node reachable", true
+
+	case KindBlockLag:
+		p := probes[n.ID]
+		if p.err != nil {
+			return 0, false, "", false
+		}
+		// lag behind the highest head of the nodes on the same chain
+		var highest uint64
+		for _, other := range nodes {
+			if op, ok := probes[other.ID]; ok && op.err == nil && op.chainID == p.chainID && op.head > highest {
+				highest = op.head
+			}
+		}
+		lag := float64(highest - p.head)
+		return lag, lag > r.Threshold, fmt.Sprintf("node is %.0f blocks behind head %d", lag, highest), true
+
+	case KindChainIDMismatch:
+		p := probes[n.ID]
+		if p.err != nil {
+			return 0, false, "", false
+		}
+		mismatch := p.chainID != r.ChainID
+		return float64(p.chainID), mismatch, fmt.Sprintf("node chain id %d, expected %d", p.chainID, r.ChainID), true
+	}
+	return 0, false, "", false
+}
+
+// percentile returns the duration at the given percentile (nearest rank) of the samples.
+func percentile(samples []sample, p float64) time.Duration {
+	durations := make([]time.Duration, 0, len(samples))
+	for _, smp := range samples {
+		durations = append(durations, smp.duration)
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+
+	rank := int(math.Ceil(p*float64(len(durations)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return durations[rank]
+}
+
+// loadStates loads persisted alert states; once.
+func (s *Service) loadStates(ctx context.Context) error {
+	s.mu.Lock()
+	loaded := s.states != nil
+	s.mu.Unlock()
+	if loaded {
+		return nil
+	}
+
+	states, err := s.States(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states = make(map[string]State, len(states))
+	for _, st := range states {
+		s.states[st.key()] = st
+	}
+	return nil
+}
+
+// transition updates the alert state of the rule for the node.
+// Firing and resolving alerts are persisted, published on the event bus and sent to the rule's webhooks.
+func (s *Service) transition(r Rule, n node.ZethNode, value float64, firing bool, message string) {
+	now := s.now().UTC()
+	status := StatusResolved
+	if firing {
+		status = StatusFiring
+	}
+
+	s.mu.Lock()
+	prev, exists := s.states[stateKey(r.ID, n.ID)]
+	state := State{
+		RuleID:    r.ID,
+		NodeID:    n.ID,
+		NodeName:  n.Name,
+		Status:    status,
+		Value:     value,
+		Message:   message,
+		Since:     prev.Since,
+		UpdatedAt: now,
+	}
+	// an alert which never fired is not resolved
+	changed := (exists && prev.Status != status) || (!exists && firing)
+	if changed {
+		state.Since = now
+	}
+	if exists || firing {
+		s.states[state.key()] = state
+	}
+	s.mu.Unlock()
+
+	if !changed {
+		return
+	}
+
+	if err := s.saveState(state); err != nil {
+		log.Err(err).Msgf("failed to save alert state: %s", state.key())
+	}
+	s.announce(r, state)
+}
+
+// announce publishes the changed alert state on the event bus and sends it to the rule's webhooks.
+func (s *Service) announce(r Rule, state State) {
+	typ := events.AlertResolved
+	if state.Status == StatusFiring {
+		typ = events.AlertFiring
+	}
+	s.events.Publish(typ, r.ID.String(), state)
+	log.Info().Msgf("alert %s: %s (%s): %s", state.Status, r.Name, state.NodeName, state.Message)
+
+	notification := Notification{Rule: r, State: state}
+	for _, w := range r.Webhooks {
+		go s.notify(w, notification)
+	}
+}
+
+// MarshalJSON omits webhook headers and templates from notifications; they may contain credentials.
+func (n Notification) MarshalJSON() ([]byte, error) {
+	rule := n.Rule
+	rule.Webhooks = nil
+	type notification Notification
+	return json.Marshal(notification{Rule: rule, State: n.State})
+}
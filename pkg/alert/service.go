@@ -0,0 +1,158 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v3"
+	uuid "github.com/satori/go.uuid"
+	"github.com/zees-dev/zeth/pkg/datastore"
+	"github.com/zees-dev/zeth/pkg/events"
+	"github.com/zees-dev/zeth/pkg/node"
+)
+
+// note: namespaces must not share a prefix with each other or other namespaces, since datastore.GetAll iterates by prefix
+var (
+	alertRuleKey  = []byte("alertrule")
+	alertStateKey = []byte("alertstate")
+)
+
+// DefaultInterval is the interval at which alert rules are evaluated.
+const DefaultInterval = 15 * time.Second
+
+var _ RuleService = (*Service)(nil)
+
+// Service stores alert rules and evaluates them against proxied traffic and node probes.
+type Service struct {
+	store    datastore.Store
+	nodes    node.NodeService
+	events   events.Publisher
+	client   *http.Client
+	interval time.Duration
+	prober   func(context.Context, node.ZethNode) probeResult
+	now      func() time.Time
+
+	mu      sync.Mutex
+	samples map[uuid.UUID][]sample // proxied requests per node; pruned to the max window
+	states  map[string]State       // loaded from the store on first evaluation
+}
+
+func NewService(store datastore.Store, nodes node.NodeService, publisher events.Publisher) *Service {
+	s := &Service{
+		store:    store,
+		nodes:    nodes,
+		events:   publisher,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		interval: DefaultInterval,
+		now:      time.Now,
+		samples:  make(map[uuid.UUID][]sample),
+	}
+	s.prober = s.probe
+	return s
+}
+
+// Create assigns a UUID to the rule and saves it to the database.
+func (s *Service) Create(ctx context.Context, r Rule) (Rule, error) {
+	r.ID = uuid.NewV4()
+	r.DateAdded = s.now().UTC()
+
+	bodyBytes := new(bytes.Buffer)
+	json.NewEncoder(bodyBytes).Encode(r)
+
+	return r, s.store.Set(alertRuleKey, r.ID.Bytes(), bodyBytes.Bytes())
+}
+
+func (s *Service) Get(ctx context.Context, id uuid.UUID) (*Rule, error) {
+	dbRule, err := s.store.Get(alertRuleKey, id.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	var rule Rule
+	if err := json.Unmarshal(dbRule, &rule); err != nil {
+		return nil, err
+	}
+
+	return &rule, nil
+}
+
+func (s *Service) GetAll(ctx context.Context) ([]Rule, error) {
+	results := []Rule{}
+
+	rulesMap, err := s.store.GetAll(alertRuleKey)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, b := range rulesMap {
+		var rule Rule
+		if err := json.Unmarshal(b, &rule); err != nil {
+			return nil, err
+		}
+		results = append(results, rule)
+	}
+
+	return results, nil
+}
+
+func (s *Service) Update(ctx context.Context, id uuid.UUID, r Rule) error {
+	ok, err := s.store.Has(alertRuleKey, id.Bytes())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return badger.ErrKeyNotFound
+	}
+
+	bodyBytes := new(bytes.Buffer)
+	json.NewEncoder(bodyBytes).Encode(r)
+	return s.store.Set(alertRuleKey, id.Bytes(), bodyBytes.Bytes())
+}
+
+// Delete removes the rule and the alert states of the rule.
+func (s *Service) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := s.store.RemovePrefix(alertRuleKey, id.Bytes()); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.states != nil {
+		for k, st := range s.states {
+			if uuid.Equal(st.RuleID, id) {
+				delete(s.states, k)
+			}
+		}
+	}
+	return s.store.RemovePrefix(alertStateKey, []byte(id.String()))
+}
+
+// States returns the alert states of all rules.
+func (s *Service) States(ctx context.Context) ([]State, error) {
+	results := []State{}
+
+	statesMap, err := s.store.GetAll(alertStateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, b := range statesMap {
+		var state State
+		if err := json.Unmarshal(b, &state); err != nil {
+			return nil, err
+		}
+		results = append(results, state)
+	}
+
+	return results, nil
+}
+
+func (s *Service) saveState(state State) error {
+	bodyBytes := new(bytes.Buffer)
+	json.NewEncoder(bodyBytes).Encode(state)
+	return s.store.Set(alertStateKey, []byte(state.key()), bodyBytes.Bytes())
+}
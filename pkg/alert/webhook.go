@@ -0,0 +1,95 @@
+package alert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"text/template"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// retryBackoff is the delay before the first retry of a failed webhook delivery; doubled on each retry.
+var retryBackoff = time.Second
+
+// templateFuncs are the functions available in webhook templates.
+var templateFuncs = template.FuncMap{
+	// json encodes the value as JSON; e.g. {{ json .State.Message }}
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// Payload renders the webhook request body for the notification.
+func (w Webhook) Payload(n Notification) ([]byte, error) {
+	if w.Template == "" {
+		return json.Marshal(n)
+	}
+
+	tmpl, err := template.New("webhook").Funcs(templateFuncs).Parse(w.Template)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, n); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (w Webhook) maxRetries() int {
+	if w.MaxRetries == nil {
+		return DefaultMaxRetries
+	}
+	return *w.MaxRetries
+}
+
+// notify POSTs the notification to the webhook; retrying failed deliveries with exponential backoff.
+func (s *Service) notify(w Webhook, n Notification) {
+	payload, err := w.Payload(n)
+	if err != nil {
+		log.Err(err).Msgf("failed to render webhook payload: %s", w.URL)
+		return
+	}
+
+	backoff := retryBackoff
+	for attempt := 0; ; attempt++ {
+		err = s.deliver(w, payload)
+		if err == nil {
+			return
+		}
+		if attempt >= w.maxRetries() {
+			log.Err(err).Msgf("failed to deliver alert notification after %d attempts: %s", attempt+1, w.URL)
+			return
+		}
+		log.Debug().Err(err).Msgf("retrying alert notification in %s: %s", backoff, w.URL)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func (s *Service) deliver(w Webhook, payload []byte) error {
+	req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "zeth")
+	for k, v := range w.Headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("unexpected status code: %d", res.StatusCode)
+	}
+	return nil
+}
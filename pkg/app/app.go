@@ -3,14 +3,26 @@ package app
 import (
 	"context"
 	"net/http"
+	"sync"
 
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
+	"github.com/zees-dev/zeth/pkg/alert"
+	"github.com/zees-dev/zeth/pkg/chain"
 	"github.com/zees-dev/zeth/pkg/datastore"
 	"github.com/zees-dev/zeth/pkg/defi"
 	"github.com/zees-dev/zeth/pkg/defi/amm"
+	"github.com/zees-dev/zeth/pkg/events"
+	"github.com/zees-dev/zeth/pkg/geth/downloader"
+	"github.com/zees-dev/zeth/pkg/health"
+	"github.com/zees-dev/zeth/pkg/managed"
+	"github.com/zees-dev/zeth/pkg/network"
 	"github.com/zees-dev/zeth/pkg/node"
+	"github.com/zees-dev/zeth/pkg/preset"
+	"github.com/zees-dev/zeth/pkg/redact"
 	"github.com/zees-dev/zeth/pkg/settings"
+	"github.com/zees-dev/zeth/pkg/tracing"
+	"github.com/zees-dev/zeth/pkg/vulnerability"
 )
 
 const (
@@ -22,7 +34,19 @@ type (
 	Services struct {
 		Settings             settings.Settings
 		Nodes                node.NodeService
+		NodeGroups           node.NodeGroupService
 		AutomatedMarketMaker defi.AutomatedMarketMaker
+		Tracing              *tracing.Service
+		Events               *events.Bus
+		Alerts               *alert.Service
+		Redaction            *redact.Service
+		Health               *health.Service
+		Chains               chain.Registry
+		Vulnerabilities      *vulnerability.Service
+		Downloads            *downloader.Downloader
+		Managed              *managed.Service
+		Presets              *preset.Service
+		Networks             *network.Service
 	}
 	ServeSettings struct {
 		Enabled    bool
@@ -35,29 +59,41 @@ type App struct {
 	DataDir  string
 	IsDev    bool
 	Services Services
+
+	starting sync.WaitGroup // managed nodes started in the background
 }
 
 func NewApp(store datastore.Store, isDev bool) *App {
+	bus := events.NewBus(events.DefaultBacklog)
+	nodes := node.NewService(store, bus)
+	downloads := downloader.New(bus)
+	managedNodes := managed.NewService(nodes, downloads, bus, DefaultAppDir)
+
 	return &App{
 		Port:    DefaultPort,
 		DataDir: DefaultAppDir,
 		IsDev:   isDev,
 		Services: Services{
-			Settings:             settings.NewService(store),
-			Nodes:                node.NewService(store),
-			AutomatedMarketMaker: amm.NewService(store),
+			Settings:             settings.NewService(store, bus),
+			Nodes:                nodes,
+			NodeGroups:           node.NewGroupService(store),
+			AutomatedMarketMaker: amm.NewService(store, bus),
+			Tracing:              tracing.NewService(),
+			Events:               bus,
+			Alerts:               alert.NewService(store, nodes, bus),
+			Redaction:            redact.NewService(),
+			Health:               health.NewService(store, nodes, bus),
+			Chains:               chain.NewService(store, bus),
+			Vulnerabilities:      vulnerability.NewService(store, nodes, bus),
+			Downloads:            downloads,
+			Managed:              managedNodes,
+			Presets:              preset.NewService(store, bus),
+			Networks:             network.NewService(store, nodes, managedNodes, bus),
 		},
 	}
 }
 
 func (app *App) Init(isNew bool) error {
-	// TODO: app configuration
-	// configure the application on initialization
-	// err := app.Configure()
-	// if err != nil {
-	// 	return errors.Wrap(err, "failed to configure app")
-	// }
-
 	// seed database if it is new
 	if isNew {
 		if err := app.Seed(); err != nil {
@@ -65,26 +101,58 @@ func (app *App) Init(isNew bool) error {
 		}
 	}
 
+	// seed the chain registry of databases created before the registry existed
+	if err := app.Services.Chains.Seed(context.TODO()); err != nil {
+		return errors.Wrap(err, "failed to seed chain registry")
+	}
+
+	// configure span export from stored settings
+	s, err := app.Services.Settings.Get(context.TODO())
+	if err != nil {
+		return errors.Wrap(err, "failed to get settings")
+	}
+	if err := app.Services.Tracing.Configure(context.TODO(), s.Tracing); err != nil {
+		log.Err(err).Msg("failed to configure tracing")
+	}
+	if err := app.Services.Redaction.Configure(s.Redaction); err != nil {
+		log.Err(err).Msg("failed to configure redaction; using default rules")
+	}
+	if err := app.Services.Vulnerabilities.Configure(s.Vulnerabilities); err != nil {
+		log.Err(err).Msg("failed to configure vulnerability checks; using the geth feed")
+	}
+	if err := app.Services.Downloads.Configure(s.Downloads); err != nil {
+		log.Err(err).Msg("failed to configure binary downloads; using the official mirror")
+	}
+
 	return nil
 }
 
-// Configure will configure the application.
-// - download geth binary if they do not exist
-// - start up in-process geth nodes (if they were running before)
-// func (app *App) Configure() error {
-// 	// download geth binary if it does not exist
-// 	gethBinaryDir := filepath.Join(app.DataDir, downloader.DefaultGethBinaryDir)
-// 	gethBinaryPath := filepath.Join(gethBinaryDir, downloader.GethBinaryFilename(runtime.GOOS, params.Version))
-// 	if _, err := os.Stat(gethBinaryPath); os.IsNotExist(err) {
-// 		log.Info().Msgf("Downloading Geth binary for v%s...", params.Version)
-// 		err := downloader.DownloadGethBinary(gethBinaryDir)
-// 		if err != nil {
-// 			return err
-// 		}
-// 	}
-
-// 	return nil
-// }
+// Start starts the background services of the application; they are stopped when the context is cancelled.
+// Managed nodes set to start with zeth are started in the background; their geth binary is downloaded if missing.
+// Dev chains of dev nodes are started.
+func (app *App) Start(ctx context.Context) {
+	app.Services.Alerts.Start(ctx)
+	app.Services.Health.Start(ctx)
+	app.Services.Vulnerabilities.Start(ctx)
+
+	// downloading binaries must not delay serving the API
+	app.starting.Add(1)
+	go func() {
+		defer app.starting.Done()
+		if err := app.Services.Managed.StartAll(ctx); err != nil {
+			log.Err(err).Msg("failed to start managed nodes")
+		}
+	}()
+}
+
+// Stop gracefully stops the processes of managed nodes and the dev chains of dev nodes; on shutdown, before the datastore is closed.
+// The context of Start must be cancelled first; nodes being started are stopped once started. Networks being created are
+// cancelled.
+func (app *App) Stop(ctx context.Context) {
+	app.starting.Wait()
+	app.Services.Networks.Stop()
+	app.Services.Managed.StopAll(ctx)
+}
 
 // Seed will seed the datastore with some initial data.
 func (app *App) Seed() error {
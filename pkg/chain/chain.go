@@ -0,0 +1,104 @@
+package chain
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// bundledChains is a chainlist-style list of well known chains; seeded into the registry.
+// source: https://github.com/ethereum-lists/chains
+//
+//go:embed chains.json
+var bundledChains []byte
+
+// NativeCurrency is the currency gas is paid in on a chain.
+type NativeCurrency struct {
+	Name     string `json:"name"`
+	Symbol   string `json:"symbol"`
+	Decimals uint8  `json:"decimals"`
+}
+
+// Explorer is a block explorer of a chain.
+type Explorer struct {
+	Name     string `json:"name"`
+	URL      string `json:"url"`
+	Standard string `json:"standard"` // e.g. EIP3091
+}
+
+// Chain is an EVM chain; fields follow the chainlist format, extended with testnet and block time.
+type Chain struct {
+	Name           string         `json:"name"`
+	Chain          string         `json:"chain"`
+	ShortName      string         `json:"shortName"`
+	ChainID        uint64         `json:"chainId"`
+	NetworkID      uint64         `json:"networkId"`
+	NativeCurrency NativeCurrency `json:"nativeCurrency"`
+	InfoURL        string         `json:"infoURL"`
+	Explorers      []Explorer     `json:"explorers"`
+	Testnet        bool           `json:"testnet"`
+	BlockTime      float64        `json:"blockTime"` // average seconds between blocks; 0 if blocks are produced on demand
+}
+
+// Validate returns an error if the chain is invalid.
+func (c Chain) Validate() error {
+	if c.ChainID == 0 {
+		return errors.New("chainId is required")
+	}
+	if c.Name == "" {
+		return errors.New("name is required")
+	}
+	if c.BlockTime < 0 {
+		return errors.New("blockTime must not be negative")
+	}
+	for i, e := range c.Explorers {
+		u, err := url.Parse(e.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("explorer %d: invalid url", i)
+		}
+	}
+	return nil
+}
+
+// ExplorerURL returns the URL of the first explorer of the chain; empty if the chain has no explorer.
+func (c Chain) ExplorerURL() string {
+	if len(c.Explorers) == 0 {
+		return ""
+	}
+	return c.Explorers[0].URL
+}
+
+// Parse parses a chainlist-style JSON array of chains; all chains must be valid.
+func Parse(b []byte) ([]Chain, error) {
+	var chains []Chain
+	if err := json.Unmarshal(b, &chains); err != nil {
+		return nil, err
+	}
+	for i, c := range chains {
+		if err := c.Validate(); err != nil {
+			return nil, fmt.Errorf("chain %d: %w", i, err)
+		}
+	}
+	return chains, nil
+}
+
+// Bundled returns the chains bundled with zeth.
+func Bundled() []Chain {
+	chains, err := Parse(bundledChains)
+	if err != nil {
+		panic(err) // covered by tests
+	}
+	return chains
+}
+
+type Registry interface {
+	Get(ctx context.Context, chainID uint64) (*Chain, error)
+	GetAll(ctx context.Context) ([]Chain, error)
+	Upsert(ctx context.Context, c Chain) error
+	Delete(ctx context.Context, chainID uint64) error
+	Import(ctx context.Context, chains []Chain, overwrite bool) (int, error)
+	Seed(ctx context.Context) error
+}
@@ -0,0 +1,126 @@
+package chain
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zees-dev/zeth/pkg/datastore/badgerdbtest"
+	"github.com/zees-dev/zeth/pkg/events"
+)
+
+func Test_Bundled(t *testing.T) {
+	is := assert.New(t)
+
+	chains := Bundled()
+	is.NotEmpty(chains)
+
+	seen := map[uint64]bool{}
+	for _, c := range chains {
+		is.False(seen[c.ChainID], "duplicate chain %d", c.ChainID)
+		seen[c.ChainID] = true
+	}
+	// chains referenced by the bundled AMMs
+	for _, id := range []uint64{1, 250, 43114, 1313161554} {
+		is.True(seen[id], "chain %d is not bundled", id)
+	}
+}
+
+func Test_ChainValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		chain   Chain
+		wantErr bool
+	}{
+		{"valid", Chain{Name: "Ethereum Mainnet", ChainID: 1, Explorers: []Explorer{{URL: "https://etherscan.io"}}}, false},
+		{"missing chain id", Chain{Name: "Ethereum Mainnet"}, true},
+		{"missing name", Chain{ChainID: 1}, true},
+		{"negative block time", Chain{Name: "test", ChainID: 1, BlockTime: -1}, true},
+		{"invalid explorer url", Chain{Name: "test", ChainID: 1, Explorers: []Explorer{{URL: "etherscan.io"}}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := assert.New(t)
+			err := tt.chain.Validate()
+			is.Equal(tt.wantErr, err != nil)
+		})
+	}
+}
+
+func Test_Parse(t *testing.T) {
+	is := assert.New(t)
+
+	chains, err := Parse([]byte(`[{"name": "Local", "chainId": 31337, "explorers": [{"name": "local", "url": "http://localhost:4000"}]}]`))
+	is.NoError(err)
+	is.Len(chains, 1)
+	is.Equal("http://localhost:4000", chains[0].ExplorerURL())
+
+	_, err = Parse([]byte(`[{"name": "Local"}]`))
+	is.Error(err)
+
+	_, err = Parse([]byte(`{}`))
+	is.Error(err)
+}
+
+func Test_RegistryService(t *testing.T) {
+	is := assert.New(t)
+
+	store, cleanup := badgerdbtest.MustNewTestBadgerDB()
+	defer cleanup()
+
+	bus := events.NewBus(events.DefaultBacklog)
+	s := NewService(store, bus)
+	ctx := context.Background()
+
+	is.NoError(s.Upsert(ctx, Chain{Name: "one", ChainID: 1}))
+	is.NoError(s.Upsert(ctx, Chain{Name: "ten", ChainID: 10}))
+
+	c, err := s.Get(ctx, 1)
+	is.NoError(err)
+	is.Equal("one", c.Name)
+
+	// deleting chain 1 must not delete chain 10
+	is.NoError(s.Delete(ctx, 1))
+	_, err = s.Get(ctx, 1)
+	is.Error(err)
+	chains, err := s.GetAll(ctx)
+	is.NoError(err)
+	is.Len(chains, 1)
+	is.Equal(uint64(10), chains[0].ChainID)
+
+	imported, err := s.Import(ctx, []Chain{{Name: "renamed", ChainID: 10}, {Name: "two", ChainID: 2}}, false)
+	is.NoError(err)
+	is.Equal(1, imported)
+	c, _ = s.Get(ctx, 10)
+	is.Equal("ten", c.Name)
+
+	imported, err = s.Import(ctx, []Chain{{Name: "renamed", ChainID: 10}}, true)
+	is.NoError(err)
+	is.Equal(1, imported)
+	c, _ = s.Get(ctx, 10)
+	is.Equal("renamed", c.Name)
+
+	chains, _ = s.GetAll(ctx)
+	is.Equal([]uint64{2, 10}, []uint64{chains[0].ChainID, chains[1].ChainID})
+
+	// registry is not empty; nothing is seeded
+	is.NoError(s.Seed(ctx))
+	chains, _ = s.GetAll(ctx)
+	is.Len(chains, 2)
+}
+
+func Test_RegistrySeed(t *testing.T) {
+	is := assert.New(t)
+
+	store, cleanup := badgerdbtest.MustNewTestBadgerDB()
+	defer cleanup()
+
+	s := NewService(store, events.NewBus(events.DefaultBacklog))
+	ctx := context.Background()
+
+	is.NoError(s.Seed(ctx))
+	chains, err := s.GetAll(ctx)
+	is.NoError(err)
+	is.Len(chains, len(Bundled()))
+}
@@ -0,0 +1,124 @@
+package chain
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/zees-dev/zeth/pkg/datastore"
+	"github.com/zees-dev/zeth/pkg/events"
+)
+
+// note: namespace must not share a prefix with other namespaces, since datastore.GetAll iterates by prefix
+var chainKey = []byte("chain")
+
+var _ Registry = (*registryService)(nil)
+
+type registryService struct {
+	store  datastore.Store
+	events events.Publisher
+}
+
+func NewService(store datastore.Store, publisher events.Publisher) *registryService {
+	return &registryService{
+		store:  store,
+		events: publisher,
+	}
+}
+
+// chainIDKey returns the zero padded key of the chain; keys must not prefix each other since chains are removed by prefix.
+func chainIDKey(chainID uint64) []byte {
+	return []byte(fmt.Sprintf("%020d", chainID))
+}
+
+func (rs *registryService) Get(ctx context.Context, chainID uint64) (*Chain, error) {
+	dbChain, err := rs.store.Get(chainKey, chainIDKey(chainID))
+	if err != nil {
+		return nil, err
+	}
+
+	var c Chain
+	if err := json.Unmarshal(dbChain, &c); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// GetAll returns all chains of the registry ordered by chain ID.
+func (rs *registryService) GetAll(ctx context.Context) ([]Chain, error) {
+	results := []Chain{}
+
+	chainsMap, err := rs.store.GetAll(chainKey)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, b := range chainsMap {
+		var c Chain
+		if err := json.Unmarshal(b, &c); err != nil {
+			return nil, err
+		}
+		results = append(results, c)
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].ChainID < results[j].ChainID })
+	return results, nil
+}
+
+// Upsert adds the chain to the registry or replaces the chain with the same chain ID.
+func (rs *registryService) Upsert(ctx context.Context, c Chain) error {
+	bodyBytes := new(bytes.Buffer)
+	json.NewEncoder(bodyBytes).Encode(c)
+	if err := rs.store.Set(chainKey, chainIDKey(c.ChainID), bodyBytes.Bytes()); err != nil {
+		return err
+	}
+
+	rs.events.Publish(events.ChainUpdated, strconv.FormatUint(c.ChainID, 10), c)
+	return nil
+}
+
+func (rs *registryService) Delete(ctx context.Context, chainID uint64) error {
+	if err := rs.store.RemovePrefix(chainKey, chainIDKey(chainID)); err != nil {
+		return err
+	}
+
+	rs.events.Publish(events.ChainDeleted, strconv.FormatUint(chainID, 10), nil)
+	return nil
+}
+
+// Import adds the chains to the registry; existing chains are only replaced if overwrite is set.
+// Returns the number of chains added or replaced.
+func (rs *registryService) Import(ctx context.Context, chains []Chain, overwrite bool) (int, error) {
+	imported := 0
+	for _, c := range chains {
+		if !overwrite {
+			exists, err := rs.store.Has(chainKey, chainIDKey(c.ChainID))
+			if err != nil {
+				return imported, err
+			}
+			if exists {
+				continue
+			}
+		}
+		if err := rs.Upsert(ctx, c); err != nil {
+			return imported, err
+		}
+		imported++
+	}
+	return imported, nil
+}
+
+// Seed imports the bundled chains if the registry is empty.
+func (rs *registryService) Seed(ctx context.Context) error {
+	chains, err := rs.GetAll(ctx)
+	if err != nil || len(chains) > 0 {
+		return err
+	}
+
+	_, err = rs.Import(ctx, Bundled(), false)
+	return err
+}
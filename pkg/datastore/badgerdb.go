@@ -137,6 +137,20 @@ func (bdb *badgerStore) Set(namespace, key, value []byte) error {
 	return bdb.SetGlobal(badgerNamespaceKey(namespace, key), value)
 }
 
+// SetWithTTL implements the Store interface. It attempts to store a value for a given key
+// and namespace which expires after the given TTL. If the key/value pair cannot be saved, an error is returned.
+func (bdb *badgerStore) SetWithTTL(namespace, key, value []byte, ttl time.Duration) error {
+	err := bdb.db.Update(func(txn *badger.Txn) error {
+		return txn.SetEntry(badger.NewEntry(badgerNamespaceKey(namespace, key), value).WithTTL(ttl))
+	})
+	if err != nil {
+		log.Err(err).Msgf("failed to set value with ttl for namespace %s", namespace)
+		return err
+	}
+
+	return nil
+}
+
 // SetGlobal implements the Store interface. It attempts to store a value for a given globally for a namespace.
 // If the namespace/value pair cannot be saved, an error is returned.
 func (bdb *badgerStore) SetGlobal(namespace, value []byte) error {
@@ -3,6 +3,7 @@ package datastore
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -223,3 +224,26 @@ func Test_GetAll(t *testing.T) {
 		is.Equal(test.want, got)
 	}
 }
+
+func Test_SetWithTTL(t *testing.T) {
+	is := assert.New(t)
+
+	store, cleanup := mustNewTestBadgerDB()
+	defer cleanup()
+
+	err := store.SetWithTTL([]byte("health"), []byte("expiring"), []byte("val"), time.Second)
+	is.NoError(err)
+	err = store.SetWithTTL([]byte("health"), []byte("retained"), []byte("val"), time.Hour)
+	is.NoError(err)
+
+	values, err := store.GetAll([]byte("health"))
+	is.NoError(err)
+	is.Len(values, 2)
+
+	time.Sleep(2 * time.Second)
+
+	values, err = store.GetAll([]byte("health"))
+	is.NoError(err)
+	is.Len(values, 1)
+	is.Contains(values, "retained")
+}
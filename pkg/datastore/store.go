@@ -1,6 +1,9 @@
 package datastore
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // Store is the interface that wraps common datastore operations.
 type Store interface {
@@ -8,6 +11,7 @@ type Store interface {
 	GetGlobal(namespace []byte) ([]byte, error)
 	GetAll(namespace []byte) (map[string][]byte, error)
 	Set(namespace, key, value []byte) error
+	SetWithTTL(namespace, key, value []byte, ttl time.Duration) error
 	SetGlobal(namespace, value []byte) error
 	Has(namespace, key []byte) (bool, error)
 	RemovePrefix(namespace, key []byte) error
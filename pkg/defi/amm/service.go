@@ -7,6 +7,7 @@ import (
 
 	uuid "github.com/satori/go.uuid"
 	"github.com/zees-dev/zeth/pkg/datastore"
+	"github.com/zees-dev/zeth/pkg/events"
 )
 
 var (
@@ -14,12 +15,14 @@ var (
 )
 
 type ammService struct {
-	store datastore.Store
+	store  datastore.Store
+	events events.Publisher
 }
 
-func NewService(store datastore.Store) *ammService {
+func NewService(store datastore.Store, publisher events.Publisher) *ammService {
 	return &ammService{
 		store,
+		publisher,
 	}
 }
 
@@ -33,7 +36,12 @@ func (svc *ammService) Create(ctx context.Context, amm AMM) (AMM, error) {
 
 	json.NewEncoder(bodyBytes).Encode(amm)
 
-	return amm, svc.store.Set(ammKey, id.Bytes(), bodyBytes.Bytes())
+	if err := svc.store.Set(ammKey, id.Bytes(), bodyBytes.Bytes()); err != nil {
+		return amm, err
+	}
+
+	svc.events.Publish(events.AMMCreated, id.String(), amm)
+	return amm, nil
 }
 
 func (svc *ammService) Get(ctx context.Context, id uuid.UUID) (*AMM, error) {
@@ -101,7 +109,12 @@ func (svc *ammService) GetByChainID(ctx context.Context, id int) ([]AMM, error)
 }
 
 func (svc *ammService) Delete(ctx context.Context, id uuid.UUID) error {
-	return svc.store.RemovePrefix(ammKey, id.Bytes())
+	if err := svc.store.RemovePrefix(ammKey, id.Bytes()); err != nil {
+		return err
+	}
+
+	svc.events.Publish(events.AMMDeleted, id.String(), nil)
+	return nil
 }
 
 func unmarshal(b []byte) (*AMM, error) {
@@ -0,0 +1,174 @@
+package events
+
+import (
+	"path"
+	"sync"
+	"time"
+)
+
+// DefaultBacklog is the number of recent events retained by the bus for replay.
+const DefaultBacklog = 1024
+
+// Type is the type of a lifecycle event; types are namespaced by the subsystem which publishes them.
+type Type string
+
+const (
+	NodeCreated    Type = "node.created"
+	NodeUpdated    Type = "node.updated"
+	NodeDeleted    Type = "node.deleted"
+	NodeEnabled    Type = "node.enabled"
+	NodeDisabled   Type = "node.disabled"
+	NodeHealthy    Type = "node.healthy"    // the node recovered from being unhealthy
+	NodeUnhealthy  Type = "node.unhealthy"  // the node failed to serve a request
+	NodeVulnerable Type = "node.vulnerable" // the client version of the node has newly found vulnerabilities
+	NodeStarted    Type = "node.started"    // the process of a managed node was started
+	NodeStopped    Type = "node.stopped"    // the process of a managed node was stopped
+	NodeExited     Type = "node.exited"     // the process of a managed node exited unexpectedly
+	NodeUpgraded   Type = "node.upgraded"   // the geth version of a managed node was changed, or rolled back
+
+	SettingsUpdated Type = "settings.updated"
+
+	AMMCreated Type = "amm.created"
+	AMMDeleted Type = "amm.deleted"
+
+	ChainUpdated Type = "chain.updated" // a chain was added to or replaced in the registry
+	ChainDeleted Type = "chain.deleted"
+
+	PresetUpdated Type = "preset.updated" // a network preset was added or replaced
+	PresetDeleted Type = "preset.deleted"
+
+	NetworkCreated Type = "network.created" // a private network of managed nodes was started
+	NetworkFailed  Type = "network.failed"  // creating a private network failed; its members were removed
+	NetworkDeleted Type = "network.deleted"
+
+	BinaryDownload Type = "binary.download" // progress of downloading a geth binary
+
+	AlertFiring   Type = "alert.firing"
+	AlertResolved Type = "alert.resolved"
+)
+
+// Event is a lifecycle event published on the bus.
+type Event struct {
+	ID      uint64      `json:"id"`
+	Type    Type        `json:"type"`
+	Time    time.Time   `json:"time"`
+	Subject string      `json:"subject,omitempty"` // ID of the entity the event is about; if any
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// Publisher publishes lifecycle events.
+type Publisher interface {
+	Publish(typ Type, subject string, data interface{})
+}
+
+var _ Publisher = (*Bus)(nil)
+
+// Bus is the internal event bus of the application.
+// Recent events are retained in a bounded ring buffer so subscribers can replay what they missed.
+type Bus struct {
+	mu          sync.Mutex
+	lastID      uint64
+	backlog     []Event // ring buffer
+	next        int     // index of the next write into the ring buffer
+	full        bool
+	subscribers map[chan Event][]string
+}
+
+func NewBus(capacity int) *Bus {
+	if capacity <= 0 {
+		capacity = DefaultBacklog
+	}
+	return &Bus{
+		backlog:     make([]Event, capacity),
+		subscribers: map[chan Event][]string{},
+	}
+}
+
+// Publish assigns the next event ID to the event, retains it in the backlog and sends it to matching subscribers.
+// Subscribers which are not ready to receive miss the event; they can detect the gap from the event IDs.
+func (b *Bus) Publish(typ Type, subject string, data interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+	ev := Event{ID: b.lastID, Type: typ, Time: time.Now().UTC(), Subject: subject, Data: data}
+
+	b.backlog[b.next] = ev
+	b.next = (b.next + 1) % len(b.backlog)
+	if b.next == 0 {
+		b.full = true
+	}
+
+	for c, types := range b.subscribers {
+		if !MatchType(types, typ) {
+			continue
+		}
+		select {
+		case c <- ev:
+		default:
+		}
+	}
+}
+
+// MatchType returns whether the type matches any of the patterns, e.g. node.*; an empty list matches all types.
+func MatchType(patterns []string, typ Type) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, string(typ)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateTypePattern returns an error if the type pattern is malformed.
+func ValidateTypePattern(pattern string) error {
+	_, err := path.Match(pattern, "")
+	return err
+}
+
+// events returns the retained events in publishing order.
+func (b *Bus) events() []Event {
+	if !b.full {
+		return append([]Event{}, b.backlog[:b.next]...)
+	}
+	return append(append([]Event{}, b.backlog[b.next:]...), b.backlog[:b.next]...)
+}
+
+func (b *Bus) backlogAfter(afterID uint64, types []string) []Event {
+	events := b.events()
+	// an ID ahead of the bus was issued before a restart; replay everything
+	if afterID != 0 && afterID <= b.lastID {
+		for len(events) > 0 && events[0].ID <= afterID {
+			events = events[1:]
+		}
+	}
+
+	matched := []Event{}
+	for _, ev := range events {
+		if MatchType(types, ev.Type) {
+			matched = append(matched, ev)
+		}
+	}
+	return matched
+}
+
+// Subscribe registers the channel for new events of the given types, and returns the matching retained events published after afterID.
+// Registration and backlog retrieval are atomic, so no event is missed or received twice.
+func (b *Bus) Subscribe(c chan Event, afterID uint64, types []string) ([]Event, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.subscribers[c] = types
+	backlog := b.backlogAfter(afterID, types)
+
+	unsubscribeFn := func() {
+		b.mu.Lock()
+		delete(b.subscribers, c)
+		b.mu.Unlock()
+	}
+
+	return backlog, unsubscribeFn
+}
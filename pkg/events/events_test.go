@@ -0,0 +1,49 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MatchType(t *testing.T) {
+	is := assert.New(t)
+
+	is.True(MatchType(nil, NodeCreated))
+	is.True(MatchType([]string{"node.*"}, NodeUnhealthy))
+	is.True(MatchType([]string{"amm.*", "settings.updated"}, SettingsUpdated))
+	is.False(MatchType([]string{"node.*"}, SettingsUpdated))
+	is.Error(ValidateTypePattern("node.["))
+}
+
+func Test_BusSubscribe(t *testing.T) {
+	is := assert.New(t)
+
+	bus := NewBus(2)
+	bus.Publish(NodeCreated, "a", nil)
+	bus.Publish(SettingsUpdated, "", nil)
+	bus.Publish(NodeDeleted, "a", nil)
+
+	// backlog is bounded and filtered by type
+	c := make(chan Event, 2)
+	backlog, unsubscribe := bus.Subscribe(c, 0, []string{"node.*"})
+	is.Len(backlog, 1)
+	is.Equal(uint64(3), backlog[0].ID)
+	is.Equal(NodeDeleted, backlog[0].Type)
+
+	bus.Publish(SettingsUpdated, "", nil)
+	bus.Publish(NodeUnhealthy, "b", map[string]string{"error": "timeout"})
+	ev := <-c
+	is.Equal(uint64(5), ev.ID)
+	is.Equal("b", ev.Subject)
+	is.Len(c, 0)
+
+	unsubscribe()
+	bus.Publish(NodeHealthy, "b", nil)
+	is.Len(c, 0)
+
+	// resuming replays the events published after the last event ID
+	backlog, _ = bus.Subscribe(make(chan Event), 5, nil)
+	is.Len(backlog, 1)
+	is.Equal(NodeHealthy, backlog[0].Type)
+}
@@ -0,0 +1,97 @@
+package geth
+
+import (
+	"bytes"
+	"io/ioutil"
+	"reflect"
+	"sort"
+
+	"github.com/naoina/toml"
+)
+
+// ConfigChange is a setting which differs between two configs; the path is the dotted TOML key, e.g. Node.HTTPPort.
+type ConfigChange struct {
+	Path string      `json:"path"`
+	From interface{} `json:"from"` // nil if not set
+	To   interface{} `json:"to"`   // nil if not set
+}
+
+// ParseConfig decodes the TOML config as geth does when loading --config; settings which are not set retain their defaults,
+// and unknown settings are rejected.
+// source: https://github.com/ethereum/go-ethereum/blob/v1.10.11/cmd/geth/config.go#L98
+func ParseConfig(data []byte) (GethConfig, error) {
+	cfg := DefaultGethConfig
+	// errors include the line of the invalid setting
+	err := tomlSettings.NewDecoder(bytes.NewReader(data)).Decode(&cfg)
+	return cfg, err
+}
+
+// MarshalConfig encodes the config in the format of `geth dumpconfig`.
+func MarshalConfig(cfg GethConfig) ([]byte, error) {
+	var b bytes.Buffer
+	if err := dumpConfig(cfg, &b); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// LoadConfigFile loads the TOML config file; e.g. the config file of a managed node.
+func LoadConfigFile(path string) (GethConfig, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return GethConfig{}, err
+	}
+	return ParseConfig(data)
+}
+
+// DiffConfig returns the settings which differ between the configs, ordered by path; compared as encoded in TOML.
+func DiffConfig(from, to GethConfig) ([]ConfigChange, error) {
+	fromSettings, err := flattenConfig(from)
+	if err != nil {
+		return nil, err
+	}
+	toSettings, err := flattenConfig(to)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []ConfigChange{}
+	for path, value := range fromSettings {
+		if other, ok := toSettings[path]; !ok || !reflect.DeepEqual(value, other) {
+			changes = append(changes, ConfigChange{Path: path, From: value, To: toSettings[path]})
+		}
+	}
+	for path, value := range toSettings {
+		if _, ok := fromSettings[path]; !ok {
+			changes = append(changes, ConfigChange{Path: path, To: value})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+// flattenConfig returns the settings of the config by their dotted TOML key.
+func flattenConfig(cfg GethConfig) (map[string]interface{}, error) {
+	data, err := MarshalConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var tables map[string]interface{}
+	if err := toml.Unmarshal(data, &tables); err != nil {
+		return nil, err
+	}
+
+	settings := map[string]interface{}{}
+	var flatten func(prefix string, table map[string]interface{})
+	flatten = func(prefix string, table map[string]interface{}) {
+		for key, value := range table {
+			if sub, ok := value.(map[string]interface{}); ok {
+				flatten(prefix+key+".", sub)
+				continue
+			}
+			settings[prefix+key] = value
+		}
+	}
+	flatten("", tables)
+	return settings, nil
+}
@@ -0,0 +1,55 @@
+package geth
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseConfig(t *testing.T) {
+	is := assert.New(t)
+
+	data, err := MarshalConfig(MainnetGethConfig())
+	is.NoError(err)
+	cfg, err := ParseConfig(data)
+	is.NoError(err)
+	changes, err := DiffConfig(MainnetGethConfig(), cfg)
+	is.NoError(err)
+	is.Empty(changes, "dumped config is loaded as is")
+
+	// settings which are not set retain their defaults
+	cfg, err = ParseConfig([]byte("[Node]\nHTTPPort = 9545\n"))
+	is.NoError(err)
+	is.Equal(9545, cfg.Node.HTTPPort)
+	is.Equal(DefaultGethConfig.Eth.NetworkId, cfg.Eth.NetworkId)
+
+	// deprecated settings are ignored
+	_, err = ParseConfig([]byte("[Eth]\nEVMInterpreter = \"\"\n"))
+	is.NoError(err)
+
+	_, err = ParseConfig([]byte("[Node]\nHTTPPorts = 9545\n"))
+	is.Error(err, "unknown field")
+	_, err = ParseConfig([]byte("[Node]\nHTTPPort = \"9545\"\n"))
+	is.Error(err, "invalid type")
+	_, err = ParseConfig([]byte("[Node\n"))
+	is.Error(err, "invalid syntax")
+}
+
+func Test_DiffConfig(t *testing.T) {
+	is := assert.New(t)
+
+	from := ManagedGethConfig(MainnetGethConfig(), filepath.Join(t.TempDir(), "node"), 8545, 8546, 30303)
+	to := from
+	to.Node.HTTPPort = 9545
+	to.Node.WSHost = ""
+	to.Eth.NetworkId = 5
+
+	changes, err := DiffConfig(from, to)
+	is.NoError(err)
+	is.Equal([]ConfigChange{
+		{Path: "Eth.NetworkId", From: int64(1), To: int64(5)},
+		{Path: "Node.HTTPPort", From: int64(8545), To: int64(9545)},
+		{Path: "Node.WSHost", From: "127.0.0.1", To: ""},
+	}, changes)
+}
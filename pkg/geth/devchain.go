@@ -0,0 +1,100 @@
+package geth
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/node"
+)
+
+// DevChainID is the chain id of dev chains; as of the geth --dev mode.
+var DevChainID = core.DeveloperGenesisBlock(0, common.Address{}).Config.ChainID.Uint64()
+
+// DevGenesis returns the genesis block of a dev chain sealed by the signer with the accounts prefunded.
+// Blocks are sealed every period seconds; on demand when transactions are pending if the period is 0.
+// A gas limit of 0 retains the geth default.
+func DevGenesis(period, gasLimit uint64, signer common.Address, alloc core.GenesisAlloc) *core.Genesis {
+	genesis := core.DeveloperGenesisBlock(period, signer)
+	if gasLimit > 0 {
+		genesis.GasLimit = gasLimit
+	}
+	for addr, account := range alloc {
+		genesis.Alloc[addr] = account
+	}
+	return genesis
+}
+
+// DevChain is an in-process go-ethereum dev chain; the chain is held in memory and lost once closed.
+type DevChain struct {
+	stack   *node.Node
+	backend *eth.Ethereum
+}
+
+// StartDevChain starts a dev chain of the genesis sealed by the signer; the RPC endpoints of the config are served,
+// while the data dir, IPC and p2p networking are disabled.
+func StartDevChain(cfg GethConfig, genesis *core.Genesis, signer *ecdsa.PrivateKey) (*DevChain, error) {
+	cfg.Node.Name = "Geth" // web3_clientVersion of geth
+	cfg.Node.DataDir = ""
+	cfg.Node.KeyStoreDir = ""
+	cfg.Node.IPCPath = ""
+	cfg.Node.UseLightweightKDF = true
+	cfg.Node.P2P.MaxPeers = 0
+	cfg.Node.P2P.NoDiscovery = true
+	cfg.Node.P2P.DiscoveryV5 = false
+	cfg.Node.P2P.ListenAddr = ""
+	cfg.Node.P2P.NoDial = true
+
+	stack, err := node.New(&cfg.Node)
+	if err != nil {
+		return nil, err
+	}
+
+	// clique seals blocks with the signer's key from the keystore; the keystore dir is temporary
+	ks := keystore.NewKeyStore(stack.KeyStoreDir(), keystore.LightScryptN, keystore.LightScryptP)
+	stack.AccountManager().AddBackend(ks)
+	account, err := ks.ImportECDSA(signer, "")
+	if err == nil {
+		err = ks.Unlock(account, "")
+	}
+	if err != nil {
+		stack.Close()
+		return nil, fmt.Errorf("failed to import signer: %w", err)
+	}
+
+	cfg.Eth.Genesis = genesis
+	cfg.Eth.NetworkId = genesis.Config.ChainID.Uint64()
+	cfg.Eth.Miner.Etherbase = account.Address
+	cfg.Eth.Miner.GasCeil = genesis.GasLimit
+	backend, err := eth.New(stack, &cfg.Eth)
+	if err != nil {
+		stack.Close()
+		return nil, err
+	}
+	stack.RegisterAPIs(tracers.APIs(backend.APIBackend))
+
+	if err := stack.Start(); err != nil {
+		stack.Close()
+		return nil, err
+	}
+	if err := backend.StartMining(1); err != nil {
+		stack.Close()
+		return nil, err
+	}
+
+	return &DevChain{stack: stack, backend: backend}, nil
+}
+
+// HTTPEndpoint returns the url of the HTTP RPC endpoint; empty if not served.
+func (c *DevChain) HTTPEndpoint() string {
+	return c.stack.HTTPEndpoint()
+}
+
+// Close stops the dev chain, discarding the chain.
+func (c *DevChain) Close() error {
+	return c.stack.Close()
+}
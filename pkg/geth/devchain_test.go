@@ -0,0 +1,91 @@
+package geth
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DevGenesis(t *testing.T) {
+	is := assert.New(t)
+
+	signer := common.HexToAddress("0x1")
+	funded := common.HexToAddress("0x2")
+	genesis := DevGenesis(5, 30000000, signer, core.GenesisAlloc{
+		signer: {Balance: big.NewInt(100)},
+		funded: {Balance: big.NewInt(200)},
+	})
+	is.Equal(uint64(5), genesis.Config.Clique.Period)
+	is.Equal(uint64(30000000), genesis.GasLimit)
+	is.Equal(big.NewInt(100), genesis.Alloc[signer].Balance)
+	is.Equal(big.NewInt(200), genesis.Alloc[funded].Balance)
+	is.Equal(DevChainID, genesis.Config.ChainID.Uint64())
+
+	// the geth dev mode gas limit is retained
+	is.Equal(uint64(11500000), DevGenesis(0, 0, signer, nil).GasLimit)
+}
+
+func Test_StartDevChain(t *testing.T) {
+	is := assert.New(t)
+
+	signer, _ := crypto.GenerateKey()
+	sender, _ := crypto.GenerateKey()
+	signerAddr, senderAddr := crypto.PubkeyToAddress(signer.PublicKey), crypto.PubkeyToAddress(sender.PublicKey)
+	balance := big.NewInt(1e18)
+	genesis := DevGenesis(0, 0, signerAddr, core.GenesisAlloc{
+		signerAddr: {Balance: balance},
+		senderAddr: {Balance: balance},
+	})
+
+	cfg := DevModeConfig(0)
+	cfg.Node.HTTPHost = "127.0.0.1"
+	cfg.Node.HTTPPort = 0 // random port
+	chain, err := StartDevChain(cfg, genesis, signer)
+	is.NoError(err)
+	defer chain.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	c, err := ethclient.DialContext(ctx, chain.HTTPEndpoint())
+	is.NoError(err)
+	defer c.Close()
+
+	chainID, err := c.ChainID(ctx)
+	is.NoError(err)
+	is.Equal(DevChainID, chainID.Uint64())
+
+	got, err := c.BalanceAt(ctx, senderAddr, nil)
+	is.NoError(err)
+	is.Equal(balance, got)
+
+	// blocks are sealed on demand by the signer
+	gasPrice, err := c.SuggestGasPrice(ctx)
+	is.NoError(err)
+	tx, err := types.SignTx(
+		types.NewTransaction(0, signerAddr, big.NewInt(1), 21000, gasPrice, nil),
+		types.NewEIP155Signer(chainID), sender,
+	)
+	is.NoError(err)
+	is.NoError(c.SendTransaction(ctx, tx))
+
+	for {
+		receipt, err := c.TransactionReceipt(ctx, tx.Hash())
+		if err == nil {
+			is.Equal(types.ReceiptStatusSuccessful, receipt.Status)
+			break
+		}
+		select {
+		case <-ctx.Done():
+			t.Fatal("transaction was not sealed")
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,357 @@
+package downloader
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zees-dev/zeth/pkg/events"
+	"golang.org/x/crypto/openpgp"
+)
+
+var (
+	ErrUnknownRelease    = errors.New("geth release not found on mirror")
+	ErrChecksumMismatch  = errors.New("archive does not match the published checksum")
+	ErrSignatureMismatch = errors.New("archive does not match the published signature")
+	ErrUnverified        = errors.New("archive has no published checksum or signature")
+)
+
+// progressInterval is the minimum interval between progress events of a download.
+const progressInterval = 500 * time.Millisecond
+
+// validatorSuffix is the suffix of the file storing the validator (ETag or Last-Modified) of a partial download; the
+// download is only resumed if the archive of the mirror still matches the validator.
+const validatorSuffix = ".validator"
+
+// Config configures where geth release archives are downloaded from and how they are verified.
+type Config struct {
+	// MirrorURL is the base url of the release archives; e.g. an internal file server. Defaults to the official blob storage
+	MirrorURL string `json:"mirrorUrl"`
+	// Keyring are the armored PGP public keys the signatures of archives (<archive>.asc) are verified with; e.g. the geth builder keys.
+	// No keys are bundled; archives of the official mirror are only checked against the MD5 checksums of its listing otherwise
+	Keyring string `json:"keyring"`
+	// AllowUnverified installs archives without a published checksum or signature; e.g. of mirrors publishing archives only
+	AllowUnverified bool `json:"allowUnverified"`
+}
+
+// Validate returns an error if the mirror url is malformed or the keyring can not be read.
+func (cfg Config) Validate() error {
+	if cfg.MirrorURL != "" {
+		u, err := url.Parse(cfg.MirrorURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.New("mirrorUrl must be a http(s) url")
+		}
+	}
+	if cfg.Keyring != "" {
+		if _, err := openpgp.ReadArmoredKeyRing(strings.NewReader(cfg.Keyring)); err != nil {
+			return fmt.Errorf("keyring must be armored PGP public keys: %w", err)
+		}
+	}
+	return nil
+}
+
+func (cfg Config) mirrorURL() string {
+	if cfg.MirrorURL == "" {
+		return DefaultMirrorURL
+	}
+	return strings.TrimSuffix(cfg.MirrorURL, "/")
+}
+
+// Progress is the progress of a download; published as the data of binary download events.
+type Progress struct {
+	Version    string `json:"version"`
+	URL        string `json:"url"`
+	Downloaded int64  `json:"downloaded"` // bytes, including bytes of a resumed download
+	Total      int64  `json:"total"`      // bytes; 0 if unknown
+	Done       bool   `json:"done"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Manifest records the release a geth binary was installed from; written next to the binary.
+type Manifest struct {
+	Release
+	Verification Verification `json:"verification"`
+	InstalledAt  time.Time    `json:"installedAt"`
+}
+
+// ManifestFilename returns the filename of the manifest of the geth binary of the version.
+func ManifestFilename(os, version string) string {
+	return GethBinaryFilename(os, version) + ".json"
+}
+
+// Downloader downloads, verifies and installs geth binaries; downloads are resumed, reporting their progress as events.
+type Downloader struct {
+	client *http.Client
+	events events.Publisher
+	goos   string
+	goarch string
+
+	mu       sync.Mutex
+	cfg      Config
+	installs map[string]chan struct{} // locks of installs by binary path; installs of a version share the partial download
+}
+
+func New(publisher events.Publisher) *Downloader {
+	return &Downloader{
+		client:   http.DefaultClient,
+		events:   publisher,
+		goos:     runtime.GOOS,
+		goarch:   runtime.GOARCH,
+		installs: map[string]chan struct{}{},
+	}
+}
+
+// Configure replaces the configuration; applied from the next download.
+func (d *Downloader) Configure(cfg Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	d.cfg = cfg
+	d.mu.Unlock()
+	return nil
+}
+
+func (d *Downloader) config() Config {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.cfg
+}
+
+// lock locks installing the binary of the path until unlocked; an error if the context is done first.
+func (d *Downloader) lock(ctx context.Context, path string) (func(), error) {
+	d.mu.Lock()
+	l, ok := d.installs[path]
+	if !ok {
+		l = make(chan struct{}, 1)
+		d.installs[path] = l
+	}
+	d.mu.Unlock()
+
+	select {
+	case l <- struct{}{}:
+		return func() { <-l }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// DownloadGethBinary downloads the release archive of the version from the mirror to the dir, verifies it, and installs the
+// geth binary of the archive to the dir; the manifest of the binary records the release and its verification. The last
+// progress event of a download is done; with the error of a failed download.
+// An interrupted download is resumed from the partially downloaded archive. Concurrent downloads of a version wait for the
+// first; the binary it installed is not downloaded again.
+func (d *Downloader) DownloadGethBinary(ctx context.Context, dir, version string) (Manifest, error) {
+	binary := filepath.Join(dir, GethBinaryFilename(d.goos, version))
+	unlock, err := d.lock(ctx, binary)
+	if err != nil {
+		return Manifest{}, err
+	}
+	defer unlock()
+	if _, err := os.Stat(binary); err == nil {
+		return readManifest(filepath.Join(dir, ManifestFilename(d.goos, version)))
+	}
+
+	cfg := d.config()
+	release, err := resolveRelease(ctx, d.client, cfg.mirrorURL(), d.goos, d.goarch, version)
+	if err != nil {
+		d.events.Publish(events.BinaryDownload, version, Progress{Version: version, Done: true, Error: err.Error()})
+		return Manifest{}, err
+	}
+
+	progress := Progress{Version: version, URL: release.URL}
+	manifest, err := d.install(ctx, cfg, release, dir, &progress)
+	if err != nil {
+		progress.Error = err.Error()
+	}
+	progress.Done = true
+	d.events.Publish(events.BinaryDownload, version, progress)
+	return manifest, err
+}
+
+func (d *Downloader) install(ctx context.Context, cfg Config, release Release, dir string, progress *Progress) (Manifest, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return Manifest{}, err
+	}
+	archive := filepath.Join(dir, filepath.Base(release.URL))
+	partial := archive + ".part"
+
+	log.Info().Msgf("Downloading geth v%s from %s", release.Version, release.URL)
+	start := time.Now()
+	lastEvent := time.Time{}
+	err := download(ctx, d.client, release.URL, partial, func(downloaded, total int64) {
+		progress.Downloaded, progress.Total = downloaded, total
+		if time.Since(lastEvent) >= progressInterval {
+			lastEvent = time.Now()
+			d.events.Publish(events.BinaryDownload, release.Version, *progress)
+		}
+	})
+	if err != nil {
+		return Manifest{}, err
+	}
+	log.Info().Msgf("Download completed in %s", time.Since(start))
+
+	// a corrupt partial download must not be resumed
+	// archives only checked for integrity are installed, since the official mirror publishes no SHA-256 checksums; they are
+	// not reported as verified
+	verification, err := verify(ctx, d.client, release, partial, cfg.Keyring)
+	if err == nil && !cfg.AllowUnverified && !verification.Verified() && !verification.IntegrityOnly() {
+		err = ErrUnverified
+	}
+	if err != nil {
+		os.Remove(partial)
+		os.Remove(partial + validatorSuffix)
+		return Manifest{}, err
+	}
+
+	if err := os.Rename(partial, archive); err != nil {
+		return Manifest{}, err
+	}
+	defer os.Remove(archive)
+	if err := extractGethArchive(d.goos, release.Version, archive, dir); err != nil {
+		return Manifest{}, err
+	}
+
+	manifest := Manifest{Release: release, Verification: verification, InstalledAt: time.Now().UTC()}
+	b, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return Manifest{}, err
+	}
+	return manifest, ioutil.WriteFile(filepath.Join(dir, ManifestFilename(d.goos, release.Version)), b, 0644)
+}
+
+// readManifest returns the manifest of an installed binary; binaries installed without a manifest have an empty manifest.
+func readManifest(path string) (Manifest, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Manifest{}, nil
+	}
+	if err != nil {
+		return Manifest{}, err
+	}
+	var manifest Manifest
+	return manifest, json.Unmarshal(data, &manifest)
+}
+
+// download downloads the url to the path; the download is resumed with a range request if the file exists and the
+// validator of the file stored alongside it still matches the url (If-Range), and started over otherwise.
+// onProgress is called with the downloaded and total bytes as the file is written; the total is 0 if unknown.
+func download(ctx context.Context, client *http.Client, url, path string, onProgress func(downloaded, total int64)) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	validatorPath := path + validatorSuffix
+	validator, err := ioutil.ReadFile(validatorPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	// a file without a validator may be of another version of the url
+	if offset > 0 && len(validator) > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("If-Range", string(validator))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download file: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var total int64
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		if resp.ContentLength >= 0 {
+			total = offset + resp.ContentLength
+		}
+	case http.StatusOK:
+		// the server does not support range requests, or the url changed; the download starts over
+		if err := storeValidator(validatorPath, resp); err != nil {
+			return err
+		}
+		if err := f.Truncate(0); err != nil {
+			return err
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		offset = 0
+		if resp.ContentLength >= 0 {
+			total = resp.ContentLength
+		}
+	case http.StatusRequestedRangeNotSatisfiable:
+		// the file is downloaded entirely
+		onProgress(offset, offset)
+		return removeFile(validatorPath)
+	default:
+		return fmt.Errorf("failed to download file: %s", resp.Status)
+	}
+
+	w := &progressWriter{w: f, written: offset, total: total, onProgress: onProgress}
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("failed to persist file: %w", err)
+	}
+	if total > 0 && w.written != total {
+		return fmt.Errorf("failed to download file: %d of %d bytes received", w.written, total)
+	}
+	onProgress(w.written, w.written)
+	return removeFile(validatorPath)
+}
+
+// storeValidator stores the validator of the response to resume the download with; the strong ETag, or the last
+// modification date. Responses without a validator can not be resumed.
+func storeValidator(path string, resp *http.Response) error {
+	validator := resp.Header.Get("ETag")
+	if validator == "" || strings.HasPrefix(validator, "W/") {
+		// weak ETags can not be used with If-Range
+		validator = resp.Header.Get("Last-Modified")
+	}
+	if validator == "" {
+		return removeFile(path)
+	}
+	return ioutil.WriteFile(path, []byte(validator), 0644)
+}
+
+// removeFile removes the file if it exists.
+func removeFile(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+type progressWriter struct {
+	w          io.Writer
+	written    int64
+	total      int64
+	onProgress func(downloaded, total int64)
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.written += int64(n)
+	w.onProgress(w.written, w.total)
+	return n, err
+}
@@ -0,0 +1,361 @@
+package downloader
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zees-dev/zeth/pkg/events"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+)
+
+// gethArchive returns a release archive containing the geth binary; as published for linux.
+func gethArchive(t *testing.T, name string) []byte {
+	buf := new(bytes.Buffer)
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+	dir := strings.TrimSuffix(name, ".tar.gz")
+	binary := []byte("#!/bin/sh\necho geth\n")
+	tw.WriteHeader(&tar.Header{Name: dir + "/", Typeflag: tar.TypeDir, Mode: 0755})
+	tw.WriteHeader(&tar.Header{Name: dir + "/geth", Typeflag: tar.TypeReg, Mode: 0755, Size: int64(len(binary))})
+	tw.Write(binary)
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+// mirror serves the files, and a container listing of the files with their MD5 checksums.
+func mirror(t *testing.T, files map[string][]byte) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("comp") == "list" {
+			prefix := r.URL.Query().Get("prefix")
+			fmt.Fprint(w, "<EnumerationResults><Blobs>")
+			for name, data := range files {
+				if strings.HasPrefix(name, prefix) {
+					sum := md5.Sum(data)
+					fmt.Fprintf(w, "<Blob><Name>%s</Name><Properties><Content-MD5>%s</Content-MD5></Properties></Blob>",
+						name, base64.StdEncoding.EncodeToString(sum[:]))
+				}
+			}
+			fmt.Fprint(w, "</Blobs></EnumerationResults>")
+			return
+		}
+		data, ok := files[strings.TrimPrefix(r.URL.Path, "/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeContent(w, r, r.URL.Path, time.Time{}, bytes.NewReader(data))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestDownloader(cfg Config) (*Downloader, *events.Bus) {
+	bus := events.NewBus(events.DefaultBacklog)
+	d := New(bus)
+	d.goos, d.goarch = "linux", "amd64"
+	d.Configure(cfg)
+	return d, bus
+}
+
+func Test_resolveRelease(t *testing.T) {
+	is := assert.New(t)
+
+	known := ArchiveName("linux", "amd64", "1.10.11", "7231b3efb8095d3dd18d7164c3fa84d7705759d3")
+	srv := mirror(t, map[string][]byte{
+		known: []byte("known"),
+		"geth-linux-amd64-1.10.12-6c4dc6c3.tar.gz":          []byte("listed"),
+		"geth-linux-amd64-1.10.12-6c4dc6c3.tar.gz.asc":      []byte("signature"),
+		"geth-linux-amd64-1.10.13-unstable-7a0c19f8.tar.gz": []byte("unstable"),
+	})
+	ctx := context.Background()
+
+	release, err := resolveRelease(ctx, http.DefaultClient, srv.URL, "linux", "amd64", "1.10.11")
+	is.NoError(err)
+	is.Equal(srv.URL+"/"+known, release.URL)
+	sum := md5.Sum([]byte("known"))
+	is.Equal(base64.StdEncoding.EncodeToString(sum[:]), release.MD5)
+
+	release, err = resolveRelease(ctx, http.DefaultClient, srv.URL, "linux", "amd64", "1.10.12")
+	is.NoError(err)
+	is.Equal("6c4dc6c3", release.Commit)
+	is.Equal(srv.URL+"/geth-linux-amd64-1.10.12-6c4dc6c3.tar.gz", release.URL)
+
+	_, err = resolveRelease(ctx, http.DefaultClient, srv.URL, "linux", "amd64", "1.10.13")
+	is.ErrorIs(err, ErrUnknownRelease)
+
+	// mirrors without a listing serve known releases only
+	plain := httptest.NewServer(http.NotFoundHandler())
+	defer plain.Close()
+	release, err = resolveRelease(ctx, http.DefaultClient, plain.URL, "linux", "amd64", "1.10.11")
+	is.NoError(err)
+	is.Empty(release.MD5)
+	_, err = resolveRelease(ctx, http.DefaultClient, plain.URL, "linux", "amd64", "1.10.12")
+	is.ErrorIs(err, ErrUnknownRelease)
+
+	// releases are looked up in the directory index of file servers
+	index := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" || r.URL.RawQuery != "" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `<html><body><pre><a href="../">../</a>
+<a href="geth-linux-amd64-1.10.12-6c4dc6c3.tar.gz">geth-linux-amd64-1.10.12-6c4dc6c3.tar.gz</a>
+<a href="/builds/geth-linux-amd64-1.10.12-6c4dc6c3.tar.gz.asc">geth-linux-amd64-1.10.12-6c4dc6c3.tar.gz.asc</a>
+</pre></body></html>`)
+	}))
+	defer index.Close()
+	release, err = resolveRelease(ctx, http.DefaultClient, index.URL, "linux", "amd64", "1.10.12")
+	is.NoError(err)
+	is.Equal("6c4dc6c3", release.Commit)
+	is.Equal(index.URL+"/geth-linux-amd64-1.10.12-6c4dc6c3.tar.gz", release.URL)
+	is.Empty(release.MD5)
+	_, err = resolveRelease(ctx, http.DefaultClient, index.URL, "linux", "amd64", "1.10.13")
+	is.ErrorIs(err, ErrUnknownRelease)
+}
+
+func Test_download(t *testing.T) {
+	is := assert.New(t)
+
+	data := bytes.Repeat([]byte("geth"), 1024)
+	etag := `"v1"`
+	var ranges []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		w.Header().Set("ETag", etag)
+		http.ServeContent(w, r, "archive", time.Time{}, bytes.NewReader(data))
+	}))
+	defer srv.Close()
+
+	// a partially downloaded file without a validator starts over
+	path := filepath.Join(t.TempDir(), "archive.part")
+	is.NoError(ioutil.WriteFile(path, []byte("stale"), 0644))
+	is.NoError(download(context.Background(), http.DefaultClient, srv.URL, path, func(int64, int64) {}))
+	is.Equal([]string{""}, ranges)
+	got, _ := ioutil.ReadFile(path)
+	is.Equal(data, got)
+	is.NoFileExists(path + validatorSuffix)
+
+	// the partially downloaded file is resumed if the url is unchanged
+	ranges = nil
+	is.NoError(ioutil.WriteFile(path, data[:1000], 0644))
+	is.NoError(ioutil.WriteFile(path+validatorSuffix, []byte(etag), 0644))
+	var downloaded, total int64
+	err := download(context.Background(), http.DefaultClient, srv.URL, path, func(d, t int64) { downloaded, total = d, t })
+	is.NoError(err)
+	is.Equal([]string{"bytes=1000-"}, ranges)
+	is.Equal(int64(len(data)), downloaded)
+	is.Equal(int64(len(data)), total)
+	got, _ = ioutil.ReadFile(path)
+	is.Equal(data, got)
+	is.NoFileExists(path + validatorSuffix)
+
+	// the download starts over if the url changed
+	is.NoError(ioutil.WriteFile(path, []byte("stale"), 0644))
+	is.NoError(ioutil.WriteFile(path+validatorSuffix, []byte(`"v0"`), 0644))
+	is.NoError(download(context.Background(), http.DefaultClient, srv.URL, path, func(int64, int64) {}))
+	got, _ = ioutil.ReadFile(path)
+	is.Equal(data, got)
+
+	// the validator of an interrupted download is stored
+	interrupted := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.Write(data[:1000])
+	}))
+	defer interrupted.Close()
+	is.NoError(os.Remove(path))
+	is.Error(download(context.Background(), http.DefaultClient, interrupted.URL, path, func(int64, int64) {}))
+	validator, err := ioutil.ReadFile(path + validatorSuffix)
+	is.NoError(err)
+	is.Equal(etag, string(validator))
+}
+
+func Test_DownloadGethBinary(t *testing.T) {
+	is := assert.New(t)
+
+	name := ArchiveName("linux", "amd64", "1.10.11", releaseCommits["1.10.11"])
+	archive := gethArchive(t, name)
+	sum := sha256.Sum256(archive)
+	ctx := context.Background()
+
+	t.Run("verified against the published sha256 checksum", func(t *testing.T) {
+		srv := mirror(t, map[string][]byte{
+			name:             archive,
+			name + ".sha256": []byte(hex.EncodeToString(sum[:]) + "  " + name + "\n"),
+		})
+		d, bus := newTestDownloader(Config{MirrorURL: srv.URL})
+		c := make(chan events.Event, 100)
+		_, unsub := bus.Subscribe(c, 0, []string{string(events.BinaryDownload)})
+		defer unsub()
+
+		dir := t.TempDir()
+		manifest, err := d.DownloadGethBinary(ctx, dir, "1.10.11")
+		is.NoError(err)
+		is.Equal("sha256", manifest.Verification.Checksum)
+		is.Equal(hex.EncodeToString(sum[:]), manifest.Verification.SHA256)
+		is.FileExists(filepath.Join(dir, GethBinaryFilename("linux", "1.10.11")))
+		is.FileExists(filepath.Join(dir, ManifestFilename("linux", "1.10.11")))
+		is.NoFileExists(filepath.Join(dir, name))
+		is.NoFileExists(filepath.Join(dir, name+".part"))
+
+		var last Progress
+		for len(c) > 0 {
+			last = (<-c).Data.(Progress)
+		}
+		is.True(last.Done)
+		is.Empty(last.Error)
+		is.Equal(int64(len(archive)), last.Downloaded)
+	})
+
+	t.Run("checked against the md5 checksum of the listing", func(t *testing.T) {
+		srv := mirror(t, map[string][]byte{name: archive})
+		d, _ := newTestDownloader(Config{MirrorURL: srv.URL})
+
+		manifest, err := d.DownloadGethBinary(ctx, t.TempDir(), "1.10.11")
+		is.NoError(err)
+		is.Equal("md5", manifest.Verification.Checksum)
+		is.True(manifest.Verification.IntegrityOnly())
+		is.False(manifest.Verification.Verified())
+	})
+
+	t.Run("checksum mismatch", func(t *testing.T) {
+		srv := mirror(t, map[string][]byte{
+			name:             archive,
+			name + ".sha256": []byte(strings.Repeat("0", 64) + "  " + name + "\n"),
+		})
+		d, _ := newTestDownloader(Config{MirrorURL: srv.URL})
+
+		dir := t.TempDir()
+		_, err := d.DownloadGethBinary(ctx, dir, "1.10.11")
+		is.ErrorIs(err, ErrChecksumMismatch)
+		is.NoFileExists(filepath.Join(dir, GethBinaryFilename("linux", "1.10.11")))
+		// the corrupt download is not resumed
+		is.NoFileExists(filepath.Join(dir, name+".part"))
+	})
+
+	t.Run("unverified", func(t *testing.T) {
+		srv := mirror(t, map[string][]byte{name: archive})
+		// the mirror publishes neither a listing nor checksums
+		plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("comp") == "list" {
+				http.NotFound(w, r)
+				return
+			}
+			srv.Config.Handler.ServeHTTP(w, r)
+		}))
+		defer plain.Close()
+
+		d, _ := newTestDownloader(Config{MirrorURL: plain.URL})
+		_, err := d.DownloadGethBinary(ctx, t.TempDir(), "1.10.11")
+		is.ErrorIs(err, ErrUnverified)
+
+		d, _ = newTestDownloader(Config{MirrorURL: plain.URL, AllowUnverified: true})
+		manifest, err := d.DownloadGethBinary(ctx, t.TempDir(), "1.10.11")
+		is.NoError(err)
+		is.False(manifest.Verification.Verified())
+	})
+
+	t.Run("verified against the signature", func(t *testing.T) {
+		signer, err := openpgp.NewEntity("builder", "", "builder@example.com", nil)
+		is.NoError(err)
+		keyring := new(bytes.Buffer)
+		w, _ := armor.Encode(keyring, openpgp.PublicKeyType, nil)
+		is.NoError(signer.Serialize(w))
+		w.Close()
+		sig := new(bytes.Buffer)
+		is.NoError(openpgp.ArmoredDetachSign(sig, signer, bytes.NewReader(archive), nil))
+
+		srv := mirror(t, map[string][]byte{name: archive, name + ".asc": sig.Bytes()})
+		d, _ := newTestDownloader(Config{MirrorURL: srv.URL, Keyring: keyring.String()})
+		manifest, err := d.DownloadGethBinary(ctx, t.TempDir(), "1.10.11")
+		is.NoError(err)
+		is.True(manifest.Verification.Signature)
+
+		other, _ := openpgp.NewEntity("other", "", "other@example.com", nil)
+		sig.Reset()
+		is.NoError(openpgp.ArmoredDetachSign(sig, other, bytes.NewReader(archive), nil))
+		srv = mirror(t, map[string][]byte{name: archive, name + ".asc": sig.Bytes()})
+		d, _ = newTestDownloader(Config{MirrorURL: srv.URL, Keyring: keyring.String()})
+		_, err = d.DownloadGethBinary(ctx, t.TempDir(), "1.10.11")
+		is.ErrorIs(err, ErrSignatureMismatch)
+	})
+}
+
+func Test_DownloadGethBinaryConcurrently(t *testing.T) {
+	is := assert.New(t)
+
+	name := ArchiveName("linux", "amd64", "1.10.11", releaseCommits["1.10.11"])
+	srv := mirror(t, map[string][]byte{name: gethArchive(t, name)})
+	var downloads int32
+	counting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/"+name {
+			atomic.AddInt32(&downloads, 1)
+		}
+		srv.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer counting.Close()
+
+	// installs of a version wait for the first install
+	d, _ := newTestDownloader(Config{MirrorURL: counting.URL})
+	dir := t.TempDir()
+	var wg sync.WaitGroup
+	errs := make([]error, 5)
+	manifests := make([]Manifest, 5)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			manifests[i], errs[i] = d.DownloadGethBinary(context.Background(), dir, "1.10.11")
+		}(i)
+	}
+	wg.Wait()
+
+	is.Equal(int32(1), atomic.LoadInt32(&downloads))
+	for i, err := range errs {
+		is.NoError(err)
+		is.Equal("md5", manifests[i].Verification.Checksum)
+	}
+	is.FileExists(filepath.Join(dir, GethBinaryFilename("linux", "1.10.11")))
+}
+
+func Test_ConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"defaults", Config{}, false},
+		{"mirror", Config{MirrorURL: "https://files.internal/geth"}, false},
+		{"file mirror", Config{MirrorURL: "file:///var/lib/geth"}, true},
+		{"relative mirror", Config{MirrorURL: "geth"}, true},
+		{"invalid keyring", Config{Keyring: "not a key"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := assert.New(t)
+			is.Equal(tt.wantErr, tt.cfg.Validate() != nil)
+		})
+	}
+}
@@ -3,16 +3,13 @@ package downloader
 import (
 	"archive/tar"
 	"archive/zip"
-	"bytes"
 	"compress/gzip"
-	"encoding/json"
+	"context"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
-	"path"
 	"path/filepath"
-	"runtime"
 	"strings"
 	"time"
 
@@ -35,56 +32,10 @@ func GethBinaryFilename(os, version string) string {
 	return fmt.Sprintf("geth-v%s%s", version, binFileExt)
 }
 
-// DownloadGethBinary downloads the geth binary for the specified version and OS
-func DownloadGethBinary(downloadDir string) error {
-	version := params.Version
-	hash, err := getReleaseHash(version)
-	if err != nil {
-		return err
-	}
-
-	url, exists, err := GethBinaryURL(runtime.GOOS, runtime.GOARCH, version, hash)
-	if err != nil {
-		return errors.Wrap(err, "failed to get geth binary url")
-	} else if !exists {
-		return fmt.Errorf("geth binary for version %s not found", version)
-	}
-
-	archive, err := DownloadFile(url, downloadDir)
-	if err != nil {
-		return errors.Wrap(err, "failed to download geth binary")
-	}
-	defer os.RemoveAll(archive.Name())
-
-	err = ExtractGethArchive(runtime.GOOS, archive.Name(), downloadDir)
-	if err != nil {
-		return errors.Wrap(err, "failed to extract geth binary")
-	}
-
-	return nil
-}
-
 // GethBinaryURL returns the URL of the geth binary for the specified version if the binary exists in remote blob storage
 // example call: GethBinaryURL(runtime.GOOS, runtime.GOARCH, "1.10.11", "7231b3efb8095d3")
 func GethBinaryURL(os, arch, version, hash string) (string, bool, error) {
-	fileExt := "tar.gz"
-	if os == "windows" {
-		fileExt = "zip"
-	}
-
-	if os == "darwin" {
-		// no download binaries for M1 macs since runtime.GOARCH == "arm64"
-		arch = "amd64"
-	}
-
-	gethURL := fmt.Sprintf(
-		"https://gethstore.blob.core.windows.net/builds/geth-%s-%s-%s-%.8s.%s",
-		os,
-		arch,
-		version,
-		hash,
-		fileExt,
-	)
+	gethURL := DefaultMirrorURL + "/" + ArchiveName(os, arch, version, hash)
 
 	resp, err := http.Head(gethURL)
 	if err != nil {
@@ -99,33 +50,11 @@ func GethBinaryURL(os, arch, version, hash string) (string, bool, error) {
 	return gethURL, true, nil
 }
 
-// getReleaseHash retrieves the latest stable geth release data from official go-ethereum github repo.
-// version is the semantic version of the geth release
-func getReleaseHash(semanticVersion string) (string, error) {
-	var response map[string]interface{}
-
-	resp, err := http.Get("https://api.github.com/repos/ethereum/go-ethereum/commits/v" + semanticVersion)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	err = json.NewDecoder(resp.Body).Decode(&response)
-	if err != nil {
-		return "", err
-	}
-
-	return fmt.Sprintf("%v", response["sha"]), nil
-}
-
-// DownloadFile downloads a file from url and saves it to the specified filepath.
+// DownloadFile downloads a file from url and saves it to the specified filepath; a partially downloaded file is resumed.
 func DownloadFile(url string, dest string) (*os.File, error) {
-	file := path.Base(url)
-
-	log.Info().Msgf("Downloading file %s from %s", filepath.Join(dest, file), url)
+	path := filepath.Join(dest, filepath.Base(url))
 
-	var path bytes.Buffer
-	path.WriteString(dest + string(os.PathSeparator) + file)
+	log.Info().Msgf("Downloading file %s from %s", path, url)
 
 	start := time.Now()
 
@@ -133,33 +62,14 @@ func DownloadFile(url string, dest string) (*os.File, error) {
 		return nil, errors.Wrap(err, "failed to create directory")
 	}
 
-	outFile, err := os.Create(path.String())
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to create file")
-	}
-	defer outFile.Close()
-
-	headResp, err := http.Head(url)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to get file size")
-	}
-	defer headResp.Body.Close()
-
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to download file")
-	}
-	defer resp.Body.Close()
-
-	_, err = io.Copy(outFile, resp.Body)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to persist file")
+	if err := download(context.Background(), http.DefaultClient, url, path, func(int64, int64) {}); err != nil {
+		return nil, err
 	}
 
 	elapsed := time.Since(start)
 	log.Info().Msgf("Download completed in %s", elapsed)
 
-	return outFile, nil
+	return os.Open(path)
 }
 
 // ExtractGethArchive extracts the geth binary from .tar.gz or .zip to the specified geth directory.
@@ -169,6 +79,11 @@ func DownloadFile(url string, dest string) (*os.File, error) {
 // - removes the .tar.gz or .zip (windows)archive
 // - change mode of the geth binary to executable
 func ExtractGethArchive(goOS, filePath, gethDir string) error {
+	return extractGethArchive(goOS, params.Version, filePath, gethDir)
+}
+
+// extractGethArchive extracts the geth binary of the version from the archive; see ExtractGethArchive.
+func extractGethArchive(goOS, version, filePath, gethDir string) error {
 	log.Info().Msgf("Extracting %s to %s\n", filePath, gethDir)
 
 	start := time.Now()
@@ -199,7 +114,7 @@ func ExtractGethArchive(goOS, filePath, gethDir string) error {
 	log.Info().Msgf("Extraction completed in %s", time.Since(start))
 
 	// move and rename extracted geth binary
-	newGethBinaryPath := filepath.Join(gethDir, GethBinaryFilename(goOS, params.Version))
+	newGethBinaryPath := filepath.Join(gethDir, GethBinaryFilename(goOS, version))
 	gethBinaryPath := filepath.Join(gethBinaryDir, "geth")
 	if goOS == "windows" {
 		gethBinaryPath += ".exe"
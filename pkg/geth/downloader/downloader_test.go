@@ -10,33 +10,6 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-func Test_getReleaseHash(t *testing.T) {
-	is := assert.New(t)
-
-	tests := []struct {
-		version string
-		wantSHA string
-	}{
-		{
-			version: "1.10.11",
-			wantSHA: "7231b3efb8095d3dd18d7164c3fa84d7705759d3",
-		},
-		{
-			version: "1.10.9",
-			wantSHA: "eae3b1946a276ac099e0018fc792d9e8c3bfda6d",
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.version, func(t *testing.T) {
-			gotSHA, err := getReleaseHash(tt.version)
-			is.NoError(err)
-
-			is.Equal(tt.wantSHA, gotSHA)
-		})
-	}
-}
-
 func Test_GethBinaryURL(t *testing.T) {
 	is := assert.New(t)
 
@@ -0,0 +1,162 @@
+package downloader
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// DefaultMirrorURL is the base url of the official geth release archives.
+const DefaultMirrorURL = "https://gethstore.blob.core.windows.net/builds"
+
+// releaseCommits are the commits of known geth releases; archives are named after the first 8 characters of the commit.
+var releaseCommits = map[string]string{
+	"1.10.9":  "eae3b1946a276ac099e0018fc792d9e8c3bfda6d",
+	"1.10.11": "7231b3efb8095d3dd18d7164c3fa84d7705759d3",
+}
+
+// Release is a geth release archive of a mirror.
+type Release struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit"`
+	URL     string `json:"url"`
+	// MD5 is the base64 encoded MD5 checksum published by the mirror listing; empty if not published
+	MD5 string `json:"md5,omitempty"`
+}
+
+// ArchiveName returns the filename of the geth release archive of the platform.
+func ArchiveName(os, arch, version, commit string) string {
+	fileExt := "tar.gz"
+	if os == "windows" {
+		fileExt = "zip"
+	}
+
+	if os == "darwin" {
+		// no download binaries for M1 macs since runtime.GOARCH == "arm64"
+		arch = "amd64"
+	}
+
+	return fmt.Sprintf("geth-%s-%s-%s-%.8s.%s", os, arch, version, commit, fileExt)
+}
+
+// resolveRelease returns the release archive of the version on the mirror; the commit of releases which are not known
+// is looked up in the container listing of the mirror, which is served by the official blob storage and compatible mirrors,
+// or in the directory index of the mirror, as served by file servers.
+func resolveRelease(ctx context.Context, client *http.Client, mirror, os, arch, version string) (Release, error) {
+	mirror = strings.TrimSuffix(mirror, "/")
+
+	// the listing publishes the checksums of known releases too
+	prefix := strings.TrimSuffix(ArchiveName(os, arch, version, ""), "."+archiveExt(os))
+	blobs, err := listBlobs(ctx, client, mirror, prefix)
+	if err != nil || len(blobs) == 0 {
+		if indexed, indexErr := listIndex(ctx, client, mirror, prefix); indexErr == nil {
+			blobs, err = indexed, nil
+		}
+	}
+	if commit, ok := releaseCommits[version]; ok {
+		release := Release{Version: version, Commit: commit, URL: mirror + "/" + ArchiveName(os, arch, version, commit)}
+		for _, blob := range blobs {
+			if mirror+"/"+blob.Name == release.URL {
+				release.MD5 = blob.Properties.ContentMD5
+			}
+		}
+		return release, nil
+	}
+	if err != nil {
+		return Release{}, fmt.Errorf("%w: v%s: %v", ErrUnknownRelease, version, err)
+	}
+
+	for _, blob := range blobs {
+		if !strings.HasSuffix(blob.Name, "."+archiveExt(os)) {
+			continue
+		}
+		commit := strings.TrimSuffix(strings.TrimPrefix(blob.Name, prefix), "."+archiveExt(os))
+		if len(commit) != 8 {
+			continue
+		}
+		return Release{Version: version, Commit: commit, URL: mirror + "/" + blob.Name, MD5: blob.Properties.ContentMD5}, nil
+	}
+	return Release{}, fmt.Errorf("%w: v%s", ErrUnknownRelease, version)
+}
+
+func archiveExt(os string) string {
+	if os == "windows" {
+		return "zip"
+	}
+	return "tar.gz"
+}
+
+// blob is an entry of the container listing of Azure blob storage.
+type blob struct {
+	Name       string `xml:"Name"`
+	Properties struct {
+		ContentMD5 string `xml:"Content-MD5"`
+	} `xml:"Properties"`
+}
+
+// listBlobs returns the blobs of the mirror with the prefix.
+// source: https://learn.microsoft.com/en-us/rest/api/storageservices/list-blobs
+func listBlobs(ctx context.Context, client *http.Client, mirror, prefix string) ([]blob, error) {
+	listURL := fmt.Sprintf("%s?restype=container&comp=list&prefix=%s", mirror, url.QueryEscape(prefix))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, listURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("listing not available: %s", resp.Status)
+	}
+
+	var listing struct {
+		Blobs []blob `xml:"Blobs>Blob"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&listing); err != nil {
+		return nil, fmt.Errorf("malformed listing: %w", err)
+	}
+	return listing.Blobs, nil
+}
+
+// hrefPattern matches the links of a directory index.
+var hrefPattern = regexp.MustCompile(`href="([^"?#]+)"`)
+
+// listIndex returns the files of the directory index of the mirror with the prefix; without checksums.
+func listIndex(ctx context.Context, client *http.Client, mirror, prefix string) ([]blob, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, mirror+"/", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("index not available: %s", resp.Status)
+	}
+
+	// indexes of release archives are small
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return nil, err
+	}
+	var blobs []blob
+	for _, match := range hrefPattern.FindAllStringSubmatch(string(body), -1) {
+		name, err := url.PathUnescape(path.Base(match[1]))
+		if err != nil || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		blobs = append(blobs, blob{Name: name})
+	}
+	return blobs, nil
+}
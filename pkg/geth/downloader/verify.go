@@ -0,0 +1,127 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// Verification is the result of verifying a release archive against the checksums and signature published by the mirror.
+type Verification struct {
+	SHA256 string `json:"sha256"` // of the archive
+	// Checksum is the published checksum the archive was verified against; sha256 or md5, empty if none was published
+	Checksum string `json:"checksum"`
+	// Signature is set if the archive was verified against its PGP signature with the configured keyring
+	Signature bool `json:"signature"`
+}
+
+// Verified returns whether the archive was verified against a published SHA-256 checksum or signature.
+func (v Verification) Verified() bool {
+	return v.Checksum == "sha256" || v.Signature
+}
+
+// IntegrityOnly returns whether the archive was only checked against the MD5 checksum of the mirror listing; the check
+// detects corrupt downloads, but not archives replaced on the mirror, since the listing is served by the mirror too.
+func (v Verification) IntegrityOnly() bool {
+	return !v.Verified() && v.Checksum == "md5"
+}
+
+// verify verifies the archive against the checksum published as <url>.sha256, or the MD5 checksum of the listing,
+// and against the PGP signature published as <url>.asc if a keyring is configured. Missing checksums and signatures
+// are not an error; mismatches are.
+func verify(ctx context.Context, client *http.Client, release Release, path, keyring string) (Verification, error) {
+	sha256Sum, md5Sum, err := fileChecksums(path)
+	if err != nil {
+		return Verification{}, err
+	}
+	v := Verification{SHA256: hex.EncodeToString(sha256Sum)}
+
+	published, err := fetchOptional(ctx, client, release.URL+".sha256")
+	if err != nil {
+		return v, err
+	}
+	switch {
+	case published != nil:
+		// as written by sha256sum: <checksum>  <filename>
+		fields := strings.Fields(string(published))
+		if len(fields) == 0 || !strings.EqualFold(fields[0], v.SHA256) {
+			return v, fmt.Errorf("%w: sha256 %s", ErrChecksumMismatch, v.SHA256)
+		}
+		v.Checksum = "sha256"
+	case release.MD5 != "":
+		if release.MD5 != base64.StdEncoding.EncodeToString(md5Sum) {
+			return v, fmt.Errorf("%w: md5", ErrChecksumMismatch)
+		}
+		v.Checksum = "md5"
+	}
+
+	if keyring == "" {
+		return v, nil
+	}
+	sig, err := fetchOptional(ctx, client, release.URL+".asc")
+	if err != nil || sig == nil {
+		return v, err
+	}
+	keys, err := openpgp.ReadArmoredKeyRing(strings.NewReader(keyring))
+	if err != nil {
+		return v, fmt.Errorf("invalid keyring: %w", err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return v, err
+	}
+	defer f.Close()
+	if _, err := openpgp.CheckArmoredDetachedSignature(keys, f, bytes.NewReader(sig)); err != nil {
+		return v, fmt.Errorf("%w: %v", ErrSignatureMismatch, err)
+	}
+	v.Signature = true
+	return v, nil
+}
+
+// fileChecksums returns the SHA-256 and MD5 checksums of the file.
+func fileChecksums(path string) ([]byte, []byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	sha256Hash, md5Hash := sha256.New(), md5.New()
+	if _, err := io.Copy(io.MultiWriter(sha256Hash, md5Hash), f); err != nil {
+		return nil, nil, err
+	}
+	return sha256Hash.Sum(nil), md5Hash.Sum(nil), nil
+}
+
+// fetchOptional returns the body of the url; nil if the mirror does not publish it.
+func fetchOptional(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("failed to fetch %s: %s", url, resp.Status)
+	}
+	// checksums and signatures are small
+	return ioutil.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
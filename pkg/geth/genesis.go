@@ -0,0 +1,203 @@
+package geth
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// GenesisFilename is the filename of the genesis file written to the data dir of a managed node it is initialised with.
+const GenesisFilename = "genesis.json"
+
+// DefaultGenesisGasLimit is the block gas limit of genesis blocks built without a gas limit.
+const DefaultGenesisGasLimit = 30000000
+
+// Consensus is the consensus engine of a private network.
+type Consensus string
+
+const (
+	// ConsensusDev seals blocks by a single signer; on demand when transactions are pending unless a period is set
+	ConsensusDev Consensus = "dev"
+	// ConsensusClique seals blocks in turns by the signers every period seconds
+	ConsensusClique Consensus = "clique"
+)
+
+// blockForks are the forks activated by block number supported by the bundled geth version, in activation order.
+var blockForks = []struct {
+	name  string
+	block func(*params.ChainConfig) **big.Int
+}{
+	{"homestead", func(c *params.ChainConfig) **big.Int { return &c.HomesteadBlock }},
+	{"eip150", func(c *params.ChainConfig) **big.Int { return &c.EIP150Block }},
+	{"eip155", func(c *params.ChainConfig) **big.Int { return &c.EIP155Block }},
+	{"eip158", func(c *params.ChainConfig) **big.Int { return &c.EIP158Block }},
+	{"byzantium", func(c *params.ChainConfig) **big.Int { return &c.ByzantiumBlock }},
+	{"constantinople", func(c *params.ChainConfig) **big.Int { return &c.ConstantinopleBlock }},
+	{"petersburg", func(c *params.ChainConfig) **big.Int { return &c.PetersburgBlock }},
+	{"istanbul", func(c *params.ChainConfig) **big.Int { return &c.IstanbulBlock }},
+	{"muirGlacier", func(c *params.ChainConfig) **big.Int { return &c.MuirGlacierBlock }},
+	{"berlin", func(c *params.ChainConfig) **big.Int { return &c.BerlinBlock }},
+	{"london", func(c *params.ChainConfig) **big.Int { return &c.LondonBlock }},
+}
+
+// timeForks are activated by timestamp once the network transitioned to proof of stake; which clique does not support.
+var timeForks = []string{"shanghai", "cancun", "prague"}
+
+// GenesisSpec are the parameters of the genesis block of a private network.
+type GenesisSpec struct {
+	ChainID   uint64           `json:"chainId"`
+	Consensus Consensus        `json:"consensus"`
+	Signers   []common.Address `json:"signers"` // the single signer of dev chains, or the initial clique signers
+	Period    uint64           `json:"period"`  // seconds between blocks; required by clique
+	Epoch     uint64           `json:"epoch"`   // blocks between clique checkpoints; defaults to 30000
+	GasLimit  uint64           `json:"gasLimit"`
+	Timestamp uint64           `json:"timestamp"`
+	// Forks are the activation blocks of forks by name, e.g. {"london": 100}; forks not set are active from the genesis block
+	Forks map[string]uint64 `json:"forks"`
+	// Alloc are the accounts of the genesis state; prefunded balances, and the code and storage of predeployed contracts
+	Alloc core.GenesisAlloc `json:"alloc"`
+}
+
+// Validate returns an error if the genesis block can not be built.
+func (spec GenesisSpec) Validate() error {
+	if spec.ChainID == 0 {
+		return errors.New("chainId is required")
+	}
+	switch spec.Consensus {
+	case ConsensusDev:
+		if len(spec.Signers) != 1 {
+			return errors.New("dev consensus requires a single signer")
+		}
+	case ConsensusClique:
+		if len(spec.Signers) == 0 {
+			return errors.New("clique consensus requires at least one signer")
+		}
+		if spec.Period == 0 {
+			return errors.New("clique consensus requires a period")
+		}
+	default:
+		return fmt.Errorf("consensus must be %s or %s", ConsensusDev, ConsensusClique)
+	}
+	seen := map[common.Address]bool{}
+	for _, signer := range spec.Signers {
+		if signer == (common.Address{}) || seen[signer] {
+			return fmt.Errorf("invalid signer %s: signers must be unique non-zero addresses", signer)
+		}
+		seen[signer] = true
+	}
+	if spec.GasLimit != 0 && spec.GasLimit < params.MinGasLimit {
+		return fmt.Errorf("gasLimit must be at least %d", params.MinGasLimit)
+	}
+
+	for name := range spec.Forks {
+		if !knownBlockFork(name) {
+			for _, fork := range timeForks {
+				if name == fork {
+					return fmt.Errorf("fork %s is activated by timestamp after the transition to proof of stake, which clique does not support", name)
+				}
+			}
+			return fmt.Errorf("unknown fork %s", name)
+		}
+	}
+	if err := spec.chainConfig().CheckConfigForkOrder(); err != nil {
+		return err
+	}
+
+	for addr, account := range spec.Alloc {
+		if account.Balance == nil || account.Balance.Sign() < 0 {
+			return fmt.Errorf("invalid alloc %s: balance must not be negative", addr)
+		}
+		if len(account.Storage) > 0 && len(account.Code) == 0 {
+			return fmt.Errorf("invalid alloc %s: storage requires code", addr)
+		}
+	}
+	return nil
+}
+
+func knownBlockFork(name string) bool {
+	for _, fork := range blockForks {
+		if fork.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// chainConfig returns the chain config of the spec; sealed by clique.
+func (spec GenesisSpec) chainConfig() *params.ChainConfig {
+	epoch := spec.Epoch
+	if epoch == 0 {
+		epoch = params.AllCliqueProtocolChanges.Clique.Epoch
+	}
+	config := &params.ChainConfig{
+		ChainID: new(big.Int).SetUint64(spec.ChainID),
+		Clique:  &params.CliqueConfig{Period: spec.Period, Epoch: epoch},
+	}
+	for _, fork := range blockForks {
+		*fork.block(config) = new(big.Int).SetUint64(spec.Forks[fork.name])
+	}
+	return config
+}
+
+// Build returns the genesis block of the spec.
+func (spec GenesisSpec) Build() (*core.Genesis, error) {
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+
+	gasLimit := spec.GasLimit
+	if gasLimit == 0 {
+		gasLimit = DefaultGenesisGasLimit
+	}
+	config := spec.chainConfig()
+
+	// clique reads the initial signers, in ascending order, from the extra data; between the vanity and the seal
+	signers := append([]common.Address{}, spec.Signers...)
+	sort.Slice(signers, func(i, j int) bool { return bytes.Compare(signers[i].Bytes(), signers[j].Bytes()) < 0 })
+	extra := make([]byte, 32)
+	for _, signer := range signers {
+		extra = append(extra, signer[:]...)
+	}
+	extra = append(extra, make([]byte, crypto.SignatureLength)...)
+
+	alloc := core.GenesisAlloc{}
+	for addr, account := range spec.Alloc {
+		alloc[addr] = account
+	}
+
+	genesis := &core.Genesis{
+		Config:     config,
+		Timestamp:  spec.Timestamp,
+		ExtraData:  extra,
+		GasLimit:   gasLimit,
+		Difficulty: big.NewInt(1),
+		Alloc:      alloc,
+	}
+	if config.IsLondon(common.Big0) {
+		genesis.BaseFee = big.NewInt(params.InitialBaseFee)
+	}
+	return genesis, nil
+}
+
+// MarshalGenesis returns the genesis as the genesis.json read by geth init.
+func MarshalGenesis(genesis *core.Genesis) ([]byte, error) {
+	return json.MarshalIndent(genesis, "", "  ")
+}
+
+// WriteGenesisFile writes the genesis to the file at the path.
+func WriteGenesisFile(genesis *core.Genesis, path string) error {
+	b, err := MarshalGenesis(genesis)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0600)
+}
@@ -0,0 +1,108 @@
+package geth
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GenesisSpecValidate(t *testing.T) {
+	signer := common.HexToAddress("0x1")
+	tests := []struct {
+		name    string
+		spec    GenesisSpec
+		wantErr bool
+	}{
+		{"valid dev", GenesisSpec{ChainID: 1337, Consensus: ConsensusDev, Signers: []common.Address{signer}}, false},
+		{"valid clique", GenesisSpec{ChainID: 1337, Consensus: ConsensusClique, Signers: []common.Address{signer, common.HexToAddress("0x2")}, Period: 5}, false},
+		{"valid forks", GenesisSpec{ChainID: 1337, Consensus: ConsensusDev, Signers: []common.Address{signer}, Forks: map[string]uint64{"berlin": 5, "london": 10}}, false},
+		{"missing chain id", GenesisSpec{Consensus: ConsensusDev, Signers: []common.Address{signer}}, true},
+		{"unknown consensus", GenesisSpec{ChainID: 1337, Consensus: "ethash", Signers: []common.Address{signer}}, true},
+		{"dev without signer", GenesisSpec{ChainID: 1337, Consensus: ConsensusDev}, true},
+		{"dev with signers", GenesisSpec{ChainID: 1337, Consensus: ConsensusDev, Signers: []common.Address{signer, common.HexToAddress("0x2")}}, true},
+		{"clique without period", GenesisSpec{ChainID: 1337, Consensus: ConsensusClique, Signers: []common.Address{signer}}, true},
+		{"duplicate signers", GenesisSpec{ChainID: 1337, Consensus: ConsensusClique, Signers: []common.Address{signer, signer}, Period: 5}, true},
+		{"gas limit too low", GenesisSpec{ChainID: 1337, Consensus: ConsensusDev, Signers: []common.Address{signer}, GasLimit: 1}, true},
+		{"unknown fork", GenesisSpec{ChainID: 1337, Consensus: ConsensusDev, Signers: []common.Address{signer}, Forks: map[string]uint64{"frontier": 1}}, true},
+		{"timestamp fork", GenesisSpec{ChainID: 1337, Consensus: ConsensusDev, Signers: []common.Address{signer}, Forks: map[string]uint64{"shanghai": 1}}, true},
+		{"fork order", GenesisSpec{ChainID: 1337, Consensus: ConsensusDev, Signers: []common.Address{signer}, Forks: map[string]uint64{"berlin": 10, "london": 5}}, true},
+		{"alloc without balance", GenesisSpec{ChainID: 1337, Consensus: ConsensusDev, Signers: []common.Address{signer}, Alloc: core.GenesisAlloc{signer: {}}}, true},
+		{"storage without code", GenesisSpec{ChainID: 1337, Consensus: ConsensusDev, Signers: []common.Address{signer}, Alloc: core.GenesisAlloc{
+			signer: {Balance: big.NewInt(0), Storage: map[common.Hash]common.Hash{{}: common.HexToHash("0x1")}},
+		}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := assert.New(t)
+			err := tt.spec.Validate()
+			is.Equal(tt.wantErr, err != nil, err)
+		})
+	}
+}
+
+func Test_GenesisSpecBuild(t *testing.T) {
+	is := assert.New(t)
+
+	signers := []common.Address{common.HexToAddress("0x2"), common.HexToAddress("0x1")}
+	contract := common.HexToAddress("0x1000")
+	code := common.FromHex("0x600160005260206000f3")
+	spec := GenesisSpec{
+		ChainID:   1337,
+		Consensus: ConsensusClique,
+		Signers:   signers,
+		Period:    5,
+		Forks:     map[string]uint64{"london": 10},
+		Alloc: core.GenesisAlloc{
+			signers[0]: {Balance: big.NewInt(1e18)},
+			contract:   {Balance: big.NewInt(0), Code: code, Storage: map[common.Hash]common.Hash{{}: common.HexToHash("0x2a")}},
+		},
+	}
+
+	genesis, err := spec.Build()
+	is.NoError(err)
+	is.Equal(uint64(1337), genesis.Config.ChainID.Uint64())
+	is.Equal(uint64(5), genesis.Config.Clique.Period)
+	is.Equal(uint64(30000), genesis.Config.Clique.Epoch)
+	is.Equal(uint64(DefaultGenesisGasLimit), genesis.GasLimit)
+	is.Equal(big.NewInt(0), genesis.Config.BerlinBlock)
+	is.Equal(big.NewInt(10), genesis.Config.LondonBlock)
+	is.Nil(genesis.BaseFee) // london is not active in the genesis block
+
+	// signers are sorted between the vanity and the seal
+	is.Len(genesis.ExtraData, 32+2*common.AddressLength+65)
+	is.Equal(signers[1].Bytes(), genesis.ExtraData[32:52])
+	is.Equal(signers[0].Bytes(), genesis.ExtraData[52:72])
+
+	// signers are sorted by their bytes; not by their checksum encoding, which sorts 0xB000… before 0xa000…
+	mixedCase := []common.Address{common.HexToAddress("0xb000000000000000000000000000000000000000"), common.HexToAddress("0xa000000000000000000000000000000000000000")}
+	sorted, err := GenesisSpec{ChainID: 1337, Consensus: ConsensusClique, Signers: mixedCase, Period: 5}.Build()
+	is.NoError(err)
+	is.Equal(mixedCase[1].Bytes(), sorted.ExtraData[32:52])
+
+	// geth initialises a chain database from the genesis
+	b, err := MarshalGenesis(genesis)
+	is.NoError(err)
+	var parsed core.Genesis
+	is.NoError(parsed.UnmarshalJSON(b))
+
+	db := rawdb.NewMemoryDatabase()
+	config, hash, err := core.SetupGenesisBlock(db, &parsed)
+	is.NoError(err)
+	is.Equal(genesis.ToBlock(nil).Hash(), hash)
+	is.Equal(big.NewInt(10), config.LondonBlock)
+
+	statedb, err := state.New(rawdb.ReadBlock(db, hash, 0).Root(), state.NewDatabase(db), nil)
+	is.NoError(err)
+	is.Equal(code, statedb.GetCode(contract))
+	is.Equal(common.HexToHash("0x2a"), statedb.GetState(contract, common.Hash{}))
+	is.Equal(big.NewInt(1e18), statedb.GetBalance(signers[0]))
+
+	_, err = GenesisSpec{ChainID: 1337, Consensus: ConsensusDev}.Build()
+	is.Error(err)
+}
@@ -5,7 +5,7 @@ package geth
 import (
 	"math/big"
 
-	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/ethereum/go-ethereum/eth/ethconfig"
 	"github.com/ethereum/go-ethereum/log"
@@ -44,21 +44,21 @@ var DefaultGethConfig = func() GethConfig {
 	}
 }()
 
-// SetupMainnetGethConfig creates a geth configuration
-// note: only mainnet based setup is supported
+// MainnetGethConfig creates a geth configuration of the Ethereum mainnet
+// note: other networks are configured by applying their Preset; see MainnetPreset
 func MainnetGethConfig() GethConfig {
 	cfg := DefaultGethConfig
 
 	setBootstrapNodes(&cfg.Node.P2P)
 	setBootstrapNodesV5(&cfg.Node.P2P)
 
-	utils.SetDNSDiscoveryDefaults(&cfg.Eth, params.MainnetGenesisHash)
+	setDNSDiscoveryDefaults(&cfg.Eth, params.MainnetGenesisHash)
 
 	return cfg
 }
 
 // DevModeConfig returns a config with all settings at their defaults for the dev mode
-// note: period is ignored as it is only used for the genesis block (which is not set at the moment)
+// note: period is ignored as it is only used for the genesis block; see DevGenesis and StartDevChain
 func DevModeConfig(period int) GethConfig {
 	cfg := DefaultGethConfig
 
@@ -66,23 +66,6 @@ func DevModeConfig(period int) GethConfig {
 	cfg.Eth.NetworkId = 1337
 	cfg.Eth.SyncMode = downloader.FullSync
 
-	// var ks *keystore.KeyStore
-	// if keystores := stack.AccountManager().Backends(keystore.KeyStoreType); len(keystores) > 0 {
-	// 	ks = keystores[0].(*keystore.KeyStore)
-	// }
-
-	// developer, err = ks.NewAccount("")
-	// if err != nil {
-	// 	return GethConfig{}, err
-	// }
-
-	// if err := ks.Unlock(developer, passphrase); err != nil {
-	// 	return GethConfig{}, err
-	// }
-	// log.Info("Using developer account", "address", developer.Address)
-
-	// cfg.Eth.Genesis = core.DeveloperGenesisBlock(period, developer.Address)
-
 	cfg.Eth.Miner.GasPrice = big.NewInt(1)
 
 	// Node config
@@ -161,3 +144,21 @@ func setBootstrapNodesV5(cfg *p2p.Config) {
 		}
 	}
 }
+
+// setDNSDiscoveryDefaults configures DNS discovery with the given URL if
+// no URLs are set.
+// note: not imported from cmd/utils; its dependencies do not link with recent go versions
+// source: https://github.com/ethereum/go-ethereum/blob/v1.10.11/cmd/utils/flags.go#L1669
+func setDNSDiscoveryDefaults(cfg *ethconfig.Config, genesis common.Hash) {
+	if cfg.EthDiscoveryURLs != nil {
+		return // already set through flags/config
+	}
+	protocol := "all"
+	if cfg.SyncMode == downloader.LightSync {
+		protocol = "les"
+	}
+	if url := params.KnownDNSNetwork(genesis, protocol); url != "" {
+		cfg.EthDiscoveryURLs = []string{url}
+		cfg.SnapDiscoveryURLs = cfg.EthDiscoveryURLs
+	}
+}
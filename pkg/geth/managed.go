@@ -0,0 +1,40 @@
+package geth
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ConfigFilename is the name of the geth config file in the data dir of a managed node.
+const ConfigFilename = "config.toml"
+
+// ManagedGethConfig returns the config of a geth process managed by zeth, based on the given config.
+// HTTP and WS are served on the loopback interface; the IPC socket is created in the data dir.
+func ManagedGethConfig(cfg GethConfig, dataDir string, httpPort, wsPort, p2pPort int) GethConfig {
+	cfg.Node.DataDir = dataDir
+	cfg.Node.IPCPath = "geth.ipc"
+
+	cfg.EnableHTTP()
+	cfg.Node.HTTPPort = httpPort
+	cfg.Node.WSHost = "127.0.0.1"
+	cfg.Node.WSPort = wsPort
+	cfg.Node.P2P.ListenAddr = fmt.Sprintf(":%d", p2pPort)
+
+	return cfg
+}
+
+// WriteConfigFile dumps the configuration to the file; in the format of `geth dumpconfig`.
+func WriteConfigFile(cfg GethConfig, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return dumpConfig(cfg, f)
+}
@@ -0,0 +1,34 @@
+package geth
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_WriteManagedConfigFile(t *testing.T) {
+	is := assert.New(t)
+
+	dataDir := t.TempDir()
+	cfg := ManagedGethConfig(MainnetGethConfig(), dataDir, 8645, 8646, 30403)
+	is.Equal(filepath.Join(dataDir, "geth.ipc"), cfg.Node.IPCEndpoint())
+
+	path := filepath.Join(dataDir, ConfigFilename)
+	is.NoError(WriteConfigFile(cfg, path))
+
+	f, err := os.Open(path)
+	is.NoError(err)
+	defer f.Close()
+
+	// the dumped config is loaded by geth via --config
+	var loaded GethConfig
+	is.NoError(tomlSettings.NewDecoder(f).Decode(&loaded))
+	is.Equal(dataDir, loaded.Node.DataDir)
+	is.Equal("127.0.0.1", loaded.Node.HTTPHost)
+	is.Equal(8645, loaded.Node.HTTPPort)
+	is.Equal(8646, loaded.Node.WSPort)
+	is.Equal(":30403", loaded.Node.P2P.ListenAddr)
+	is.Equal(uint64(1), loaded.Eth.NetworkId)
+}
@@ -0,0 +1,149 @@
+package geth
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// ethDiscoPrefix is the prefix of the DNS discovery lists of the public networks maintained by the Ethereum Foundation.
+// source: https://github.com/ethereum/go-ethereum/blob/v1.10.11/params/bootnodes.go#L88
+const ethDiscoPrefix = "enrtree://AKA3AM6LPBYEUDMVNU3BSVQJ5AD45Y7YPOHJLEF6W26QOE4VTUDPE@all."
+
+// Preset describes the network a geth config connects to; its network id, genesis and peer discovery.
+type Preset struct {
+	Name      string `json:"name"`
+	NetworkID uint64 `json:"networkId"`
+	// GenesisHash identifies the genesis block of the network; data dirs of networks other than mainnet must be initialised
+	// with the genesis block, since it is not bundled with geth
+	GenesisHash  common.Hash `json:"genesisHash"`
+	Bootnodes    []string    `json:"bootnodes"`    // enode urls of the discovery v4 bootstrap nodes
+	BootnodesV5  []string    `json:"bootnodesV5"`  // enode or enr urls of the discovery v5 bootstrap nodes
+	DNSDiscovery []string    `json:"dnsDiscovery"` // enrtree urls of DNS discovery lists
+	// MinVersion is the oldest geth version which can follow the network; empty if the bundled geth version can
+	MinVersion string `json:"minVersion,omitempty"`
+	Builtin    bool   `json:"builtin"` // bundled presets can not be modified
+}
+
+// testnetMinVersion is the first geth release scheduling the Prague fork of the sepolia and holesky testnets; older versions
+// can not follow either network since the fork. Later forks of the networks may require upgrading the node.
+const testnetMinVersion = "1.15.0"
+
+// MainnetPreset is the preset of the Ethereum mainnet; the network geth connects to by default.
+var MainnetPreset = Preset{
+	Name:         "mainnet",
+	NetworkID:    1,
+	GenesisHash:  params.MainnetGenesisHash,
+	Bootnodes:    params.MainnetBootnodes,
+	BootnodesV5:  params.V5Bootnodes,
+	DNSDiscovery: []string{params.KnownDNSNetwork(params.MainnetGenesisHash, "all")},
+	Builtin:      true,
+}
+
+// BuiltinPresets returns the bundled presets; mainnet first.
+// source: https://github.com/ethereum/go-ethereum/blob/master/params/bootnodes.go
+func BuiltinPresets() []Preset {
+	return []Preset{
+		MainnetPreset,
+		{
+			Name:        "sepolia",
+			NetworkID:   11155111,
+			GenesisHash: common.HexToHash("0x25a5cc106eea7138acab33231d7160d69cb777ee0c2c553fcddf5138993e6dd9"),
+			Bootnodes: []string{
+				"enode://4e5e92199ee224a01932a377160aa432f31d0b351f84ab413a8e0a42f4f36476f8fb1cbe914af0d9aef0d51665c214cf653c651c4bbd9d5550a934f241f1682b@138.197.51.181:30303",
+				"enode://143e11fb766781d22d92a2e33f8f104cddae4411a122295ed1fdb6638de96a6ce65f5b7c964ba3763bba27961738fef7d3ecc739268f3e5e771fb4c87b6234ba@146.190.1.103:30303",
+			},
+			BootnodesV5:  params.V5Bootnodes,
+			DNSDiscovery: []string{ethDiscoPrefix + "sepolia.ethdisco.net"},
+			MinVersion:   testnetMinVersion,
+			Builtin:      true,
+		},
+		{
+			Name:        "holesky",
+			NetworkID:   17000,
+			GenesisHash: common.HexToHash("0xb5f7f912443c940f21fd611f12828d75b534364ed9e95ca4e307729a4661bde4"),
+			Bootnodes: []string{
+				"enode://ac906289e4b7f12df423d654c5a962b6ebe5b3a74cc9e06292a85221f9a64a6f1cfdd6b714ed6dacef51578f92b34c60ee91e9ede9c7f8fadc4d347326d95e2b@146.190.13.128:30303",
+			},
+			BootnodesV5:  params.V5Bootnodes,
+			DNSDiscovery: []string{ethDiscoPrefix + "holesky.ethdisco.net"},
+			MinVersion:   testnetMinVersion,
+			Builtin:      true,
+		},
+	}
+}
+
+// BuiltinPreset returns the bundled preset of the name.
+func BuiltinPreset(name string) (Preset, bool) {
+	for _, p := range BuiltinPresets() {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Preset{}, false
+}
+
+var presetName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// Validate returns an error if the name is not a lowercase slug, the network id is not set, or a url is malformed.
+func (p Preset) Validate() error {
+	if !presetName.MatchString(p.Name) {
+		return errors.New("name must consist of lowercase letters, digits and dashes")
+	}
+	if p.NetworkID == 0 {
+		return errors.New("networkId is required")
+	}
+	if p.MinVersion != "" && !ValidVersion(p.MinVersion) {
+		return errors.New("minVersion must be a geth release version, e.g. 1.10.11")
+	}
+	for _, url := range p.Bootnodes {
+		if _, err := enode.Parse(enode.ValidSchemes, url); err != nil {
+			return fmt.Errorf("invalid bootnode %s: %w", url, err)
+		}
+	}
+	for _, url := range p.BootnodesV5 {
+		if _, err := enode.Parse(enode.ValidSchemes, url); err != nil {
+			return fmt.Errorf("invalid v5 bootnode %s: %w", url, err)
+		}
+	}
+	for _, url := range p.DNSDiscovery {
+		if !strings.HasPrefix(url, "enrtree://") {
+			return fmt.Errorf("invalid DNS discovery url %s: must be an enrtree:// url", url)
+		}
+	}
+	return nil
+}
+
+// RequiresGenesis returns whether data dirs must be initialised with the genesis block of the network before geth is started;
+// geth initialises data dirs with the mainnet genesis block otherwise.
+func (p Preset) RequiresGenesis() bool {
+	return p.GenesisHash != params.MainnetGenesisHash
+}
+
+// Apply returns the config with the network id and peer discovery of the preset.
+// note: the preset must be valid
+func (p Preset) Apply(cfg GethConfig) GethConfig {
+	cfg.Eth.NetworkId = p.NetworkID
+	cfg.Eth.EthDiscoveryURLs = append([]string{}, p.DNSDiscovery...)
+	cfg.Eth.SnapDiscoveryURLs = append([]string{}, p.DNSDiscovery...)
+
+	cfg.Node.P2P.BootstrapNodes = parseEnodes(p.Bootnodes)
+	cfg.Node.P2P.BootstrapNodesV5 = parseEnodes(p.BootnodesV5)
+	return cfg
+}
+
+// parseEnodes parses the urls; invalid urls are skipped.
+func parseEnodes(urls []string) []*enode.Node {
+	nodes := make([]*enode.Node, 0, len(urls))
+	for _, url := range urls {
+		if n, err := enode.Parse(enode.ValidSchemes, url); err == nil {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes
+}
@@ -0,0 +1,65 @@
+package geth
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_BuiltinPresets(t *testing.T) {
+	is := assert.New(t)
+
+	names := map[string]bool{}
+	for _, p := range BuiltinPresets() {
+		is.NoError(p.Validate(), p.Name)
+		is.True(p.Builtin)
+		is.NotEmpty(p.Bootnodes, p.Name)
+		is.Len(p.Apply(DefaultGethConfig).Node.P2P.BootstrapNodes, len(p.Bootnodes), p.Name)
+		is.False(names[p.Name], "duplicate preset %s", p.Name)
+		names[p.Name] = true
+	}
+
+	// the mainnet preset is the default network of geth
+	changes, err := DiffConfig(MainnetGethConfig(), MainnetPreset.Apply(DefaultGethConfig))
+	is.NoError(err)
+	is.Empty(changes)
+	is.False(MainnetPreset.RequiresGenesis())
+
+	sepolia, ok := BuiltinPreset("sepolia")
+	is.True(ok)
+	is.True(sepolia.RequiresGenesis())
+	cfg := sepolia.Apply(MainnetGethConfig())
+	is.Equal(uint64(11155111), cfg.Eth.NetworkId)
+	is.Equal([]string{"enrtree://AKA3AM6LPBYEUDMVNU3BSVQJ5AD45Y7YPOHJLEF6W26QOE4VTUDPE@all.sepolia.ethdisco.net"}, cfg.Eth.EthDiscoveryURLs)
+	is.Equal(1, CompareVersions(sepolia.MinVersion, params.Version))
+
+	holesky, ok := BuiltinPreset("holesky")
+	is.True(ok)
+	is.Equal(uint64(17000), holesky.NetworkID)
+	is.NoError(holesky.Validate())
+	is.NotEmpty(holesky.MinVersion)
+}
+
+func Test_PresetValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		preset  Preset
+		wantErr bool
+	}{
+		{"minimal", Preset{Name: "private-1", NetworkID: 1337}, false},
+		{"invalid name", Preset{Name: "My Network", NetworkID: 1337}, true},
+		{"missing network id", Preset{Name: "private"}, true},
+		{"invalid bootnode", Preset{Name: "private", NetworkID: 1337, Bootnodes: []string{"enode://invalid@127.0.0.1:30303"}}, true},
+		{"invalid dns discovery", Preset{Name: "private", NetworkID: 1337, DNSDiscovery: []string{"https://example.com"}}, true},
+		{"min version", Preset{Name: "private", NetworkID: 1337, MinVersion: "1.15.0"}, false},
+		{"invalid min version", Preset{Name: "private", NetworkID: 1337, MinVersion: "latest"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := assert.New(t)
+			is.Equal(tt.wantErr, tt.preset.Validate() != nil)
+		})
+	}
+}
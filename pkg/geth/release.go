@@ -0,0 +1,50 @@
+package geth
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// versionPattern matches geth release versions; e.g. 1.10.11 or 1.10.12-unstable.
+var versionPattern = regexp.MustCompile(`^\d+\.\d+\.\d+(-[0-9A-Za-z]+)?$`)
+
+// ValidVersion returns whether the version is a geth release version.
+func ValidVersion(version string) bool {
+	return versionPattern.MatchString(version)
+}
+
+// CompareVersions compares the release versions numerically; a version with a suffix precedes the release, e.g. 1.10.12-unstable < 1.10.12.
+func CompareVersions(a, b string) int {
+	aRelease, aSuffix := splitVersion(a)
+	bRelease, bSuffix := splitVersion(b)
+	for i := range aRelease {
+		if aRelease[i] != bRelease[i] {
+			if aRelease[i] < bRelease[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case aSuffix == bSuffix:
+		return 0
+	case aSuffix == "":
+		return 1
+	case bSuffix == "":
+		return -1
+	}
+	return strings.Compare(aSuffix, bSuffix)
+}
+
+func splitVersion(version string) ([3]int, string) {
+	var release [3]int
+	parts := strings.SplitN(version, "-", 2)
+	for i, part := range strings.SplitN(parts[0], ".", 3) {
+		release[i], _ = strconv.Atoi(part)
+	}
+	if len(parts) == 2 {
+		return release, parts[1]
+	}
+	return release, ""
+}
@@ -0,0 +1,27 @@
+package geth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.10.11", "1.10.11", 0},
+		{"1.10.11", "1.10.9", 1},
+		{"1.9.25", "1.10.0", -1},
+		{"1.10.12-unstable", "1.10.12", -1},
+		{"1.10.12-unstable", "1.10.11", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			is := assert.New(t)
+			is.Equal(tt.want, CompareVersions(tt.a, tt.b))
+		})
+	}
+}
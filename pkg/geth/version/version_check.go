@@ -3,6 +3,7 @@
 package version
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -10,13 +11,15 @@ import (
 	"net/http"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/jedisct1/go-minisign"
 	"golang.org/x/sync/errgroup"
 )
 
-var gethPubKeys []string = []string{
+// GethPubKeys are the minisign public keys the vulnerabilities feed is signed with.
+var GethPubKeys []string = []string{
 	//@holiman, minisign public key FB1D084D39BAEC24
 	"RWQk7Lo5TQgd+wxBNZM+Zoy+7UhhMHaWKzqoes9tvSbFLJYZhNTbrIjx",
 	//minisign public key 138B1CA303E51687
@@ -25,81 +28,95 @@ var gethPubKeys []string = []string{
 	"RWSEhAnSshOY/b+GmaiDkObbCWefsAoavjoLcPjBo1xn71yuOH5I+Lts",
 }
 
-type vulnJson struct {
-	Name        string
-	Uid         string
-	Summary     string
-	Description string
-	Links       []string
-	Introduced  string
-	Fixed       string
-	Published   string
-	Severity    string
-	Check       string
-	CVE         string
+// Vulnerability is an entry of the geth vulnerabilities feed.
+type Vulnerability struct {
+	Name        string   `json:"name"`
+	Uid         string   `json:"uid"`
+	Summary     string   `json:"summary"`
+	Description string   `json:"description"`
+	Links       []string `json:"links"`
+	Introduced  string   `json:"introduced"`
+	Fixed       string   `json:"fixed"`
+	Published   string   `json:"published"`
+	Severity    string   `json:"severity"`
+	Check       string   `json:"check"` // regular expression matched against the client version
+	CVE         string   `json:"CVE"`
 }
 
 const VersionCheckUrl = "https://geth.ethereum.org/docs/vulnerabilities/vulnerabilities.json"
 
+// fetchTimeout is the timeout of fetching the vulnerabilities feed or its signature.
+const fetchTimeout = 30 * time.Second
+
+var client = &http.Client{Timeout: fetchTimeout}
+
 // CheckVersion checks the version of Geth for vulnerabilities.
 // The current url must pass the regex check of the vulnerability json file.
 // The current version must be in following format: `Geth/v1.2.3-`
-func CheckVersion(url, current string) (*vulnJson, error) {
-	var (
-		data []byte
-		sig  []byte
-		err  error
-	)
-	if data, err = fetch(url); err != nil {
-		return nil, fmt.Errorf("could not retrieve data: %w", err)
-	}
-	if sig, err = fetch(fmt.Sprintf("%v.minisig", url)); err != nil {
-		return nil, fmt.Errorf("could not retrieve signature: %w", err)
-	}
-	if err = verifySignature(gethPubKeys, data, sig); err != nil {
+func CheckVersion(ctx context.Context, url, current string) (*Vulnerability, error) {
+	data, sig, err := FetchFeed(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	vulns, err := ParseFeed(GethPubKeys, data, sig)
+	if err != nil {
 		return nil, err
 	}
-	var vulns []vulnJson
-	if err = json.Unmarshal(data, &vulns); err != nil {
+	matches, err := Match(vulns, current)
+	if err != nil || len(matches) == 0 {
 		return nil, err
 	}
-	// allOk := true
+	return &matches[0], nil
+}
+
+// FetchFeed fetches the vulnerabilities feed and its minisign signature; file:// urls are read from disk.
+func FetchFeed(ctx context.Context, url string) (data, sig []byte, err error) {
+	if data, err = fetch(ctx, url); err != nil {
+		return nil, nil, fmt.Errorf("could not retrieve data: %w", err)
+	}
+	if sig, err = fetch(ctx, fmt.Sprintf("%v.minisig", url)); err != nil {
+		return nil, nil, fmt.Errorf("could not retrieve signature: %w", err)
+	}
+	return data, sig, nil
+}
+
+// ParseFeed verifies the signature of the vulnerabilities feed against the public keys and parses it.
+func ParseFeed(pubkeys []string, data, sig []byte) ([]Vulnerability, error) {
+	if err := verifySignature(pubkeys, data, sig); err != nil {
+		return nil, err
+	}
+	var vulns []Vulnerability
+	if err := json.Unmarshal(data, &vulns); err != nil {
+		return nil, err
+	}
+	return vulns, nil
+}
+
+// Match returns the vulnerabilities whose check matches the client version; e.g. Geth/v1.10.0-stable/linux-amd64/go1.16
+func Match(vulns []Vulnerability, current string) ([]Vulnerability, error) {
+	matches := []Vulnerability{}
 	for _, vuln := range vulns {
 		r, err := regexp.Compile(vuln.Check)
 		if err != nil {
 			return nil, err
 		}
 		if r.MatchString(current) {
-			// allOk = false
-			// fmt.Printf("## Vulnerable to %v (%v)\n\n", vuln.Uid, vuln.Name)
-			// fmt.Printf("Severity: %v\n", vuln.Severity)
-			// fmt.Printf("Summary : %v\n", vuln.Summary)
-			// fmt.Printf("Fixed in: %v\n", vuln.Fixed)
-			// if len(vuln.CVE) > 0 {
-			// 	fmt.Printf("CVE: %v\n", vuln.CVE)
-			// }
-			// if len(vuln.Links) > 0 {
-			// 	fmt.Printf("References:\n")
-			// 	for _, ref := range vuln.Links {
-			// 		fmt.Printf("\t- %v\n", ref)
-			// 	}
-			// }
-			// fmt.Println()
-			return &vuln, nil
+			matches = append(matches, vuln)
 		}
 	}
-	// if allOk {
-	// 	fmt.Println("No vulnerabilities found")
-	// }
-	return nil, nil
+	return matches, nil
 }
 
 // fetch makes an HTTP request to the given url and returns the response body
-func fetch(url string) ([]byte, error) {
+func fetch(ctx context.Context, url string) ([]byte, error) {
 	if filep := strings.TrimPrefix(url, "file://"); filep != url {
 		return ioutil.ReadFile(filep)
 	}
-	res, err := http.Get(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -162,15 +179,15 @@ func SemanticVersionToGethVersion(sv string) string {
 
 // GetVulnerabilities returns a list of vulnerabilities for specified Geth releases (in parallel)
 // TODO: testing
-func GetVulnerabilities(semanticVersions ...string) ([]vulnJson, error) {
-	var vulnerabilityList []vulnJson
+func GetVulnerabilities(ctx context.Context, semanticVersions ...string) ([]Vulnerability, error) {
+	var vulnerabilityList []Vulnerability
 	var g errgroup.Group
 
 	for _, version := range semanticVersions {
 		version := version
 		g.Go(func() error {
 			gethVersion := SemanticVersionToGethVersion(version)
-			vuln, err := CheckVersion(VersionCheckUrl, gethVersion)
+			vuln, err := CheckVersion(ctx, VersionCheckUrl, gethVersion)
 			if err == nil {
 				vulnerabilityList = append(vulnerabilityList, *vuln)
 			}
@@ -1,6 +1,7 @@
 package version
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -32,8 +33,8 @@ func Test_versionCheck(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		// vuln, err := CheckVersion(VersionCheckUrl, "Geth/v1.10.0-")
-		vuln, err := CheckVersion(VersionCheckUrl, SemanticVersionToGethVersion(test.semanticVersion))
+		// vuln, err := CheckVersion(context.Background(), VersionCheckUrl, "Geth/v1.10.0-")
+		vuln, err := CheckVersion(context.Background(), VersionCheckUrl, SemanticVersionToGethVersion(test.semanticVersion))
 		is.NoError(err)
 
 		isVulnerable := vuln != nil
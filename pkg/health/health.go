@@ -0,0 +1,67 @@
+package health
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// Status is the health status of a node at the time of a probe.
+type Status string
+
+const (
+	StatusHealthy     Status = "healthy"
+	StatusDegraded    Status = "degraded" // reachable, but syncing or the latest block is stale
+	StatusUnreachable Status = "unreachable"
+)
+
+// Defaults of the health monitor.
+const (
+	DefaultInterval    = 30 * time.Second
+	DefaultRetention   = 24 * time.Hour
+	DefaultMaxBlockAge = 5 * time.Minute // dev nodes are exempt; they may only produce blocks on demand
+)
+
+// Sample is the result of probing a node.
+type Sample struct {
+	Time         time.Time `json:"time"`
+	Status       Status    `json:"status"`
+	Error        string    `json:"error,omitempty"`
+	Latency      int64     `json:"latency"` // milliseconds; round trip of fetching the latest block header
+	BlockNumber  uint64    `json:"blockNumber"`
+	BlockAge     int64     `json:"blockAge"`            // seconds since the timestamp of the latest block
+	PeerCount    *uint64   `json:"peerCount,omitempty"` // nil if net_peerCount is not supported, e.g. by hosted providers
+	Syncing      bool      `json:"syncing"`
+	HighestBlock uint64    `json:"highestBlock,omitempty"` // if syncing
+}
+
+// Reachable returns whether the node responded to the probe.
+func (s Sample) Reachable() bool {
+	return s.Status != StatusUnreachable
+}
+
+// Report is the current status and health history of a node.
+type Report struct {
+	NodeID  uuid.UUID `json:"nodeId"`
+	Current *Sample   `json:"current"` // nil if the node has not been probed yet
+	// Uptime is the ratio of probes in the window the node was reachable; nil if there are no probes in the window
+	Uptime  *float64  `json:"uptime"`
+	From    time.Time `json:"from"`
+	To      time.Time `json:"to"`
+	History []Sample  `json:"history"`
+}
+
+// uptime returns the ratio of reachable samples; nil if there are no samples.
+func uptime(samples []Sample) *float64 {
+	if len(samples) == 0 {
+		return nil
+	}
+	reachable := 0
+	for _, s := range samples {
+		if s.Reachable() {
+			reachable++
+		}
+	}
+	ratio := float64(reachable) / float64(len(samples))
+	return &ratio
+}
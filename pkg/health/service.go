@@ -0,0 +1,254 @@
+package health
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/rs/zerolog/log"
+	uuid "github.com/satori/go.uuid"
+	"github.com/zees-dev/zeth/pkg/datastore"
+	"github.com/zees-dev/zeth/pkg/events"
+	"github.com/zees-dev/zeth/pkg/node"
+)
+
+// probeTimeout is the timeout of probing a node.
+const probeTimeout = 10 * time.Second
+
+// healthKey returns the namespace of the health history of the node.
+// note: namespaces must not share a prefix with other namespaces, since datastore.GetAll iterates by prefix
+func healthKey(id uuid.UUID) []byte {
+	return []byte(fmt.Sprintf("health:%s", id))
+}
+
+// sampleKey returns the key of a sample; zero padded so keys sort chronologically.
+func sampleKey(t time.Time) []byte {
+	return []byte(fmt.Sprintf("%020d", t.UnixNano()))
+}
+
+// eventData is the data of node health events published by the monitor.
+type eventData struct {
+	Source string `json:"source"`
+	Status Status `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Service periodically probes all enabled nodes and stores the results as a time series.
+// Samples expire from the store after the retention period.
+type Service struct {
+	store       datastore.Store
+	nodes       node.NodeService
+	events      events.Publisher
+	interval    time.Duration
+	retention   time.Duration
+	maxBlockAge time.Duration
+	prober      func(context.Context, node.ZethNode) Sample
+	now         func() time.Time
+
+	mu      sync.Mutex
+	current map[uuid.UUID]Sample // latest sample of each node
+}
+
+func NewService(store datastore.Store, nodes node.NodeService, publisher events.Publisher) *Service {
+	s := &Service{
+		store:       store,
+		nodes:       nodes,
+		events:      publisher,
+		interval:    DefaultInterval,
+		retention:   DefaultRetention,
+		maxBlockAge: DefaultMaxBlockAge,
+		now:         time.Now,
+		current:     make(map[uuid.UUID]Sample),
+	}
+	s.prober = s.probe
+	return s
+}
+
+// Start probes all enabled nodes periodically until the context is cancelled.
+func (s *Service) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			if err := s.ProbeAll(ctx); err != nil {
+				log.Err(err).Msg("failed to probe node health")
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// ProbeAll probes all enabled nodes concurrently and records the results.
+func (s *Service) ProbeAll(ctx context.Context) error {
+	nodes, err := s.nodes.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	for _, n := range nodes {
+		if !n.Enabled {
+			continue
+		}
+		wg.Add(1)
+		go func(n node.ZethNode) {
+			defer wg.Done()
+			s.record(n.ID, s.prober(ctx, n))
+		}(n)
+	}
+	wg.Wait()
+	return nil
+}
+
+// probe fetches the latest block header, sync status and peer count of the node.
+func (s *Service) probe(ctx context.Context, n node.ZethNode) Sample {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	sample := Sample{Time: s.now().UTC(), Status: StatusUnreachable}
+
+	transport, err := s.nodes.Transport(n)
+	if err != nil {
+		sample.Error = err.Error()
+		return sample
+	}
+	c, err := n.DialRPCWithTransport(transport)
+	if err != nil {
+		sample.Error = err.Error()
+		return sample
+	}
+	defer c.Close()
+	client := ethclient.NewClient(c)
+
+	start := time.Now()
+	header, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		sample.Error = err.Error()
+		return sample
+	}
+	sample.Latency = time.Since(start).Milliseconds()
+	sample.BlockNumber = header.Number.Uint64()
+	if age := sample.Time.Unix() - int64(header.Time); age > 0 {
+		sample.BlockAge = age
+	}
+
+	if progress, err := client.SyncProgress(ctx); err == nil && progress != nil {
+		sample.Syncing = true
+		sample.HighestBlock = progress.HighestBlock
+	}
+
+	var peers hexutil.Uint64
+	if err := c.CallContext(ctx, &peers, "net_peerCount"); err == nil {
+		count := uint64(peers)
+		sample.PeerCount = &count
+	}
+
+	sample.Status = StatusHealthy
+	if sample.Syncing || (!n.IsDev && time.Duration(sample.BlockAge)*time.Second > s.maxBlockAge) {
+		sample.Status = StatusDegraded
+	}
+	return sample
+}
+
+// record stores the sample of the node; publishing a health event if the node became unreachable or recovered.
+func (s *Service) record(id uuid.UUID, sample Sample) {
+	s.mu.Lock()
+	prev, probed := s.current[id]
+	s.current[id] = sample
+	s.mu.Unlock()
+
+	bodyBytes := new(bytes.Buffer)
+	json.NewEncoder(bodyBytes).Encode(sample)
+	if err := s.store.SetWithTTL(healthKey(id), sampleKey(sample.Time), bodyBytes.Bytes(), s.retention); err != nil {
+		log.Err(err).Msgf("failed to store health sample of node: %s", id)
+	}
+
+	data := eventData{Source: "monitor", Status: sample.Status, Error: sample.Error}
+	switch {
+	case !sample.Reachable() && (!probed || prev.Reachable()):
+		s.events.Publish(events.NodeUnhealthy, id.String(), data)
+	case sample.Reachable() && probed && !prev.Reachable():
+		s.events.Publish(events.NodeHealthy, id.String(), data)
+	}
+}
+
+// Current returns the latest sample of the node; nil if the node has not been probed.
+func (s *Service) Current(id uuid.UUID) *Sample {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sample, ok := s.current[id]
+	if !ok {
+		return nil
+	}
+	return &sample
+}
+
+// History returns the retained samples of the node in the time window, in chronological order.
+// A zero from or to leaves the window open on that side.
+func (s *Service) History(ctx context.Context, id uuid.UUID, from, to time.Time) ([]Sample, error) {
+	results := []Sample{}
+
+	samplesMap, err := s.store.GetAll(healthKey(id))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, b := range samplesMap {
+		var sample Sample
+		if err := json.Unmarshal(b, &sample); err != nil {
+			return nil, err
+		}
+		if (!from.IsZero() && sample.Time.Before(from)) || (!to.IsZero() && sample.Time.After(to)) {
+			continue
+		}
+		results = append(results, sample)
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Time.Before(results[j].Time) })
+	return results, nil
+}
+
+// Report returns the current status of the node, and its history and uptime in the time window.
+// The window defaults to the retention period.
+func (s *Service) Report(ctx context.Context, id uuid.UUID, from, to time.Time) (Report, error) {
+	if to.IsZero() {
+		to = s.now().UTC()
+	}
+	if from.IsZero() {
+		from = to.Add(-s.retention)
+	}
+
+	history, err := s.History(ctx, id, from, to)
+	if err != nil {
+		return Report{}, err
+	}
+
+	return Report{
+		NodeID:  id,
+		Current: s.Current(id),
+		Uptime:  uptime(history),
+		From:    from,
+		To:      to,
+		History: history,
+	}, nil
+}
+
+// Remove deletes the health history of the node.
+func (s *Service) Remove(id uuid.UUID) error {
+	s.mu.Lock()
+	delete(s.current, id)
+	s.mu.Unlock()
+
+	return s.store.RemovePrefix(healthKey(id), nil)
+}
@@ -0,0 +1,172 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/zees-dev/zeth/pkg/datastore/badgerdbtest"
+	"github.com/zees-dev/zeth/pkg/events"
+	"github.com/zees-dev/zeth/pkg/node"
+)
+
+func Test_ServiceRecordsHistory(t *testing.T) {
+	is := assert.New(t)
+
+	store, cleanup := badgerdbtest.MustNewTestBadgerDB()
+	defer cleanup()
+
+	bus := events.NewBus(events.DefaultBacklog)
+	nodes := node.NewService(store, bus)
+	ctx := context.Background()
+
+	n, err := nodes.Create(ctx, node.ZethNode{Name: "test", Enabled: true})
+	is.NoError(err)
+	_, err = nodes.Create(ctx, node.ZethNode{Name: "disabled", Enabled: false})
+	is.NoError(err)
+
+	s := NewService(store, nodes, bus)
+	now := time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	statuses := []Status{StatusHealthy, StatusUnreachable, StatusUnreachable, StatusDegraded}
+	probes := 0
+	s.prober = func(_ context.Context, probed node.ZethNode) Sample {
+		is.Equal(n.ID, probed.ID)
+		sample := Sample{Time: now, Status: statuses[probes]}
+		probes++
+		return sample
+	}
+
+	c := make(chan events.Event, 10)
+	_, unsubscribe := bus.Subscribe(c, 0, []string{"node.healthy", "node.unhealthy"})
+	defer unsubscribe()
+
+	is.Nil(s.Current(n.ID))
+	for range statuses {
+		is.NoError(s.ProbeAll(ctx))
+		now = now.Add(time.Minute)
+	}
+	is.Equal(len(statuses), probes)
+
+	// events are only published on transitions
+	types := []events.Type{}
+	for len(c) > 0 {
+		ev := <-c
+		is.Equal(n.ID.String(), ev.Subject)
+		types = append(types, ev.Type)
+	}
+	is.Equal([]events.Type{events.NodeUnhealthy, events.NodeHealthy}, types)
+
+	report, err := s.Report(ctx, n.ID, time.Time{}, time.Time{})
+	is.NoError(err)
+	is.Equal(StatusDegraded, report.Current.Status)
+	is.Len(report.History, 4)
+	for i, sample := range report.History {
+		is.Equal(statuses[i], sample.Status)
+	}
+	is.Equal(0.5, *report.Uptime)
+
+	// window
+	from := time.Date(2021, 12, 1, 0, 1, 0, 0, time.UTC)
+	report, err = s.Report(ctx, n.ID, from, from.Add(time.Minute))
+	is.NoError(err)
+	is.Len(report.History, 2)
+	is.Equal(0.0, *report.Uptime)
+
+	is.NoError(s.Remove(n.ID))
+	is.Nil(s.Current(n.ID))
+	history, err := s.History(ctx, n.ID, time.Time{}, time.Time{})
+	is.NoError(err)
+	is.Len(history, 0)
+}
+
+// newTestRPCServer returns a JSON-RPC server serving the methods used by the probe.
+func newTestRPCServer(t *testing.T, header *types.Header, syncing bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+
+		var result interface{}
+		switch req.Method {
+		case "eth_getBlockByNumber":
+			result = header
+		case "eth_syncing":
+			result = false
+			if syncing {
+				result = map[string]string{"startingBlock": "0x0", "currentBlock": "0x64", "highestBlock": "0xc8"}
+			}
+		case "net_peerCount":
+			result = "0x19"
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+	}))
+}
+
+func Test_Probe(t *testing.T) {
+	store, cleanup := badgerdbtest.MustNewTestBadgerDB()
+	defer cleanup()
+
+	bus := events.NewBus(events.DefaultBacklog)
+	s := NewService(store, node.NewService(store, bus), bus)
+	now := time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	tests := []struct {
+		name     string
+		blockAge time.Duration
+		syncing  bool
+		isDev    bool
+		want     Status
+	}{
+		{"healthy", 10 * time.Second, false, false, StatusHealthy},
+		{"stale head", time.Hour, false, false, StatusDegraded},
+		{"stale dev node", time.Hour, false, true, StatusHealthy},
+		{"syncing", 10 * time.Second, true, false, StatusDegraded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := assert.New(t)
+
+			header := &types.Header{Number: big.NewInt(100), Difficulty: big.NewInt(1), Time: uint64(now.Add(-tt.blockAge).Unix())}
+			srv := newTestRPCServer(t, header, tt.syncing)
+			defer srv.Close()
+
+			n := node.NewNode(srv.URL, "")
+			n.IsDev = tt.isDev
+			sample := s.probe(context.Background(), *n)
+			is.Empty(sample.Error)
+			is.Equal(tt.want, sample.Status)
+			is.Equal(uint64(100), sample.BlockNumber)
+			is.Equal(int64(tt.blockAge.Seconds()), sample.BlockAge)
+			is.Equal(uint64(25), *sample.PeerCount)
+			is.Equal(tt.syncing, sample.Syncing)
+		})
+	}
+
+	t.Run("unreachable", func(t *testing.T) {
+		is := assert.New(t)
+
+		srv := httptest.NewServer(http.NotFoundHandler())
+		srv.Close()
+
+		sample := s.probe(context.Background(), *node.NewNode(srv.URL, ""))
+		is.Equal(StatusUnreachable, sample.Status)
+		is.NotEmpty(sample.Error)
+		is.False(sample.Reachable())
+	})
+}
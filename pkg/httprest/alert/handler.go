@@ -0,0 +1,26 @@
+package alert
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/zees-dev/zeth/pkg/alert"
+	"github.com/zees-dev/zeth/pkg/app"
+)
+
+type handler struct {
+	alertSvc alert.RuleService
+}
+
+func RegisterRoutes(app *app.App, baseRouter *mux.Router) {
+	h := handler{
+		alertSvc: app.Services.Alerts,
+	}
+
+	baseRouter.HandleFunc("/alerts", h.getAlerts).Methods(http.MethodGet)
+	baseRouter.HandleFunc("/alerts/rules", h.getAllRules).Methods(http.MethodGet)
+	baseRouter.HandleFunc("/alerts/rules", h.createRule).Methods(http.MethodPost)
+	baseRouter.HandleFunc("/alerts/rules/{uuid}", h.getRule).Methods(http.MethodGet)
+	baseRouter.HandleFunc("/alerts/rules/{uuid}", h.updateRule).Methods(http.MethodPut)
+	baseRouter.HandleFunc("/alerts/rules/{uuid}", h.removeRule).Methods(http.MethodDelete)
+}
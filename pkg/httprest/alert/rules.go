@@ -0,0 +1,192 @@
+package alert
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+	uuid "github.com/satori/go.uuid"
+	"github.com/zees-dev/zeth/pkg/alert"
+	"github.com/zees-dev/zeth/pkg/httprest/rest"
+)
+
+// getAlerts returns the alert states of all rules; firing and resolved.
+/* curl request:
+curl \
+	-H "Content-Type: application/json" \
+	http://localhost:7000/api/v1/alerts
+*/
+func (h *handler) getAlerts(w http.ResponseWriter, r *http.Request) {
+	states, err := h.alertSvc.States(r.Context())
+	if err != nil {
+		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	rest.JSON(w, states)
+}
+
+/* curl request:
+curl \
+	-H "Content-Type: application/json" \
+	http://localhost:7000/api/v1/alerts/rules
+*/
+func (h *handler) getAllRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.alertSvc.GetAll(r.Context())
+	if err != nil {
+		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	rest.JSON(w, rules)
+}
+
+type rulePayload struct {
+	Name          string          `json:"name"`
+	Enabled       bool            `json:"enabled"`
+	Kind          alert.RuleKind  `json:"kind"`
+	NodeIDs       []uuid.UUID     `json:"nodeIds"`
+	WindowSeconds int64           `json:"windowSeconds"`
+	MinRequests   int             `json:"minRequests"`
+	Threshold     float64         `json:"threshold"`
+	ChainID       uint64          `json:"chainId"`
+	Webhooks      []alert.Webhook `json:"webhooks"`
+}
+
+func (payload *rulePayload) rule() alert.Rule {
+	return alert.Rule{
+		Name:          payload.Name,
+		Enabled:       payload.Enabled,
+		Kind:          payload.Kind,
+		NodeIDs:       payload.NodeIDs,
+		WindowSeconds: payload.WindowSeconds,
+		MinRequests:   payload.MinRequests,
+		Threshold:     payload.Threshold,
+		ChainID:       payload.ChainID,
+		Webhooks:      payload.Webhooks,
+	}
+}
+
+func (payload *rulePayload) Validate() url.Values {
+	errs := url.Values{}
+
+	if err := payload.rule().Validate(); err != nil {
+		errs.Add("rule", err.Error())
+	}
+
+	return errs
+}
+
+/* curl request:
+curl -X POST \
+	-H "Content-Type: application/json" \
+	-d '{"name":"high error rate","enabled":true,"kind":"error_rate","threshold":0.1,"windowSeconds":300,"webhooks":[{"url":"http://localhost:9000/hook"}]}' \
+	http://localhost:7000/api/v1/alerts/rules
+*/
+func (h *handler) createRule(w http.ResponseWriter, r *http.Request) {
+	payload := rulePayload{}
+	if ok := rest.DecodeAndValidateJSONPayload(w, r.Body, &payload); !ok {
+		log.Debug().Msg("validation failed")
+		return
+	}
+
+	rule, err := h.alertSvc.Create(r.Context(), payload.rule())
+	if err != nil {
+		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	rest.JSON(w, rule)
+}
+
+/* curl request:
+curl \
+	-H "Content-Type: application/json" \
+	http://localhost:7000/api/v1/alerts/rules/21fa9b25-840a-40e9-acda-fad525615e58
+*/
+func (h *handler) getRule(w http.ResponseWriter, r *http.Request) {
+	// get id from request parameters
+	id := mux.Vars(r)["uuid"]
+
+	uid, err := uuid.FromString(id)
+	if err != nil {
+		http.Error(w, rest.HTTPBadRequest, http.StatusBadRequest)
+		return
+	}
+
+	rule, err := h.alertSvc.Get(r.Context(), uid)
+	if err != nil {
+		http.Error(w, rest.HTTPNotFound, http.StatusNotFound)
+		return
+	}
+
+	rest.JSON(w, rule)
+}
+
+/* curl request:
+curl -X PUT \
+	-H "Content-Type: application/json" \
+	-d '{"name":"slow node","enabled":true,"kind":"p95_latency","threshold":500}' \
+	http://localhost:7000/api/v1/alerts/rules/21fa9b25-840a-40e9-acda-fad525615e58
+*/
+func (h *handler) updateRule(w http.ResponseWriter, r *http.Request) {
+	// get id from request parameters
+	id := mux.Vars(r)["uuid"]
+
+	uid, err := uuid.FromString(id)
+	if err != nil {
+		http.Error(w, rest.HTTPBadRequest, http.StatusBadRequest)
+		return
+	}
+
+	existing, err := h.alertSvc.Get(r.Context(), uid)
+	if err != nil {
+		http.Error(w, rest.HTTPNotFound, http.StatusNotFound)
+		return
+	}
+
+	payload := rulePayload{}
+	if ok := rest.DecodeAndValidateJSONPayload(w, r.Body, &payload); !ok {
+		log.Debug().Msg("validation failed")
+		return
+	}
+
+	rule := payload.rule()
+	rule.ID = existing.ID
+	rule.DateAdded = existing.DateAdded
+
+	if err := h.alertSvc.Update(r.Context(), uid, rule); err != nil {
+		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	rest.JSON(w, rule)
+}
+
+/* curl request:
+curl -X DELETE \
+	http://localhost:7000/api/v1/alerts/rules/21fa9b25-840a-40e9-acda-fad525615e58
+*/
+func (h *handler) removeRule(w http.ResponseWriter, r *http.Request) {
+	// get id from request parameters
+	id := mux.Vars(r)["uuid"]
+
+	uid, err := uuid.FromString(id)
+	if err != nil {
+		http.Error(w, rest.HTTPBadRequest, http.StatusBadRequest)
+		return
+	}
+
+	if _, err = h.alertSvc.Get(r.Context(), uid); err != nil {
+		http.Error(w, rest.HTTPNotFound, http.StatusNotFound)
+		return
+	}
+
+	if err = h.alertSvc.Delete(r.Context(), uid); err != nil {
+		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,119 @@
+package binary
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+	"github.com/zees-dev/zeth/pkg/geth/downloader"
+	"github.com/zees-dev/zeth/pkg/httprest/rest"
+	"github.com/zees-dev/zeth/pkg/managed"
+)
+
+// getBinaries returns the installed geth binaries, newest version first; with their size, verification status and the nodes using them.
+/* curl request:
+curl \
+	http://localhost:7000/api/v1/binaries
+*/
+func (h *handler) getBinaries(w http.ResponseWriter, r *http.Request) {
+	binaries, err := h.managed.Binaries(r.Context())
+	if err != nil {
+		log.Err(err).Msg("failed to list geth binaries")
+		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	rest.JSON(w, binaries)
+}
+
+/* curl request:
+curl \
+	http://localhost:7000/api/v1/binaries/1.10.11
+*/
+func (h *handler) getBinary(w http.ResponseWriter, r *http.Request) {
+	b, err := h.managed.Binary(r.Context(), mux.Vars(r)["version"])
+	if err != nil {
+		switch {
+		case errors.Is(err, managed.ErrInvalidVersion):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, managed.ErrBinaryNotInstalled):
+			http.Error(w, rest.HTTPNotFound, http.StatusNotFound)
+		default:
+			http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	rest.JSON(w, b)
+}
+
+type installBinaryRequestPayload struct {
+	Version string `json:"version"`
+}
+
+func (payload *installBinaryRequestPayload) Validate() url.Values {
+	errs := url.Values{}
+
+	if !managed.ValidVersion(payload.Version) {
+		errs.Add("version", managed.ErrInvalidVersion.Error())
+	}
+
+	return errs
+}
+
+// installBinary downloads, verifies and installs the geth binary of the version in the background; the installed binary is
+// returned if the version is already installed. The progress and the result of the download are published as binary download
+// events; the binary is served by getBinary once installed.
+/* curl request:
+curl -X POST \
+	-H "Content-Type: application/json" \
+	-d '{"version": "1.10.11"}' \
+	http://localhost:7000/api/v1/binaries
+*/
+func (h *handler) installBinary(w http.ResponseWriter, r *http.Request) {
+	payload := installBinaryRequestPayload{}
+	if ok := rest.DecodeAndValidateJSONPayload(w, r.Body, &payload); !ok {
+		log.Debug().Msg("validation failed")
+		return
+	}
+
+	b, err := h.managed.InstallBinaryInBackground(r.Context(), payload.Version)
+	if err != nil {
+		log.Err(err).Msgf("failed to install geth binary v%s", payload.Version)
+		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		return
+	}
+	if b != nil {
+		rest.JSON(w, b)
+		return
+	}
+
+	rest.JSONStatus(w, http.StatusAccepted, downloader.Progress{Version: payload.Version})
+}
+
+// removeBinary deletes the installed geth binary of the version; refused if a managed node is configured with the version.
+/* curl request:
+curl -X DELETE \
+	http://localhost:7000/api/v1/binaries/1.10.9
+*/
+func (h *handler) removeBinary(w http.ResponseWriter, r *http.Request) {
+	version := mux.Vars(r)["version"]
+	if err := h.managed.RemoveBinary(r.Context(), version); err != nil {
+		switch {
+		case errors.Is(err, managed.ErrInvalidVersion):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, managed.ErrBinaryNotInstalled):
+			http.Error(w, rest.HTTPNotFound, http.StatusNotFound)
+		case errors.Is(err, managed.ErrBinaryInUse):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			log.Err(err).Msgf("failed to remove geth binary v%s", version)
+			http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
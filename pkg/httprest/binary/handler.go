@@ -0,0 +1,24 @@
+package binary
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/zees-dev/zeth/pkg/app"
+	"github.com/zees-dev/zeth/pkg/managed"
+)
+
+type handler struct {
+	managed *managed.Service
+}
+
+func RegisterRoutes(app *app.App, baseRouter *mux.Router) {
+	h := handler{
+		managed: app.Services.Managed,
+	}
+
+	baseRouter.HandleFunc("/binaries", h.getBinaries).Methods(http.MethodGet)
+	baseRouter.HandleFunc("/binaries", h.installBinary).Methods(http.MethodPost)
+	baseRouter.HandleFunc("/binaries/{version}", h.getBinary).Methods(http.MethodGet)
+	baseRouter.HandleFunc("/binaries/{version}", h.removeBinary).Methods(http.MethodDelete)
+}
@@ -0,0 +1,188 @@
+package chain
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+	"github.com/zees-dev/zeth/pkg/chain"
+	"github.com/zees-dev/zeth/pkg/httprest/rest"
+)
+
+// maxImportSize is the maximum size of an imported chain list; the full chainlist is ~1MB.
+const maxImportSize = 16 << 20
+
+// chainID returns the chain ID of the request path.
+func chainID(r *http.Request) (uint64, bool) {
+	id, err := strconv.ParseUint(mux.Vars(r)["chainId"], 10, 64)
+	return id, err == nil && id > 0
+}
+
+/* curl request:
+curl \
+	-H "Content-Type: application/json" \
+	"http://localhost:7000/api/v1/chains?testnet=false"
+*/
+func (h *handler) getChains(w http.ResponseWriter, r *http.Request) {
+	var testnet *bool
+	if v := r.URL.Query().Get("testnet"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			rest.ValidationErrors(w, url.Values{"testnet": {"testnet must be true or false"}})
+			return
+		}
+		testnet = &b
+	}
+
+	chains, err := h.chains.GetAll(r.Context())
+	if err != nil {
+		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	results := []chain.Chain{}
+	for _, c := range chains {
+		if testnet == nil || c.Testnet == *testnet {
+			results = append(results, c)
+		}
+	}
+
+	rest.JSON(w, results)
+}
+
+/* curl request:
+curl \
+	-H "Content-Type: application/json" \
+	http://localhost:7000/api/v1/chains/1
+*/
+func (h *handler) getChain(w http.ResponseWriter, r *http.Request) {
+	id, ok := chainID(r)
+	if !ok {
+		http.Error(w, rest.HTTPBadRequest, http.StatusBadRequest)
+		return
+	}
+
+	c, err := h.chains.Get(r.Context(), id)
+	if err != nil {
+		http.Error(w, rest.HTTPNotFound, http.StatusNotFound)
+		return
+	}
+
+	rest.JSON(w, c)
+}
+
+type chainPayload chain.Chain
+
+func (payload *chainPayload) Validate() url.Values {
+	errs := url.Values{}
+
+	if err := chain.Chain(*payload).Validate(); err != nil {
+		errs.Add("chain", err.Error())
+	}
+
+	return errs
+}
+
+// upsertChain adds the chain to the registry, or replaces the chain with the chain ID of the path.
+/* curl request:
+curl -X PUT \
+	-H "Content-Type: application/json" \
+	-d '{"name": "Local Devnet", "chain": "ETH", "shortName": "local", "chainId": 31337, "nativeCurrency": {"name": "Ether", "symbol": "ETH", "decimals": 18}, "testnet": true, "blockTime": 0}' \
+	http://localhost:7000/api/v1/chains/31337
+*/
+func (h *handler) upsertChain(w http.ResponseWriter, r *http.Request) {
+	id, ok := chainID(r)
+	if !ok {
+		http.Error(w, rest.HTTPBadRequest, http.StatusBadRequest)
+		return
+	}
+
+	payload := chainPayload{}
+	if ok := rest.DecodeAndValidateJSONPayload(w, r.Body, &payload); !ok {
+		log.Debug().Msg("validation failed")
+		return
+	}
+	if payload.ChainID != id {
+		rest.ValidationErrors(w, url.Values{"chainId": {"chainId must match the chain ID of the path"}})
+		return
+	}
+
+	c := chain.Chain(payload)
+	if err := h.chains.Upsert(r.Context(), c); err != nil {
+		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	rest.JSON(w, c)
+}
+
+/* curl request:
+curl -X DELETE \
+	http://localhost:7000/api/v1/chains/31337
+*/
+func (h *handler) removeChain(w http.ResponseWriter, r *http.Request) {
+	id, ok := chainID(r)
+	if !ok {
+		http.Error(w, rest.HTTPBadRequest, http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.chains.Get(r.Context(), id); err != nil {
+		http.Error(w, rest.HTTPNotFound, http.StatusNotFound)
+		return
+	}
+
+	if err := h.chains.Delete(r.Context(), id); err != nil {
+		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type importChainsResponse struct {
+	Imported int `json:"imported"`
+}
+
+// importChains imports a chainlist-style JSON array of chains; e.g. a local copy of https://chainid.network/chains.json
+// Existing chains are only replaced if overwrite is set; the bundled chains are imported if bundled is set.
+/* curl request:
+curl -X POST \
+	-H "Content-Type: application/json" \
+	--data-binary @chains.json \
+	"http://localhost:7000/api/v1/chains/import?overwrite=true"
+
+restore the bundled chains:
+curl -X POST "http://localhost:7000/api/v1/chains/import?bundled=true"
+*/
+func (h *handler) importChains(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	overwrite, _ := strconv.ParseBool(q.Get("overwrite"))
+	bundled, _ := strconv.ParseBool(q.Get("bundled"))
+
+	var chains []chain.Chain
+	if bundled {
+		chains = chain.Bundled()
+	} else {
+		b, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxImportSize))
+		if err != nil {
+			http.Error(w, rest.HTTPBadRequest, http.StatusBadRequest)
+			return
+		}
+		if chains, err = chain.Parse(b); err != nil {
+			rest.ValidationErrors(w, url.Values{"chains": {err.Error()}})
+			return
+		}
+	}
+
+	imported, err := h.chains.Import(r.Context(), chains, overwrite)
+	if err != nil {
+		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	rest.JSON(w, importChainsResponse{Imported: imported})
+}
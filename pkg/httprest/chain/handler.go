@@ -0,0 +1,25 @@
+package chain
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/zees-dev/zeth/pkg/app"
+	"github.com/zees-dev/zeth/pkg/chain"
+)
+
+type handler struct {
+	chains chain.Registry
+}
+
+func RegisterRoutes(app *app.App, baseRouter *mux.Router) {
+	h := handler{
+		chains: app.Services.Chains,
+	}
+
+	baseRouter.HandleFunc("/chains", h.getChains).Methods(http.MethodGet)
+	baseRouter.HandleFunc("/chains/import", h.importChains).Methods(http.MethodPost)
+	baseRouter.HandleFunc("/chains/{chainId}", h.getChain).Methods(http.MethodGet)
+	baseRouter.HandleFunc("/chains/{chainId}", h.upsertChain).Methods(http.MethodPut)
+	baseRouter.HandleFunc("/chains/{chainId}", h.removeChain).Methods(http.MethodDelete)
+}
@@ -1,6 +1,7 @@
 package defi
 
 import (
+	"fmt"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -100,6 +101,11 @@ func (h *handler) createAMM(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if _, err := h.chains.Get(r.Context(), uint64(payload.ChainID)); err != nil {
+		rest.ValidationErrors(w, url.Values{"chainId": {fmt.Sprintf("chain %d is not registered", payload.ChainID)}})
+		return
+	}
+
 	amm := amm.AMM{
 		ID:             uuid.NewV4(),
 		Name:           payload.Name,
@@ -5,16 +5,19 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/zees-dev/zeth/pkg/app"
+	"github.com/zees-dev/zeth/pkg/chain"
 	"github.com/zees-dev/zeth/pkg/defi"
 )
 
 type handler struct {
 	ammSvc defi.AutomatedMarketMaker
+	chains chain.Registry
 }
 
 func RegisterRoutes(app *app.App, baseRouter *mux.Router) {
 	h := handler{
 		ammSvc: app.Services.AutomatedMarketMaker,
+		chains: app.Services.Chains,
 	}
 
 	baseRouter.HandleFunc("/defi/amm", h.getAllAMMs).Methods(http.MethodGet)
@@ -0,0 +1,171 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
+	"github.com/zees-dev/zeth/pkg/app"
+	"github.com/zees-dev/zeth/pkg/events"
+	"github.com/zees-dev/zeth/pkg/httprest/rest"
+)
+
+type eventsHandler struct {
+	bus *events.Bus
+}
+
+func RegisterRoutes(app *app.App, baseRouter *mux.Router) {
+	h := eventsHandler{
+		bus: app.Services.Events,
+	}
+
+	baseRouter.HandleFunc("/events", h.handleSSE).Methods(http.MethodGet)
+	baseRouter.HandleFunc("/events/ws", h.handleWS).Methods(http.MethodGet)
+}
+
+// parseTypes parses the event type patterns of the type query parameter; it can be repeated or comma separated.
+func parseTypes(q url.Values) ([]string, url.Values) {
+	errs := url.Values{}
+	types := []string{}
+	for _, v := range q["type"] {
+		for _, t := range strings.Split(v, ",") {
+			if t = strings.TrimSpace(t); t == "" {
+				continue
+			}
+			if err := events.ValidateTypePattern(t); err != nil {
+				errs.Add("type", fmt.Sprintf("invalid type pattern: %s", t))
+			}
+			types = append(types, t)
+		}
+	}
+	return types, errs
+}
+
+// lastEventID returns the ID of the last event received by a reconnecting client.
+func lastEventID(r *http.Request) uint64 {
+	v := r.Header.Get("Last-Event-ID")
+	if v == "" {
+		v = r.URL.Query().Get("lastEventId")
+	}
+	id, _ := strconv.ParseUint(v, 10, 64)
+	return id
+}
+
+// subscribe subscribes to the event bus with the types of the request; an error response is written on failure.
+func (h *eventsHandler) subscribe(w http.ResponseWriter, r *http.Request) (chan events.Event, []events.Event, func(), bool) {
+	types, errs := parseTypes(r.URL.Query())
+	if len(errs) > 0 {
+		rest.ValidationErrors(w, errs)
+		return nil, nil, nil, false
+	}
+
+	c := make(chan events.Event, events.DefaultBacklog)
+	backlog, unsubscribeFn := h.bus.Subscribe(c, lastEventID(r), types)
+	return c, backlog, unsubscribeFn, true
+}
+
+/* curl request:
+curl -v http://localhost:7000/api/v1/events
+
+filter by event type and resume after the last received event:
+curl -v -H "Last-Event-ID: 42" "http://localhost:7000/api/v1/events?type=node.*,settings.updated"
+*/
+func (h *eventsHandler) handleSSE(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	c, backlog, unsubscribeFn, ok := h.subscribe(w, r)
+	if !ok {
+		return
+	}
+	defer unsubscribeFn()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	write := func(ev events.Event) {
+		b, err := json.Marshal(ev)
+		if err != nil {
+			log.Debug().Err(err).Msgf("failed to marshal event: %d", ev.ID)
+			return
+		}
+		fmt.Fprintf(w, "id: %d\ndata: %s\n\n", ev.ID, b)
+	}
+
+	for _, ev := range backlog {
+		write(ev)
+	}
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case ev := <-c:
+			write(ev)
+			flusher.Flush()
+		}
+	}
+}
+
+// wsUpgrader rejects cross-origin connections; events may contain data of nodes which must not be readable by other sites.
+// The SSE stream is not readable cross-origin either, since it does not allow other origins.
+var wsUpgrader = websocket.Upgrader{}
+
+/*
+WebSocket equivalent of the SSE stream; accepts the same query parameters.
+websocat "ws://localhost:7000/api/v1/events/ws?type=node.*"
+*/
+func (h *eventsHandler) handleWS(w http.ResponseWriter, r *http.Request) {
+	c, backlog, unsubscribeFn, ok := h.subscribe(w, r)
+	if !ok {
+		return
+	}
+	defer unsubscribeFn()
+
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Debug().Err(err).Msg("failed to upgrade websocket connection")
+		return
+	}
+	defer conn.Close()
+
+	// read (and discard) client messages to process control frames and detect the client closing the connection
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for _, ev := range backlog {
+		if err := conn.WriteJSON(ev); err != nil {
+			return
+		}
+	}
+
+	for {
+		select {
+		case <-closed:
+			return
+		case ev := <-c:
+			if err := conn.WriteJSON(ev); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package genesis
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zees-dev/zeth/pkg/geth"
+	"github.com/zees-dev/zeth/pkg/httprest/rest"
+)
+
+type genesisSpecPayload geth.GenesisSpec
+
+func (payload *genesisSpecPayload) Validate() url.Values {
+	errs := url.Values{}
+
+	if err := geth.GenesisSpec(*payload).Validate(); err != nil {
+		errs.Add("genesis", err.Error())
+	}
+
+	return errs
+}
+
+// buildGenesis returns the genesis.json of a private network built from the parameters; as read by geth init.
+/* curl request:
+curl -X POST \
+	-H "Content-Type: application/json" \
+	-d '{"chainId": 1337, "consensus": "clique", "signers": ["0x71c7656ec7ab88b098defb751b7401b5f6d8976f"], "period": 5, "gasLimit": 30000000, "forks": {"london": 10}, "alloc": {"0x71c7656ec7ab88b098defb751b7401b5f6d8976f": {"balance": "0x3635c9adc5dea00000"}, "0x0000000000000000000000000000000000001000": {"balance": "0x0", "code": "0x600160005260206000f3"}}}' \
+	http://localhost:7000/api/v1/genesis
+*/
+func (h *handler) buildGenesis(w http.ResponseWriter, r *http.Request) {
+	payload := genesisSpecPayload{}
+	if ok := rest.DecodeAndValidateJSONPayload(w, r.Body, &payload); !ok {
+		log.Debug().Msg("validation failed")
+		return
+	}
+
+	genesis, err := geth.GenesisSpec(payload).Build()
+	if err != nil {
+		rest.ValidationErrors(w, url.Values{"genesis": {err.Error()}})
+		return
+	}
+
+	rest.JSON(w, genesis)
+}
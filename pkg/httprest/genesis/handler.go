@@ -0,0 +1,16 @@
+package genesis
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/zees-dev/zeth/pkg/app"
+)
+
+type handler struct{}
+
+func RegisterRoutes(app *app.App, baseRouter *mux.Router) {
+	h := handler{}
+
+	baseRouter.HandleFunc("/genesis", h.buildGenesis).Methods(http.MethodPost)
+}
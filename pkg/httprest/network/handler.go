@@ -0,0 +1,25 @@
+package network
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/zees-dev/zeth/pkg/app"
+	"github.com/zees-dev/zeth/pkg/network"
+)
+
+type handler struct {
+	networks *network.Service
+}
+
+func RegisterRoutes(app *app.App, baseRouter *mux.Router) {
+	h := handler{
+		networks: app.Services.Networks,
+	}
+
+	baseRouter.HandleFunc("/networks", h.getNetworks).Methods(http.MethodGet)
+	baseRouter.HandleFunc("/networks", h.createNetwork).Methods(http.MethodPost)
+	baseRouter.HandleFunc("/networks/{uuid}", h.getNetwork).Methods(http.MethodGet)
+	baseRouter.HandleFunc("/networks/{uuid}", h.removeNetwork).Methods(http.MethodDelete)
+	baseRouter.HandleFunc("/networks/{uuid}/status", h.getNetworkStatus).Methods(http.MethodGet)
+}
@@ -0,0 +1,179 @@
+package network
+
+import (
+	"errors"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+	uuid "github.com/satori/go.uuid"
+	"github.com/zees-dev/zeth/pkg/httprest/rest"
+	"github.com/zees-dev/zeth/pkg/managed"
+	"github.com/zees-dev/zeth/pkg/network"
+)
+
+type createNetworkRequestPayload struct {
+	Name     string `json:"name"` // members are named <name>-<n>
+	Nodes    int    `json:"nodes"`
+	Version  string `json:"version"`
+	ChainID  uint64 `json:"chainId"`
+	Period   uint64 `json:"period"`
+	GasLimit uint64 `json:"gasLimit"`
+	Accounts int    `json:"accounts"` // number of prefunded accounts; defaults to node.DefaultDevAccounts
+	Balance  uint64 `json:"balance"`  // balance of each account in ether; defaults to node.DefaultDevBalance
+}
+
+func (payload *createNetworkRequestPayload) Validate() url.Values {
+	errs := url.Values{}
+
+	if strings.TrimSpace(payload.Name) == "" {
+		errs.Add("name", "name is required")
+	}
+
+	if err := payload.config().Validate(); err != nil {
+		errs.Add("network", err.Error())
+	}
+
+	return errs
+}
+
+func (payload *createNetworkRequestPayload) config() network.Config {
+	cfg := network.Config{
+		Nodes:    payload.Nodes,
+		Version:  payload.Version,
+		ChainID:  payload.ChainID,
+		Period:   payload.Period,
+		GasLimit: payload.GasLimit,
+		Accounts: payload.Accounts,
+	}
+	if payload.Balance > 0 {
+		cfg.Balance = new(big.Int).Mul(new(big.Int).SetUint64(payload.Balance), big.NewInt(params.Ether))
+	}
+	return cfg
+}
+
+/* curl request:
+curl \
+	http://localhost:7000/api/v1/networks
+*/
+func (h *handler) getNetworks(w http.ResponseWriter, r *http.Request) {
+	networks, err := h.networks.GetAll(r.Context())
+	if err != nil {
+		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	rest.JSON(w, networks)
+}
+
+// createNetwork creates a private network of managed geth nodes sharing a generated genesis block, and starts the nodes;
+// each node is a clique signer, statically peered with all other nodes. The network is created in the background; it is
+// accepted in the creating state, and its state is set to created or failed once done (see the network.created and
+// network.failed events). The prefunded accounts, including their private keys, are returned with the network.
+/* curl request:
+curl -X POST \
+	-H "Content-Type: application/json" \
+	-d '{"name": "private", "nodes": 3, "chainId": 1337, "period": 5, "accounts": 5, "balance": 1000}' \
+	http://localhost:7000/api/v1/networks
+*/
+func (h *handler) createNetwork(w http.ResponseWriter, r *http.Request) {
+	payload := createNetworkRequestPayload{}
+	if ok := rest.DecodeAndValidateJSONPayload(w, r.Body, &payload); !ok {
+		log.Debug().Msg("validation failed")
+		return
+	}
+
+	n, err := h.networks.Create(r.Context(), payload.Name, payload.config())
+	if err != nil {
+		if errors.Is(err, managed.ErrBinaryNotInstalled) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Err(err).Msgf("failed to create network: %s", payload.Name)
+		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	rest.JSONStatus(w, http.StatusAccepted, n)
+}
+
+/* curl request:
+curl \
+	http://localhost:7000/api/v1/networks/00000000-0000-0000-0000-000000000000
+*/
+func (h *handler) getNetwork(w http.ResponseWriter, r *http.Request) {
+	uid, err := uuid.FromString(mux.Vars(r)["uuid"])
+	if err != nil {
+		http.Error(w, rest.HTTPBadRequest, http.StatusBadRequest)
+		return
+	}
+
+	n, err := h.networks.Get(r.Context(), uid)
+	if err != nil {
+		http.Error(w, rest.HTTPNotFound, http.StatusNotFound)
+		return
+	}
+
+	rest.JSON(w, n)
+}
+
+// getNetworkStatus returns the peers and head of each node of the network, and whether the chain forked.
+/* curl request:
+curl \
+	http://localhost:7000/api/v1/networks/00000000-0000-0000-0000-000000000000/status
+*/
+func (h *handler) getNetworkStatus(w http.ResponseWriter, r *http.Request) {
+	uid, err := uuid.FromString(mux.Vars(r)["uuid"])
+	if err != nil {
+		http.Error(w, rest.HTTPBadRequest, http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.networks.Get(r.Context(), uid); err != nil {
+		http.Error(w, rest.HTTPNotFound, http.StatusNotFound)
+		return
+	}
+
+	status, err := h.networks.Status(r.Context(), uid)
+	if err != nil {
+		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	rest.JSON(w, status)
+}
+
+// removeNetwork tears the network down; its nodes are stopped and removed, including their data dirs.
+// Networks being created can not be removed.
+/* curl request:
+curl -X DELETE \
+	http://localhost:7000/api/v1/networks/00000000-0000-0000-0000-000000000000
+*/
+func (h *handler) removeNetwork(w http.ResponseWriter, r *http.Request) {
+	uid, err := uuid.FromString(mux.Vars(r)["uuid"])
+	if err != nil {
+		http.Error(w, rest.HTTPBadRequest, http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.networks.Get(r.Context(), uid); err != nil {
+		http.Error(w, rest.HTTPNotFound, http.StatusNotFound)
+		return
+	}
+
+	if err := h.networks.Remove(r.Context(), uid); err != nil {
+		if errors.Is(err, network.ErrCreating) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		log.Err(err).Msgf("failed to remove network: %s", uid)
+		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
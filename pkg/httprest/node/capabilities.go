@@ -0,0 +1,106 @@
+package node
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+	uuid "github.com/satori/go.uuid"
+	"github.com/zees-dev/zeth/pkg/httprest/rest"
+	"github.com/zees-dev/zeth/pkg/node"
+)
+
+// capabilityProbeTimeout is the timeout of probing the capabilities of a node in the background.
+const capabilityProbeTimeout = time.Minute
+
+// probeCapabilities probes the capabilities of the node and stores them on the node.
+func (h *nodesHandler) probeCapabilities(ctx context.Context, id uuid.UUID) (*node.Capabilities, error) {
+	n, err := h.nodes.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	transport, err := h.nodes.Transport(*n)
+	if err != nil {
+		return nil, err
+	}
+	capabilities, err := n.ProbeCapabilities(ctx, transport)
+	if err != nil {
+		return nil, err
+	}
+
+	// the node may have been updated while probing
+	if err := h.nodes.UpdateCapabilities(ctx, id, capabilities); err != nil {
+		return nil, err
+	}
+
+	return capabilities, nil
+}
+
+// probeCapabilitiesAsync probes the capabilities of the node in the background; e.g. on registration.
+func (h *nodesHandler) probeCapabilitiesAsync(id uuid.UUID) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), capabilityProbeTimeout)
+		defer cancel()
+
+		if _, err := h.probeCapabilities(ctx, id); err != nil {
+			log.Debug().Err(err).Msgf("failed to probe capabilities of node: %s", id)
+		}
+	}()
+}
+
+// getNodeCapabilities returns the last probed capabilities of the node; null if the node has not been probed.
+/* curl request:
+curl \
+	http://localhost:7000/api/v1/nodes/00000000-0000-0000-0000-000000000000/capabilities
+*/
+func (h *nodesHandler) getNodeCapabilities(w http.ResponseWriter, r *http.Request) {
+	// get id from request parameters
+	id := mux.Vars(r)["uuid"]
+
+	uid, err := uuid.FromString(id)
+	if err != nil {
+		http.Error(w, rest.HTTPBadRequest, http.StatusBadRequest)
+		return
+	}
+
+	n, err := h.nodes.Get(r.Context(), uid)
+	if err != nil {
+		http.Error(w, rest.HTTPNotFound, http.StatusNotFound)
+		return
+	}
+
+	rest.JSON(w, n.Capabilities)
+}
+
+// probeNodeCapabilities probes the capabilities of the node on demand.
+/* curl request:
+curl -X POST \
+	http://localhost:7000/api/v1/nodes/00000000-0000-0000-0000-000000000000/capabilities
+*/
+func (h *nodesHandler) probeNodeCapabilities(w http.ResponseWriter, r *http.Request) {
+	// get id from request parameters
+	id := mux.Vars(r)["uuid"]
+
+	uid, err := uuid.FromString(id)
+	if err != nil {
+		http.Error(w, rest.HTTPBadRequest, http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.nodes.Get(r.Context(), uid); err != nil {
+		http.Error(w, rest.HTTPNotFound, http.StatusNotFound)
+		return
+	}
+
+	capabilities, err := h.probeCapabilities(r.Context(), uid)
+	if err != nil {
+		log.Debug().Err(err).Msg("failed to probe capabilities of node")
+		http.Error(w, "failed to probe capabilities of node", http.StatusBadGateway)
+		return
+	}
+
+	rest.JSON(w, capabilities)
+}
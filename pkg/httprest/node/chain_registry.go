@@ -0,0 +1,34 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/zees-dev/zeth/pkg/node"
+)
+
+// validateChain checks the expected chain of the node against the chain registry and the discovered chain metadata.
+// The explorer URL of the node defaults to the explorer of the registered chain.
+func (h *nodesHandler) validateChain(ctx context.Context, chainID uint64, n *node.ZethNode) url.Values {
+	errs := url.Values{}
+	if chainID == 0 {
+		return errs
+	}
+
+	c, err := h.chains.Get(ctx, chainID)
+	if err != nil {
+		errs.Add("chainId", fmt.Sprintf("chain %d is not registered", chainID))
+		return errs
+	}
+
+	if n.Chain != nil && n.Chain.ChainID != chainID {
+		errs.Add("chainId", fmt.Sprintf("node is on chain %d; expected chain %d", n.Chain.ChainID, chainID))
+		return errs
+	}
+
+	if n.ExplorerURL == "" {
+		n.ExplorerURL = c.ExplorerURL()
+	}
+	return errs
+}
@@ -0,0 +1,149 @@
+package node
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+	uuid "github.com/satori/go.uuid"
+	"github.com/zees-dev/zeth/pkg/httprest/rest"
+	"github.com/zees-dev/zeth/pkg/node"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var errChaosConnectionDropped = errors.New("chaos: connection dropped")
+
+// injectRequestFaults injects faults which take effect before the request is proxied.
+// A synthesized response is returned if the request must not be proxied to the upstream node.
+func (rt rpcRoundTripper) injectRequestFaults(r *http.Request, event *RPCEvent, faults []node.ChaosFault) (*http.Response, error) {
+	for _, f := range faults {
+		switch f.Type {
+		case node.ChaosLatency:
+			select {
+			case <-time.After(f.Latency(rt.chaos.Int63n)):
+			case <-r.Context().Done():
+				return nil, r.Context().Err()
+			}
+		case node.ChaosDropConnection:
+			log.Info().Msgf("chaos: dropping connection for rpc request: %s", event.ID)
+			// the published request event records the fault; the request has no response event
+			endRPCSpan(trace.SpanFromContext(r.Context()), event, errChaosConnectionDropped)
+			// aborts the handler; the server closes the client connection without a response
+			panic(http.ErrAbortHandler)
+		case node.ChaosHTTPError:
+			statusCode := f.HTTPStatusCode()
+			return newChaosResponse(r, statusCode, "text/plain", []byte(http.StatusText(statusCode))), nil
+		case node.ChaosRPCError:
+			return newChaosResponse(r, http.StatusOK, "application/json", f.RPCErrorResponse([]byte(event.Request.Body))), nil
+		}
+	}
+	return nil, nil
+}
+
+// injectResponseFaults injects faults which modify the upstream response.
+func (rt rpcRoundTripper) injectResponseFaults(res *http.Response, event *RPCEvent, faults []node.ChaosFault) {
+	for _, f := range faults {
+		switch f.Type {
+		case node.ChaosStaleBlock:
+			if body, ok := f.StaleBlockNumber([]byte(event.Request.Body), []byte(event.Response.Body)); ok {
+				event.ReplaceResponse(res, res.StatusCode, body)
+			}
+		case node.ChaosTruncate:
+			event.ReplaceResponse(res, res.StatusCode, node.TruncateBody([]byte(event.Response.Body)))
+		}
+	}
+}
+
+func newChaosResponse(r *http.Request, statusCode int, contentType string, body []byte) *http.Response {
+	header := http.Header{}
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	header.Set("X-Zeth-Chaos", "true")
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+		StatusCode:    statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewBuffer(body)),
+		ContentLength: int64(len(body)),
+		Request:       r,
+	}
+}
+
+type chaosRequestPayload node.ChaosConfig
+
+func (payload *chaosRequestPayload) Validate() url.Values {
+	errs := url.Values{}
+
+	if err := node.ChaosConfig(*payload).Validate(); err != nil {
+		errs.Add("faults", err.Error())
+	}
+
+	return errs
+}
+
+/* curl request:
+curl \
+	-H "Content-Type: application/json" \
+	http://localhost:7000/api/v1/nodes/00000000-0000-0000-0000-000000000000/chaos
+*/
+func (h *nodesHandler) getChaos(w http.ResponseWriter, r *http.Request) {
+	uid, err := uuid.FromString(mux.Vars(r)["uuid"])
+	if err != nil {
+		http.Error(w, rest.HTTPBadRequest, http.StatusBadRequest)
+		return
+	}
+
+	n, err := h.nodes.Get(r.Context(), uid)
+	if err != nil {
+		http.Error(w, rest.HTTPNotFound, http.StatusNotFound)
+		return
+	}
+
+	rest.JSON(w, n.Chaos)
+}
+
+/* curl request:
+curl -X PUT \
+	-H "Content-Type: application/json" \
+	-d '{"enabled": true, "faults": [{"type": "latency", "rate": 0.5, "latencyMs": 2000, "jitterMs": 500}, {"type": "stale_block", "rate": 1, "methods": ["eth_blockNumber"], "staleBlocks": 5}]}' \
+	http://localhost:7000/api/v1/nodes/00000000-0000-0000-0000-000000000000/chaos
+*/
+func (h *nodesHandler) updateChaos(w http.ResponseWriter, r *http.Request) {
+	payload := chaosRequestPayload{}
+	if ok := rest.DecodeAndValidateJSONPayload(w, r.Body, &payload); !ok {
+		log.Debug().Msg("validation failed")
+		return
+	}
+
+	uid, err := uuid.FromString(mux.Vars(r)["uuid"])
+	if err != nil {
+		http.Error(w, rest.HTTPBadRequest, http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.nodes.Get(r.Context(), uid); err != nil {
+		http.Error(w, rest.HTTPNotFound, http.StatusNotFound)
+		return
+	}
+
+	cfg := node.ChaosConfig(payload)
+
+	// note: updating the node evicts its cached reverse proxies, applying the new faults to subsequent requests
+	if err := h.nodes.UpdateChaos(r.Context(), uid, cfg); err != nil {
+		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	rest.JSON(w, cfg)
+}
@@ -7,7 +7,6 @@ import (
 	"time"
 
 	"github.com/rs/zerolog/log"
-	uuid "github.com/satori/go.uuid"
 	"github.com/zees-dev/zeth/pkg/httprest/rest"
 	"github.com/zees-dev/zeth/pkg/node"
 )
@@ -15,9 +14,12 @@ import (
 type registerNodeRequestPayload struct {
 	Name string `json:"name"`
 	// Enabled     bool     `json:"enabled"`
-	ExplorerURL    string   `json:"explorerUrl"`
-	RPC            node.RPC `json:"rpc"`
-	TestConnection bool     `json:"test"`
+	ExplorerURL    string               `json:"explorerUrl"`
+	RPC            node.RPC             `json:"rpc"`
+	Transport      node.TransportConfig `json:"transport"`
+	TestConnection bool                 `json:"test"`
+	DiscoverChain  bool                 `json:"discover"` // query and store the chain metadata of the node
+	ChainID        uint64               `json:"chainId"`  // expected chain of the node; must be registered
 }
 
 func (payload *registerNodeRequestPayload) Validate() url.Values {
@@ -45,6 +47,10 @@ func (payload *registerNodeRequestPayload) Validate() url.Values {
 		errs.Add("rpc.default", "rpc default is invalid; must be 0 (http) or 1 (ws)")
 	}
 
+	if err := payload.Transport.Validate(); err != nil {
+		errs.Add("transport", err.Error())
+	}
+
 	return errs
 }
 
@@ -52,7 +58,7 @@ func (payload *registerNodeRequestPayload) Validate() url.Values {
 /* curl request:
 curl -X POST \
 	-H "Content-Type: application/json" \
-	-d '{"name": "test", "rpc": { "http": "http://localhost:8545", "default": 0 } }' \
+	-d '{"name": "test", "rpc": { "http": "http://localhost:8545", "default": 0 }, "discover": true }' \
 	http://localhost:7000/api/v1/nodes/remote
 */
 func (h *nodesHandler) createNode(w http.ResponseWriter, r *http.Request) {
@@ -63,12 +69,12 @@ func (h *nodesHandler) createNode(w http.ResponseWriter, r *http.Request) {
 	}
 
 	remoteNode := node.ZethNode{
-		ID:          uuid.NewV4(),
 		Name:        payload.Name,
 		Enabled:     true,
 		ExplorerURL: payload.ExplorerURL,
 		DateAdded:   time.Now().UTC(),
 		RPC:         payload.RPC,
+		Transport:   payload.Transport,
 	}
 
 	exists, err := h.remoteNodeAlreadyExists(r.Context(), payload)
@@ -77,21 +83,44 @@ func (h *nodesHandler) createNode(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// the node is not registered yet; its transport is not cached
+	transport, err := remoteNode.Transport.NewTransport()
+	if err != nil {
+		rest.ValidationErrors(w, url.Values{"transport": {err.Error()}})
+		return
+	}
+	defer transport.CloseIdleConnections()
+
 	if payload.TestConnection {
-		if err := remoteNode.TestConnection(r.Context()); err != nil {
+		if err := remoteNode.TestConnection(r.Context(), transport); err != nil {
 			log.Debug().Err(err).Msg("failed to connect to node")
 			http.Error(w, "failed to connect to node", http.StatusBadRequest)
 			return
 		}
 	}
 
+	if payload.DiscoverChain {
+		if remoteNode.Chain, err = remoteNode.DiscoverChain(r.Context(), transport); err != nil {
+			log.Debug().Err(err).Msg("failed to discover chain metadata of node")
+			http.Error(w, "failed to discover chain metadata of node", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if errs := h.validateChain(r.Context(), payload.ChainID, &remoteNode); len(errs) > 0 {
+		rest.ValidationErrors(w, errs)
+		return
+	}
+
 	node, err := h.nodes.Create(r.Context(), remoteNode)
 	if err != nil {
 		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
 		return
 	}
+	h.nodeRPCMonitor.Hub(node.ID)
+	h.probeCapabilitiesAsync(node.ID)
 
-	rest.JSON(w, node)
+	rest.JSON(w, node.Redacted())
 }
 
 // remoteNodeAlreadyExists checks if node with the same name or http rpc URL is already registered.
@@ -0,0 +1,104 @@
+package node
+
+import (
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/rs/zerolog/log"
+	"github.com/zees-dev/zeth/pkg/httprest/rest"
+	"github.com/zees-dev/zeth/pkg/node"
+)
+
+type createDevNodeRequestPayload struct {
+	Name     string `json:"name"`
+	Period   uint64 `json:"period"`   // seconds between blocks; blocks are sealed on demand if 0
+	GasLimit uint64 `json:"gasLimit"` // block gas limit; defaults to the geth dev mode gas limit
+	HTTPPort int    `json:"httpPort"`
+	WSPort   int    `json:"wsPort"`
+	Accounts int    `json:"accounts"` // number of prefunded accounts; defaults to node.DefaultDevAccounts
+	Balance  uint64 `json:"balance"`  // balance of each account in ether; defaults to node.DefaultDevBalance
+}
+
+func (payload *createDevNodeRequestPayload) Validate() url.Values {
+	errs := url.Values{}
+
+	if strings.TrimSpace(payload.Name) == "" {
+		errs.Add("name", "name is required")
+	}
+
+	if payload.Accounts < 0 || payload.Accounts > node.MaxDevAccounts {
+		errs.Add("accounts", fmt.Sprintf("accounts must be between 0 and %d", node.MaxDevAccounts))
+	}
+
+	if err := payload.config().Validate(); err != nil {
+		errs.Add("dev", err.Error())
+	}
+
+	return errs
+}
+
+func (payload *createDevNodeRequestPayload) config() node.DevChainConfig {
+	return node.DevChainConfig{
+		Period:   payload.Period,
+		GasLimit: payload.GasLimit,
+		HTTPPort: payload.HTTPPort,
+		WSPort:   payload.WSPort,
+	}
+}
+
+// createDevNode registers a dev node and starts its in-process dev chain; the prefunded accounts, including their private keys,
+// are returned with the node. The dev chain is served through the RPC proxy of the node like any other node.
+/* curl request:
+curl -X POST \
+	-H "Content-Type: application/json" \
+	-d '{"name": "local dev chain", "period": 0, "gasLimit": 30000000, "accounts": 5, "balance": 1000}' \
+	http://localhost:7000/api/v1/nodes/dev
+*/
+func (h *nodesHandler) createDevNode(w http.ResponseWriter, r *http.Request) {
+	payload := createDevNodeRequestPayload{}
+	if ok := rest.DecodeAndValidateJSONPayload(w, r.Body, &payload); !ok {
+		log.Debug().Msg("validation failed")
+		return
+	}
+
+	exists, err := h.nodeNameExists(r.Context(), payload.Name)
+	if exists || err != nil {
+		http.Error(w, "a node with name already exists", http.StatusBadRequest)
+		return
+	}
+
+	numAccounts, balance := node.DefaultDevAccounts, node.DefaultDevBalance
+	if payload.Accounts > 0 {
+		numAccounts = payload.Accounts
+	}
+	if payload.Balance > 0 {
+		balance = new(big.Int).Mul(new(big.Int).SetUint64(payload.Balance), big.NewInt(params.Ether))
+	}
+	cfg := payload.config()
+	if cfg.Accounts, err = node.NewDevAccounts(numAccounts, balance); err != nil {
+		log.Err(err).Msg("failed to generate dev accounts")
+		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	n, err := h.managed.CreateDev(r.Context(), payload.Name, cfg)
+	if err != nil {
+		log.Err(err).Msg("failed to create dev node")
+		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		return
+	}
+	h.nodeRPCMonitor.Hub(n.ID)
+
+	// failures are reported in the process status of the node
+	if _, err := h.managed.Start(r.Context(), n.ID); err != nil {
+		log.Err(err).Msgf("failed to start dev node: %s", n.ID)
+	} else {
+		h.probeCapabilitiesAsync(n.ID)
+	}
+
+	rest.JSON(w, n.Redacted())
+}
@@ -0,0 +1,95 @@
+package node
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+	uuid "github.com/satori/go.uuid"
+	"github.com/zees-dev/zeth/pkg/httprest/rest"
+	"github.com/zees-dev/zeth/pkg/node"
+)
+
+type errorNormalizationResponse struct {
+	node.ErrorNormalizationConfig
+	DefaultRules []node.ErrorRule `json:"defaultRules"`
+}
+
+type errorNormalizationRequestPayload node.ErrorNormalizationConfig
+
+func (payload *errorNormalizationRequestPayload) Validate() url.Values {
+	errs := url.Values{}
+
+	names := map[string]bool{}
+	for _, rule := range payload.Rules {
+		if err := node.ValidateErrorRule(rule); err != nil {
+			errs.Add("rules", err.Error())
+		}
+		if names[rule.Name] {
+			errs.Add("rules", "duplicate rule name: "+rule.Name)
+		}
+		names[rule.Name] = true
+	}
+
+	return errs
+}
+
+/* curl request:
+curl \
+	-H "Content-Type: application/json" \
+	http://localhost:7000/api/v1/nodes/00000000-0000-0000-0000-000000000000/normalization
+*/
+func (h *nodesHandler) getErrorNormalization(w http.ResponseWriter, r *http.Request) {
+	uid, err := uuid.FromString(mux.Vars(r)["uuid"])
+	if err != nil {
+		http.Error(w, rest.HTTPBadRequest, http.StatusBadRequest)
+		return
+	}
+
+	n, err := h.nodes.Get(r.Context(), uid)
+	if err != nil {
+		http.Error(w, rest.HTTPNotFound, http.StatusNotFound)
+		return
+	}
+
+	rest.JSON(w, errorNormalizationResponse{
+		ErrorNormalizationConfig: n.ErrorNormalization,
+		DefaultRules:             node.DefaultErrorRules,
+	})
+}
+
+/* curl request:
+curl -X PUT \
+	-H "Content-Type: application/json" \
+	-d '{"enabled": true, "rules": [{"name": "infura-capacity", "errorCode": -32005, "category": "rate_limited", "code": -32005, "message": "rate limited"}]}' \
+	http://localhost:7000/api/v1/nodes/00000000-0000-0000-0000-000000000000/normalization
+*/
+func (h *nodesHandler) updateErrorNormalization(w http.ResponseWriter, r *http.Request) {
+	payload := errorNormalizationRequestPayload{}
+	if ok := rest.DecodeAndValidateJSONPayload(w, r.Body, &payload); !ok {
+		log.Debug().Msg("validation failed")
+		return
+	}
+
+	uid, err := uuid.FromString(mux.Vars(r)["uuid"])
+	if err != nil {
+		http.Error(w, rest.HTTPBadRequest, http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.nodes.Get(r.Context(), uid); err != nil {
+		http.Error(w, rest.HTTPNotFound, http.StatusNotFound)
+		return
+	}
+
+	cfg := node.ErrorNormalizationConfig(payload)
+
+	// note: updating the node evicts its cached reverse proxies, applying the new rules to subsequent requests
+	if err := h.nodes.UpdateErrorNormalization(r.Context(), uid, cfg); err != nil {
+		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	rest.JSON(w, cfg)
+}
@@ -0,0 +1,302 @@
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zees-dev/zeth/pkg/node"
+)
+
+// StreamMode selects which events of a request are streamed to a subscriber.
+type StreamMode string
+
+const (
+	StreamAll      StreamMode = "all"      // request and response events
+	StreamRequest  StreamMode = "request"  // request events only
+	StreamResponse StreamMode = "response" // response events only, without the request
+	StreamPaired   StreamMode = "paired"   // response events only, including the request
+)
+
+func (m StreamMode) IsValid() bool {
+	switch m {
+	case StreamAll, StreamRequest, StreamResponse, StreamPaired:
+		return true
+	}
+	return false
+}
+
+// rpcEventMeta holds the properties of an RPC event which subscribers can filter on.
+type rpcEventMeta struct {
+	event       RPCEvent // snapshot of the event when it was published
+	methods     []string
+	apiKey      string
+	hasRPCError bool
+}
+
+func newRPCEventMeta(ev *RPCEvent) *rpcEventMeta {
+	meta := &rpcEventMeta{
+		event:   *ev,
+		methods: node.RequestMethods([]byte(ev.Request.Body)),
+		apiKey:  ev.apiKey,
+	}
+	if meta.apiKey == "" {
+		meta.apiKey = requestAPIKey(ev)
+	}
+	if ev.Type == response {
+		meta.hasRPCError = hasRPCError([]byte(ev.Response.Body))
+	}
+	return meta
+}
+
+// requestAPIKey returns the API key the client used for the request; if any.
+func requestAPIKey(ev *RPCEvent) string {
+	var headers http.Header
+	if err := json.Unmarshal([]byte(ev.Request.Headers), &headers); err == nil {
+		if key := headers.Get("X-Api-Key"); key != "" {
+			return key
+		}
+		if auth := headers.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+			return strings.TrimPrefix(auth, "Bearer ")
+		}
+	}
+	if u, err := url.Parse(ev.URI); err == nil {
+		q := u.Query()
+		for _, k := range []string{"apiKey", "api_key", "key"} {
+			if key := q.Get(k); key != "" {
+				return key
+			}
+		}
+	}
+	return ""
+}
+
+// hasRPCError returns whether the JSON-RPC response (or any response of a batch) is an error.
+func hasRPCError(body []byte) bool {
+	var responses []struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(body, &responses); err != nil {
+		var res struct {
+			Error json.RawMessage `json:"error"`
+		}
+		if err := json.Unmarshal(body, &res); err != nil {
+			return false
+		}
+		responses = append(responses, res)
+	}
+	for _, res := range responses {
+		if len(res.Error) > 0 && string(res.Error) != "null" {
+			return true
+		}
+	}
+	return false
+}
+
+// EventFilter selects the RPC events streamed to a subscriber.
+// Criteria on the response (errors, status codes, duration) only match response events.
+type EventFilter struct {
+	Methods     []string // method patterns, e.g. eth_* - https://pkg.go.dev/path#Match
+	ErrorsOnly  bool     // HTTP error status, JSON-RPC error or normalized error
+	StatusCodes []string // status codes or classes, e.g. 429 or 5xx
+	MinDuration time.Duration
+	Clients     []string // client IP addresses or CIDR ranges
+	APIKeys     []string
+	Mode        StreamMode
+}
+
+/*
+ParseEventFilter parses the filter from query parameters; list parameters can be repeated or comma separated:
+
+	method=eth_call,eth_get*  errors=true  status=429,5xx  minDuration=500 (ms)
+	client=10.0.0.0/8  apiKey=...  events=all|request|response|paired
+*/
+func ParseEventFilter(q url.Values) (EventFilter, url.Values) {
+	errs := url.Values{}
+	list := func(key string) []string {
+		values := []string{}
+		for _, v := range q[key] {
+			for _, s := range strings.Split(v, ",") {
+				if s = strings.TrimSpace(s); s != "" {
+					values = append(values, s)
+				}
+			}
+		}
+		return values
+	}
+
+	f := EventFilter{
+		Methods:     list("method"),
+		StatusCodes: list("status"),
+		Clients:     list("client"),
+		APIKeys:     list("apiKey"),
+		Mode:        StreamMode(q.Get("events")),
+	}
+
+	for _, m := range f.Methods {
+		if _, err := path.Match(m, ""); err != nil {
+			errs.Add("method", fmt.Sprintf("invalid method pattern: %s", m))
+		}
+	}
+	if v := q.Get("errors"); v != "" {
+		errorsOnly, err := strconv.ParseBool(v)
+		if err != nil {
+			errs.Add("errors", "must be a boolean")
+		}
+		f.ErrorsOnly = errorsOnly
+	}
+	for _, s := range f.StatusCodes {
+		if !isStatusPattern(s) {
+			errs.Add("status", fmt.Sprintf("invalid status code: %s", s))
+		}
+	}
+	if v := q.Get("minDuration"); v != "" {
+		ms, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || ms < 0 {
+			errs.Add("minDuration", "must be a non-negative number of milliseconds")
+		}
+		f.MinDuration = time.Duration(ms) * time.Millisecond
+	}
+	for _, c := range f.Clients {
+		if net.ParseIP(c) == nil {
+			if _, _, err := net.ParseCIDR(c); err != nil {
+				errs.Add("client", fmt.Sprintf("invalid IP address or CIDR: %s", c))
+			}
+		}
+	}
+	if f.Mode == "" {
+		f.Mode = StreamAll
+	}
+	if !f.Mode.IsValid() {
+		errs.Add("events", "must be one of all, request, response or paired")
+	}
+
+	return f, errs
+}
+
+// isStatusPattern returns whether s is a status code (e.g. 429) or class (e.g. 5xx).
+func isStatusPattern(s string) bool {
+	if len(s) != 3 {
+		return false
+	}
+	if strings.HasSuffix(s, "xx") {
+		return s[0] >= '1' && s[0] <= '5'
+	}
+	code, err := strconv.Atoi(s)
+	return err == nil && code >= 100 && code <= 599
+}
+
+func matchStatus(pattern string, code int) bool {
+	if strings.HasSuffix(pattern, "xx") {
+		return code/100 == int(pattern[0]-'0')
+	}
+	return pattern == strconv.Itoa(code)
+}
+
+// requiresResponse returns whether the filter has criteria which can only be evaluated on response events.
+func (f EventFilter) requiresResponse() bool {
+	return f.ErrorsOnly || len(f.StatusCodes) > 0 || f.MinDuration > 0
+}
+
+// Match returns whether the event is streamed to the subscriber.
+// Events without RPC event properties (e.g. published as raw bytes) are only filtered by mode.
+func (f EventFilter) Match(ev Event) bool {
+	meta := ev.meta
+	if meta == nil {
+		return (f.Mode == "" || f.Mode == StreamAll) && len(f.Methods) == 0 && len(f.Clients) == 0 && len(f.APIKeys) == 0 && !f.requiresResponse()
+	}
+	rpc := meta.event
+
+	switch f.Mode {
+	case StreamRequest:
+		if rpc.Type != request {
+			return false
+		}
+	case StreamResponse, StreamPaired:
+		if rpc.Type != response {
+			return false
+		}
+	}
+	if f.requiresResponse() && rpc.Type != response {
+		return false
+	}
+
+	if len(f.Methods) > 0 && !matchAny(meta.methods, f.Methods) {
+		return false
+	}
+	if f.ErrorsOnly && rpc.Response.StatusCode < 400 && !meta.hasRPCError && rpc.Normalization == nil {
+		return false
+	}
+	if len(f.StatusCodes) > 0 {
+		matched := false
+		for _, s := range f.StatusCodes {
+			if matchStatus(s, rpc.Response.StatusCode) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if f.MinDuration > 0 && time.Duration(rpc.Duration)*time.Millisecond < f.MinDuration {
+		return false
+	}
+	if len(f.Clients) > 0 && !matchClient(rpc.ClientAddr, f.Clients) {
+		return false
+	}
+	if len(f.APIKeys) > 0 && !contains(f.APIKeys, meta.apiKey) {
+		return false
+	}
+
+	return true
+}
+
+// Render returns the payload of the event streamed to the subscriber.
+func (f EventFilter) Render(ev Event) []byte {
+	if f.Mode != StreamResponse || ev.meta == nil {
+		return ev.Data
+	}
+	rpc := ev.meta.event
+	rpc.Request.Headers, rpc.Request.Body = "", ""
+	return rpc.Bytes()
+}
+
+func matchAny(methods, patterns []string) bool {
+	for _, m := range methods {
+		for _, p := range patterns {
+			if ok, _ := path.Match(p, m); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchClient(addr string, clients []string) bool {
+	ip := net.ParseIP(addr)
+	for _, c := range clients {
+		if c == addr {
+			return true
+		}
+		if _, cidr, err := net.ParseCIDR(c); err == nil && ip != nil && cidr.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+package node
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zees-dev/zeth/pkg/redact"
+)
+
+func newTestRPCEvent(typ callType, method string, status int, body string, duration int64) *RPCEvent {
+	ev := NewRPCEvent("http://localhost:8545")
+	ev.Type = typ
+	ev.URI = "/api/v1/nodes/rpc/00000000-0000-0000-0000-000000000000?apiKey=secret"
+	ev.ClientAddr = "10.0.0.5"
+	ev.Request.Headers = `{"Content-Type":["application/json"]}`
+	ev.Request.Body = `{"jsonrpc":"2.0","id":1,"method":"` + method + `","params":[]}`
+	if typ == response {
+		ev.Response.StatusCode = status
+		ev.Response.Body = body
+		ev.Duration = duration
+	}
+	return ev
+}
+
+func Test_ParseEventFilter(t *testing.T) {
+	is := assert.New(t)
+
+	f, errs := ParseEventFilter(url.Values{
+		"method":      {"eth_call,eth_get*", "debug_*"},
+		"errors":      {"true"},
+		"status":      {"429,5xx"},
+		"minDuration": {"250"},
+		"client":      {"10.0.0.0/8"},
+		"events":      {"paired"},
+	})
+	is.Empty(errs)
+	is.Equal([]string{"eth_call", "eth_get*", "debug_*"}, f.Methods)
+	is.True(f.ErrorsOnly)
+	is.Equal([]string{"429", "5xx"}, f.StatusCodes)
+	is.Equal(int64(250), f.MinDuration.Milliseconds())
+	is.Equal(StreamPaired, f.Mode)
+
+	f, errs = ParseEventFilter(url.Values{})
+	is.Empty(errs)
+	is.Equal(StreamAll, f.Mode)
+
+	_, errs = ParseEventFilter(url.Values{
+		"method":      {"eth_["},
+		"errors":      {"maybe"},
+		"status":      {"6xx"},
+		"minDuration": {"-1"},
+		"client":      {"localhost"},
+		"events":      {"everything"},
+	})
+	for _, k := range []string{"method", "errors", "status", "minDuration", "client", "events"} {
+		is.Contains(errs, k)
+	}
+}
+
+func Test_EventFilterMatch(t *testing.T) {
+	req := newTestRPCEvent(request, "eth_call", 0, "", 0)
+	ok := newTestRPCEvent(response, "eth_call", 200, `{"jsonrpc":"2.0","id":1,"result":"0x"}`, 10)
+	rpcErr := newTestRPCEvent(response, "eth_call", 200, `{"jsonrpc":"2.0","id":1,"error":{"code":3,"message":"execution reverted"}}`, 10)
+	slow := newTestRPCEvent(response, "eth_getLogs", 503, "service unavailable", 900)
+
+	tests := []struct {
+		name  string
+		query url.Values
+		ev    *RPCEvent
+		want  bool
+	}{
+		{"no filter matches request", url.Values{}, req, true},
+		{"no filter matches response", url.Values{}, ok, true},
+		{"request mode excludes response", url.Values{"events": {"request"}}, ok, false},
+		{"response mode excludes request", url.Values{"events": {"response"}}, req, false},
+		{"paired mode matches response", url.Values{"events": {"paired"}}, ok, true},
+		{"method pattern matches", url.Values{"method": {"eth_get*"}}, slow, true},
+		{"method pattern excludes", url.Values{"method": {"eth_get*"}}, ok, false},
+		{"errors excludes success", url.Values{"errors": {"true"}}, ok, false},
+		{"errors matches rpc error", url.Values{"errors": {"true"}}, rpcErr, true},
+		{"errors matches http error", url.Values{"errors": {"true"}}, slow, true},
+		{"errors excludes request", url.Values{"errors": {"true"}}, req, false},
+		{"status class matches", url.Values{"status": {"5xx"}}, slow, true},
+		{"status code excludes", url.Values{"status": {"429"}}, slow, false},
+		{"min duration matches", url.Values{"minDuration": {"500"}}, slow, true},
+		{"min duration excludes", url.Values{"minDuration": {"500"}}, ok, false},
+		{"client cidr matches", url.Values{"client": {"10.0.0.0/24"}}, req, true},
+		{"client address excludes", url.Values{"client": {"10.0.0.6"}}, req, false},
+		{"api key matches", url.Values{"apiKey": {"secret"}}, req, true},
+		{"api key excludes", url.Values{"apiKey": {"other"}}, req, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := assert.New(t)
+			f, errs := ParseEventFilter(tt.query)
+			is.Empty(errs)
+			is.Equal(tt.want, f.Match(Event{ID: 1, Data: tt.ev.Bytes(), meta: newRPCEventMeta(tt.ev)}))
+		})
+	}
+}
+
+func Test_EventHubFiltersSubscribers(t *testing.T) {
+	is := assert.New(t)
+
+	hub := NewEventHub(DefaultEventBacklog)
+	hub.PublishRPCEvent(newTestRPCEvent(request, "eth_call", 0, "", 0))
+	hub.PublishRPCEvent(newTestRPCEvent(response, "eth_call", 200, `{"jsonrpc":"2.0","id":1,"result":"0x"}`, 10))
+
+	f, _ := ParseEventFilter(url.Values{"events": {"response"}})
+	c := make(chan Event, 2)
+	backlog, _ := hub.Subscribe(c, 0, f)
+	is.Equal([]uint64{2}, eventIDs(backlog))
+
+	// response mode omits the request
+	var ev RPCEvent
+	is.NoError(json.Unmarshal(f.Render(backlog[0]), &ev))
+	is.Empty(ev.Request.Body)
+	is.Equal(200, ev.Response.StatusCode)
+
+	hub.PublishRPCEvent(newTestRPCEvent(request, "eth_call", 0, "", 0))
+	hub.Publish([]byte("raw"))
+	is.Len(c, 0)
+}
+
+func Test_RedactedEventMatchesAPIKey(t *testing.T) {
+	is := assert.New(t)
+
+	r, err := redact.New(redact.Config{})
+	is.NoError(err)
+
+	ev := newTestRPCEvent(request, "personal_unlockAccount", 0, "", 0)
+	ev.RPCURL = "https://mainnet.infura.io/v3/9aa3d95b3bc440fa88ea12eaa4456161"
+	ev.Request.Body = `{"jsonrpc":"2.0","id":1,"method":"personal_unlockAccount","params":["0x407d73d8a49eeb85d32cf465507dd71d507100c1","hunter2"]}`
+
+	redacted := ev.Redacted(r)
+	b := string(redacted.Bytes())
+	is.NotContains(b, "secret")
+	is.NotContains(b, "hunter2")
+	is.NotContains(b, "9aa3d95b3bc440fa88ea12eaa4456161")
+	is.NotContains(b, "apiKey\":")
+
+	// the original event is unchanged
+	is.Contains(ev.URI, "apiKey=secret")
+
+	hub := NewEventHub(DefaultEventBacklog)
+	is.NoError(hub.PublishRPCEvent(redacted))
+
+	f, errs := ParseEventFilter(url.Values{"apiKey": {"secret"}})
+	is.Empty(errs)
+	is.Len(hub.Backlog(0, f), 1)
+}
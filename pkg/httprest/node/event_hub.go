@@ -0,0 +1,156 @@
+package node
+
+import (
+	"sync"
+)
+
+// DefaultEventBacklog is the number of recent events retained by an event hub for replay.
+const DefaultEventBacklog = 256
+
+var (
+	_ Publisher         = (*EventHub)(nil)
+	_ RPCEventPublisher = (*EventHub)(nil)
+)
+
+// RPCEventPublisher publishes the RPC events of a node's proxy.
+type RPCEventPublisher interface {
+	PublishRPCEvent(ev *RPCEvent) error
+}
+
+// Event is a published message and its sequential ID within the hub.
+type Event struct {
+	ID   uint64
+	Data []byte
+
+	meta *rpcEventMeta // set if the event was published as an RPC event
+}
+
+// EventHub is a long-lived publisher of a node's events.
+// Recent events are retained in a bounded ring buffer so subscribers can replay what they missed.
+type EventHub struct {
+	mu          sync.Mutex
+	lastID      uint64
+	backlog     []Event // ring buffer
+	next        int     // index of the next write into the ring buffer
+	full        bool
+	subscribers map[chan Event]EventFilter
+}
+
+func NewEventHub(capacity int) *EventHub {
+	if capacity <= 0 {
+		capacity = DefaultEventBacklog
+	}
+	return &EventHub{
+		backlog:     make([]Event, capacity),
+		subscribers: map[chan Event]EventFilter{},
+	}
+}
+
+// Publish publishes a raw message; it is only streamed to subscribers without filters.
+func (h *EventHub) Publish(b []byte) error {
+	h.publish(b, nil)
+	return nil
+}
+
+// PublishRPCEvent publishes a snapshot of the RPC event; subscribers can filter on its properties.
+func (h *EventHub) PublishRPCEvent(ev *RPCEvent) error {
+	h.publish(ev.Bytes(), newRPCEventMeta(ev))
+	return nil
+}
+
+// publish assigns the next event ID to the message, retains it in the backlog and sends it to matching subscribers.
+// Subscribers which are not ready to receive miss the event; they can detect the gap from the event IDs.
+func (h *EventHub) publish(b []byte, meta *rpcEventMeta) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastID++
+	ev := Event{ID: h.lastID, Data: b, meta: meta}
+
+	h.backlog[h.next] = ev
+	h.next = (h.next + 1) % len(h.backlog)
+	if h.next == 0 {
+		h.full = true
+	}
+
+	for c, filter := range h.subscribers {
+		if !filter.Match(ev) {
+			continue
+		}
+		select {
+		case c <- ev:
+		default:
+		}
+	}
+}
+
+// events returns the retained events in publishing order.
+func (h *EventHub) events() []Event {
+	if !h.full {
+		return append([]Event{}, h.backlog[:h.next]...)
+	}
+	return append(append([]Event{}, h.backlog[h.next:]...), h.backlog[:h.next]...)
+}
+
+// Retained returns the retained events in publishing order, and whether older events were evicted from the backlog.
+func (h *EventHub) Retained() ([]Event, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.events(), h.lastID > uint64(len(h.backlog))
+}
+
+// Backlog returns the retained events matching the filter published after the event with the given ID.
+// All retained events are returned if the ID is zero or unknown to the hub.
+func (h *EventHub) Backlog(afterID uint64, filter EventFilter) []Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.backlogAfter(afterID, filter)
+}
+
+func (h *EventHub) backlogAfter(afterID uint64, filter EventFilter) []Event {
+	events := h.events()
+	// an ID ahead of the hub was issued by a previous hub (e.g. before a restart); replay everything
+	if afterID != 0 && afterID <= h.lastID {
+		for len(events) > 0 && events[0].ID <= afterID {
+			events = events[1:]
+		}
+	}
+
+	matched := []Event{}
+	for _, ev := range events {
+		if filter.Match(ev) {
+			matched = append(matched, ev)
+		}
+	}
+	return matched
+}
+
+// Subscribe registers the channel for new events matching the filter and returns the matching retained events published after afterID.
+// Registration and backlog retrieval are atomic, so no event is missed or received twice.
+func (h *EventHub) Subscribe(c chan Event, afterID uint64, filter EventFilter) ([]Event, UnsubscribeFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.subscribers[c] = filter
+	backlog := h.backlogAfter(afterID, filter)
+
+	unsubscribeFn := func() error {
+		h.mu.Lock()
+		delete(h.subscribers, c)
+		h.mu.Unlock()
+		return nil
+	}
+
+	return backlog, unsubscribeFn
+}
+
+// Close closes the channels of all subscribers, signalling the end of the stream.
+func (h *EventHub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for c := range h.subscribers {
+		delete(h.subscribers, c)
+		close(c)
+	}
+}
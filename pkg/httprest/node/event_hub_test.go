@@ -0,0 +1,80 @@
+package node
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func eventIDs(events []Event) []uint64 {
+	ids := []uint64{}
+	for _, ev := range events {
+		ids = append(ids, ev.ID)
+	}
+	return ids
+}
+
+func Test_EventHubBacklog(t *testing.T) {
+	is := assert.New(t)
+
+	hub := NewEventHub(3)
+	is.Empty(hub.Backlog(0, EventFilter{}))
+
+	hub.Publish([]byte("1"))
+	hub.Publish([]byte("2"))
+	is.Equal([]uint64{1, 2}, eventIDs(hub.Backlog(0, EventFilter{})))
+
+	// ring buffer retains the most recent events
+	hub.Publish([]byte("3"))
+	hub.Publish([]byte("4"))
+	hub.Publish([]byte("5"))
+
+	tests := []struct {
+		name    string
+		afterID uint64
+		want    []uint64
+	}{
+		{"no last event id", 0, []uint64{3, 4, 5}},
+		{"evicted last event id", 1, []uint64{3, 4, 5}},
+		{"retained last event id", 3, []uint64{4, 5}},
+		{"up to date", 5, []uint64{}},
+		{"unknown last event id", 100, []uint64{3, 4, 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is.Equal(tt.want, eventIDs(hub.Backlog(tt.afterID, EventFilter{})))
+		})
+	}
+
+	is.Equal([]byte("5"), hub.Backlog(4, EventFilter{})[0].Data)
+}
+
+func Test_EventHubSubscribe(t *testing.T) {
+	is := assert.New(t)
+
+	hub := NewEventHub(DefaultEventBacklog)
+	for i := 0; i < 3; i++ {
+		hub.Publish([]byte(fmt.Sprint(i)))
+	}
+
+	c := make(chan Event, 1)
+	backlog, unsubscribe := hub.Subscribe(c, 2, EventFilter{})
+	is.Equal([]uint64{3}, eventIDs(backlog))
+
+	hub.Publish([]byte("live"))
+	ev := <-c
+	is.Equal(uint64(4), ev.ID)
+	is.Equal([]byte("live"), ev.Data)
+
+	is.NoError(unsubscribe())
+	hub.Publish([]byte("after unsubscribe"))
+	is.Len(c, 0)
+
+	// closing the hub ends the streams of subscribers
+	c2 := make(chan Event, 1)
+	hub.Subscribe(c2, 0, EventFilter{})
+	hub.Close()
+	_, ok := <-c2
+	is.False(ok)
+}
@@ -0,0 +1,334 @@
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+	uuid "github.com/satori/go.uuid"
+	"github.com/zees-dev/zeth/pkg/httprest/rest"
+)
+
+// HAR 1.2 - http://www.softwareishard.com/blog/har-12-spec/
+type (
+	harLog struct {
+		Log harLogContent `json:"log"`
+	}
+	harLogContent struct {
+		Version string     `json:"version"`
+		Creator harCreator `json:"creator"`
+		Entries []harEntry `json:"entries"`
+		Comment string     `json:"comment,omitempty"`
+	}
+	harCreator struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	harEntry struct {
+		StartedDateTime string      `json:"startedDateTime"`
+		Time            float64     `json:"time"`
+		Request         harRequest  `json:"request"`
+		Response        harResponse `json:"response"`
+		Cache           struct{}    `json:"cache"`
+		Timings         harTimings  `json:"timings"`
+		Comment         string      `json:"comment,omitempty"`
+	}
+	harRequest struct {
+		Method      string         `json:"method"`
+		URL         string         `json:"url"`
+		HTTPVersion string         `json:"httpVersion"`
+		Cookies     []harNameValue `json:"cookies"`
+		Headers     []harNameValue `json:"headers"`
+		QueryString []harNameValue `json:"queryString"`
+		PostData    *harPostData   `json:"postData,omitempty"`
+		HeadersSize int            `json:"headersSize"`
+		BodySize    int            `json:"bodySize"`
+	}
+	harResponse struct {
+		Status      int            `json:"status"`
+		StatusText  string         `json:"statusText"`
+		HTTPVersion string         `json:"httpVersion"`
+		Cookies     []harNameValue `json:"cookies"`
+		Headers     []harNameValue `json:"headers"`
+		Content     harContent     `json:"content"`
+		RedirectURL string         `json:"redirectURL"`
+		HeadersSize int            `json:"headersSize"`
+		BodySize    int            `json:"bodySize"`
+	}
+	harNameValue struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+	harPostData struct {
+		MimeType string `json:"mimeType"`
+		Text     string `json:"text"`
+	}
+	harContent struct {
+		Size     int    `json:"size"`
+		MimeType string `json:"mimeType"`
+		Text     string `json:"text"`
+	}
+	harTimings struct {
+		Send    float64 `json:"send"`
+		Wait    float64 `json:"wait"`
+		Receive float64 `json:"receive"`
+	}
+)
+
+// harHeaders converts JSON encoded HTTP headers of an event into HAR name/value pairs, sorted by name.
+func harHeaders(encoded string) []harNameValue {
+	var headers http.Header
+	json.Unmarshal([]byte(encoded), &headers)
+
+	pairs := []harNameValue{}
+	for name, values := range headers {
+		for _, v := range values {
+			pairs = append(pairs, harNameValue{Name: name, Value: v})
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].Name < pairs[j].Name })
+	return pairs
+}
+
+func headerValue(pairs []harNameValue, name string) string {
+	for _, p := range pairs {
+		if http.CanonicalHeaderKey(p.Name) == http.CanonicalHeaderKey(name) {
+			return p.Value
+		}
+	}
+	return ""
+}
+
+// newHAREntry converts a captured RPC event into a HAR entry.
+// Events without a response (e.g. failed or dropped requests) have a response status of 0.
+func newHAREntry(ev RPCEvent) harEntry {
+	reqHeaders := harHeaders(ev.Request.Headers)
+	resHeaders := harHeaders(ev.Response.Headers)
+
+	method := ev.Request.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+
+	queryString := []harNameValue{}
+	if u, err := url.Parse(ev.RPCURL); err == nil {
+		for name, values := range u.Query() {
+			for _, v := range values {
+				queryString = append(queryString, harNameValue{Name: name, Value: v})
+			}
+		}
+	}
+
+	entry := harEntry{
+		StartedDateTime: ev.Time.Format(time.RFC3339Nano),
+		Time:            float64(ev.Duration),
+		Request: harRequest{
+			Method:      method,
+			URL:         ev.RPCURL,
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []harNameValue{},
+			Headers:     reqHeaders,
+			QueryString: queryString,
+			HeadersSize: -1,
+			BodySize:    len(ev.Request.Body),
+		},
+		Response: harResponse{
+			Status:      ev.Response.StatusCode,
+			StatusText:  http.StatusText(ev.Response.StatusCode),
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []harNameValue{},
+			Headers:     resHeaders,
+			Content: harContent{
+				Size:     len(ev.Response.Body),
+				MimeType: headerValue(resHeaders, "Content-Type"),
+				Text:     ev.Response.Body,
+			},
+			HeadersSize: -1,
+			BodySize:    len(ev.Response.Body),
+		},
+		// only the total duration of proxied requests is captured
+		Timings: harTimings{Send: 0, Wait: float64(ev.Duration), Receive: 0},
+		Comment: fmt.Sprintf("zeth event %s", ev.ID),
+	}
+	if ev.Request.Body != "" {
+		mimeType := headerValue(reqHeaders, "Content-Type")
+		if mimeType == "" {
+			mimeType = "application/json"
+		}
+		entry.Request.PostData = &harPostData{MimeType: mimeType, Text: ev.Request.Body}
+	}
+	if ev.Type != response {
+		entry.Response.BodySize = -1
+		entry.Comment += " (no response)"
+	}
+
+	return entry
+}
+
+// newHAR converts captured RPC events into a HAR 1.2 log; truncation of the capture is noted in the comment of the log.
+func newHAR(capture Capture) harLog {
+	entries := make([]harEntry, 0, len(capture.Events))
+	for _, ev := range capture.Events {
+		entries = append(entries, newHAREntry(ev))
+	}
+	return harLog{Log: harLogContent{
+		Version: "1.2",
+		Creator: harCreator{Name: "zeth", Version: "1.0"},
+		Entries: entries,
+		Comment: capture.Comment(),
+	}}
+}
+
+// Capture is the captured RPC events of a time window.
+type Capture struct {
+	Events []RPCEvent
+	// Truncated is set if events of the window may have been evicted from the backlog of the hub
+	Truncated bool
+	Since     time.Time // time of the oldest retained event; zero if no RPC event is retained
+}
+
+// Comment describes the truncation of the capture; empty if the capture is complete.
+func (c Capture) Comment() string {
+	switch {
+	case !c.Truncated:
+		return ""
+	case c.Since.IsZero():
+		return "incomplete: the captured events of the time window were evicted from the backlog"
+	}
+	return fmt.Sprintf("incomplete: events captured before %s were evicted from the backlog", c.Since.Format(time.RFC3339Nano))
+}
+
+// CapturedRPCEvents returns the captured RPC events of the hub received within the time window, in order of arrival.
+// Each request is returned once; with its response if it completed. Only the backlog of the hub is retained; the capture
+// is truncated if the window starts before the oldest retained event and older events were evicted.
+func (h *EventHub) CapturedRPCEvents(from, to time.Time) Capture {
+	retained, evicted := h.Retained()
+
+	index := map[string]int{}
+	capture := Capture{Events: []RPCEvent{}}
+	for _, ev := range retained {
+		if ev.meta == nil {
+			continue
+		}
+		rpc := ev.meta.event
+		if capture.Since.IsZero() {
+			capture.Since = rpc.Time
+		}
+		if (!from.IsZero() && rpc.Time.Before(from)) || (!to.IsZero() && rpc.Time.After(to)) {
+			continue
+		}
+		if i, ok := index[rpc.ID]; ok {
+			// the response event supersedes the request event
+			capture.Events[i] = rpc
+			continue
+		}
+		index[rpc.ID] = len(capture.Events)
+		capture.Events = append(capture.Events, rpc)
+	}
+	capture.Truncated = evicted && (from.IsZero() || capture.Since.IsZero() || from.Before(capture.Since))
+	return capture
+}
+
+// truncatedHeader is set on exports of truncated captures; to the time of the oldest retained event, if any.
+const truncatedHeader = "X-Zeth-Truncated"
+
+// setTruncated sets the truncated header if the capture is truncated.
+func setTruncated(w http.ResponseWriter, capture Capture) {
+	if !capture.Truncated {
+		return
+	}
+	since := "true"
+	if !capture.Since.IsZero() {
+		since = capture.Since.Format(time.RFC3339Nano)
+	}
+	w.Header().Set(truncatedHeader, since)
+}
+
+// parseTimeWindow parses the from/to query parameters; RFC3339 timestamps.
+func parseTimeWindow(q url.Values) (time.Time, time.Time, url.Values) {
+	errs := url.Values{}
+	parse := func(key string) time.Time {
+		v := q.Get(key)
+		if v == "" {
+			return time.Time{}
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			errs.Add(key, "must be an RFC3339 timestamp")
+		}
+		return t
+	}
+	from, to := parse("from"), parse("to")
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		errs.Add("to", "must not be before from")
+	}
+	return from, to, errs
+}
+
+// capturedRPCEvents returns the captured RPC events of the node in the request's time window; the truncated header is set
+// if the capture is truncated. An error response is written on failure.
+func (h *nodesHandler) capturedRPCEvents(w http.ResponseWriter, r *http.Request) (uuid.UUID, Capture, bool) {
+	uid, err := uuid.FromString(mux.Vars(r)["uuid"])
+	if err != nil {
+		http.Error(w, rest.HTTPBadRequest, http.StatusBadRequest)
+		return uuid.UUID{}, Capture{}, false
+	}
+
+	if _, err := h.nodes.Get(r.Context(), uid); err != nil {
+		http.Error(w, rest.HTTPNotFound, http.StatusNotFound)
+		return uuid.UUID{}, Capture{}, false
+	}
+
+	from, to, errs := parseTimeWindow(r.URL.Query())
+	if len(errs) > 0 {
+		rest.ValidationErrors(w, errs)
+		return uuid.UUID{}, Capture{}, false
+	}
+
+	capture := h.nodeRPCMonitor.Hub(uid).CapturedRPCEvents(from, to)
+	setTruncated(w, capture)
+	return uid, capture, true
+}
+
+// exportHAR exports the captured RPC events of the node as a HAR log; only the recent events retained by the monitor of the
+// node are captured, see the X-Zeth-Truncated header and the comment of the log.
+/* curl request:
+curl -o traffic.har \
+	"http://localhost:7000/api/v1/nodes/00000000-0000-0000-0000-000000000000/export/har?from=2021-12-01T00:00:00Z&to=2021-12-02T00:00:00Z"
+*/
+func (h *nodesHandler) exportHAR(w http.ResponseWriter, r *http.Request) {
+	uid, capture, ok := h.capturedRPCEvents(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="zeth-%s.har"`, uid))
+	rest.JSON(w, newHAR(capture))
+}
+
+// exportJSONL exports the captured RPC events of the node as JSON lines; see exportHAR.
+/* curl request:
+curl -o traffic.jsonl \
+	"http://localhost:7000/api/v1/nodes/00000000-0000-0000-0000-000000000000/export/jsonl?from=2021-12-01T00:00:00Z"
+*/
+func (h *nodesHandler) exportJSONL(w http.ResponseWriter, r *http.Request) {
+	uid, capture, ok := h.capturedRPCEvents(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="zeth-%s.jsonl"`, uid))
+	w.WriteHeader(http.StatusOK)
+	for _, ev := range capture.Events {
+		if _, err := w.Write(ev.Bytes()); err != nil {
+			log.Debug().Err(err).Msg("failed to write exported event")
+			return
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package node
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CapturedRPCEvents(t *testing.T) {
+	is := assert.New(t)
+
+	start := time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC)
+	hub := NewEventHub(DefaultEventBacklog)
+
+	completed := newTestRPCEvent(request, "eth_call", 0, "", 0)
+	completed.Time = start
+	hub.PublishRPCEvent(completed)
+	completed.Type = response
+	completed.Response.StatusCode = 200
+	completed.Response.Headers = `{"Content-Type":["application/json"]}`
+	completed.Response.Body = `{"jsonrpc":"2.0","id":1,"result":"0x"}`
+	completed.Duration = 42
+	hub.PublishRPCEvent(completed)
+
+	dropped := newTestRPCEvent(request, "eth_getLogs", 0, "", 0)
+	dropped.Time = start.Add(time.Hour)
+	hub.PublishRPCEvent(dropped)
+	hub.Publish([]byte("raw"))
+
+	capture := hub.CapturedRPCEvents(time.Time{}, time.Time{})
+	is.False(capture.Truncated)
+	events := capture.Events
+	is.Len(events, 2)
+	is.Equal(completed.ID, events[0].ID)
+	is.Equal(response, events[0].Type)
+	is.Equal(dropped.ID, events[1].ID)
+
+	// time window
+	is.Len(hub.CapturedRPCEvents(start.Add(time.Minute), time.Time{}).Events, 1)
+	is.Len(hub.CapturedRPCEvents(time.Time{}, start.Add(time.Minute)).Events, 1)
+
+	har := newHAR(capture)
+	b, err := json.Marshal(har)
+	is.NoError(err)
+	is.Contains(string(b), `"version":"1.2"`)
+	is.Empty(har.Log.Comment)
+
+	entry := har.Log.Entries[0]
+	is.Equal("2021-12-01T00:00:00Z", entry.StartedDateTime)
+	is.Equal(float64(42), entry.Time)
+	is.Equal("POST", entry.Request.Method)
+	is.Equal("http://localhost:8545", entry.Request.URL)
+	is.Equal(completed.Request.Body, entry.Request.PostData.Text)
+	is.Equal(200, entry.Response.Status)
+	is.Equal("application/json", entry.Response.Content.MimeType)
+	is.Equal(completed.Response.Body, entry.Response.Content.Text)
+
+	is.Equal(0, har.Log.Entries[1].Response.Status)
+}
+
+func Test_CapturedRPCEventsTruncated(t *testing.T) {
+	is := assert.New(t)
+
+	start := time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC)
+	hub := NewEventHub(2)
+	for i := 0; i < 3; i++ {
+		ev := newTestRPCEvent(request, "eth_call", 0, "", 0)
+		ev.Time = start.Add(time.Duration(i) * time.Minute)
+		hub.PublishRPCEvent(ev)
+	}
+
+	// the first event was evicted
+	capture := hub.CapturedRPCEvents(time.Time{}, time.Time{})
+	is.Len(capture.Events, 2)
+	is.True(capture.Truncated)
+	is.Equal(start.Add(time.Minute), capture.Since)
+	is.Contains(newHAR(capture).Log.Comment, "2021-12-01T00:01:00Z")
+
+	is.True(hub.CapturedRPCEvents(start, time.Time{}).Truncated)
+	is.False(hub.CapturedRPCEvents(start.Add(time.Minute), time.Time{}).Truncated)
+}
+
+func Test_parseTimeWindow(t *testing.T) {
+	is := assert.New(t)
+
+	from, to, errs := parseTimeWindow(url.Values{"from": {"2021-12-01T00:00:00Z"}, "to": {"2021-12-02T00:00:00Z"}})
+	is.Empty(errs)
+	is.Equal(24*time.Hour, to.Sub(from))
+
+	_, _, errs = parseTimeWindow(url.Values{"from": {"yesterday"}})
+	is.Contains(errs, "from")
+
+	_, _, errs = parseTimeWindow(url.Values{"from": {"2021-12-02T00:00:00Z"}, "to": {"2021-12-01T00:00:00Z"}})
+	is.Contains(errs, "to")
+}
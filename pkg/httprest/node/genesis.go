@@ -0,0 +1,68 @@
+package node
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zees-dev/zeth/pkg/geth"
+	"github.com/zees-dev/zeth/pkg/httprest/rest"
+	"github.com/zees-dev/zeth/pkg/managed"
+)
+
+type initGenesisRequestPayload geth.GenesisSpec
+
+func (payload *initGenesisRequestPayload) Validate() url.Values {
+	errs := url.Values{}
+
+	if err := geth.GenesisSpec(*payload).Validate(); err != nil {
+		errs.Add("genesis", err.Error())
+	}
+
+	return errs
+}
+
+// initGenesis initialises the data dir of the stopped managed node with the genesis block of a private network built from the parameters;
+// the network id of the node is set to the chain id.
+/* curl request:
+curl -X POST \
+	-H "Content-Type: application/json" \
+	-d '{"chainId": 1337, "consensus": "dev", "signers": ["0x71c7656ec7ab88b098defb751b7401b5f6d8976f"], "alloc": {"0x71c7656ec7ab88b098defb751b7401b5f6d8976f": {"balance": "0x3635c9adc5dea00000"}}}' \
+	http://localhost:7000/api/v1/nodes/00000000-0000-0000-0000-000000000000/genesis
+*/
+func (h *nodesHandler) initGenesis(w http.ResponseWriter, r *http.Request) {
+	uid, ok := h.managedNodeID(w, r)
+	if !ok {
+		return
+	}
+
+	payload := initGenesisRequestPayload{}
+	if ok := rest.DecodeAndValidateJSONPayload(w, r.Body, &payload); !ok {
+		log.Debug().Msg("validation failed")
+		return
+	}
+	genesis, err := geth.GenesisSpec(payload).Build()
+	if err != nil {
+		rest.ValidationErrors(w, url.Values{"genesis": {err.Error()}})
+		return
+	}
+
+	if ok := h.requireBinary(w, r, uid); !ok {
+		return
+	}
+	n, err := h.managed.InitGenesis(r.Context(), uid, genesis)
+	if err != nil {
+		switch {
+		case errors.Is(err, managed.ErrNotManaged), errors.Is(err, managed.ErrRunning),
+			errors.Is(err, managed.ErrGenesisInitialised), errors.Is(err, managed.ErrBinaryNotInstalled):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Err(err).Msgf("failed to initialise genesis of node: %s", uid)
+		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	rest.JSON(w, n.Redacted())
+}
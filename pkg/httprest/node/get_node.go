@@ -29,5 +29,5 @@ func (h *nodesHandler) getNode(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	rest.JSON(w, node)
+	rest.JSON(w, node.Redacted())
 }
@@ -2,6 +2,9 @@ package node
 
 import (
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/zees-dev/zeth/pkg/httprest/rest"
 	"github.com/zees-dev/zeth/pkg/node"
@@ -11,20 +14,85 @@ type allNodesResponse struct {
 	Nodes []node.ZethNode `json:"nodes"`
 }
 
+// nodeFilter selects nodes by their discovered chain metadata and probed capabilities;
+// nodes without metadata only match an empty chain filter.
+type nodeFilter struct {
+	chainID      uint64
+	client       string
+	capabilities []node.Capability
+}
+
+func parseNodeFilter(q url.Values) (nodeFilter, url.Values) {
+	errs := url.Values{}
+	f := nodeFilter{client: strings.ToLower(q.Get("client"))}
+
+	if v := q.Get("chainId"); v != "" {
+		chainID, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			errs.Add("chainId", "chainId must be a positive integer")
+		}
+		f.chainID = chainID
+	}
+
+	if v := q.Get("capability"); v != "" {
+		capabilities, err := node.ParseCapabilities(v)
+		if err != nil {
+			errs.Add("capability", err.Error())
+		}
+		f.capabilities = capabilities
+	}
+
+	return f, errs
+}
+
+func (f nodeFilter) match(n node.ZethNode) bool {
+	for _, capability := range f.capabilities {
+		if !n.Supports(capability) {
+			return false
+		}
+	}
+	if f.chainID == 0 && f.client == "" {
+		return true
+	}
+	if n.Chain == nil {
+		return false
+	}
+	return (f.chainID == 0 || n.Chain.ChainID == f.chainID) && (f.client == "" || n.Chain.Client == f.client)
+}
+
 /* curl request:
 curl \
 	-H "Content-Type: application/json" \
 	http://localhost:7000/api/v1/nodes
+
+filter by discovered chain metadata:
+curl "http://localhost:7000/api/v1/nodes?chainId=1&client=geth"
+
+filter by probed capabilities:
+curl "http://localhost:7000/api/v1/nodes?capability=debug,archive"
 */
 func (h *nodesHandler) getNodes(w http.ResponseWriter, r *http.Request) {
+	filter, errs := parseNodeFilter(r.URL.Query())
+	if len(errs) > 0 {
+		rest.ValidationErrors(w, errs)
+		return
+	}
+
 	nodes, err := h.nodes.GetAll(r.Context())
 	if err != nil {
 		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
 		return
 	}
 
+	matched := []node.ZethNode{}
+	for _, n := range nodes {
+		if filter.match(n) {
+			matched = append(matched, n.Redacted())
+		}
+	}
+
 	response := allNodesResponse{
-		Nodes: nodes,
+		Nodes: matched,
 	}
 
 	rest.JSON(w, response)
@@ -0,0 +1,93 @@
+package node
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zees-dev/zeth/pkg/httprest/rest"
+	"github.com/zees-dev/zeth/pkg/managed"
+)
+
+type updateGethConfigRequestPayload struct {
+	TOML string `json:"toml"` // the full config; settings which are not set retain the geth defaults
+}
+
+func (payload *updateGethConfigRequestPayload) Validate() url.Values {
+	errs := url.Values{}
+
+	if strings.TrimSpace(payload.TOML) == "" {
+		errs.Add("toml", "toml is required")
+	}
+
+	return errs
+}
+
+// getGethConfig returns the geth config of the managed node as TOML and JSON.
+/* curl request:
+curl \
+	http://localhost:7000/api/v1/nodes/00000000-0000-0000-0000-000000000000/config
+*/
+func (h *nodesHandler) getGethConfig(w http.ResponseWriter, r *http.Request) {
+	uid, ok := h.managedNodeID(w, r)
+	if !ok {
+		return
+	}
+
+	cfg, err := h.managed.Config(r.Context(), uid)
+	if err != nil {
+		if errors.Is(err, managed.ErrNotManaged) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Err(err).Msgf("failed to read geth config of node: %s", uid)
+		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	rest.JSON(w, cfg)
+}
+
+// updateGethConfig validates the geth config and returns its changes from the current and the default config of the managed node;
+// the config is applied by restarting the node unless dryRun is set.
+/* curl request:
+curl -X PUT \
+	-H "Content-Type: application/json" \
+	-d '{"toml": "[Eth]\nSyncMode = \"full\"\n\n[Node]\nDataDir = \"/home/user/.zeth/nodes/00000000-0000-0000-0000-000000000000\"\nHTTPHost = \"127.0.0.1\"\nHTTPPort = 8545\n"}' \
+	"http://localhost:7000/api/v1/nodes/00000000-0000-0000-0000-000000000000/config?dryRun=true"
+*/
+func (h *nodesHandler) updateGethConfig(w http.ResponseWriter, r *http.Request) {
+	uid, ok := h.managedNodeID(w, r)
+	if !ok {
+		return
+	}
+
+	payload := updateGethConfigRequestPayload{}
+	if ok := rest.DecodeAndValidateJSONPayload(w, r.Body, &payload); !ok {
+		log.Debug().Msg("validation failed")
+		return
+	}
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dryRun"))
+
+	update, err := h.managed.UpdateConfig(r.Context(), uid, []byte(payload.TOML), dryRun)
+	switch {
+	case errors.Is(err, managed.ErrInvalidConfig):
+		rest.ValidationErrors(w, url.Values{"toml": []string{err.Error()}})
+		return
+	case errors.Is(err, managed.ErrNotManaged):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil && !update.Applied:
+		log.Err(err).Msgf("failed to update geth config of node: %s", uid)
+		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		return
+	case err != nil:
+		// the config is written; failures to restart are reported in the process status of the node
+		log.Err(err).Msgf("failed to restart node with updated geth config: %s", uid)
+	}
+
+	rest.JSON(w, update)
+}
@@ -1,32 +1,101 @@
 package node
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
 	"github.com/zees-dev/zeth/pkg/app"
+	"github.com/zees-dev/zeth/pkg/chain"
+	"github.com/zees-dev/zeth/pkg/health"
+	"github.com/zees-dev/zeth/pkg/managed"
 	"github.com/zees-dev/zeth/pkg/node"
+	"github.com/zees-dev/zeth/pkg/preset"
+	"github.com/zees-dev/zeth/pkg/redact"
+	"github.com/zees-dev/zeth/pkg/vulnerability"
 )
 
 type nodesHandler struct {
 	nodes          node.NodeService
+	groups         node.NodeGroupService
+	groupRouter    *node.GroupRouter
 	nodeRPCMonitor *NodeRPCMonitor
+	proxyHealth    *proxyHealth
+	rpcObserver    RPCObserver
+	redaction      *redact.Service
+	health         *health.Service
+	chains         chain.Registry
+	vulns          *vulnerability.Service
+	managed        *managed.Service
+	presets        *preset.Service
 }
 
 func RegisterRoutes(app *app.App, baseRouter *mux.Router) {
-	nodeRPCMonitor := NewNodeRPCMonitor()
+	nodeRPCMonitor := NewNodeRPCMonitor(app.Services.Nodes)
+
+	// create the event hubs of existing nodes
+	nodes, err := app.Services.Nodes.GetAll(context.Background())
+	if err != nil {
+		log.Err(err).Msg("failed to get nodes")
+	}
+	for _, n := range nodes {
+		nodeRPCMonitor.Hub(n.ID)
+	}
 
 	h := nodesHandler{
 		nodes:          app.Services.Nodes,
+		groups:         app.Services.NodeGroups,
+		groupRouter:    node.NewGroupRouter(app.Services.Nodes),
 		nodeRPCMonitor: nodeRPCMonitor,
+		proxyHealth:    newProxyHealth(app.Services.Events),
+		rpcObserver:    app.Services.Alerts,
+		redaction:      app.Services.Redaction,
+		health:         app.Services.Health,
+		chains:         app.Services.Chains,
+		vulns:          app.Services.Vulnerabilities,
+		managed:        app.Services.Managed,
+		presets:        app.Services.Presets,
 	}
 
 	baseRouter.HandleFunc("/nodes", h.getNodes).Methods(http.MethodGet)
 	baseRouter.HandleFunc("/nodes", h.createNode).Methods(http.MethodPost)
+	baseRouter.HandleFunc("/nodes/managed", h.createManagedNode).Methods(http.MethodPost)
+	baseRouter.HandleFunc("/nodes/dev", h.createDevNode).Methods(http.MethodPost)
 	baseRouter.HandleFunc("/nodes/{uuid}", h.getNode).Methods(http.MethodGet)
 	baseRouter.HandleFunc("/nodes/{uuid}", h.updateNode).Methods(http.MethodPut)
 	baseRouter.HandleFunc("/nodes/{uuid}", h.removeNode).Methods(http.MethodDelete)
+	baseRouter.HandleFunc("/nodes/{uuid}/normalization", h.getErrorNormalization).Methods(http.MethodGet)
+	baseRouter.HandleFunc("/nodes/{uuid}/normalization", h.updateErrorNormalization).Methods(http.MethodPut)
+	baseRouter.HandleFunc("/nodes/{uuid}/chaos", h.getChaos).Methods(http.MethodGet)
+	baseRouter.HandleFunc("/nodes/{uuid}/chaos", h.updateChaos).Methods(http.MethodPut)
+	baseRouter.HandleFunc("/nodes/{uuid}/export/har", h.exportHAR).Methods(http.MethodGet)
+	baseRouter.HandleFunc("/nodes/{uuid}/export/jsonl", h.exportJSONL).Methods(http.MethodGet)
+	baseRouter.HandleFunc("/nodes/{uuid}/health", h.getNodeHealth).Methods(http.MethodGet)
+	baseRouter.HandleFunc("/nodes/{uuid}/capabilities", h.getNodeCapabilities).Methods(http.MethodGet)
+	baseRouter.HandleFunc("/nodes/{uuid}/capabilities", h.probeNodeCapabilities).Methods(http.MethodPost)
+	baseRouter.HandleFunc("/nodes/{uuid}/vulnerabilities", h.getNodeVulnerabilities).Methods(http.MethodGet)
+	baseRouter.HandleFunc("/nodes/{uuid}/process", h.getNodeProcess).Methods(http.MethodGet)
+	baseRouter.HandleFunc("/nodes/{uuid}/start", h.startNodeProcess).Methods(http.MethodPost)
+	baseRouter.HandleFunc("/nodes/{uuid}/stop", h.stopNodeProcess).Methods(http.MethodPost)
+	baseRouter.HandleFunc("/nodes/{uuid}/restart", h.restartNodeProcess).Methods(http.MethodPost)
+	baseRouter.HandleFunc("/nodes/{uuid}/config", h.getGethConfig).Methods(http.MethodGet)
+	baseRouter.HandleFunc("/nodes/{uuid}/config", h.updateGethConfig).Methods(http.MethodPut)
+	baseRouter.HandleFunc("/nodes/{uuid}/genesis", h.initGenesis).Methods(http.MethodPost)
+	baseRouter.HandleFunc("/nodes/{uuid}/upgrade", h.getNodeUpgrade).Methods(http.MethodGet)
+	baseRouter.HandleFunc("/nodes/{uuid}/upgrade", h.upgradeNode).Methods(http.MethodPost)
+	baseRouter.HandleFunc("/nodes/{uuid}/logs", h.getNodeLogs).Methods(http.MethodGet)
+	baseRouter.HandleFunc("/nodes/{uuid}/logs/sse", h.tailNodeLogs).Methods(http.MethodGet)
 
 	baseRouter.HandleFunc("/nodes/rpc/{uuid}", h.rpcNode)
 	baseRouter.HandleFunc("/nodes/rpc/{uuid}/sse", h.nodeRPCMonitor.handleSSE).Methods(http.MethodGet)
+	baseRouter.HandleFunc("/nodes/rpc/{uuid}/ws", h.nodeRPCMonitor.handleWS).Methods(http.MethodGet)
+
+	baseRouter.HandleFunc("/groups", h.getNodeGroups).Methods(http.MethodGet)
+	baseRouter.HandleFunc("/groups", h.createNodeGroup).Methods(http.MethodPost)
+	baseRouter.HandleFunc("/groups/{uuid}", h.getNodeGroup).Methods(http.MethodGet)
+	baseRouter.HandleFunc("/groups/{uuid}", h.updateNodeGroup).Methods(http.MethodPut)
+	baseRouter.HandleFunc("/groups/{uuid}", h.removeNodeGroup).Methods(http.MethodDelete)
+
+	baseRouter.HandleFunc("/groups/rpc/{uuid}", h.rpcNodeGroup)
 }
@@ -0,0 +1,44 @@
+package node
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	uuid "github.com/satori/go.uuid"
+	"github.com/zees-dev/zeth/pkg/httprest/rest"
+)
+
+// getNodeHealth returns the current status, uptime and health history of the node; the window defaults to the retention period.
+/* curl request:
+curl \
+	"http://localhost:7000/api/v1/nodes/00000000-0000-0000-0000-000000000000/health?from=2021-12-01T00:00:00Z"
+*/
+func (h *nodesHandler) getNodeHealth(w http.ResponseWriter, r *http.Request) {
+	// get id from request parameters
+	id := mux.Vars(r)["uuid"]
+
+	uid, err := uuid.FromString(id)
+	if err != nil {
+		http.Error(w, rest.HTTPBadRequest, http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.nodes.Get(r.Context(), uid); err != nil {
+		http.Error(w, rest.HTTPNotFound, http.StatusNotFound)
+		return
+	}
+
+	from, to, errs := parseTimeWindow(r.URL.Query())
+	if len(errs) > 0 {
+		rest.ValidationErrors(w, errs)
+		return
+	}
+
+	report, err := h.health.Report(r.Context(), uid, from, to)
+	if err != nil {
+		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	rest.JSON(w, report)
+}
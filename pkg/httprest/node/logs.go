@@ -0,0 +1,147 @@
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zees-dev/zeth/pkg/httprest/rest"
+	"github.com/zees-dev/zeth/pkg/managed"
+)
+
+// parseLogQuery parses the log query of the query parameters; fields are filtered by repeated field=key:value parameters.
+func parseLogQuery(v url.Values) (managed.LogQuery, url.Values) {
+	errs := url.Values{}
+	q := managed.LogQuery{Text: v.Get("q"), Fields: map[string]string{}}
+
+	if level := v.Get("level"); level != "" {
+		var err error
+		if q.Level, err = managed.ParseLogLevel(level); err != nil {
+			errs.Add("level", err.Error())
+		}
+	}
+
+	for _, field := range v["field"] {
+		kv := strings.SplitN(field, ":", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			errs.Add("field", fmt.Sprintf("field must be key:value: %s", field))
+			continue
+		}
+		q.Fields[kv[0]] = kv[1]
+	}
+
+	for name, t := range map[string]*time.Time{"since": &q.Since, "until": &q.Until} {
+		if s := v.Get(name); s != "" {
+			var err error
+			if *t, err = time.Parse(time.RFC3339, s); err != nil {
+				errs.Add(name, fmt.Sprintf("%s must be a RFC3339 time", name))
+			}
+		}
+	}
+
+	if s := v.Get("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit < 1 || limit > managed.MaxLogLimit {
+			errs.Add("limit", fmt.Sprintf("limit must be between 1 and %d", managed.MaxLogLimit))
+		}
+		q.Limit = limit
+	}
+
+	return q, errs
+}
+
+// getNodeLogs searches the captured output of the managed node; the latest matching entries are returned in chronological order.
+/* curl request:
+curl \
+	"http://localhost:7000/api/v1/nodes/00000000-0000-0000-0000-000000000000/logs?level=warn&q=peer&field=err:timeout&limit=50"
+*/
+func (h *nodesHandler) getNodeLogs(w http.ResponseWriter, r *http.Request) {
+	uid, ok := h.managedNodeID(w, r)
+	if !ok {
+		return
+	}
+
+	q, errs := parseLogQuery(r.URL.Query())
+	if len(errs) > 0 {
+		rest.ValidationErrors(w, errs)
+		return
+	}
+
+	entries, err := h.managed.Logs(uid, q)
+	if err != nil {
+		log.Err(err).Msgf("failed to search logs of node: %s", uid)
+		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	rest.JSON(w, entries)
+}
+
+// tailNodeLogs streams the captured output of the managed node; the latest matching entries are sent first (limit), followed by
+// new matching entries as they are captured.
+/* curl request:
+curl -v "http://localhost:7000/api/v1/nodes/00000000-0000-0000-0000-000000000000/logs/sse?level=info&limit=20"
+*/
+func (h *nodesHandler) tailNodeLogs(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	uid, ok := h.managedNodeID(w, r)
+	if !ok {
+		return
+	}
+
+	q, errs := parseLogQuery(r.URL.Query())
+	if len(errs) > 0 {
+		rest.ValidationErrors(w, errs)
+		return
+	}
+
+	c := make(chan managed.LogEntry, managed.MaxLogLimit)
+	backlog, unsubscribeFn, err := h.managed.SubscribeLogs(uid, c, q)
+	if err != nil {
+		log.Err(err).Msgf("failed to search logs of node: %s", uid)
+		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		return
+	}
+	defer unsubscribeFn()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	write := func(e managed.LogEntry) {
+		b, err := json.Marshal(e)
+		if err != nil {
+			log.Debug().Err(err).Msg("failed to marshal log entry")
+			return
+		}
+		fmt.Fprintf(w, "data: %s\n\n", b)
+	}
+
+	for _, e := range backlog {
+		write(e)
+	}
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e := <-c:
+			if q.Match(e) {
+				write(e)
+				flusher.Flush()
+			}
+		}
+	}
+}
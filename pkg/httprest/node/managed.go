@@ -0,0 +1,231 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+	uuid "github.com/satori/go.uuid"
+	"github.com/zees-dev/zeth/pkg/httprest/rest"
+	"github.com/zees-dev/zeth/pkg/managed"
+	"github.com/zees-dev/zeth/pkg/node"
+)
+
+type createManagedNodeRequestPayload struct {
+	Name string `json:"name"`
+	node.ManagedConfig
+	Preset string `json:"preset"` // name of the network preset; defaults to mainnet
+	Start  bool   `json:"start"`  // start the process once the node is registered
+}
+
+func (payload *createManagedNodeRequestPayload) Validate() url.Values {
+	errs := url.Values{}
+
+	if strings.TrimSpace(payload.Name) == "" {
+		errs.Add("name", "name is required")
+	}
+
+	if err := payload.ManagedConfig.Validate(); err != nil {
+		errs.Add("managed", err.Error())
+	}
+	if payload.DataDir != "" {
+		errs.Add("dataDir", managed.ErrDataDirNotAllowed.Error())
+	}
+
+	return errs
+}
+
+// createManagedNode registers a geth process run by zeth as a node; its RPC endpoints are registered automatically.
+/* curl request:
+curl -X POST \
+	-H "Content-Type: application/json" \
+	-d '{"name": "local geth", "preset": "mainnet", "restartPolicy": "on-failure", "autoStart": true, "start": true}' \
+	http://localhost:7000/api/v1/nodes/managed
+*/
+func (h *nodesHandler) createManagedNode(w http.ResponseWriter, r *http.Request) {
+	payload := createManagedNodeRequestPayload{}
+	if ok := rest.DecodeAndValidateJSONPayload(w, r.Body, &payload); !ok {
+		log.Debug().Msg("validation failed")
+		return
+	}
+
+	exists, err := h.nodeNameExists(r.Context(), payload.Name)
+	if exists || err != nil {
+		http.Error(w, "a node with name already exists", http.StatusBadRequest)
+		return
+	}
+
+	if payload.Preset != "" {
+		network, err := h.presets.Get(r.Context(), payload.Preset)
+		if err != nil {
+			rest.ValidationErrors(w, url.Values{"preset": {"preset not found"}})
+			return
+		}
+		payload.ManagedConfig.Network = &network
+		if err := payload.ManagedConfig.CheckNetworkVersion(); err != nil {
+			rest.ValidationErrors(w, url.Values{"version": {err.Error()}})
+			return
+		}
+	}
+
+	n, err := h.managed.Create(r.Context(), payload.Name, payload.ManagedConfig)
+	if err != nil {
+		log.Err(err).Msg("failed to create managed node")
+		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		return
+	}
+	h.nodeRPCMonitor.Hub(n.ID)
+
+	if payload.Start {
+		// failures are reported in the process status of the node; a missing binary is installed in the background instead
+		if err := h.managed.RequireBinary(r.Context(), n.ID); err != nil {
+			log.Err(err).Msgf("not starting managed node: %s", n.ID)
+		} else if _, err := h.managed.Start(r.Context(), n.ID); err != nil {
+			log.Err(err).Msgf("failed to start managed node: %s", n.ID)
+		}
+	}
+
+	rest.JSON(w, n.Redacted())
+}
+
+// nodeNameExists checks if a node with the name is already registered.
+func (h *nodesHandler) nodeNameExists(ctx context.Context, name string) (bool, error) {
+	nodes, err := h.nodes.GetAll(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, n := range nodes {
+		if n.Name == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// managedNodeID returns the ID of the managed or dev node of the request; writing the error response if the node is not run by zeth.
+func (h *nodesHandler) managedNodeID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	// get id from request parameters
+	id := mux.Vars(r)["uuid"]
+
+	uid, err := uuid.FromString(id)
+	if err != nil {
+		http.Error(w, rest.HTTPBadRequest, http.StatusBadRequest)
+		return uid, false
+	}
+
+	n, err := h.nodes.Get(r.Context(), uid)
+	if err != nil {
+		http.Error(w, rest.HTTPNotFound, http.StatusNotFound)
+		return uid, false
+	}
+	if n.Managed == nil && n.Dev == nil {
+		http.Error(w, managed.ErrNotManaged.Error(), http.StatusBadRequest)
+		return uid, false
+	}
+
+	return uid, true
+}
+
+/* curl request:
+curl \
+	http://localhost:7000/api/v1/nodes/00000000-0000-0000-0000-000000000000/process
+*/
+func (h *nodesHandler) getNodeProcess(w http.ResponseWriter, r *http.Request) {
+	uid, ok := h.managedNodeID(w, r)
+	if !ok {
+		return
+	}
+
+	rest.JSON(w, h.managed.Status(uid))
+}
+
+// startNodeProcess starts the process of the node; if its geth binary is missing, it is installed in the background and the node
+// must be started again once installed.
+/* curl request:
+curl -X POST \
+	http://localhost:7000/api/v1/nodes/00000000-0000-0000-0000-000000000000/start
+*/
+func (h *nodesHandler) startNodeProcess(w http.ResponseWriter, r *http.Request) {
+	uid, ok := h.managedNodeID(w, r)
+	if !ok {
+		return
+	}
+
+	if ok := h.requireBinary(w, r, uid); !ok {
+		return
+	}
+	status, err := h.managed.Start(r.Context(), uid)
+	if err != nil {
+		log.Debug().Err(err).Msg("failed to start managed node")
+		code := http.StatusInternalServerError
+		if errors.Is(err, managed.ErrBinaryNotInstalled) || errors.Is(err, managed.ErrGenesisRequired) {
+			code = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	rest.JSON(w, status)
+}
+
+/* curl request:
+curl -X POST \
+	http://localhost:7000/api/v1/nodes/00000000-0000-0000-0000-000000000000/stop
+*/
+func (h *nodesHandler) stopNodeProcess(w http.ResponseWriter, r *http.Request) {
+	uid, ok := h.managedNodeID(w, r)
+	if !ok {
+		return
+	}
+
+	status, err := h.managed.Stop(r.Context(), uid)
+	if err != nil {
+		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	rest.JSON(w, status)
+}
+
+/* curl request:
+curl -X POST \
+	http://localhost:7000/api/v1/nodes/00000000-0000-0000-0000-000000000000/restart
+*/
+func (h *nodesHandler) restartNodeProcess(w http.ResponseWriter, r *http.Request) {
+	uid, ok := h.managedNodeID(w, r)
+	if !ok {
+		return
+	}
+
+	if ok := h.requireBinary(w, r, uid); !ok {
+		return
+	}
+	status, err := h.managed.Restart(r.Context(), uid)
+	if err != nil {
+		log.Debug().Err(err).Msg("failed to restart managed node")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rest.JSON(w, status)
+}
+
+// requireBinary checks the geth binary of the node is installed; a missing binary is installed in the background rather than
+// downloaded while the request waits. The error response is written if it is not installed.
+func (h *nodesHandler) requireBinary(w http.ResponseWriter, r *http.Request, uid uuid.UUID) bool {
+	err := h.managed.RequireBinary(r.Context(), uid)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, managed.ErrBinaryNotInstalled):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		log.Err(err).Msgf("failed to check geth binary of node: %s", uid)
+		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+	}
+	return false
+}
@@ -0,0 +1,205 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+	uuid "github.com/satori/go.uuid"
+	"github.com/zees-dev/zeth/pkg/httprest/rest"
+	"github.com/zees-dev/zeth/pkg/node"
+)
+
+type nodeGroupRequestPayload struct {
+	Name         string                 `json:"name"`
+	Mode         node.NodeGroupMode     `json:"mode"`
+	ArchiveDepth uint64                 `json:"archiveDepth"`
+	Members      []node.NodeGroupMember `json:"members"`
+}
+
+func (payload *nodeGroupRequestPayload) Validate() url.Values {
+	errs := url.Values{}
+
+	if len(strings.TrimSpace(payload.Name)) == 0 {
+		errs.Add("name", "name is required")
+	}
+
+	if !payload.Mode.IsValid() {
+		errs.Add("mode", fmt.Sprintf("mode is invalid; must < %d", node.GroupModeEnd))
+	}
+
+	if len(payload.Members) == 0 {
+		errs.Add("members", "at least one member is required")
+	}
+
+	seen := map[uuid.UUID]bool{}
+	for _, m := range payload.Members {
+		if seen[m.NodeID] {
+			errs.Add("members", fmt.Sprintf("node %s is listed more than once", m.NodeID))
+		}
+		seen[m.NodeID] = true
+	}
+
+	return errs
+}
+
+// validateMembers checks that all group members are registered nodes on the same chain.
+// Members without discovered chain metadata are not checked.
+func (h *nodesHandler) validateMembers(ctx context.Context, members []node.NodeGroupMember) error {
+	var chain *node.ChainMetadata
+	for _, m := range members {
+		n, err := h.nodes.Get(ctx, m.NodeID)
+		if err != nil {
+			return fmt.Errorf("node %s not found", m.NodeID)
+		}
+		if n.Chain == nil {
+			continue
+		}
+		if chain != nil && !chain.SameChain(*n.Chain) {
+			return fmt.Errorf("node %s is on a different chain (chain id %d) than other members (chain id %d)", m.NodeID, n.Chain.ChainID, chain.ChainID)
+		}
+		chain = n.Chain
+	}
+	return nil
+}
+
+/* curl request:
+curl \
+	-H "Content-Type: application/json" \
+	http://localhost:7000/api/v1/groups
+*/
+func (h *nodesHandler) getNodeGroups(w http.ResponseWriter, r *http.Request) {
+	groups, err := h.groups.GetAll(r.Context())
+	if err != nil {
+		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	rest.JSON(w, groups)
+}
+
+/* curl request:
+curl \
+	-H "Content-Type: application/json" \
+	http://localhost:7000/api/v1/groups/00000000-0000-0000-0000-000000000000
+*/
+func (h *nodesHandler) getNodeGroup(w http.ResponseWriter, r *http.Request) {
+	uid, err := uuid.FromString(mux.Vars(r)["uuid"])
+	if err != nil {
+		http.Error(w, rest.HTTPBadRequest, http.StatusBadRequest)
+		return
+	}
+
+	group, err := h.groups.Get(r.Context(), uid)
+	if err != nil {
+		http.Error(w, rest.HTTPNotFound, http.StatusNotFound)
+		return
+	}
+
+	rest.JSON(w, group)
+}
+
+/* curl request:
+curl -X POST \
+	-H "Content-Type: application/json" \
+	-d '{"name": "mainnet", "mode": 0, "archiveDepth": 128, "members": [{"nodeId": "b38bad92-619f-41e4-b01f-36a3de1b3a52", "archive": true}, {"nodeId": "3475ce0e-0124-4e8b-a661-b4b6e22cdf34", "archive": false}]}' \
+	http://localhost:7000/api/v1/groups
+*/
+func (h *nodesHandler) createNodeGroup(w http.ResponseWriter, r *http.Request) {
+	payload := nodeGroupRequestPayload{}
+	if ok := rest.DecodeAndValidateJSONPayload(w, r.Body, &payload); !ok {
+		log.Debug().Msg("validation failed")
+		return
+	}
+
+	if err := h.validateMembers(r.Context(), payload.Members); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	group, err := h.groups.Create(r.Context(), node.NodeGroup{
+		Name:         payload.Name,
+		Mode:         payload.Mode,
+		ArchiveDepth: payload.ArchiveDepth,
+		Members:      payload.Members,
+		DateAdded:    time.Now().UTC(),
+	})
+	if err != nil {
+		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	rest.JSON(w, group)
+}
+
+/* curl request:
+curl -X PUT \
+	-H "Content-Type: application/json" \
+	-d '{"name": "mainnet", "mode": 0, "archiveDepth": 256, "members": [{"nodeId": "b38bad92-619f-41e4-b01f-36a3de1b3a52", "archive": true}]}' \
+	http://localhost:7000/api/v1/groups/00000000-0000-0000-0000-000000000000
+*/
+func (h *nodesHandler) updateNodeGroup(w http.ResponseWriter, r *http.Request) {
+	payload := nodeGroupRequestPayload{}
+	if ok := rest.DecodeAndValidateJSONPayload(w, r.Body, &payload); !ok {
+		log.Debug().Msg("validation failed")
+		return
+	}
+
+	uid, err := uuid.FromString(mux.Vars(r)["uuid"])
+	if err != nil {
+		http.Error(w, rest.HTTPBadRequest, http.StatusBadRequest)
+		return
+	}
+
+	group, err := h.groups.Get(r.Context(), uid)
+	if err != nil {
+		http.Error(w, rest.HTTPNotFound, http.StatusNotFound)
+		return
+	}
+
+	if err := h.validateMembers(r.Context(), payload.Members); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	group.Name = payload.Name
+	group.Mode = payload.Mode
+	group.ArchiveDepth = payload.ArchiveDepth
+	group.Members = payload.Members
+
+	if err := h.groups.Update(r.Context(), group.ID, *group); err != nil {
+		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	rest.JSON(w, group)
+}
+
+/* curl request:
+curl -X DELETE \
+	http://localhost:7000/api/v1/groups/00000000-0000-0000-0000-000000000000
+*/
+func (h *nodesHandler) removeNodeGroup(w http.ResponseWriter, r *http.Request) {
+	uid, err := uuid.FromString(mux.Vars(r)["uuid"])
+	if err != nil {
+		http.Error(w, rest.HTTPBadRequest, http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.groups.Get(r.Context(), uid); err != nil {
+		http.Error(w, rest.HTTPNotFound, http.StatusNotFound)
+		return
+	}
+
+	if err := h.groups.Delete(r.Context(), uid); err != nil {
+		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,65 @@
+package node
+
+import (
+	"net/http"
+	"sync"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/zees-dev/zeth/pkg/events"
+)
+
+// proxyHealthData is the data of node health events published by the proxy.
+type proxyHealthData struct {
+	Source     string `json:"source"`
+	StatusCode int    `json:"statusCode,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// proxyHealth tracks whether nodes serve proxied requests successfully.
+// A health event is published when a node's upstream starts failing or recovers.
+type proxyHealth struct {
+	events events.Publisher
+
+	mu        sync.Mutex
+	unhealthy map[uuid.UUID]bool
+}
+
+func newProxyHealth(publisher events.Publisher) *proxyHealth {
+	return &proxyHealth{events: publisher, unhealthy: make(map[uuid.UUID]bool)}
+}
+
+// report records the outcome of a request proxied to the node's upstream.
+// Transport errors and 5xx responses mark the node unhealthy.
+func (p *proxyHealth) report(id uuid.UUID, statusCode int, err error) {
+	failed := err != nil || statusCode >= http.StatusInternalServerError
+
+	p.mu.Lock()
+	changed := p.unhealthy[id] != failed
+	if failed {
+		p.unhealthy[id] = true
+	} else {
+		delete(p.unhealthy, id)
+	}
+	p.mu.Unlock()
+
+	if !changed {
+		return
+	}
+
+	data := proxyHealthData{Source: "proxy", StatusCode: statusCode}
+	if err != nil {
+		data.Error = err.Error()
+	}
+	if failed {
+		p.events.Publish(events.NodeUnhealthy, id.String(), data)
+	} else {
+		p.events.Publish(events.NodeHealthy, id.String(), data)
+	}
+}
+
+// forget removes the health state of a removed node.
+func (p *proxyHealth) forget(id uuid.UUID) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.unhealthy, id)
+}
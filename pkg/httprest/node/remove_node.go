@@ -2,15 +2,18 @@ package node
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
 	uuid "github.com/satori/go.uuid"
 	"github.com/zees-dev/zeth/pkg/httprest/rest"
 )
 
+// removeNode removes the node; the process of a managed node is stopped, and its data dir is deleted if purge is set.
 /* curl request:
 curl -X DELETE \
-	http://localhost:7000/api/v1/nodes/00000000-0000-0000-0000-000000000000
+	"http://localhost:7000/api/v1/nodes/00000000-0000-0000-0000-000000000000?purge=true"
 */
 func (h *nodesHandler) removeNode(w http.ResponseWriter, r *http.Request) {
 	// get id from request parameters
@@ -22,17 +25,30 @@ func (h *nodesHandler) removeNode(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err = h.nodes.Get(r.Context(), uid)
+	n, err := h.nodes.Get(r.Context(), uid)
 	if err != nil {
 		http.Error(w, rest.HTTPNotFound, http.StatusNotFound)
 		return
 	}
 
+	purge, _ := strconv.ParseBool(r.URL.Query().Get("purge"))
+	if err := h.managed.Remove(r.Context(), *n, purge); err != nil {
+		log.Err(err).Msgf("failed to remove managed node: %s", uid)
+		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		return
+	}
+
 	err = h.nodes.Delete(r.Context(), uid)
 	if err != nil {
 		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
 		return
 	}
+	h.nodeRPCMonitor.Remove(uid)
+	h.proxyHealth.forget(uid)
+	if err := h.health.Remove(uid); err != nil {
+		log.Debug().Err(err).Msgf("failed to remove health history of node: %s", uid)
+	}
+	h.vulns.Remove(uid)
 
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -7,9 +7,11 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -18,6 +20,7 @@ import (
 	"github.com/yhat/wsutil"
 	"github.com/zees-dev/zeth/pkg/httprest/rest"
 	"github.com/zees-dev/zeth/pkg/node"
+	"github.com/zees-dev/zeth/pkg/redact"
 )
 
 /* curl request:
@@ -36,6 +39,11 @@ func (h *nodesHandler) rpcNode(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.serveNodeRPC(w, r, uid)
+}
+
+// serveNodeRPC proxies the request to the RPC endpoint of the node with the given UUID.
+func (h *nodesHandler) serveNodeRPC(w http.ResponseWriter, r *http.Request, uid uuid.UUID) {
 	// get rpcReverseProxy from cache if possible
 	if nodeRPCReverseProxy, ok := h.nodes.ReverseProxyCache().Get(r, uid); ok {
 		log.Debug().Msgf("nodeRPCReverseProxy found in cache for node: %s", uid)
@@ -44,18 +52,24 @@ func (h *nodesHandler) rpcNode(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	rpcURLs, err := h.getRPC(r, uid)
+	n, err := h.nodes.Get(r.Context(), uid)
+	if err != nil {
+		http.Error(w, rest.HTTPNotFound, http.StatusNotFound)
+		return
+	}
+
+	cfg, err := h.rpcProxyConfig(*n)
 	if err != nil {
+		log.Debug().Err(err).Msgf("failed to configure reverse proxy for node: %s", uid)
 		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
 		return
 	}
 
 	// serve the reverse proxy
-	nc := NewNotificationCenter()
-	h.nodeRPCMonitor.rpcs[uid] = nc
-	proxy, err := createNodeReverseProxy(rpcURLs, h.nodeRPCMonitor.rpcs[uid], r)
+	proxy, err := createNodeReverseProxy(cfg, r)
 	if err != nil {
 		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		return
 	}
 
 	// store proxy in cache for quicker subsequent lookups; automatically evict cache after x minutes
@@ -65,37 +79,88 @@ func (h *nodesHandler) rpcNode(w http.ResponseWriter, r *http.Request) {
 	proxy.ServeHTTP(w, r)
 }
 
-// getRPCURL returns the RPC URL for the given node.
-func (h *nodesHandler) getRPC(r *http.Request, uuid uuid.UUID) (node.RPC, error) {
-	var httpRPCURL, websocketRPCURL string
+// rpcProxyConfig contains the node specific configuration of a node's reverse proxy.
+type rpcProxyConfig struct {
+	node       node.ZethNode
+	rpc        node.RPC
+	publisher  RPCEventPublisher
+	health     *proxyHealth
+	observer   RPCObserver
+	redaction  *redact.Service
+	transport  *http.Transport
+	dialer     *net.Dialer
+	normalizer *node.ErrorNormalizer // nil if error normalization is disabled
+	chaos      *node.ChaosInjector   // nil if chaos mode is disabled
+}
 
-	if n, err := h.nodes.Get(r.Context(), uuid); err == nil {
-		// case node.TypeGethNodeInProcess:
-		// 	// check request for `Upgrade` header to identify whether its http request or websocket
-		// 	gipNode := n.(*node.GethInProcessNode)
-		// 	var scheme, host string
-		// 	var port int
-		// 	if wsutil.IsWebSocketRequest(r) {
-		// 		scheme, host, port = "http", gipNode.GethConfig.Node.HTTPHost, gipNode.GethConfig.Node.HTTPPort
-		// 	} else {
-		// 		scheme, host, port = "ws", gipNode.GethConfig.Node.WSHost, gipNode.GethConfig.Node.WSPort
-		// 	}
-		// 	rpcURL = fmt.Sprintf("%s://%s:%d", scheme, host, port)
-		if _, err := url.Parse(n.RPC.HTTP); err != nil {
-			return node.RPC{}, err
+// rpcProxyConfig creates the reverse proxy configuration for the given node.
+func (h *nodesHandler) rpcProxyConfig(n node.ZethNode) (rpcProxyConfig, error) {
+	rpc, err := getRPC(n)
+	if err != nil {
+		return rpcProxyConfig{}, err
+	}
+
+	transport, err := h.nodes.Transport(n)
+	if err != nil {
+		return rpcProxyConfig{}, err
+	}
+
+	cfg := rpcProxyConfig{
+		node:      n,
+		rpc:       rpc,
+		publisher: h.nodeRPCMonitor.Hub(n.ID),
+		health:    h.proxyHealth,
+		observer:  h.rpcObserver,
+		redaction: h.redaction,
+		transport: transport,
+		dialer:    n.Transport.NewDialer(),
+	}
+	if n.ErrorNormalization.Enabled {
+		cfg.normalizer, err = node.NewErrorNormalizer(n.ErrorNormalization.Rules)
+		if err != nil {
+			return rpcProxyConfig{}, err
 		}
-		httpRPCURL = n.RPC.HTTP
-		if _, err := url.Parse(n.RPC.WS); err == nil {
-			websocketRPCURL = n.RPC.WS
+	}
+	if n.Chaos.Enabled {
+		cfg.chaos, err = node.NewChaosInjector(n.Chaos)
+		if err != nil {
+			return rpcProxyConfig{}, err
 		}
 	}
 
+	return cfg, nil
+}
+
+// getRPC returns the RPC URLs for the given node.
+func getRPC(n node.ZethNode) (node.RPC, error) {
+	var httpRPCURL, websocketRPCURL string
+
+	// case node.TypeGethNodeInProcess:
+	// 	// check request for `Upgrade` header to identify whether its http request or websocket
+	// 	gipNode := n.(*node.GethInProcessNode)
+	// 	var scheme, host string
+	// 	var port int
+	// 	if wsutil.IsWebSocketRequest(r) {
+	// 		scheme, host, port = "http", gipNode.GethConfig.Node.HTTPHost, gipNode.GethConfig.Node.HTTPPort
+	// 	} else {
+	// 		scheme, host, port = "ws", gipNode.GethConfig.Node.WSHost, gipNode.GethConfig.Node.WSPort
+	// 	}
+	// 	rpcURL = fmt.Sprintf("%s://%s:%d", scheme, host, port)
+	if _, err := url.Parse(n.RPC.HTTP); err != nil {
+		return node.RPC{}, err
+	}
+	httpRPCURL = n.RPC.HTTP
+	if _, err := url.Parse(n.RPC.WS); err == nil {
+		websocketRPCURL = n.RPC.WS
+	}
+
 	return node.RPC{HTTP: httpRPCURL, WS: websocketRPCURL}, nil
 }
 
 // createNodeReverseProxy gets the relevant http or websocket reverse-proxy for the calling request.
-func createNodeReverseProxy(rpc node.RPC, publisher Publisher, r *http.Request) (http.Handler, error) {
+func createNodeReverseProxy(cfg rpcProxyConfig, r *http.Request) (http.Handler, error) {
 	var proxy http.Handler
+	rpc := cfg.rpc
 
 	// Override http/websocket reverse proxies to support https/wss - https://stackoverflow.com/a/53007606/10813908
 	if wsutil.IsWebSocketRequest(r) {
@@ -112,6 +177,8 @@ func createNodeReverseProxy(rpc node.RPC, publisher Publisher, r *http.Request)
 			r.URL.Path = url.Path
 		}
 		// p.Transport = rpcRoundTripper{rpc.WS}
+		p.Dial = cfg.dialer.Dial
+		p.TLSClientConfig = cfg.transport.TLSClientConfig
 		proxy = p
 	} else {
 		url, err := url.Parse(rpc.HTTP)
@@ -128,12 +195,23 @@ func createNodeReverseProxy(rpc node.RPC, publisher Publisher, r *http.Request)
 			r.URL.Path = url.Path
 			r.Host = url.Host // set Host header as expected by target
 		}
-		p.Transport = rpcRoundTripper{rpcURL: rpc.HTTP, publisher: publisher}
+		p.Transport = rpcRoundTripper{
+			nodeID:     cfg.node.ID,
+			nodeName:   cfg.node.Name,
+			rpcURL:     rpc.HTTP,
+			publisher:  cfg.publisher,
+			health:     cfg.health,
+			observer:   cfg.observer,
+			redaction:  cfg.redaction,
+			transport:  cfg.transport,
+			normalizer: cfg.normalizer,
+			chaos:      cfg.chaos,
+		}
 		proxy = p
 	}
 
-	// strip the RPC request's prefix from the request to forward it to the target node
-	proxy = http.StripPrefix(r.URL.Path, proxy)
+	// note: the request path is not stripped since the director overrides it with the target node's path;
+	// this allows cached proxies to be shared between node and node group RPC endpoints
 
 	return proxy, nil
 }
@@ -146,11 +224,37 @@ const (
 	response
 )
 
+func (t callType) MarshalText() ([]byte, error) {
+	switch t {
+	case request:
+		return []byte("request"), nil
+	case response:
+		return []byte("response"), nil
+	}
+	return nil, fmt.Errorf("unknown call type: %d", t)
+}
+
+func (t *callType) UnmarshalText(b []byte) error {
+	switch string(b) {
+	case "request":
+		*t = request
+	case "response":
+		*t = response
+	default:
+		return fmt.Errorf("unknown call type: %s", b)
+	}
+	return nil
+}
+
 type RPCEvent struct {
-	ID      string `json:"id"`
-	URI     string `json:"uri"`
-	RPCURL  string `json:"rpcURL"`
-	Request struct {
+	ID         string    `json:"id"`
+	Type       callType  `json:"type,omitempty"` // request or response; set when published
+	Time       time.Time `json:"time"`           // time the request was received
+	URI        string    `json:"uri"`
+	RPCURL     string    `json:"rpcURL"`
+	ClientAddr string    `json:"clientAddr"`
+	Request    struct {
+		Method  string `json:"method"`
 		Headers string `json:"headers"`
 		Body    string `json:"body"`
 	} `json:"request"`
@@ -160,11 +264,20 @@ type RPCEvent struct {
 		// Body       map[string]interface{} `json:"body"`
 		StatusCode int `json:"statusCode"`
 	} `json:"response"`
-	Duration int64 `json:"duration,omitempty"` // duration in milliseconds
+	Duration int64                 `json:"duration,omitempty"` // duration in milliseconds
+	Routing  *node.RoutingDecision `json:"routing,omitempty"`  // set if the request was proxied via a node group
+
+	// Normalization preserves the original upstream response if the response error was normalized
+	Normalization *node.NormalizationResult `json:"normalization,omitempty"`
+
+	// Chaos lists the faults injected into the request/response if chaos mode is enabled for the node
+	Chaos []node.ChaosFaultType `json:"chaos,omitempty"`
+
+	apiKey string // API key of the client; retained for stream filtering after redaction, never serialized
 }
 
 func NewRPCEvent(rpcURL string) *RPCEvent {
-	return &RPCEvent{ID: uuid.NewV4().String(), RPCURL: rpcURL}
+	return &RPCEvent{ID: uuid.NewV4().String(), Time: time.Now().UTC(), RPCURL: rpcURL}
 }
 
 func (ev *RPCEvent) ParseRequest(r *http.Request) {
@@ -176,6 +289,11 @@ func (ev *RPCEvent) ParseRequest(r *http.Request) {
 
 	// set event request properties
 	ev.URI = r.RequestURI
+	ev.ClientAddr = r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		ev.ClientAddr = host
+	}
+	ev.Request.Method = r.Method
 	ev.Request.Headers = string(reqHeadersBytes)
 	ev.Request.Body = buf.String()
 
@@ -211,16 +329,78 @@ func (ev *RPCEvent) ParseResponse(res *http.Response) {
 	res.Body = ioutil.NopCloser(bytes.NewBuffer(buf.Bytes()))
 }
 
+// NormalizeResponse rewrites provider-specific errors of the response into consistent JSON-RPC errors.
+// The original upstream response is preserved in the event.
+func (ev *RPCEvent) NormalizeResponse(normalizer *node.ErrorNormalizer, res *http.Response) {
+	body, result := normalizer.Normalize([]byte(ev.Request.Body), ev.Response.StatusCode, []byte(ev.Response.Body))
+	if result == nil {
+		return
+	}
+
+	// normalized errors are always served as HTTP 200
+	res.Header.Set("Content-Type", "application/json")
+	ev.ReplaceResponse(res, http.StatusOK, body)
+	ev.Normalization = result
+}
+
+// ReplaceResponse replaces the status and (uncompressed) body of the response and updates the event accordingly.
+func (ev *RPCEvent) ReplaceResponse(res *http.Response, statusCode int, body []byte) {
+	res.Header.Del("Content-Encoding")
+	res.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	res.ContentLength = int64(len(body))
+	res.StatusCode = statusCode
+	res.Status = fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode))
+	res.Body = ioutil.NopCloser(bytes.NewBuffer(body))
+
+	resHeadersBytes, _ := json.Marshal(res.Header)
+	ev.Response.Headers = string(resHeadersBytes)
+	ev.Response.StatusCode = res.StatusCode
+	ev.Response.Body = string(body)
+}
+
+// Redacted returns a copy of the event with sensitive data masked; only redacted events are logged and published.
+func (ev RPCEvent) Redacted(r *redact.Redactor) *RPCEvent {
+	if ev.apiKey == "" {
+		ev.apiKey = requestAPIKey(&ev)
+	}
+
+	ev.URI = r.URL(ev.URI)
+	ev.RPCURL = r.URL(ev.RPCURL)
+	ev.Request.Headers = r.HeaderJSON(ev.Request.Headers)
+	ev.Request.Body = r.RequestBody(ev.Request.Body)
+	ev.Response.Headers = r.HeaderJSON(ev.Response.Headers)
+	ev.Response.Body = r.String(ev.Response.Body)
+	if ev.Normalization != nil {
+		normalization := *ev.Normalization
+		normalization.OriginalBody = r.String(normalization.OriginalBody)
+		ev.Normalization = &normalization
+	}
+	return &ev
+}
+
 func (ev RPCEvent) Bytes() []byte {
 	b := new(bytes.Buffer)
 	json.NewEncoder(b).Encode(ev)
 	return b.Bytes()
 }
 
+// RPCObserver observes the outcome of requests proxied to nodes; e.g. to evaluate alert rules.
+type RPCObserver interface {
+	ObserveRPC(nodeID uuid.UUID, failed bool, duration time.Duration)
+}
+
 // rpcRoundTripper satisfies the http.RoundTripper interface
 type rpcRoundTripper struct {
-	rpcURL    string
-	publisher Publisher
+	nodeID     uuid.UUID
+	nodeName   string
+	rpcURL     string
+	publisher  RPCEventPublisher
+	health     *proxyHealth
+	observer   RPCObserver
+	redaction  *redact.Service
+	transport  http.RoundTripper // dedicated transport of the node
+	normalizer *node.ErrorNormalizer
+	chaos      *node.ChaosInjector
 }
 
 // RoundTrip satisfies the http.RoundTripper interface
@@ -233,36 +413,92 @@ func (rt rpcRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
 	// r.Header.Set("X-Forwarded-Host", r.Header.Get("Host"))
 
 	event := NewRPCEvent(rt.rpcURL)
+	event.Routing = routingDecisionFromContext(r.Context())
+
+	// sensitive data is redacted from the event before it is logged or published
+	redactor := rt.redactor()
 
 	// parse request, log request, publish to subscriber
 	event.ParseRequest(r)
+	redacted := event.Redacted(redactor)
 	log.Info().Msg(fmt.Sprintf(
 		"proxying rpc request:\n\treq: %s\n\trpc: %s\n\theaders: %s\n\tbody: %s",
-		event.URI,
-		event.RPCURL,
-		event.Request.Headers,
-		event.Request.Body,
+		redacted.URI,
+		redacted.RPCURL,
+		redacted.Request.Headers,
+		redacted.Request.Body,
 	))
-	rt.publisher.Publish(event.Bytes())
+	// sample faults to inject if chaos mode is enabled
+	var faults []node.ChaosFault
+	if rt.chaos != nil {
+		faults = rt.chaos.Sample(node.RequestMethods([]byte(event.Request.Body)))
+		for _, f := range faults {
+			event.Chaos = append(event.Chaos, f.Type)
+		}
+	}
 
-	// perform roundtrip against actual underlying rpc endpoint
-	res, err := http.DefaultTransport.RoundTrip(r)
+	event.Type = request
+	rt.publisher.PublishRPCEvent(event.Redacted(redactor))
+
+	// trace the proxied call; propagating the trace context upstream
+	ctx, span := rt.startRPCSpan(r, event.Redacted(redactor))
+	r = r.WithContext(ctx)
+
+	// inject request faults; a response is returned if the request must not be proxied
+	res, err := rt.injectRequestFaults(r, event, faults)
 	if err != nil {
+		endRPCSpan(span, event, err)
 		return nil, err
 	}
 
-	// parse response, calc duration, log response, publish to subscriber
+	// perform roundtrip against actual underlying rpc endpoint
+	if res == nil {
+		res, err = rt.transport.RoundTrip(r)
+		if err != nil {
+			rt.health.report(rt.nodeID, 0, err)
+			rt.observe(true, time.Since(reqStartTime))
+			endRPCSpan(span, event, err)
+			return nil, err
+		}
+		rt.health.report(rt.nodeID, res.StatusCode, nil)
+	}
+
+	// parse response, inject response faults, normalize provider errors, calc duration, log response, publish to subscriber
 	event.ParseResponse(res)
+	rt.injectResponseFaults(res, event, faults)
+	if rt.normalizer != nil {
+		event.NormalizeResponse(rt.normalizer, res)
+	}
 	event.Duration = time.Since(reqStartTime).Milliseconds()
+	event.Type = response
+	redacted = event.Redacted(redactor)
 	log.Info().Msg(fmt.Sprintf(
 		"proxied rpc response:\n\trpc: %s\n\theaders: %s\n\tstatus: %d\n\tbody: %s\n\tduration: %d",
-		event.RPCURL,
-		event.Response.Headers,
-		event.Response.StatusCode,
-		event.Response.Body,
-		event.Duration,
+		redacted.RPCURL,
+		redacted.Response.Headers,
+		redacted.Response.StatusCode,
+		redacted.Response.Body,
+		redacted.Duration,
 	))
-	rt.publisher.Publish(event.Bytes())
+	rt.publisher.PublishRPCEvent(redacted)
+	failed := event.Response.StatusCode >= http.StatusBadRequest || hasRPCError([]byte(event.Response.Body))
+	rt.observe(failed, time.Since(reqStartTime))
+	endRPCSpan(span, event, nil)
 
 	return res, nil
 }
+
+// observe reports the outcome of the proxied request to the observer; if any.
+func (rt rpcRoundTripper) observe(failed bool, duration time.Duration) {
+	if rt.observer != nil {
+		rt.observer.ObserveRPC(rt.nodeID, failed, duration)
+	}
+}
+
+// redactor returns the current redactor; falling back to the default rules if redaction is not configured.
+func (rt rpcRoundTripper) redactor() *redact.Redactor {
+	if rt.redaction == nil {
+		return redact.NewService().Redactor()
+	}
+	return rt.redaction.Redactor()
+}
@@ -0,0 +1,73 @@
+package node
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+	uuid "github.com/satori/go.uuid"
+	"github.com/yhat/wsutil"
+	"github.com/zees-dev/zeth/pkg/httprest/rest"
+	"github.com/zees-dev/zeth/pkg/node"
+)
+
+type routingDecisionKey struct{}
+
+func withRoutingDecision(ctx context.Context, decision node.RoutingDecision) context.Context {
+	return context.WithValue(ctx, routingDecisionKey{}, decision)
+}
+
+// routingDecisionFromContext returns the routing decision of a request proxied via a node group (if any).
+func routingDecisionFromContext(ctx context.Context) *node.RoutingDecision {
+	decision, ok := ctx.Value(routingDecisionKey{}).(node.RoutingDecision)
+	if !ok {
+		return nil
+	}
+	return &decision
+}
+
+/* curl request:
+curl -v localhost:7000/api/v1/groups/rpc/b38bad92-619f-41e4-b01f-36a3de1b3a52 \
+	-X POST \
+	-H "Content-Type: application/json" \
+	-d '{"jsonrpc":"2.0","method":"eth_getBalance","params":["0x0000000000000000000000000000000000000000", "0x1"],"id":1}'
+*/
+func (h *nodesHandler) rpcNodeGroup(w http.ResponseWriter, r *http.Request) {
+	uid, err := uuid.FromString(mux.Vars(r)["uuid"])
+	if err != nil {
+		http.Error(w, rest.HTTPBadRequest, http.StatusBadRequest)
+		return
+	}
+
+	group, err := h.groups.Get(r.Context(), uid)
+	if err != nil {
+		http.Error(w, rest.HTTPNotFound, http.StatusNotFound)
+		return
+	}
+
+	var buf bytes.Buffer
+	io.Copy(&buf, r.Body)
+	r.Body = ioutil.NopCloser(bytes.NewBuffer(buf.Bytes()))
+
+	decision, err := h.groupRouter.Route(r.Context(), *group, buf.Bytes(), wsutil.IsWebSocketRequest(r))
+	if err != nil {
+		log.Debug().Err(err).Msgf("failed to route request for node group: %s", uid)
+		switch {
+		case errors.Is(err, node.ErrInvalidRPCRequest):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, node.ErrNoGroupMembers):
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		default:
+			http.Error(w, err.Error(), http.StatusBadGateway)
+		}
+		return
+	}
+	log.Debug().Msgf("routing request for node group %s to %s node %s: %s", uid, decision.Target, decision.NodeID, decision.Reason)
+
+	h.serveNodeRPC(w, r.WithContext(withRoutingDecision(r.Context(), decision)), decision.NodeID)
+}
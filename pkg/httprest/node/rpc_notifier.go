@@ -1,128 +1,225 @@
 package node
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"sync"
 
 	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
 	uuid "github.com/satori/go.uuid"
+	"github.com/zees-dev/zeth/pkg/httprest/rest"
+	"github.com/zees-dev/zeth/pkg/node"
 )
 
-// source: https://gist.github.com/rikonor/e53a33c27ed64861c91a095a59f0aa44
+type UnsubscribeFunc func() error
 
-type PubSub interface {
-	Publisher
-	Subscriber
+type Publisher interface {
+	Publish(b []byte) error
 }
 
-var _ PubSub = (*NotificationCenter)(nil)
-
-type UnsubscribeFunc func() error
+// NodeRPCMonitor holds the event hub of each node.
+type NodeRPCMonitor struct {
+	nodes node.NodeService
 
-type Subscriber interface {
-	Subscribe(c chan []byte) (UnsubscribeFunc, error)
+	mu   sync.RWMutex
+	hubs map[uuid.UUID]*EventHub
 }
 
-type Publisher interface {
-	Publish(b []byte) error
+func NewNodeRPCMonitor(nodes node.NodeService) *NodeRPCMonitor {
+	return &NodeRPCMonitor{nodes: nodes, hubs: make(map[uuid.UUID]*EventHub)}
 }
 
-type NotificationCenter struct {
-	subscribers   map[chan []byte]struct{}
-	subscribersMu *sync.Mutex
-}
+// Hub returns the event hub of the node; creating it if it does not exist.
+func (n *NodeRPCMonitor) Hub(id uuid.UUID) *EventHub {
+	n.mu.RLock()
+	hub, ok := n.hubs[id]
+	n.mu.RUnlock()
+	if ok {
+		return hub
+	}
 
-func NewNotificationCenter() *NotificationCenter {
-	return &NotificationCenter{
-		subscribers:   map[chan []byte]struct{}{},
-		subscribersMu: &sync.Mutex{},
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if hub, ok := n.hubs[id]; ok {
+		return hub
 	}
+	hub = NewEventHub(DefaultEventBacklog)
+	n.hubs[id] = hub
+	return hub
 }
 
-func (nc *NotificationCenter) Subscribe(c chan []byte) (UnsubscribeFunc, error) {
-	nc.subscribersMu.Lock()
-	nc.subscribers[c] = struct{}{}
-	nc.subscribersMu.Unlock()
+// Remove closes the event hub of the node, ending the streams of its subscribers.
+func (n *NodeRPCMonitor) Remove(id uuid.UUID) {
+	n.mu.Lock()
+	hub, ok := n.hubs[id]
+	delete(n.hubs, id)
+	n.mu.Unlock()
 
-	unsubscribeFn := func() error {
-		nc.subscribersMu.Lock()
-		delete(nc.subscribers, c)
-		nc.subscribersMu.Unlock()
+	if ok {
+		hub.Close()
+	}
+}
 
-		return nil
+// lastEventID returns the ID of the last event received by a reconnecting client.
+// The Last-Event-ID header is set by EventSource clients on reconnect; the query parameter allows resuming manually.
+func lastEventID(r *http.Request) uint64 {
+	v := r.Header.Get("Last-Event-ID")
+	if v == "" {
+		v = r.URL.Query().Get("lastEventId")
 	}
+	id, _ := strconv.ParseUint(v, 10, 64)
+	return id
+}
 
-	return unsubscribeFn, nil
+// writeSSEEvent writes the event in the SSE format; the event ID allows clients to resume the stream.
+func writeSSEEvent(w http.ResponseWriter, id uint64, data []byte) {
+	fmt.Fprintf(w, "id: %d\ndata: %s\n\n", id, bytes.TrimRight(data, "\n"))
 }
 
-func (nc *NotificationCenter) Publish(b []byte) error {
-	nc.subscribersMu.Lock()
-	defer nc.subscribersMu.Unlock()
+// subscribe validates the node and stream filter of the request, and subscribes to the node's event hub.
+// An error response is written if the subscription fails.
+func (n *NodeRPCMonitor) subscribe(w http.ResponseWriter, r *http.Request) (chan Event, []Event, EventFilter, UnsubscribeFunc, bool) {
+	id := mux.Vars(r)["uuid"]
+	nodeUUID, err := uuid.FromString(id)
+	if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return nil, nil, EventFilter{}, nil, false
+	}
 
-	for c := range nc.subscribers {
-		select {
-		case c <- b:
-		default:
-		}
+	if _, err := n.nodes.Get(r.Context(), nodeUUID); err != nil {
+		http.Error(w, fmt.Sprintf("node not found: %s", nodeUUID), http.StatusNotFound)
+		return nil, nil, EventFilter{}, nil, false
 	}
 
-	return nil
-}
+	filter, errs := ParseEventFilter(r.URL.Query())
+	if len(errs) > 0 {
+		rest.ValidationErrors(w, errs)
+		return nil, nil, EventFilter{}, nil, false
+	}
 
-type NodeRPCMonitor struct {
-	rpcs map[uuid.UUID]PubSub
-}
+	// Subscribe; receiving the events missed since the last event ID
+	c := make(chan Event, DefaultEventBacklog)
+	backlog, unsubscribeFn := n.Hub(nodeUUID).Subscribe(c, lastEventID(r), filter)
 
-func NewNodeRPCMonitor() *NodeRPCMonitor {
-	return &NodeRPCMonitor{rpcs: make(map[uuid.UUID]PubSub)}
+	return c, backlog, filter, unsubscribeFn, true
 }
 
 /*
 curl request:
 curl -v http://localhost:7000/api/v1/nodes/rpc/3475ce0e-0124-4e8b-a661-b4b6e22cdf34/sse
+
+resume after the last received event:
+curl -v -H "Last-Event-ID: 42" http://localhost:7000/api/v1/nodes/rpc/3475ce0e-0124-4e8b-a661-b4b6e22cdf34/sse
+
+filter events:
+curl -v "http://localhost:7000/api/v1/nodes/rpc/3475ce0e-0124-4e8b-a661-b4b6e22cdf34/sse?method=eth_get*&errors=true&events=paired"
 */
 func (n *NodeRPCMonitor) handleSSE(w http.ResponseWriter, r *http.Request) {
-	id := mux.Vars(r)["uuid"]
-	nodeUUID, err := uuid.FromString(id)
-	if err != nil {
-		http.Error(w, "Bad request", http.StatusBadRequest)
-		return
-	}
-
-	subscriber, ok := n.rpcs[nodeUUID]
+	flusher, ok := w.(http.Flusher)
 	if !ok {
-		http.Error(w, fmt.Sprintf("node subscriber not found: %s", nodeUUID), http.StatusNotFound)
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
 		return
 	}
 
-	// Subscribe
-	c := make(chan []byte)
-	unsubscribeFn, err := subscriber.Subscribe(c)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	c, backlog, filter, unsubscribeFn, ok := n.subscribe(w, r)
+	if !ok {
 		return
 	}
+	defer unsubscribeFn()
 
 	// Signal SSE Support
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(http.StatusOK)
+
+	for _, ev := range backlog {
+		writeSSEEvent(w, ev.ID, filter.Render(ev))
+	}
+	flusher.Flush()
 
 	for {
 		select {
 		case <-r.Context().Done():
-			if err := unsubscribeFn(); err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		case ev, ok := <-c:
+			if !ok {
+				// hub closed; the node was removed
 				return
 			}
+			writeSSEEvent(w, ev.ID, filter.Render(ev))
+			flusher.Flush()
+		}
+	}
+}
+
+// wsEvent is a monitor event sent over WebSocket; the ID can be used to resume via the lastEventId query parameter.
+type wsEvent struct {
+	ID   uint64          `json:"id"`
+	Data json.RawMessage `json:"data"`
+}
+
+// wsUpgrader rejects cross-origin connections; captured traffic must not be readable by other sites.
+var wsUpgrader = websocket.Upgrader{}
+
+/*
+WebSocket equivalent of the SSE stream; accepts the same query parameters.
+websocat "ws://localhost:7000/api/v1/nodes/rpc/3475ce0e-0124-4e8b-a661-b4b6e22cdf34/ws?events=response&status=5xx"
+*/
+func (n *NodeRPCMonitor) handleWS(w http.ResponseWriter, r *http.Request) {
+	c, backlog, filter, unsubscribeFn, ok := n.subscribe(w, r)
+	if !ok {
+		return
+	}
+	defer unsubscribeFn()
+
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Debug().Err(err).Msg("failed to upgrade websocket connection")
+		return
+	}
+	defer conn.Close()
+
+	// read (and discard) client messages to process control frames and detect the client closing the connection
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	write := func(ev Event) error {
+		return conn.WriteJSON(wsEvent{ID: ev.ID, Data: bytes.TrimRight(filter.Render(ev), "\n")})
+	}
+
+	for _, ev := range backlog {
+		if err := write(ev); err != nil {
 			return
-		default:
-			b := <-c
-			fmt.Fprintf(w, "data: %s\n\n", b)
-			// fmt.Fprintf(w, string(b))
-			w.(http.Flusher).Flush()
+		}
+	}
+
+	for {
+		select {
+		case <-closed:
+			return
+		case ev, ok := <-c:
+			if !ok {
+				// hub closed; the node was removed
+				conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "node removed"))
+				return
+			}
+			if err := write(ev); err != nil {
+				return
+			}
 		}
 	}
 }
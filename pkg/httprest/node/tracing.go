@@ -0,0 +1,99 @@
+package node
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/zees-dev/zeth/pkg/node"
+	"github.com/zees-dev/zeth/pkg/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startRPCSpan starts a client span for a JSON-RPC request proxied to the node,
+// and injects the span context into the upstream request headers.
+// The event must be redacted; its URLs are recorded as span attributes.
+func (rt rpcRoundTripper) startRPCSpan(r *http.Request, event *RPCEvent) (context.Context, trace.Span) {
+	methods := node.RequestMethods([]byte(event.Request.Body))
+
+	name := "jsonrpc"
+	switch len(methods) {
+	case 0:
+	case 1:
+		name = methods[0]
+	default:
+		name = "jsonrpc batch"
+	}
+
+	attrs := []attribute.KeyValue{
+		semconv.RPCSystemKey.String("jsonrpc"),
+		semconv.RPCServiceKey.String(rt.nodeName),
+		attribute.StringSlice("rpc.jsonrpc.methods", methods),
+		attribute.Int("rpc.jsonrpc.batch_size", len(methods)),
+		attribute.String("zeth.node.id", rt.nodeID.String()),
+		attribute.String("zeth.node.name", rt.nodeName),
+		attribute.String("zeth.upstream", event.RPCURL),
+	}
+	if len(methods) == 1 {
+		attrs = append(attrs, semconv.RPCMethodKey.String(methods[0]))
+	}
+	if d := event.Routing; d != nil {
+		attrs = append(attrs,
+			attribute.String("zeth.group.id", d.GroupID.String()),
+			attribute.String("zeth.group.target", string(d.Target)),
+			attribute.Bool("zeth.group.fallback", d.Fallback),
+		)
+	}
+	if len(event.Chaos) > 0 {
+		faults := make([]string, 0, len(event.Chaos))
+		for _, f := range event.Chaos {
+			faults = append(faults, string(f))
+		}
+		attrs = append(attrs, attribute.StringSlice("zeth.chaos.faults", faults))
+	}
+
+	ctx, span := otel.Tracer(tracing.InstrumentationName).Start(
+		r.Context(),
+		name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+		trace.WithAttributes(semconv.HTTPClientAttributesFromHTTPRequest(r)...),
+		trace.WithAttributes(semconv.HTTPURLKey.String(event.RPCURL)), // overrides the unredacted upstream URL
+	)
+
+	// propagate trace context to the upstream node
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(r.Header))
+
+	return ctx, span
+}
+
+// endRPCSpan records the outcome of the proxied request and ends the span.
+func endRPCSpan(span trace.Span, event *RPCEvent, err error) {
+	defer span.End()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+
+	span.SetAttributes(semconv.HTTPAttributesFromHTTPStatusCode(event.Response.StatusCode)...)
+	span.SetAttributes(attribute.Bool("zeth.normalized", event.Normalization != nil))
+	if event.Normalization != nil {
+		categories := make([]string, 0, len(event.Normalization.Errors))
+		for _, e := range event.Normalization.Errors {
+			categories = append(categories, e.Category)
+		}
+		span.SetAttributes(
+			attribute.StringSlice("zeth.normalized.categories", categories),
+			attribute.Int("zeth.upstream.status_code", event.Normalization.OriginalStatusCode),
+		)
+	}
+
+	status, msg := semconv.SpanStatusFromHTTPStatusCode(event.Response.StatusCode)
+	span.SetStatus(status, msg)
+}
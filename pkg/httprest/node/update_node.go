@@ -14,11 +14,14 @@ import (
 )
 
 type updateNodeRequestPayload struct {
-	Name           string   `json:"name"`
-	Enabled        bool     `json:"enabled"`
-	ExplorerURL    string   `json:"explorerUrl"`
-	RPC            node.RPC `json:"rpc"`
-	TestConnection bool     `json:"test"`
+	Name           string               `json:"name"`
+	Enabled        bool                 `json:"enabled"`
+	ExplorerURL    string               `json:"explorerUrl"`
+	RPC            node.RPC             `json:"rpc"`
+	Transport      node.TransportConfig `json:"transport"`
+	TestConnection bool                 `json:"test"`
+	DiscoverChain  bool                 `json:"discover"` // query and store the chain metadata of the node
+	ChainID        uint64               `json:"chainId"`  // expected chain of the node; must be registered
 }
 
 func (payload *updateNodeRequestPayload) Validate() url.Values {
@@ -48,13 +51,22 @@ func (payload *updateNodeRequestPayload) Validate() url.Values {
 		errs.Add("rpc.default", fmt.Sprintf("rpc default is invalid; must < %d", node.DefaultRPCend))
 	}
 
+	// the client key is write-only; the stored key is kept if the payload omits it, see keepClientKey
+	transport := payload.Transport
+	if transport.TLS.ClientKey == "" {
+		transport.TLS.ClientCert = ""
+	}
+	if err := transport.Validate(); err != nil {
+		errs.Add("transport", err.Error())
+	}
+
 	return errs
 }
 
 /* curl request:
 curl -X PUT \
 	-H "Content-Type: application/json" \
-	-d '{ "name": "avalanche mainnet", "enabled": true, "explorerUrl": "", "rpc": { "http":"https://api.avax.network/ext/bc/C/rpc", "ws": "", "default": 0 }, "test": true }' \
+	-d '{ "name": "avalanche mainnet", "enabled": true, "explorerUrl": "", "rpc": { "http":"https://api.avax.network/ext/bc/C/rpc", "ws": "", "default": 0 }, "test": true, "discover": true }' \
 	http://localhost:7000/api/v1/nodes/f0470395-76a6-4d30-a79a-57387d93fb1b
 */
 func (h *nodesHandler) updateNode(w http.ResponseWriter, r *http.Request) {
@@ -79,23 +91,66 @@ func (h *nodesHandler) updateNode(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// chain metadata and capabilities probed from a previous endpoint no longer apply
+	endpointChanged := node.RPC.HTTP != payload.RPC.HTTP
+	if endpointChanged {
+		node.Chain = nil
+		node.Capabilities = nil
+	}
+
 	node.Name = payload.Name
 	node.Enabled = payload.Enabled
 	node.ExplorerURL = payload.ExplorerURL
 	node.RPC = payload.RPC
+	node.Transport = keepClientKey(payload.Transport, node.Transport)
+	if err := node.Transport.Validate(); err != nil {
+		rest.ValidationErrors(w, url.Values{"transport": {err.Error()}})
+		return
+	}
+
+	transport, err := h.nodes.Transport(*node)
+	if err != nil {
+		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		return
+	}
 
 	if payload.TestConnection {
-		if err := node.TestConnection(r.Context()); err != nil {
+		if err := node.TestConnection(r.Context(), transport); err != nil {
 			log.Debug().Err(err).Msg("failed to connect to node")
 			http.Error(w, "failed to connect to node", http.StatusBadRequest)
 			return
 		}
 	}
 
+	if payload.DiscoverChain {
+		if node.Chain, err = node.DiscoverChain(r.Context(), transport); err != nil {
+			log.Debug().Err(err).Msg("failed to discover chain metadata of node")
+			http.Error(w, "failed to discover chain metadata of node", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if errs := h.validateChain(r.Context(), payload.ChainID, node); len(errs) > 0 {
+		rest.ValidationErrors(w, errs)
+		return
+	}
+
 	if err := h.nodes.Update(r.Context(), node.ID, *node); err != nil {
 		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
 		return
 	}
+	if endpointChanged {
+		h.probeCapabilitiesAsync(node.ID)
+	}
 
-	rest.JSON(w, node)
+	rest.JSON(w, node.Redacted())
+}
+
+// keepClientKey returns the updated transport config with the stored TLS client key if the update omits the key;
+// the key is write-only, so updates based on a fetched node do not include it.
+func keepClientKey(update, stored node.TransportConfig) node.TransportConfig {
+	if update.TLS.ClientKey == "" && update.TLS.ClientCert != "" && update.TLS.ClientCert == stored.TLS.ClientCert {
+		update.TLS.ClientKey = stored.TLS.ClientKey
+	}
+	return update
 }
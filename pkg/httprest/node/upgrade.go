@@ -0,0 +1,89 @@
+package node
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zees-dev/zeth/pkg/httprest/rest"
+	"github.com/zees-dev/zeth/pkg/managed"
+)
+
+type upgradeNodeRequestPayload struct {
+	Version string `json:"version"`
+	Timeout int    `json:"timeout"` // seconds the node has to become healthy; defaults to managed.DefaultUpgradeTimeout
+}
+
+func (payload *upgradeNodeRequestPayload) Validate() url.Values {
+	errs := url.Values{}
+
+	if !managed.ValidVersion(payload.Version) {
+		errs.Add("version", managed.ErrInvalidVersion.Error())
+	}
+	if payload.Timeout < 0 {
+		errs.Add("timeout", "timeout must not be negative")
+	}
+
+	return errs
+}
+
+// upgradeNode changes the geth version of the managed node in the background, installing the binary if missing; a running
+// node is restarted and rolled back to the previous version if it does not become healthy within the timeout. The upgrade is
+// accepted pending; its result is published as a node upgraded event and served by getNodeUpgrade.
+/* curl request:
+curl -X POST \
+	-H "Content-Type: application/json" \
+	-d '{"version": "1.10.11", "timeout": 120}' \
+	http://localhost:7000/api/v1/nodes/00000000-0000-0000-0000-000000000000/upgrade
+*/
+func (h *nodesHandler) upgradeNode(w http.ResponseWriter, r *http.Request) {
+	uid, ok := h.managedNodeID(w, r)
+	if !ok {
+		return
+	}
+
+	payload := upgradeNodeRequestPayload{}
+	if ok := rest.DecodeAndValidateJSONPayload(w, r.Body, &payload); !ok {
+		log.Debug().Msg("validation failed")
+		return
+	}
+
+	upgrade, err := h.managed.UpgradeInBackground(r.Context(), uid, payload.Version, time.Duration(payload.Timeout)*time.Second)
+	if err != nil {
+		switch {
+		case errors.Is(err, managed.ErrNotManaged), errors.Is(err, managed.ErrUnsupportedVersion):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, managed.ErrUpgrading):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			log.Err(err).Msgf("failed to upgrade node: %s", uid)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	rest.JSONStatus(w, http.StatusAccepted, upgrade)
+}
+
+// getNodeUpgrade returns the last upgrade of the managed node; done once the node was upgraded or rolled back, or the upgrade
+// failed. Rollbacks are reported in the result.
+/* curl request:
+curl \
+	http://localhost:7000/api/v1/nodes/00000000-0000-0000-0000-000000000000/upgrade
+*/
+func (h *nodesHandler) getNodeUpgrade(w http.ResponseWriter, r *http.Request) {
+	uid, ok := h.managedNodeID(w, r)
+	if !ok {
+		return
+	}
+
+	upgrade, ok := h.managed.LastUpgrade(uid)
+	if !ok {
+		http.Error(w, rest.HTTPNotFound, http.StatusNotFound)
+		return
+	}
+
+	rest.JSON(w, upgrade)
+}
@@ -0,0 +1,32 @@
+package node
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	uuid "github.com/satori/go.uuid"
+	"github.com/zees-dev/zeth/pkg/httprest/rest"
+)
+
+// getNodeVulnerabilities returns the latest vulnerability report of the node; null if the node has not been checked.
+/* curl request:
+curl \
+	http://localhost:7000/api/v1/nodes/00000000-0000-0000-0000-000000000000/vulnerabilities
+*/
+func (h *nodesHandler) getNodeVulnerabilities(w http.ResponseWriter, r *http.Request) {
+	// get id from request parameters
+	id := mux.Vars(r)["uuid"]
+
+	uid, err := uuid.FromString(id)
+	if err != nil {
+		http.Error(w, rest.HTTPBadRequest, http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.nodes.Get(r.Context(), uid); err != nil {
+		http.Error(w, rest.HTTPNotFound, http.StatusNotFound)
+		return
+	}
+
+	rest.JSON(w, h.vulns.Report(uid))
+}
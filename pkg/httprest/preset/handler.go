@@ -0,0 +1,24 @@
+package preset
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/zees-dev/zeth/pkg/app"
+	"github.com/zees-dev/zeth/pkg/preset"
+)
+
+type handler struct {
+	presets *preset.Service
+}
+
+func RegisterRoutes(app *app.App, baseRouter *mux.Router) {
+	h := handler{
+		presets: app.Services.Presets,
+	}
+
+	baseRouter.HandleFunc("/presets", h.getPresets).Methods(http.MethodGet)
+	baseRouter.HandleFunc("/presets/{name}", h.getPreset).Methods(http.MethodGet)
+	baseRouter.HandleFunc("/presets/{name}", h.upsertPreset).Methods(http.MethodPut)
+	baseRouter.HandleFunc("/presets/{name}", h.removePreset).Methods(http.MethodDelete)
+}
@@ -0,0 +1,106 @@
+package preset
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+	"github.com/zees-dev/zeth/pkg/geth"
+	"github.com/zees-dev/zeth/pkg/httprest/rest"
+	"github.com/zees-dev/zeth/pkg/preset"
+)
+
+/* curl request:
+curl \
+	http://localhost:7000/api/v1/presets
+*/
+func (h *handler) getPresets(w http.ResponseWriter, r *http.Request) {
+	presets, err := h.presets.GetAll(r.Context())
+	if err != nil {
+		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	rest.JSON(w, presets)
+}
+
+/* curl request:
+curl \
+	http://localhost:7000/api/v1/presets/sepolia
+*/
+func (h *handler) getPreset(w http.ResponseWriter, r *http.Request) {
+	p, err := h.presets.Get(r.Context(), mux.Vars(r)["name"])
+	if err != nil {
+		http.Error(w, rest.HTTPNotFound, http.StatusNotFound)
+		return
+	}
+
+	rest.JSON(w, p)
+}
+
+type presetPayload geth.Preset
+
+func (payload *presetPayload) Validate() url.Values {
+	errs := url.Values{}
+
+	if err := geth.Preset(*payload).Validate(); err != nil {
+		errs.Add("preset", err.Error())
+	}
+
+	return errs
+}
+
+// upsertPreset adds the user preset, or replaces the user preset of the name of the path; builtin presets can not be replaced.
+/* curl request:
+curl -X PUT \
+	-H "Content-Type: application/json" \
+	-d '{"name": "private", "networkId": 7777, "genesisHash": "0x0000000000000000000000000000000000000000000000000000000000000000", "bootnodes": [], "dnsDiscovery": []}' \
+	http://localhost:7000/api/v1/presets/private
+*/
+func (h *handler) upsertPreset(w http.ResponseWriter, r *http.Request) {
+	payload := presetPayload{}
+	if ok := rest.DecodeAndValidateJSONPayload(w, r.Body, &payload); !ok {
+		log.Debug().Msg("validation failed")
+		return
+	}
+	if payload.Name != mux.Vars(r)["name"] {
+		rest.ValidationErrors(w, url.Values{"name": {"name must match the name of the path"}})
+		return
+	}
+
+	p := geth.Preset(payload)
+	if err := h.presets.Upsert(r.Context(), p); err != nil {
+		if errors.Is(err, preset.ErrBuiltin) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		return
+	}
+	p.Builtin = false
+
+	rest.JSON(w, p)
+}
+
+/* curl request:
+curl -X DELETE \
+	http://localhost:7000/api/v1/presets/private
+*/
+func (h *handler) removePreset(w http.ResponseWriter, r *http.Request) {
+	err := h.presets.Delete(r.Context(), mux.Vars(r)["name"])
+	switch {
+	case errors.Is(err, preset.ErrBuiltin):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, preset.ErrNotFound):
+		http.Error(w, rest.HTTPNotFound, http.StatusNotFound)
+		return
+	case err != nil:
+		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -7,8 +7,13 @@ import (
 
 // JSON encodes data to rw in JSON format
 func JSON(rw http.ResponseWriter, data interface{}) {
+	JSONStatus(rw, http.StatusOK, data)
+}
+
+// JSONStatus encodes data to rw in JSON format with the status code
+func JSONStatus(rw http.ResponseWriter, statusCode int, data interface{}) {
 	rw.Header().Set("Content-Type", "application/json")
-	rw.WriteHeader(http.StatusOK)
+	rw.WriteHeader(statusCode)
 
 	err := json.NewEncoder(rw).Encode(data)
 	if err != nil {
@@ -17,12 +17,17 @@ func DecodeAndValidateJSONPayload(w http.ResponseWriter, body io.ReadCloser, v V
 		return false
 	}
 
-	validationMap := make(map[string]interface{})
 	if validErrs := v.Validate(); len(validErrs) > 0 {
-		validationMap["validationErrors"] = validErrs
-		w.Header().Set("Content-type", "applciation/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(validationMap)
+		ValidationErrors(w, validErrs)
+		return false
 	}
-	return len(validationMap) == 0
+	return true
+}
+
+// ValidationErrors responds with the validation errors and a 400 status code.
+func ValidationErrors(w http.ResponseWriter, errs url.Values) {
+	validationMap := map[string]interface{}{"validationErrors": errs}
+	w.Header().Set("Content-type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(validationMap)
 }
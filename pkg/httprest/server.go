@@ -15,9 +15,19 @@ import (
 	"github.com/rs/zerolog/log"
 	zapp "github.com/zees-dev/zeth/app"
 	"github.com/zees-dev/zeth/pkg/app"
+	"github.com/zees-dev/zeth/pkg/httprest/alert"
+	"github.com/zees-dev/zeth/pkg/httprest/binary"
+	"github.com/zees-dev/zeth/pkg/httprest/chain"
 	"github.com/zees-dev/zeth/pkg/httprest/defi"
+	"github.com/zees-dev/zeth/pkg/httprest/events"
+	"github.com/zees-dev/zeth/pkg/httprest/genesis"
+	"github.com/zees-dev/zeth/pkg/httprest/network"
 	"github.com/zees-dev/zeth/pkg/httprest/node"
+	"github.com/zees-dev/zeth/pkg/httprest/preset"
 	"github.com/zees-dev/zeth/pkg/httprest/settings"
+	"github.com/zees-dev/zeth/pkg/httprest/vulnerability"
+	"github.com/zees-dev/zeth/pkg/tracing"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
 )
 
 // Routing will return the router for the server. It will load all routes and sub-routes.
@@ -36,9 +46,24 @@ func Routing(app *app.App) *mux.Router {
 	// use some middleware
 	// h.baseRouter.Use()
 
+	// trace API handlers; continuing traces from incoming trace context headers
+	apiRouter.Use(otelmux.Middleware(
+		tracing.DefaultServiceName,
+		otelmux.WithTracerProvider(app.Services.Tracing.Provider()),
+		otelmux.WithPropagators(app.Services.Tracing.Propagator()),
+	))
+
 	settings.RegisterRoutes(app, apiRouter)
 	node.RegisterRoutes(app, apiRouter)
 	defi.RegisterRoutes(app, apiRouter)
+	events.RegisterRoutes(app, apiRouter)
+	alert.RegisterRoutes(app, apiRouter)
+	chain.RegisterRoutes(app, apiRouter)
+	vulnerability.RegisterRoutes(app, apiRouter)
+	preset.RegisterRoutes(app, apiRouter)
+	genesis.RegisterRoutes(app, apiRouter)
+	network.RegisterRoutes(app, apiRouter)
+	binary.RegisterRoutes(app, apiRouter)
 
 	// Setup file server to serve UI.
 	// Reference static dir if in dev mode; use embedded dir for production (single binary).
@@ -3,29 +3,46 @@ package settings
 import (
 	"net/http"
 	"net/url"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/rs/zerolog/log"
 	uuid "github.com/satori/go.uuid"
 	"github.com/zees-dev/zeth/pkg/app"
+	"github.com/zees-dev/zeth/pkg/geth/downloader"
 	"github.com/zees-dev/zeth/pkg/httprest/rest"
 	"github.com/zees-dev/zeth/pkg/node"
+	"github.com/zees-dev/zeth/pkg/redact"
 	"github.com/zees-dev/zeth/pkg/settings"
+	"github.com/zees-dev/zeth/pkg/tracing"
+	"github.com/zees-dev/zeth/pkg/vulnerability"
 )
 
 type settingsHandler struct {
-	settings settings.Settings
-	nodes    node.NodeService
+	settings  settings.Settings
+	nodes     node.NodeService
+	tracing   *tracing.Service
+	redaction *redact.Service
+	vulns     *vulnerability.Service
+	downloads *downloader.Downloader
 }
 
 func RegisterRoutes(app *app.App, baseRouter *mux.Router) {
 	h := settingsHandler{
-		settings: app.Services.Settings,
-		nodes:    app.Services.Nodes,
+		settings:  app.Services.Settings,
+		nodes:     app.Services.Nodes,
+		tracing:   app.Services.Tracing,
+		redaction: app.Services.Redaction,
+		vulns:     app.Services.Vulnerabilities,
+		downloads: app.Services.Downloads,
 	}
 
 	baseRouter.HandleFunc("/settings", h.get).Methods(http.MethodGet)
 	baseRouter.HandleFunc("/settings/node", h.updateDefaultNode).Methods(http.MethodPut)
+	baseRouter.HandleFunc("/settings/tracing", h.updateTracing).Methods(http.MethodPut)
+	baseRouter.HandleFunc("/settings/redaction", h.updateRedaction).Methods(http.MethodPut)
+	baseRouter.HandleFunc("/settings/vulnerabilities", h.updateVulnerabilities).Methods(http.MethodPut)
+	baseRouter.HandleFunc("/settings/downloads", h.updateDownloads).Methods(http.MethodPut)
 }
 
 /* curl request:
@@ -40,7 +57,7 @@ func (h *settingsHandler) get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	rest.JSON(w, settings)
+	rest.JSON(w, settings.Redacted())
 }
 
 type settingsNodeUpdateRequestBody struct {
@@ -92,5 +109,216 @@ func (h *settingsHandler) updateDefaultNode(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	rest.JSON(w, s)
+	rest.JSON(w, s.Redacted())
+}
+
+type settingsTracingUpdateRequestBody tracing.Config
+
+func (s *settingsTracingUpdateRequestBody) Validate() url.Values {
+	errs := url.Values{}
+
+	if s.Enabled && s.Endpoint == "" {
+		errs.Add("endpoint", "endpoint is required if tracing is enabled")
+	}
+	if strings.Contains(s.Endpoint, "://") {
+		errs.Add("endpoint", "endpoint must be host:port without scheme")
+	}
+	if s.URLPath != "" && !strings.HasPrefix(s.URLPath, "/") {
+		errs.Add("urlPath", "url path must start with /")
+	}
+
+	return errs
+}
+
+// updateTracing configures span export; header values are write-only, headers without a value keep their stored value.
+/* curl request:
+curl -X PUT \
+	-H "Content-Type: application/json" \
+	-d '{"enabled": true, "endpoint": "localhost:4318", "insecure": true, "headers": {"Authorization": "Bearer token"}}' \
+	http://localhost:7000/api/v1/settings/tracing
+*/
+func (h *settingsHandler) updateTracing(w http.ResponseWriter, r *http.Request) {
+	payload := settingsTracingUpdateRequestBody{}
+	if ok := rest.DecodeAndValidateJSONPayload(w, r.Body, &payload); !ok {
+		log.Debug().Msg("validation failed")
+		return
+	}
+
+	s, err := h.settings.Get(r.Context())
+	if err != nil {
+		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	s.Tracing = keepHeaders(tracing.Config(payload), s.Tracing)
+
+	if err := h.tracing.Configure(r.Context(), s.Tracing); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.settings.Update(r.Context(), s); err != nil {
+		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	rest.JSON(w, s.Redacted())
+}
+
+// keepHeaders returns the config with the stored values of headers without a value; header values are write-only, so
+// settings returned by the API can be sent back unchanged.
+func keepHeaders(cfg, stored tracing.Config) tracing.Config {
+	if len(cfg.Headers) == 0 {
+		return cfg
+	}
+	headers := make(map[string]string, len(cfg.Headers))
+	for name, value := range cfg.Headers {
+		if value == "" {
+			value = stored.Headers[name]
+		}
+		headers[name] = value
+	}
+	cfg.Headers = headers
+	return cfg
+}
+
+type settingsRedactionUpdateRequestBody redact.Config
+
+func (s *settingsRedactionUpdateRequestBody) Validate() url.Values {
+	errs := url.Values{}
+
+	if err := redact.Config(*s).Validate(); err != nil {
+		errs.Add("redaction", err.Error())
+	}
+
+	return errs
+}
+
+/* curl request:
+curl -X PUT \
+	-H "Content-Type: application/json" \
+	-d '{"headers": ["X-Auth-Token"], "params": [{"method": "eth_sendRawTransaction", "positions": [0]}], "patterns": ["0x[0-9a-fA-F]{64}"]}' \
+	http://localhost:7000/api/v1/settings/redaction
+*/
+func (h *settingsHandler) updateRedaction(w http.ResponseWriter, r *http.Request) {
+	payload := settingsRedactionUpdateRequestBody{}
+	if ok := rest.DecodeAndValidateJSONPayload(w, r.Body, &payload); !ok {
+		log.Debug().Msg("validation failed")
+		return
+	}
+
+	s, err := h.settings.Get(r.Context())
+	if err != nil {
+		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	s.Redaction = redact.Config(payload)
+
+	if err := h.redaction.Configure(s.Redaction); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.settings.Update(r.Context(), s); err != nil {
+		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	rest.JSON(w, s.Redacted())
+}
+
+type settingsVulnerabilitiesUpdateRequestBody vulnerability.Config
+
+func (s *settingsVulnerabilitiesUpdateRequestBody) Validate() url.Values {
+	errs := url.Values{}
+
+	if err := vulnerability.Config(*s).Validate(); err != nil {
+		errs.Add("feedUrl", err.Error())
+	}
+
+	return errs
+}
+
+/* curl request:
+curl -X PUT \
+	-H "Content-Type: application/json" \
+	-d '{"disabled": false, "feedUrl": "file:///var/lib/zeth/vulnerabilities.json"}' \
+	http://localhost:7000/api/v1/settings/vulnerabilities
+*/
+func (h *settingsHandler) updateVulnerabilities(w http.ResponseWriter, r *http.Request) {
+	payload := settingsVulnerabilitiesUpdateRequestBody{}
+	if ok := rest.DecodeAndValidateJSONPayload(w, r.Body, &payload); !ok {
+		log.Debug().Msg("validation failed")
+		return
+	}
+
+	s, err := h.settings.Get(r.Context())
+	if err != nil {
+		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	s.Vulnerabilities = vulnerability.Config(payload)
+
+	if err := h.vulns.Configure(s.Vulnerabilities); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.settings.Update(r.Context(), s); err != nil {
+		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	rest.JSON(w, s.Redacted())
+}
+
+type settingsDownloadsUpdateRequestBody downloader.Config
+
+func (s *settingsDownloadsUpdateRequestBody) Validate() url.Values {
+	errs := url.Values{}
+
+	if err := (downloader.Config{MirrorURL: s.MirrorURL}).Validate(); err != nil {
+		errs.Add("mirrorUrl", err.Error())
+	}
+	if err := (downloader.Config{Keyring: s.Keyring}).Validate(); err != nil {
+		errs.Add("keyring", err.Error())
+	}
+
+	return errs
+}
+
+/* curl request:
+curl -X PUT \
+	-H "Content-Type: application/json" \
+	-d '{"mirrorUrl": "https://files.internal/geth", "keyring": "", "allowUnverified": false}' \
+	http://localhost:7000/api/v1/settings/downloads
+*/
+func (h *settingsHandler) updateDownloads(w http.ResponseWriter, r *http.Request) {
+	payload := settingsDownloadsUpdateRequestBody{}
+	if ok := rest.DecodeAndValidateJSONPayload(w, r.Body, &payload); !ok {
+		log.Debug().Msg("validation failed")
+		return
+	}
+
+	s, err := h.settings.Get(r.Context())
+	if err != nil {
+		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	s.Downloads = downloader.Config(payload)
+
+	if err := h.downloads.Configure(s.Downloads); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.settings.Update(r.Context(), s); err != nil {
+		http.Error(w, rest.HTTPInternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	rest.JSON(w, s.Redacted())
 }
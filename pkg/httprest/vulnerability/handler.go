@@ -0,0 +1,52 @@
+package vulnerability
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+	"github.com/zees-dev/zeth/pkg/app"
+	"github.com/zees-dev/zeth/pkg/httprest/rest"
+	"github.com/zees-dev/zeth/pkg/vulnerability"
+)
+
+type handler struct {
+	vulns *vulnerability.Service
+}
+
+func RegisterRoutes(app *app.App, baseRouter *mux.Router) {
+	h := handler{
+		vulns: app.Services.Vulnerabilities,
+	}
+
+	baseRouter.HandleFunc("/vulnerabilities", h.getVulnerabilities).Methods(http.MethodGet)
+	baseRouter.HandleFunc("/vulnerabilities/check", h.checkVulnerabilities).Methods(http.MethodPost)
+}
+
+type vulnerabilitiesResponse struct {
+	Feed    *vulnerability.Feed    `json:"feed"` // null if no check was run
+	Reports []vulnerability.Report `json:"reports"`
+}
+
+/* curl request:
+curl \
+	http://localhost:7000/api/v1/vulnerabilities
+*/
+func (h *handler) getVulnerabilities(w http.ResponseWriter, r *http.Request) {
+	rest.JSON(w, vulnerabilitiesResponse{Feed: h.vulns.Feed(), Reports: h.vulns.Reports()})
+}
+
+// checkVulnerabilities checks all enabled nodes against the vulnerabilities feed on demand.
+/* curl request:
+curl -X POST \
+	http://localhost:7000/api/v1/vulnerabilities/check
+*/
+func (h *handler) checkVulnerabilities(w http.ResponseWriter, r *http.Request) {
+	if err := h.vulns.CheckAll(r.Context()); err != nil {
+		log.Debug().Err(err).Msg("failed to check nodes for vulnerabilities")
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	rest.JSON(w, vulnerabilitiesResponse{Feed: h.vulns.Feed(), Reports: h.vulns.Reports()})
+}
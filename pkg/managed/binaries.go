@@ -0,0 +1,415 @@
+package managed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	uuid "github.com/satori/go.uuid"
+	"github.com/zees-dev/zeth/pkg/events"
+	"github.com/zees-dev/zeth/pkg/geth"
+	"github.com/zees-dev/zeth/pkg/geth/downloader"
+	"github.com/zees-dev/zeth/pkg/node"
+)
+
+// DefaultUpgradeTimeout is the time an upgraded node has to become healthy before it is rolled back.
+const DefaultUpgradeTimeout = 2 * time.Minute
+
+var (
+	ErrInvalidVersion     = node.ErrInvalidVersion
+	ErrUnsupportedVersion = node.ErrUnsupportedVersion
+	ErrBinaryInUse        = errors.New("geth binary is used by managed nodes")
+	ErrUpgradeFailed      = errors.New("node did not become healthy; rolled back to the previous version")
+	ErrUpgrading          = errors.New("node is being upgraded")
+)
+
+// Binary is a geth binary installed in the bin dir of zeth.
+type Binary struct {
+	Version string `json:"version"`
+	Path    string `json:"path"`
+	Size    int64  `json:"size"` // bytes
+	// Manifest records the release the binary was installed from; nil if the binary was not installed by zeth
+	Manifest *downloader.Manifest `json:"manifest,omitempty"`
+	Verified bool                 `json:"verified"` // the release archive was verified against a published SHA-256 checksum or signature
+	// IntegrityOnly is set if the release archive was only checked against the MD5 checksum of the mirror listing; see downloader.Verification
+	IntegrityOnly bool        `json:"integrityOnly"`
+	Nodes         []uuid.UUID `json:"nodes"` // managed nodes configured with the version
+}
+
+// Upgrade is the result of changing the geth version of a managed node.
+type Upgrade struct {
+	NodeID uuid.UUID `json:"nodeId"`
+	From   string    `json:"from"`
+	To     string    `json:"to"`
+	// RolledBack is set if the node did not become healthy with the new version and was restarted with the previous version
+	RolledBack bool   `json:"rolledBack"`
+	Done       bool   `json:"done"` // unset while the upgrade runs in the background
+	Error      string `json:"error,omitempty"`
+	Status     Status `json:"status"`
+}
+
+// binDir returns the dir of the installed geth binaries.
+func (s *Service) binDir() string {
+	return filepath.Join(s.dir, downloader.DefaultGethBinaryDir)
+}
+
+// Binaries returns the installed geth binaries, newest version first.
+func (s *Service) Binaries(ctx context.Context) ([]Binary, error) {
+	entries, err := ioutil.ReadDir(s.binDir())
+	if os.IsNotExist(err) {
+		return []Binary{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	usage, err := s.binaryUsage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	binaries := []Binary{}
+	for _, entry := range entries {
+		version := binaryVersion(entry.Name())
+		if entry.IsDir() || version == "" {
+			continue
+		}
+		binaries = append(binaries, s.describeBinary(version, entry.Size(), usage[version]))
+	}
+	sort.Slice(binaries, func(i, j int) bool {
+		return geth.CompareVersions(binaries[i].Version, binaries[j].Version) > 0
+	})
+	return binaries, nil
+}
+
+// ValidVersion returns whether the version is a geth release version; versions are part of the filenames of binaries.
+func ValidVersion(version string) bool {
+	return node.ValidVersion(version)
+}
+
+// Binary returns the installed geth binary of the version; ErrBinaryNotInstalled if it is not installed.
+func (s *Service) Binary(ctx context.Context, version string) (Binary, error) {
+	if !ValidVersion(version) {
+		return Binary{}, ErrInvalidVersion
+	}
+	info, err := os.Stat(filepath.Join(s.binDir(), downloader.GethBinaryFilename(runtime.GOOS, version)))
+	if os.IsNotExist(err) {
+		return Binary{}, fmt.Errorf("%w: v%s", ErrBinaryNotInstalled, version)
+	}
+	if err != nil {
+		return Binary{}, err
+	}
+	usage, err := s.binaryUsage(ctx)
+	if err != nil {
+		return Binary{}, err
+	}
+	return s.describeBinary(version, info.Size(), usage[version]), nil
+}
+
+// InstallBinaryInBackground installs the geth binary of the version in the background; the progress and the result of the
+// download are published as binary download events. The installed binary is returned if the version is already installed.
+func (s *Service) InstallBinaryInBackground(ctx context.Context, version string) (*Binary, error) {
+	b, err := s.Binary(ctx, version)
+	if err == nil {
+		return &b, nil
+	}
+	if !errors.Is(err, ErrBinaryNotInstalled) {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if _, err := s.InstallBinary(s.ctx, version); err != nil {
+			log.Err(err).Msgf("failed to install geth binary v%s", version)
+		}
+	}()
+	return nil, nil
+}
+
+// RequireBinary returns ErrBinaryNotInstalled if the geth binary of the managed node is not installed, installing it in the background;
+// request handlers check the binary before starting nodes rather than downloading it while the request waits. Dev nodes do not
+// require a binary.
+func (s *Service) RequireBinary(ctx context.Context, id uuid.UUID) error {
+	n, err := s.nodes.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if n.Managed == nil {
+		return nil
+	}
+	b, err := s.InstallBinaryInBackground(ctx, n.Managed.Version)
+	if err != nil {
+		return err
+	}
+	if b == nil {
+		return fmt.Errorf("%w: v%s; installing in the background", ErrBinaryNotInstalled, n.Managed.Version)
+	}
+	return nil
+}
+
+// InstallBinary downloads and installs the geth binary of the version; a no-op if it is already installed.
+func (s *Service) InstallBinary(ctx context.Context, version string) (Binary, error) {
+	b, err := s.Binary(ctx, version)
+	if !errors.Is(err, ErrBinaryNotInstalled) {
+		return b, err
+	}
+
+	log.Info().Msgf("Downloading Geth binary for v%s...", version)
+	if _, err := s.downloads.DownloadGethBinary(ctx, s.binDir(), version); err != nil {
+		return Binary{}, err
+	}
+	return s.Binary(ctx, version)
+}
+
+// RemoveBinary deletes the installed geth binary of the version and its manifest; ErrBinaryInUse if a managed node is configured
+// with the version.
+func (s *Service) RemoveBinary(ctx context.Context, version string) error {
+	b, err := s.Binary(ctx, version)
+	if err != nil {
+		return err
+	}
+	if len(b.Nodes) > 0 {
+		return fmt.Errorf("%w: %d nodes use v%s", ErrBinaryInUse, len(b.Nodes), version)
+	}
+
+	if err := os.Remove(b.Path); err != nil {
+		return err
+	}
+	if err := os.Remove(filepath.Join(s.binDir(), downloader.ManifestFilename(runtime.GOOS, version))); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// describeBinary returns the installed binary of the version with the manifest written by the downloader, if any.
+func (s *Service) describeBinary(version string, size int64, nodes []uuid.UUID) Binary {
+	if nodes == nil {
+		nodes = []uuid.UUID{}
+	}
+	b := Binary{
+		Version: version,
+		Path:    filepath.Join(s.binDir(), downloader.GethBinaryFilename(runtime.GOOS, version)),
+		Size:    size,
+		Nodes:   nodes,
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(s.binDir(), downloader.ManifestFilename(runtime.GOOS, version)))
+	if err != nil {
+		return b
+	}
+	var manifest downloader.Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		log.Warn().Err(err).Msgf("malformed manifest of geth binary v%s", version)
+		return b
+	}
+	b.Manifest = &manifest
+	b.Verified = manifest.Verification.Verified()
+	b.IntegrityOnly = manifest.Verification.IntegrityOnly()
+	return b
+}
+
+// binaryUsage returns the managed nodes by the geth version they are configured with.
+func (s *Service) binaryUsage(ctx context.Context) (map[string][]uuid.UUID, error) {
+	nodes, err := s.nodes.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	usage := map[string][]uuid.UUID{}
+	for _, n := range nodes {
+		if n.Managed != nil {
+			usage[n.Managed.Version] = append(usage[n.Managed.Version], n.ID)
+		}
+	}
+	return usage, nil
+}
+
+// binaryVersion returns the version of the geth binary filename; empty if the file is not a geth binary, e.g. a manifest.
+func binaryVersion(filename string) string {
+	if runtime.GOOS == "windows" {
+		if !strings.HasSuffix(filename, ".exe") {
+			return ""
+		}
+		filename = strings.TrimSuffix(filename, ".exe")
+	}
+	version := strings.TrimPrefix(filename, "geth-v")
+	if version == filename || !ValidVersion(version) {
+		return ""
+	}
+	return version
+}
+
+// UpgradeInBackground upgrades the managed node in the background; see Upgrade. The returned upgrade is pending; the result
+// is published as a node upgraded event if the version was changed or rolled back, and recorded as the last upgrade of the
+// node. ErrUpgrading if the node is being upgraded.
+func (s *Service) UpgradeInBackground(ctx context.Context, id uuid.UUID, version string, timeout time.Duration) (Upgrade, error) {
+	n, err := s.upgradableNode(ctx, id, version)
+	if err != nil {
+		return Upgrade{}, err
+	}
+	upgrade := Upgrade{NodeID: id, From: n.Managed.Version, To: version, Status: s.Status(id)}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.ctx.Err(); err != nil {
+		return Upgrade{}, err
+	}
+	if last, ok := s.upgrades[id]; ok && !last.Done {
+		return last, ErrUpgrading
+	}
+	s.upgrades[id] = upgrade
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		result, err := s.Upgrade(s.ctx, id, version, timeout)
+		if err != nil {
+			log.Err(err).Msgf("failed to upgrade node %s to geth v%s", id, version)
+			result.Error = err.Error()
+		}
+		result.Done = true
+		result.Status = s.Status(id)
+
+		s.mu.Lock()
+		s.upgrades[id] = result
+		s.mu.Unlock()
+	}()
+	return upgrade, nil
+}
+
+// LastUpgrade returns the last upgrade of the managed node run in the background; false if the node was not upgraded since zeth started.
+func (s *Service) LastUpgrade(id uuid.UUID) (Upgrade, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	upgrade, ok := s.upgrades[id]
+	return upgrade, ok
+}
+
+// Upgrade changes the geth version of the managed node, installing the binary of the version if missing. A running node is restarted
+// with the new version; if it does not become healthy within the timeout, it is restarted with the previous version and
+// ErrUpgradeFailed is returned. Downgrades are upgrades to an older version.
+// The context bounds installing the binary and waiting for the node to become healthy; processes are stopped gracefully
+// regardless of the context, since a killed geth process may corrupt its database.
+func (s *Service) Upgrade(ctx context.Context, id uuid.UUID, version string, timeout time.Duration) (Upgrade, error) {
+	n, err := s.upgradableNode(ctx, id, version)
+	if err != nil {
+		return Upgrade{}, err
+	}
+	if timeout <= 0 {
+		timeout = DefaultUpgradeTimeout
+	}
+	upgrade := Upgrade{NodeID: id, From: n.Managed.Version, To: version}
+	if _, err := s.InstallBinary(ctx, version); err != nil {
+		return upgrade, err
+	}
+
+	running := s.Status(id).Running()
+	if err := s.nodes.UpdateManagedVersion(ctx, id, version); err != nil {
+		return upgrade, err
+	}
+	if !running {
+		upgrade.Status = s.Status(id)
+		s.events.Publish(events.NodeUpgraded, id.String(), upgrade)
+		return upgrade, nil
+	}
+
+	log.Info().Msgf("Upgrading node %s from geth v%s to v%s", id, upgrade.From, version)
+	_, err = s.Restart(context.Background(), id)
+	if err == nil {
+		err = s.waitHealthy(ctx, *n, timeout)
+	}
+	if err == nil {
+		upgrade.Status = s.Status(id)
+		s.events.Publish(events.NodeUpgraded, id.String(), upgrade)
+		return upgrade, nil
+	}
+
+	log.Warn().Err(err).Msgf("node %s did not become healthy with geth v%s; rolling back to v%s", id, version, upgrade.From)
+	upgrade.RolledBack = true
+	upgrade.Error = err.Error()
+	if err := s.nodes.UpdateManagedVersion(ctx, id, upgrade.From); err != nil {
+		return upgrade, err
+	}
+	upgrade.Status, err = s.Restart(context.Background(), id)
+	if err != nil {
+		return upgrade, fmt.Errorf("failed to restart node with the previous version: %w", err)
+	}
+	s.events.Publish(events.NodeUpgraded, id.String(), upgrade)
+	return upgrade, fmt.Errorf("%w: %s", ErrUpgradeFailed, upgrade.Error)
+}
+
+// upgradableNode returns the managed node; ErrUnsupportedVersion if the network of the node requires a newer geth version.
+func (s *Service) upgradableNode(ctx context.Context, id uuid.UUID, version string) (*node.ZethNode, error) {
+	n, err := s.managedNode(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	cfg := *n.Managed
+	cfg.Version = version
+	if err := cfg.CheckNetworkVersion(); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// waitHealthy waits until the RPC endpoint of the node responds on consecutive polls; an error if the process exits,
+// or the node does not become healthy within the timeout.
+func (s *Service) waitHealthy(ctx context.Context, n node.ZethNode, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(s.healthInterval)
+	defer ticker.Stop()
+	var err error
+	polls := 0
+	for {
+		status := s.Status(n.ID)
+		switch {
+		case status.State == StateRunning && status.Restarts == 0:
+			if err = s.healthy(ctx, n); err != nil {
+				polls = 0
+			} else if polls++; polls == 2 {
+				return nil
+			}
+		case status.Running():
+			return fmt.Errorf("process exited: %s", status.Error)
+		default:
+			return fmt.Errorf("process is %s: %s", status.State, status.Error)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return fmt.Errorf("not healthy within %s: %v", timeout, err)
+		}
+	}
+}
+
+// rpcHealthy returns an error if the RPC endpoint of the node does not respond.
+func (s *Service) rpcHealthy(ctx context.Context, n node.ZethNode) error {
+	transport, err := s.nodes.Transport(n)
+	if err != nil {
+		return err
+	}
+	c, err := n.DialRPCWithTransport(transport)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	var clientVersion string
+	return c.CallContext(ctx, &clientVersion, "web3_clientVersion")
+}
@@ -0,0 +1,258 @@
+package managed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zees-dev/zeth/pkg/events"
+	"github.com/zees-dev/zeth/pkg/geth/downloader"
+	"github.com/zees-dev/zeth/pkg/node"
+)
+
+// installTestBinary installs the script as the geth binary of the version; with a manifest if the verification is set.
+func installTestBinary(t *testing.T, s *Service, version, script string, verification *downloader.Verification) {
+	if err := os.MkdirAll(s.binDir(), 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(s.binDir(), downloader.GethBinaryFilename(runtime.GOOS, version))
+	if err := ioutil.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if verification == nil {
+		return
+	}
+	manifest := downloader.Manifest{
+		Release:      downloader.Release{Version: version},
+		Verification: *verification,
+		InstalledAt:  time.Now().UTC(),
+	}
+	data, _ := json.Marshal(manifest)
+	if err := ioutil.WriteFile(filepath.Join(s.binDir(), downloader.ManifestFilename(runtime.GOOS, version)), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_Binaries(t *testing.T) {
+	is := assert.New(t)
+
+	s, _ := newTestService(t, "exec sleep 30")
+	ctx := context.Background()
+
+	binaries, err := s.Binaries(ctx)
+	is.NoError(err)
+	is.Empty(binaries)
+
+	installTestBinary(t, s, "1.10.9", "exit 0", nil)
+	installTestBinary(t, s, "1.10.10-unstable", "exit 0", &downloader.Verification{Checksum: "md5"})
+	installTestBinary(t, s, "1.10.11", "exit 0", &downloader.Verification{Checksum: "sha256"})
+	is.NoError(os.Mkdir(filepath.Join(s.binDir(), "geth-v1.10.8"), 0755))
+	is.NoError(ioutil.WriteFile(filepath.Join(s.binDir(), "geth-linux-amd64-1.10.11-7231b3ef.tar.gz.part"), nil, 0644))
+
+	n, err := s.Create(ctx, "old", node.ManagedConfig{Version: "1.10.9"})
+	is.NoError(err)
+
+	binaries, err = s.Binaries(ctx)
+	is.NoError(err)
+	is.Len(binaries, 3)
+	is.Equal("1.10.11", binaries[0].Version)
+	is.True(binaries[0].Verified)
+	is.False(binaries[0].IntegrityOnly)
+	is.NotNil(binaries[0].Manifest)
+	is.Empty(binaries[0].Nodes)
+	// the md5 checksum of the mirror listing does not verify the archive
+	is.Equal("1.10.10-unstable", binaries[1].Version)
+	is.False(binaries[1].Verified)
+	is.True(binaries[1].IntegrityOnly)
+	is.Equal("1.10.9", binaries[2].Version)
+	is.False(binaries[2].Verified)
+	is.Nil(binaries[2].Manifest)
+	is.Equal(int64(len("#!/bin/sh\nexit 0\n")), binaries[2].Size)
+	is.Equal(n.ID, binaries[2].Nodes[0])
+
+	// installed versions are not downloaded again
+	b, err := s.InstallBinary(ctx, "1.10.11")
+	is.NoError(err)
+	is.Equal(binaries[0], b)
+
+	is.ErrorIs(s.RemoveBinary(ctx, "1.10.9"), ErrBinaryInUse)
+	is.ErrorIs(s.RemoveBinary(ctx, "../nodes"), ErrInvalidVersion)
+	is.ErrorIs(s.RemoveBinary(ctx, "1.10.10"), ErrBinaryNotInstalled)
+	is.NoError(s.RemoveBinary(ctx, "1.10.11"))
+	is.NoFileExists(filepath.Join(s.binDir(), downloader.ManifestFilename(runtime.GOOS, "1.10.11")))
+	_, err = s.Binary(ctx, "1.10.11")
+	is.ErrorIs(err, ErrBinaryNotInstalled)
+}
+
+func Test_Upgrade(t *testing.T) {
+	is := assert.New(t)
+
+	s, bus := newTestService(t, "exec sleep 30")
+	s.binary = s.binaryPath
+	s.healthy = func(context.Context, node.ZethNode) error { return nil }
+	s.healthInterval = 10 * time.Millisecond
+	ctx := context.Background()
+	defer s.StopAll(ctx)
+
+	installTestBinary(t, s, "1.10.11", "exec sleep 30", nil)
+	installTestBinary(t, s, "1.10.12", "exit 1", nil)
+	installTestBinary(t, s, "1.10.13", "exec sleep 30", nil)
+
+	c := make(chan events.Event, 100)
+	_, unsub := bus.Subscribe(c, 0, []string{string(events.NodeUpgraded)})
+	defer unsub()
+
+	n, err := s.Create(ctx, "upgraded", node.ManagedConfig{Version: "1.10.11"})
+	is.NoError(err)
+
+	// stopped nodes are not started
+	upgrade, err := s.Upgrade(ctx, n.ID, "1.10.12", time.Second)
+	is.NoError(err)
+	is.Equal(StateStopped, upgrade.Status.State)
+	is.Equal(upgrade, (<-c).Data)
+	upgrade, err = s.Upgrade(ctx, n.ID, "1.10.11", time.Second)
+	is.NoError(err)
+	<-c
+
+	_, err = s.Start(ctx, n.ID)
+	is.NoError(err)
+
+	// the process of the new version exits; the node is rolled back
+	upgrade, err = s.Upgrade(ctx, n.ID, "1.10.12", 5*time.Second)
+	is.ErrorIs(err, ErrUpgradeFailed)
+	is.True(upgrade.RolledBack)
+	is.Equal("1.10.11", upgrade.From)
+	is.Equal(StateRunning, upgrade.Status.State)
+	is.Equal(upgrade, (<-c).Data)
+	stored, _ := s.nodes.Get(ctx, n.ID)
+	is.Equal("1.10.11", stored.Managed.Version)
+
+	// the node does not serve requests within the timeout; the node is rolled back
+	s.healthy = func(context.Context, node.ZethNode) error { return errors.New("connection refused") }
+	upgrade, err = s.Upgrade(ctx, n.ID, "1.10.13", 100*time.Millisecond)
+	is.ErrorIs(err, ErrUpgradeFailed)
+	is.Contains(upgrade.Error, "connection refused")
+	stored, _ = s.nodes.Get(ctx, n.ID)
+	is.Equal("1.10.11", stored.Managed.Version)
+	<-c
+
+	s.healthy = func(context.Context, node.ZethNode) error { return nil }
+	upgrade, err = s.Upgrade(ctx, n.ID, "1.10.13", 5*time.Second)
+	is.NoError(err)
+	is.False(upgrade.RolledBack)
+	is.Equal(StateRunning, upgrade.Status.State)
+	stored, _ = s.nodes.Get(ctx, n.ID)
+	is.Equal("1.10.13", stored.Managed.Version)
+
+	_, err = s.Upgrade(ctx, n.ID, "latest", time.Second)
+	is.ErrorIs(err, ErrInvalidVersion)
+}
+
+func Test_UpgradeInBackground(t *testing.T) {
+	is := assert.New(t)
+
+	s, bus := newTestService(t, "exec sleep 30")
+	s.binary = s.binaryPath
+	s.healthInterval = 10 * time.Millisecond
+	healthy := make(chan struct{})
+	s.healthy = func(ctx context.Context, n node.ZethNode) error {
+		select {
+		case <-healthy:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	installTestBinary(t, s, "1.10.11", "exec sleep 30", nil)
+	installTestBinary(t, s, "1.10.13", "exec sleep 30", nil)
+
+	c := make(chan events.Event, 100)
+	_, unsub := bus.Subscribe(c, 0, []string{string(events.NodeUpgraded)})
+	defer unsub()
+
+	n, err := s.Create(context.Background(), "upgraded", node.ManagedConfig{Version: "1.10.11"})
+	is.NoError(err)
+	_, err = s.Start(context.Background(), n.ID)
+	is.NoError(err)
+	_, ok := s.LastUpgrade(n.ID)
+	is.False(ok)
+
+	// the upgrade outlives the request
+	ctx, cancel := context.WithCancel(context.Background())
+	upgrade, err := s.UpgradeInBackground(ctx, n.ID, "1.10.13", 5*time.Second)
+	cancel()
+	is.NoError(err)
+	is.False(upgrade.Done)
+	is.Equal("1.10.11", upgrade.From)
+	_, err = s.UpgradeInBackground(context.Background(), n.ID, "1.10.13", 5*time.Second)
+	is.ErrorIs(err, ErrUpgrading)
+
+	close(healthy)
+	<-c
+	upgrade, ok = s.LastUpgrade(n.ID)
+	is.True(ok)
+	is.True(upgrade.Done)
+	is.False(upgrade.RolledBack)
+	is.Empty(upgrade.Error)
+	is.Equal(StateRunning, upgrade.Status.State)
+
+	// upgrades are cancelled on shutdown; the node is rolled back before it is stopped
+	s.healthy = func(ctx context.Context, n node.ZethNode) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	_, err = s.UpgradeInBackground(context.Background(), n.ID, "1.10.11", time.Minute)
+	is.NoError(err)
+	s.StopAll(context.Background())
+	upgrade, _ = s.LastUpgrade(n.ID)
+	is.True(upgrade.Done)
+	is.True(upgrade.RolledBack)
+	is.Equal(StateStopped, s.Status(n.ID).State)
+	stored, _ := s.nodes.Get(context.Background(), n.ID)
+	is.Equal("1.10.13", stored.Managed.Version)
+	_, err = s.UpgradeInBackground(context.Background(), n.ID, "1.10.11", time.Minute)
+	is.ErrorIs(err, context.Canceled)
+}
+
+func Test_InstallBinaryInBackground(t *testing.T) {
+	is := assert.New(t)
+
+	s, bus := newTestService(t, "exec sleep 30")
+	ctx := context.Background()
+	installTestBinary(t, s, "1.10.11", "exit 0", nil)
+
+	// installed versions are returned
+	b, err := s.InstallBinaryInBackground(ctx, "1.10.11")
+	is.NoError(err)
+	is.Equal("1.10.11", b.Version)
+
+	// failures are published as the last progress of the download
+	is.NoError(s.downloads.Configure(downloader.Config{MirrorURL: "http://127.0.0.1:1"}))
+	c := make(chan events.Event, 10)
+	_, unsub := bus.Subscribe(c, 0, []string{string(events.BinaryDownload)})
+	defer unsub()
+	b, err = s.InstallBinaryInBackground(ctx, "1.10.13")
+	is.NoError(err)
+	is.Nil(b)
+	progress := (<-c).Data.(downloader.Progress)
+	is.True(progress.Done)
+	is.NotEmpty(progress.Error)
+	_, err = s.Binary(ctx, "1.10.13")
+	is.ErrorIs(err, ErrBinaryNotInstalled)
+
+	// nodes with missing binaries are not started while their binary is installed in the background
+	n, err := s.Create(ctx, "missing", node.ManagedConfig{Version: "1.10.13"})
+	is.NoError(err)
+	is.ErrorIs(s.RequireBinary(ctx, n.ID), ErrBinaryNotInstalled)
+	is.True((<-c).Data.(downloader.Progress).Done)
+	installed, err := s.Create(ctx, "installed", node.ManagedConfig{Version: "1.10.11"})
+	is.NoError(err)
+	is.NoError(s.RequireBinary(ctx, installed.ID))
+}
@@ -0,0 +1,250 @@
+package managed
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strconv"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/zees-dev/zeth/pkg/geth"
+	"github.com/zees-dev/zeth/pkg/node"
+)
+
+// Config is the geth config of a managed node; config.toml in the data dir of the node is the source of truth.
+type Config struct {
+	TOML   string          `json:"toml"` // as produced by geth dumpconfig
+	Config geth.GethConfig `json:"config"`
+}
+
+// ConfigUpdate is the result of updating the geth config of a managed node.
+type ConfigUpdate struct {
+	Config
+	Changes  []geth.ConfigChange `json:"changes"`          // settings changed from the current config
+	Defaults []geth.ConfigChange `json:"defaults"`         // settings differing from the default config of the node
+	Applied  bool                `json:"applied"`          // false for a dry run
+	Status   *Status             `json:"status,omitempty"` // status of the process restarted to apply the config; nil if not running
+}
+
+// DefaultConfig returns the geth config the managed node was created with; of its network preset.
+func DefaultConfig(cfg node.ManagedConfig) geth.GethConfig {
+	gethCfg := geth.MainnetGethConfig()
+	if cfg.Network != nil {
+		gethCfg = cfg.Network.Apply(gethCfg)
+	}
+	return geth.ManagedGethConfig(gethCfg, cfg.DataDir, cfg.HTTPPort, cfg.WSPort, cfg.P2PPort)
+}
+
+// managedNode returns the managed node; ErrNotManaged if it is not a geth process run by zeth.
+func (s *Service) managedNode(ctx context.Context, id uuid.UUID) (*node.ZethNode, error) {
+	n, err := s.nodes.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if n.Managed == nil {
+		return nil, ErrNotManaged
+	}
+	return n, nil
+}
+
+// Config returns the geth config of the managed node from the config file in its data dir.
+func (s *Service) Config(ctx context.Context, id uuid.UUID) (Config, error) {
+	n, err := s.managedNode(ctx, id)
+	if err != nil {
+		return Config{}, err
+	}
+	return readConfig(*n.Managed)
+}
+
+func readConfig(cfg node.ManagedConfig) (Config, error) {
+	data, err := ioutil.ReadFile(ConfigPath(cfg))
+	if err != nil {
+		return Config{}, err
+	}
+	gethCfg, err := geth.ParseConfig(data)
+	if err != nil {
+		return Config{}, err
+	}
+	return Config{TOML: string(data), Config: gethCfg}, nil
+}
+
+// UpdateConfig validates the TOML geth config and diffs it against the current and the default config of the managed node.
+// Unless it is a dry run, the config is written to the config file of the node and the node is restarted if it is running;
+// the ports and RPC endpoints of the node are updated to the endpoints of the config.
+func (s *Service) UpdateConfig(ctx context.Context, id uuid.UUID, data []byte, dryRun bool) (ConfigUpdate, error) {
+	n, err := s.managedNode(ctx, id)
+	if err != nil {
+		return ConfigUpdate{}, err
+	}
+	current, err := readConfig(*n.Managed)
+	if err != nil {
+		return ConfigUpdate{}, err
+	}
+
+	gethCfg, err := geth.ParseConfig(data)
+	if err != nil {
+		return ConfigUpdate{}, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	if err := validateConfig(*n.Managed, gethCfg); err != nil {
+		return ConfigUpdate{}, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	if err := s.checkPorts(ctx, id, gethCfg); err != nil {
+		return ConfigUpdate{}, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+
+	// the config is stored as dumped by geth, regardless of the formatting of the update
+	normalized, err := geth.MarshalConfig(gethCfg)
+	if err != nil {
+		return ConfigUpdate{}, err
+	}
+	update := ConfigUpdate{Config: Config{TOML: string(normalized), Config: gethCfg}}
+	if update.Changes, err = geth.DiffConfig(current.Config, gethCfg); err != nil {
+		return ConfigUpdate{}, err
+	}
+	if update.Defaults, err = geth.DiffConfig(DefaultConfig(*n.Managed), gethCfg); err != nil {
+		return ConfigUpdate{}, err
+	}
+	if dryRun {
+		return update, nil
+	}
+
+	if err := geth.WriteConfigFile(gethCfg, ConfigPath(*n.Managed)); err != nil {
+		return ConfigUpdate{}, err
+	}
+	update.Applied = true
+
+	n.Managed.HTTPPort = gethCfg.Node.HTTPPort
+	n.Managed.WSPort = gethCfg.Node.WSPort
+	n.Managed.P2PPort = p2pPort(gethCfg)
+	n.RPC = rpcEndpoints(gethCfg, n.RPC)
+	if err := s.nodes.Update(ctx, n.ID, *n); err != nil {
+		return update, err
+	}
+
+	if s.Status(id).Running() {
+		status, err := s.Restart(ctx, id)
+		update.Status = &status
+		return update, err
+	}
+	return update, nil
+}
+
+// validateConfig returns an error if the geth config can not be run as the managed node; zeth requires the data dir to be
+// retained and the HTTP endpoint to be served. Nodes unlocking accounts must serve RPC on the loopback interface only.
+func validateConfig(cfg node.ManagedConfig, gethCfg geth.GethConfig) error {
+	if gethCfg.Node.DataDir != cfg.DataDir {
+		return fmt.Errorf("Node.DataDir must be %s", cfg.DataDir)
+	}
+	if gethCfg.Node.HTTPHost == "" || gethCfg.Node.HTTPPort <= 0 {
+		return fmt.Errorf("Node.HTTPHost and Node.HTTPPort must be set; the RPC endpoint is served by zeth")
+	}
+	if unlocksAccounts(cfg.Args) {
+		if !loopback(gethCfg.Node.HTTPHost) {
+			return fmt.Errorf("Node.HTTPHost must be a loopback address; the node unlocks accounts")
+		}
+		if gethCfg.Node.WSHost != "" && !loopback(gethCfg.Node.WSHost) {
+			return fmt.Errorf("Node.WSHost must be a loopback address; the node unlocks accounts")
+		}
+	}
+	if gethCfg.Node.P2P.ListenAddr != "" {
+		if _, port, err := net.SplitHostPort(gethCfg.Node.P2P.ListenAddr); err != nil || port == "" {
+			return fmt.Errorf("Node.P2P.ListenAddr must be host:port")
+		}
+	}
+	return nil
+}
+
+// unlocksAccounts reports whether the args of the geth process unlock accounts.
+func unlocksAccounts(args []string) bool {
+	for _, arg := range args {
+		if node.FlagName(arg) == "unlock" {
+			return true
+		}
+	}
+	return false
+}
+
+// loopback reports whether the host is served on the loopback interface only.
+func loopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// checkPorts returns an error if the ports of the geth config are used by other nodes run by zeth, or by each other.
+func (s *Service) checkPorts(ctx context.Context, id uuid.UUID, gethCfg geth.GethConfig) error {
+	nodes, err := s.nodes.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+	others := make([]node.ZethNode, 0, len(nodes))
+	for _, n := range nodes {
+		if !uuid.Equal(n.ID, id) {
+			others = append(others, n)
+		}
+	}
+	used := usedPorts(others)
+
+	ports := []struct {
+		name string
+		port int
+	}{
+		{"Node.HTTPPort", gethCfg.Node.HTTPPort},
+		{"Node.WSPort", gethCfg.Node.WSPort},
+		{"Node.P2P.ListenAddr", p2pPort(gethCfg)},
+	}
+	if gethCfg.Node.WSHost == "" {
+		ports[1].port = 0 // not served
+	}
+	for i, p := range ports {
+		if p.port == 0 {
+			continue
+		}
+		if used[p.port] {
+			return fmt.Errorf("%s %d is used by another node", p.name, p.port)
+		}
+		for _, other := range ports[:i] {
+			if other.port == p.port {
+				return fmt.Errorf("%s must differ from %s", p.name, other.name)
+			}
+		}
+	}
+	return nil
+}
+
+// p2pPort returns the port of the p2p listen address of the config; 0 if p2p networking is disabled.
+func p2pPort(gethCfg geth.GethConfig) int {
+	_, s, err := net.SplitHostPort(gethCfg.Node.P2P.ListenAddr)
+	if err != nil {
+		return 0
+	}
+	port, _ := strconv.Atoi(s)
+	return port
+}
+
+// rpcEndpoints returns the RPC endpoints served by the geth process of the config; endpoints served on all interfaces are
+// connected to via the loopback interface.
+func rpcEndpoints(gethCfg geth.GethConfig, rpc node.RPC) node.RPC {
+	rpc.HTTP = fmt.Sprintf("http://%s", net.JoinHostPort(dialHost(gethCfg.Node.HTTPHost), strconv.Itoa(gethCfg.Node.HTTPPort)))
+	rpc.WS = ""
+	if gethCfg.Node.WSHost != "" {
+		rpc.WS = fmt.Sprintf("ws://%s", net.JoinHostPort(dialHost(gethCfg.Node.WSHost), strconv.Itoa(gethCfg.Node.WSPort)))
+	}
+	rpc.IPC = gethCfg.Node.IPCEndpoint()
+	return rpc
+}
+
+// dialHost returns the host to connect to an endpoint listening on the host; the loopback address for unspecified addresses.
+func dialHost(host string) string {
+	ip := net.ParseIP(host)
+	switch {
+	case ip == nil || !ip.IsUnspecified():
+		return host
+	case ip.To4() != nil:
+		return "127.0.0.1"
+	}
+	return "::1"
+}
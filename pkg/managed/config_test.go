@@ -0,0 +1,160 @@
+package managed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zees-dev/zeth/pkg/geth"
+	"github.com/zees-dev/zeth/pkg/node"
+)
+
+func Test_UpdateConfig(t *testing.T) {
+	is := assert.New(t)
+
+	s, _ := newTestService(t, "exec sleep 30")
+	ctx := context.Background()
+
+	n, err := s.Create(ctx, "configured", node.ManagedConfig{HTTPPort: 18545})
+	is.NoError(err)
+	defer s.StopAll(ctx)
+
+	cfg, err := s.Config(ctx, n.ID)
+	is.NoError(err)
+	is.Equal(18545, cfg.Config.Node.HTTPPort)
+	is.Contains(cfg.TOML, "HTTPPort = 18545")
+
+	updated := strings.Replace(cfg.TOML, "HTTPPort = 18545", "HTTPPort = 18645", 1)
+
+	// a dry run only diffs the config
+	update, err := s.UpdateConfig(ctx, n.ID, []byte(updated), true)
+	is.NoError(err)
+	is.False(update.Applied)
+	is.Equal([]geth.ConfigChange{{Path: "Node.HTTPPort", From: int64(18545), To: int64(18645)}}, update.Changes)
+	is.Equal(update.Changes, update.Defaults)
+	current, err := s.Config(ctx, n.ID)
+	is.NoError(err)
+	is.Equal(cfg.TOML, current.TOML)
+
+	// a running node is restarted with the config applied
+	started, err := s.Start(ctx, n.ID)
+	is.NoError(err)
+	update, err = s.UpdateConfig(ctx, n.ID, []byte(updated), false)
+	is.NoError(err)
+	is.True(update.Applied)
+	is.Equal(StateRunning, update.Status.State)
+	is.NotEqual(started.PID, update.Status.PID)
+
+	stored, err := s.nodes.Get(ctx, n.ID)
+	is.NoError(err)
+	is.Equal(18645, stored.Managed.HTTPPort)
+	is.Equal("http://127.0.0.1:18645", stored.RPC.HTTP)
+	current, err = s.Config(ctx, n.ID)
+	is.NoError(err)
+	is.Equal(18645, current.Config.Node.HTTPPort)
+
+	invalid := []string{
+		"[Node]\nHTTPPorts = 8545\n",
+		"[Node]\nDataDir = \"/tmp\"\nHTTPHost = \"127.0.0.1\"\nHTTPPort = 8545\n",
+		fmt.Sprintf("[Node]\nDataDir = %q\nHTTPPort = 8545\n", n.Managed.DataDir),
+	}
+	for _, data := range invalid {
+		_, err = s.UpdateConfig(ctx, n.ID, []byte(data), false)
+		is.True(errors.Is(err, ErrInvalidConfig), err)
+	}
+
+	// ports of other nodes run by zeth are not reused
+	other, err := s.Create(ctx, "other", node.ManagedConfig{})
+	is.NoError(err)
+	conflicting := strings.Replace(current.TOML, "HTTPPort = 18645", fmt.Sprintf("HTTPPort = %d", other.Managed.HTTPPort), 1)
+	_, err = s.UpdateConfig(ctx, n.ID, []byte(conflicting), true)
+	is.ErrorIs(err, ErrInvalidConfig)
+	is.Contains(err.Error(), "used by another node")
+}
+
+func Test_rpcEndpoints(t *testing.T) {
+	tests := []struct {
+		host string
+		want string
+	}{
+		{"127.0.0.1", "http://127.0.0.1:8545"},
+		{"0.0.0.0", "http://127.0.0.1:8545"},
+		{"::", "http://[::1]:8545"},
+		{"192.168.1.10", "http://192.168.1.10:8545"},
+		{"localhost", "http://localhost:8545"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			is := assert.New(t)
+			gethCfg := geth.DefaultGethConfig
+			gethCfg.Node.HTTPHost = tt.host
+			gethCfg.Node.HTTPPort = 8545
+			is.Equal(tt.want, rpcEndpoints(gethCfg, node.RPC{}).HTTP)
+		})
+	}
+}
+
+func Test_validateConfigUnlocked(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		httpHost string
+		wsHost   string
+		wantErr  bool
+	}{
+		{"locked", nil, "0.0.0.0", "0.0.0.0", false},
+		{"loopback", []string{"--unlock", "0x0"}, "127.0.0.1", "localhost", false},
+		{"ws not served", []string{"--unlock=0x0"}, "::1", "", false},
+		{"http exposed", []string{"--unlock", "0x0"}, "0.0.0.0", "127.0.0.1", true},
+		{"http exposed with one dash", []string{"-unlock", "0x0"}, "0.0.0.0", "", true},
+		{"ws exposed", []string{"--unlock=0x0"}, "127.0.0.1", "192.168.1.10", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := assert.New(t)
+			cfg := node.ManagedConfig{DataDir: "/data", Args: tt.args}
+			gethCfg := geth.DefaultGethConfig
+			gethCfg.Node.DataDir = cfg.DataDir
+			gethCfg.Node.HTTPHost = tt.httpHost
+			gethCfg.Node.HTTPPort = 8545
+			gethCfg.Node.WSHost = tt.wsHost
+			is.Equal(tt.wantErr, validateConfig(cfg, gethCfg) != nil)
+		})
+	}
+}
+
+func Test_NetworkPreset(t *testing.T) {
+	is := assert.New(t)
+
+	s, _ := newTestService(t, "exec sleep 30")
+	ctx := context.Background()
+
+	// the bundled geth version can not follow the network
+	sepolia, _ := geth.BuiltinPreset("sepolia")
+	_, err := s.Create(ctx, "sepolia", node.ManagedConfig{Network: &sepolia})
+	is.ErrorIs(err, node.ErrUnsupportedVersion)
+
+	n, err := s.Create(ctx, "sepolia", node.ManagedConfig{Version: sepolia.MinVersion, Network: &sepolia})
+	is.NoError(err)
+	defer s.StopAll(ctx)
+
+	cfg, err := s.Config(ctx, n.ID)
+	is.NoError(err)
+	is.Equal(sepolia.NetworkID, cfg.Config.Eth.NetworkId)
+	is.Equal(sepolia.DNSDiscovery, cfg.Config.Eth.EthDiscoveryURLs)
+	is.Len(cfg.Config.Node.P2P.BootstrapNodes, len(sepolia.Bootnodes))
+
+	// geth would initialise the data dir with the mainnet genesis block
+	_, err = s.Start(ctx, n.ID)
+	is.ErrorIs(err, ErrGenesisRequired)
+
+	mainnet, err := s.Create(ctx, "mainnet", node.ManagedConfig{Network: &geth.MainnetPreset})
+	is.NoError(err)
+	_, err = s.Start(ctx, mainnet.ID)
+	is.NoError(err)
+}
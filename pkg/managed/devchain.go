@@ -0,0 +1,133 @@
+package managed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/rs/zerolog/log"
+	"github.com/zees-dev/zeth/pkg/events"
+	"github.com/zees-dev/zeth/pkg/geth"
+	"github.com/zees-dev/zeth/pkg/node"
+)
+
+// devModules are the RPC namespaces served by dev chains.
+var devModules = []string{"eth", "net", "web3", "txpool", "debug"}
+
+// CreateDev registers a dev node whose dev chain is run in-process; ports which are not set are allocated, and the default
+// number of prefunded accounts is generated if none are set. The node's RPC endpoints are set to the endpoints of the dev chain.
+func (s *Service) CreateDev(ctx context.Context, name string, cfg node.DevChainConfig) (node.ZethNode, error) {
+	if err := cfg.Validate(); err != nil {
+		return node.ZethNode{}, err
+	}
+	if len(cfg.Accounts) == 0 {
+		accounts, err := node.NewDevAccounts(node.DefaultDevAccounts, node.DefaultDevBalance)
+		if err != nil {
+			return node.ZethNode{}, err
+		}
+		cfg.Accounts = accounts
+	}
+	err := s.allocatePorts(ctx,
+		portRequest{&cfg.HTTPPort, defaultHTTPPort, 2},
+		portRequest{&cfg.WSPort, defaultWSPort, 2},
+	)
+	if err != nil {
+		return node.ZethNode{}, err
+	}
+
+	return s.nodes.Create(ctx, node.ZethNode{
+		Name:      name,
+		IsDev:     true,
+		Enabled:   true,
+		DateAdded: s.now().UTC(),
+		RPC: node.RPC{
+			HTTP:    fmt.Sprintf("http://127.0.0.1:%d", cfg.HTTPPort),
+			WS:      fmt.Sprintf("ws://127.0.0.1:%d", cfg.WSPort),
+			Default: node.DefaultHTTPRPC,
+		},
+		Dev: &cfg,
+	})
+}
+
+// startDev starts the dev chain of the dev node from its genesis block.
+func (s *Service) startDev(n node.ZethNode) (Status, error) {
+	s.mu.Lock()
+	if prev, ok := s.procs[n.ID]; ok && prev.status.Running() {
+		s.mu.Unlock()
+		return s.Status(n.ID), nil
+	}
+	chain, err := startDevChain(*n.Dev)
+	if err != nil {
+		s.mu.Unlock()
+		return s.fail(n.ID, err), err
+	}
+
+	now := s.now().UTC()
+	p := &process{
+		chain:  chain,
+		status: Status{NodeID: n.ID, State: StateRunning, StartedAt: &now},
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	s.procs[n.ID] = p
+	status := p.status
+	s.mu.Unlock()
+
+	s.events.Publish(events.NodeStarted, n.ID.String(), status)
+	return status, nil
+}
+
+// startDevChain starts a dev chain sealed by the first account of the config, serving its RPC endpoints on the loopback interface.
+func startDevChain(cfg node.DevChainConfig) (*geth.DevChain, error) {
+	if len(cfg.Accounts) == 0 {
+		return nil, errors.New("dev chain has no accounts")
+	}
+	signer, err := crypto.HexToECDSA(strings.TrimPrefix(cfg.Accounts[0].PrivateKey, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key of signer: %w", err)
+	}
+
+	alloc := core.GenesisAlloc{}
+	for _, account := range cfg.Accounts {
+		balance := new(big.Int)
+		if account.Balance != nil {
+			balance = account.Balance.ToInt()
+		}
+		alloc[account.Address] = core.GenesisAccount{Balance: balance}
+	}
+	genesis := geth.DevGenesis(cfg.Period, cfg.GasLimit, cfg.Accounts[0].Address, alloc)
+
+	gethCfg := geth.DevModeConfig(int(cfg.Period))
+	gethCfg.Node.HTTPHost = "127.0.0.1"
+	gethCfg.Node.HTTPPort = cfg.HTTPPort
+	gethCfg.Node.HTTPModules = devModules
+	gethCfg.Node.WSHost = "127.0.0.1"
+	gethCfg.Node.WSPort = cfg.WSPort
+	gethCfg.Node.WSModules = devModules
+
+	return geth.StartDevChain(gethCfg, genesis, signer)
+}
+
+// stopDev closes the dev chain of the process; the chain is discarded.
+func (s *Service) stopDev(p *process) {
+	err := p.chain.Close()
+
+	s.mu.Lock()
+	now := s.now().UTC()
+	p.status.State = StateStopped
+	p.status.ExitedAt = &now
+	p.status.Error = ""
+	if err != nil {
+		p.status.Error = err.Error()
+		log.Err(err).Msgf("failed to close dev chain of node %s", p.status.NodeID)
+	}
+	status := p.status
+	s.mu.Unlock()
+
+	close(p.done)
+	s.events.Publish(events.NodeStopped, status.NodeID.String(), status)
+}
@@ -0,0 +1,62 @@
+package managed
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core"
+	uuid "github.com/satori/go.uuid"
+	"github.com/zees-dev/zeth/pkg/geth"
+	"github.com/zees-dev/zeth/pkg/node"
+)
+
+// InitGenesis initialises the data dir of the managed node with the genesis block by running geth init with the binary of the node.
+// The node is assigned a network of the chain id and genesis hash, without bootnodes or DNS discovery; the geth config is updated accordingly.
+func (s *Service) InitGenesis(ctx context.Context, id uuid.UUID, genesis *core.Genesis) (node.ZethNode, error) {
+	n, err := s.managedNode(ctx, id)
+	if err != nil {
+		return node.ZethNode{}, err
+	}
+	if s.Status(id).Running() {
+		return *n, ErrRunning
+	}
+	if initialised(*n.Managed) {
+		return *n, ErrGenesisInitialised
+	}
+	binary, err := s.binary(ctx, n.Managed.Version)
+	if err != nil {
+		return *n, err
+	}
+
+	path := filepath.Join(n.Managed.DataDir, geth.GenesisFilename)
+	if err := geth.WriteGenesisFile(genesis, path); err != nil {
+		return *n, err
+	}
+	cmd := exec.CommandContext(ctx, binary, "init", "--datadir", n.Managed.DataDir, path)
+	cmd.Dir = n.Managed.DataDir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return *n, fmt.Errorf("geth init failed: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	network := geth.Preset{
+		Name:        fmt.Sprintf("chain-%d", genesis.Config.ChainID),
+		NetworkID:   genesis.Config.ChainID.Uint64(),
+		GenesisHash: genesis.ToBlock(nil).Hash(),
+	}
+	current, err := readConfig(*n.Managed)
+	if err != nil {
+		return *n, err
+	}
+	if err := geth.WriteConfigFile(network.Apply(current.Config), ConfigPath(*n.Managed)); err != nil {
+		return *n, err
+	}
+
+	n.Managed.Network = &network
+	if err := s.nodes.Update(ctx, n.ID, *n); err != nil {
+		return *n, err
+	}
+	return *n, nil
+}
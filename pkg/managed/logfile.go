@@ -0,0 +1,312 @@
+package managed
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// nodeLog is the log of the output of a managed node; entries are appended as JSON lines to a log file which is rotated
+// once it exceeds the max size, retaining the max number of rotated files. Subscribers receive the appended entries.
+type nodeLog struct {
+	dir string
+	now func() time.Time
+
+	mu          sync.Mutex
+	maxSize     int64
+	maxFiles    int
+	file        *os.File
+	size        int64
+	subscribers map[chan LogEntry]struct{}
+}
+
+func newNodeLog(dir string, now func() time.Time) *nodeLog {
+	return &nodeLog{
+		dir:         dir,
+		now:         now,
+		maxSize:     DefaultLogMaxSize,
+		maxFiles:    DefaultLogMaxFiles,
+		subscribers: make(map[chan LogEntry]struct{}),
+	}
+}
+
+// setRetention sets the max size of the log file and the number of rotated files retained; zero values keep the defaults.
+func (l *nodeLog) setRetention(maxSize int64, maxFiles int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.maxSize, l.maxFiles = DefaultLogMaxSize, DefaultLogMaxFiles
+	if maxSize > 0 {
+		l.maxSize = maxSize
+	}
+	if maxFiles > 0 {
+		l.maxFiles = maxFiles
+	}
+}
+
+// path returns the path of the log file; rotated files are suffixed with their generation, the most recent being 1.
+func (l *nodeLog) path(generation int) string {
+	path := filepath.Join(l.dir, LogFilename)
+	if generation > 0 {
+		path = fmt.Sprintf("%s.%d", path, generation)
+	}
+	return path
+}
+
+// append parses the line and appends the entry; failures to write the log file are returned, subscribers receive the entry regardless.
+func (l *nodeLog) append(stream, line string) error {
+	entry := ParseLogLine(line)
+	entry.Time = l.now().UTC()
+	entry.Stream = stream
+
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for c := range l.subscribers {
+		select {
+		case c <- entry:
+		default:
+		}
+	}
+
+	if l.file != nil && l.size+int64(len(b)) > l.maxSize {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+	if l.file == nil {
+		if err := l.open(); err != nil {
+			return err
+		}
+	}
+	n, err := l.file.Write(b)
+	l.size += int64(n)
+	return err
+}
+
+// open opens the log file for appending.
+// note: must be called with the lock held
+func (l *nodeLog) open() error {
+	if err := os.MkdirAll(l.dir, 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.path(0), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	l.file, l.size = f, info.Size()
+	return nil
+}
+
+// rotate closes the log file and shifts the generations of the rotated files; the oldest generation is removed.
+// note: must be called with the lock held
+func (l *nodeLog) rotate() error {
+	l.file.Close()
+	l.file, l.size = nil, 0
+
+	os.Remove(l.path(l.maxFiles))
+	for generation := l.maxFiles - 1; generation >= 0; generation-- {
+		if err := os.Rename(l.path(generation), l.path(generation+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// close closes the log file; it is reopened on the next append.
+func (l *nodeLog) close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file != nil {
+		l.file.Close()
+		l.file, l.size = nil, 0
+	}
+}
+
+// search returns the latest entries matching the query in chronological order.
+func (l *nodeLog) search(q LogQuery) ([]LogEntry, error) {
+	l.mu.Lock()
+	files, closeFn, err := l.snapshot()
+	l.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	defer closeFn()
+
+	return searchLogFiles(files, q)
+}
+
+// snapshot opens the log files, oldest first; the current file is read up to its current size, so entries appended later are not read.
+// Open files remain readable when the log is rotated, so they are scanned without holding the lock; appends are not blocked.
+// note: must be called with the lock held
+func (l *nodeLog) snapshot() ([]io.Reader, func(), error) {
+	var opened []*os.File
+	closeFn := func() {
+		for _, f := range opened {
+			f.Close()
+		}
+	}
+
+	files := []io.Reader{}
+	for generation := l.maxFiles; generation >= 0; generation-- {
+		f, err := os.Open(l.path(generation))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			closeFn()
+			return nil, nil, err
+		}
+		opened = append(opened, f)
+		if generation > 0 {
+			files = append(files, f)
+			continue
+		}
+
+		size := l.size
+		if l.file == nil {
+			info, err := f.Stat()
+			if err != nil {
+				closeFn()
+				return nil, nil, err
+			}
+			size = info.Size()
+		}
+		files = append(files, io.LimitReader(f, size))
+	}
+	return files, closeFn, nil
+}
+
+// searchLogFiles returns the latest entries of the log files, oldest first, matching the query in chronological order.
+func searchLogFiles(files []io.Reader, q LogQuery) ([]LogEntry, error) {
+	limit := q.limit()
+	entries := []LogEntry{}
+	for _, f := range files {
+		err := readLogEntries(f, func(e LogEntry) {
+			if !q.Match(e) {
+				return
+			}
+			entries = append(entries, e)
+			if len(entries) > 2*limit {
+				entries = append(entries[:0], entries[len(entries)-limit:]...)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	return entries, nil
+}
+
+// readLogEntries calls fn with each entry of the log file; malformed lines are skipped.
+func readLogEntries(r io.Reader, fn func(LogEntry)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), 4*maxLogLineLength)
+	for scanner.Scan() {
+		var e LogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err == nil {
+			fn(e)
+		}
+	}
+	return scanner.Err()
+}
+
+// subscribe registers the channel for appended entries, and returns the latest entries matching the query;
+// the files are snapshot as the channel is registered, so no entry is missed or received twice.
+func (l *nodeLog) subscribe(c chan LogEntry, q LogQuery) ([]LogEntry, func(), error) {
+	l.mu.Lock()
+	files, closeFn, err := l.snapshot()
+	if err != nil {
+		l.mu.Unlock()
+		return nil, nil, err
+	}
+	l.subscribers[c] = struct{}{}
+	l.mu.Unlock()
+	defer closeFn()
+
+	unsubscribeFn := func() {
+		l.mu.Lock()
+		delete(l.subscribers, c)
+		l.mu.Unlock()
+	}
+
+	backlog, err := searchLogFiles(files, q)
+	if err != nil {
+		unsubscribeFn()
+		return nil, nil, err
+	}
+	return backlog, unsubscribeFn, nil
+}
+
+// writer returns a writer appending each line written to it as an entry of the stream; e.g. as stdout of a process.
+func (l *nodeLog) writer(stream string) *lineWriter {
+	return &lineWriter{log: l, stream: stream}
+}
+
+// lineWriter splits the output of a process into lines; lines exceeding the max line length are split.
+type lineWriter struct {
+	log    *nodeLog
+	stream string
+	buf    []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			if len(w.buf) < maxLogLineLength {
+				return len(p), nil
+			}
+			i = maxLogLineLength
+		}
+		line := strings.TrimRight(string(w.buf[:i]), "\r")
+		if i < len(w.buf) && w.buf[i] == '\n' {
+			i++
+		}
+		w.buf = w.buf[i:]
+
+		w.emit(line)
+	}
+}
+
+// flush appends the incomplete last line; once the process exited.
+func (w *lineWriter) flush() {
+	if len(w.buf) == 0 {
+		return
+	}
+	line := strings.TrimRight(string(w.buf), "\r")
+	w.buf = nil
+	w.emit(line)
+}
+
+// emit appends the line; failures to write the log file must not stall the process, so they are only logged.
+func (w *lineWriter) emit(line string) {
+	if err := w.log.append(w.stream, line); err != nil {
+		log.Err(err).Msgf("failed to write log file: %s", w.log.dir)
+	}
+}
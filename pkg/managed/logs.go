@@ -0,0 +1,224 @@
+package managed
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defaults of the log retention of managed nodes.
+const (
+	DefaultLogMaxSize  = 10 << 20 // bytes; the log file is rotated once it exceeds the size
+	DefaultLogMaxFiles = 5        // rotated log files retained besides the current log file
+	DefaultLogLimit    = 100      // entries returned by a search
+	MaxLogLimit        = 1000
+	maxLogLineLength   = 64 << 10 // longer lines are split into multiple entries
+)
+
+// LogLevel is the level of a log entry of geth.
+type LogLevel string
+
+const (
+	LogLevelTrace LogLevel = "trace"
+	LogLevelDebug LogLevel = "debug"
+	LogLevelInfo  LogLevel = "info"
+	LogLevelWarn  LogLevel = "warn"
+	LogLevelError LogLevel = "error"
+	LogLevelCrit  LogLevel = "crit"
+)
+
+var logLevels = []LogLevel{LogLevelTrace, LogLevelDebug, LogLevelInfo, LogLevelWarn, LogLevelError, LogLevelCrit}
+
+// logLevelAliases are the abbreviations of geth's JSON log format, and common spellings.
+var logLevelAliases = map[string]LogLevel{
+	"trce":    LogLevelTrace,
+	"dbug":    LogLevelDebug,
+	"warning": LogLevelWarn,
+	"eror":    LogLevelError,
+}
+
+// ParseLogLevel parses a log level; e.g. as set in the level query parameter.
+func ParseLogLevel(s string) (LogLevel, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if level, ok := logLevelAliases[s]; ok {
+		return level, nil
+	}
+	for _, level := range logLevels {
+		if LogLevel(s) == level {
+			return level, nil
+		}
+	}
+	return "", fmt.Errorf("unknown log level %q", s)
+}
+
+// severity returns the severity of the level; 0 if the level is unknown.
+func (l LogLevel) severity() int {
+	for i, level := range logLevels {
+		if l == level {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// LogEntry is a line of the output of a geth process; the level, message and key/values are parsed from geth's log format.
+type LogEntry struct {
+	Time    time.Time         `json:"time"`   // time the line was captured
+	Stream  string            `json:"stream"` // stdout or stderr
+	Level   LogLevel          `json:"level,omitempty"`
+	Message string            `json:"message"` // the line as is if it is not in geth's log format
+	Fields  map[string]string `json:"fields,omitempty"`
+}
+
+// terminalLine matches lines of geth's terminal log format, e.g.
+// INFO [10-19|12:34:56.789] Maximum peer count                       ETH=50 LES=0 total=50
+var terminalLine = regexp.MustCompile(`^(TRACE|DEBUG|INFO|WARN|ERROR|CRIT)\s*\[[^\]]*\]\s?(.*)$`)
+
+// fieldStart matches the start of a key/value pair of geth's logfmt context.
+var fieldStart = regexp.MustCompile(`\s[^\s="]+=`)
+
+// ParseLogLine parses the level, message and key/values of a line of geth's terminal or JSON (--log.json) log format;
+// lines of other formats are retained as the message.
+func ParseLogLine(line string) LogEntry {
+	if strings.HasPrefix(line, "{") {
+		if entry, ok := parseJSONLogLine(line); ok {
+			return entry
+		}
+	}
+
+	m := terminalLine.FindStringSubmatch(line)
+	if m == nil {
+		return LogEntry{Message: line}
+	}
+	entry := LogEntry{Level: LogLevel(strings.ToLower(m[1])), Message: strings.TrimSpace(m[2])}
+
+	// the message is padded; the context follows as key=value pairs, values are quoted if they contain spaces
+	rest := " " + m[2]
+	for _, loc := range fieldStart.FindAllStringIndex(rest, -1) {
+		if fields, ok := parseLogfmt(rest[loc[0]+1:]); ok {
+			entry.Message = strings.TrimSpace(rest[:loc[0]])
+			entry.Fields = fields
+			break
+		}
+	}
+	return entry
+}
+
+// parseLogfmt parses space separated key=value pairs; ok is false if the string is not entirely made of pairs.
+func parseLogfmt(s string) (map[string]string, bool) {
+	fields := map[string]string{}
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimLeft(s, " ") {
+		eq := strings.IndexByte(s, '=')
+		if eq <= 0 || strings.ContainsAny(s[:eq], " \"") {
+			return nil, false
+		}
+		key := s[:eq]
+		s = s[eq+1:]
+
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			quoted, err := strconv.QuotedPrefix(s)
+			if err != nil {
+				return nil, false
+			}
+			value, _ = strconv.Unquote(quoted)
+			s = s[len(quoted):]
+		} else if end := strings.IndexByte(s, ' '); end >= 0 {
+			value, s = s[:end], s[end:]
+		} else {
+			value, s = s, ""
+		}
+		if s != "" && s[0] != ' ' {
+			return nil, false
+		}
+		fields[key] = value
+	}
+	return fields, len(fields) > 0
+}
+
+// parseJSONLogLine parses a line of geth's JSON log format, e.g. {"lvl":"info","msg":"Starting Geth","t":"..."}.
+func parseJSONLogLine(line string) (LogEntry, bool) {
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &record); err != nil {
+		return LogEntry{}, false
+	}
+	msg, ok := record["msg"].(string)
+	if !ok {
+		return LogEntry{}, false
+	}
+
+	entry := LogEntry{Message: msg}
+	if lvl, ok := record["lvl"].(string); ok {
+		entry.Level, _ = ParseLogLevel(lvl)
+	}
+	for key, value := range record {
+		if key == "msg" || key == "lvl" || key == "t" {
+			continue
+		}
+		if entry.Fields == nil {
+			entry.Fields = map[string]string{}
+		}
+		if s, ok := value.(string); ok {
+			entry.Fields[key] = s
+		} else {
+			b, _ := json.Marshal(value)
+			entry.Fields[key] = string(b)
+		}
+	}
+	return entry, true
+}
+
+// LogQuery filters the log entries of a node.
+type LogQuery struct {
+	Level  LogLevel          // minimum level; entries without a level are excluded if set
+	Text   string            // case-insensitive substring of the message or a value
+	Fields map[string]string // exact values of keys
+	Since  time.Time
+	Until  time.Time
+	Limit  int // the latest matching entries are returned; defaults to DefaultLogLimit
+}
+
+// Match returns whether the entry matches the query.
+func (q LogQuery) Match(e LogEntry) bool {
+	if q.Level != "" && e.Level.severity() < q.Level.severity() {
+		return false
+	}
+	if !q.Since.IsZero() && e.Time.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && e.Time.After(q.Until) {
+		return false
+	}
+	for key, value := range q.Fields {
+		if v, ok := e.Fields[key]; !ok || v != value {
+			return false
+		}
+	}
+	if q.Text == "" {
+		return true
+	}
+
+	text := strings.ToLower(q.Text)
+	if strings.Contains(strings.ToLower(e.Message), text) {
+		return true
+	}
+	for _, value := range e.Fields {
+		if strings.Contains(strings.ToLower(value), text) {
+			return true
+		}
+	}
+	return false
+}
+
+func (q LogQuery) limit() int {
+	if q.Limit <= 0 {
+		return DefaultLogLimit
+	}
+	if q.Limit > MaxLogLimit {
+		return MaxLogLimit
+	}
+	return q.Limit
+}
@@ -0,0 +1,187 @@
+package managed
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseLogLine(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want LogEntry
+	}{
+		{
+			"terminal format with context",
+			"INFO [10-19|12:34:56.789] Maximum peer count                       ETH=50 LES=0 total=50",
+			LogEntry{Level: LogLevelInfo, Message: "Maximum peer count", Fields: map[string]string{"ETH": "50", "LES": "0", "total": "50"}},
+		},
+		{
+			"terminal format without context",
+			"WARN [10-19|12:34:56.789] Sanitizing cache to Go's GC limits",
+			LogEntry{Level: LogLevelWarn, Message: "Sanitizing cache to Go's GC limits"},
+		},
+		{
+			"quoted values",
+			`ERROR[10-19|12:34:56.789] Snapshot extension registration failed   peer=1a2b err="peer connected on snap without compatible eth support"`,
+			LogEntry{Level: LogLevelError, Message: "Snapshot extension registration failed", Fields: map[string]string{
+				"peer": "1a2b",
+				"err":  "peer connected on snap without compatible eth support",
+			}},
+		},
+		{
+			"message containing equal signs",
+			"DEBUG[10-19|12:34:56.789] Checking a=b in message",
+			LogEntry{Level: LogLevelDebug, Message: "Checking a=b in message"},
+		},
+		{
+			"json format",
+			`{"lvl":"eror","msg":"Failed to journal","err":"disk full","t":"2021-10-19T12:34:56Z","count":3}`,
+			LogEntry{Level: LogLevelError, Message: "Failed to journal", Fields: map[string]string{"err": "disk full", "count": "3"}},
+		},
+		{
+			"json format with known level",
+			`{"lvl":"warn","msg":"Low disk space"}`,
+			LogEntry{Level: LogLevelWarn, Message: "Low disk space"},
+		},
+		{
+			"other format",
+			"Fatal: Failed to register the Ethereum service: incompatible genesis",
+			LogEntry{Message: "Fatal: Failed to register the Ethereum service: incompatible genesis"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := assert.New(t)
+			is.Equal(tt.want, ParseLogLine(tt.line))
+		})
+	}
+}
+
+func Test_LogQueryMatch(t *testing.T) {
+	now := time.Now()
+	entry := LogEntry{Time: now, Level: LogLevelWarn, Message: "Dropping peer", Fields: map[string]string{"reason": "Too many peers"}}
+
+	tests := []struct {
+		name  string
+		query LogQuery
+		want  bool
+	}{
+		{"empty", LogQuery{}, true},
+		{"minimum level", LogQuery{Level: LogLevelInfo}, true},
+		{"higher level", LogQuery{Level: LogLevelError}, false},
+		{"text of message", LogQuery{Text: "dropping"}, true},
+		{"text of value", LogQuery{Text: "too many"}, true},
+		{"text not found", LogQuery{Text: "synced"}, false},
+		{"field", LogQuery{Fields: map[string]string{"reason": "Too many peers"}}, true},
+		{"field mismatch", LogQuery{Fields: map[string]string{"reason": "timeout"}}, false},
+		{"missing field", LogQuery{Fields: map[string]string{"peer": "1a2b"}}, false},
+		{"since", LogQuery{Since: now.Add(-time.Minute)}, true},
+		{"until", LogQuery{Until: now.Add(-time.Minute)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := assert.New(t)
+			is.Equal(tt.want, tt.query.Match(entry))
+		})
+	}
+
+	// entries without a level are excluded by a level filter
+	assert.False(t, LogQuery{Level: LogLevelTrace}.Match(LogEntry{Message: "banner"}))
+}
+
+func Test_NodeLogRotate(t *testing.T) {
+	is := assert.New(t)
+
+	l := newNodeLog(t.TempDir(), time.Now)
+	defer l.close()
+	l.setRetention(512, 2)
+
+	w := l.writer("stderr")
+	for i := 0; i < 50; i++ {
+		fmt.Fprintf(w, "INFO [10-19|12:34:56.789] Imported new chain segment               number=%d\n", i)
+	}
+
+	// only the current and the two rotated log files are retained
+	is.FileExists(l.path(2))
+	is.NoFileExists(l.path(3))
+	for _, generation := range []int{0, 1, 2} {
+		info, err := os.Stat(l.path(generation))
+		is.NoError(err)
+		is.LessOrEqual(info.Size(), int64(512))
+	}
+
+	entries, err := l.search(LogQuery{Limit: 3})
+	is.NoError(err)
+	is.Len(entries, 3)
+	is.Equal("49", entries[2].Fields["number"])
+	is.Equal("stderr", entries[2].Stream)
+
+	entries, err = l.search(LogQuery{Fields: map[string]string{"number": "0"}})
+	is.NoError(err)
+	is.Empty(entries, "rotated out")
+}
+
+func Test_NodeLogSnapshot(t *testing.T) {
+	is := assert.New(t)
+
+	l := newNodeLog(t.TempDir(), time.Now)
+	defer l.close()
+	l.setRetention(512, 2)
+
+	w := l.writer("stdout")
+	for i := 0; i < 3; i++ {
+		fmt.Fprintf(w, "entry %d\n", i)
+	}
+
+	c := make(chan LogEntry, 100)
+	backlog, unsub, err := l.subscribe(c, LogQuery{})
+	is.NoError(err)
+	defer unsub()
+	is.Len(backlog, 3)
+
+	// entries appended while the snapshot is scanned are not read, including those of the log rotated meanwhile
+	l.mu.Lock()
+	files, closeFn, err := l.snapshot()
+	l.mu.Unlock()
+	is.NoError(err)
+	defer closeFn()
+	for i := 3; i < 20; i++ {
+		fmt.Fprintf(w, "entry %d\n", i)
+	}
+	is.FileExists(l.path(1))
+
+	entries, err := searchLogFiles(files, LogQuery{})
+	is.NoError(err)
+	is.Len(entries, 3)
+	is.Equal("entry 2", entries[2].Message)
+	is.Len(c, 17)
+}
+
+func Test_LineWriter(t *testing.T) {
+	is := assert.New(t)
+
+	l := newNodeLog(t.TempDir(), time.Now)
+	defer l.close()
+	c := make(chan LogEntry, 10)
+	_, unsub, err := l.subscribe(c, LogQuery{})
+	is.NoError(err)
+	defer unsub()
+
+	w := l.writer("stdout")
+	fmt.Fprint(w, "first line\r\nsecond ")
+	is.Equal("first line", (<-c).Message)
+	is.Empty(c)
+
+	fmt.Fprint(w, "line\nincomplete")
+	is.Equal("second line", (<-c).Message)
+
+	w.flush()
+	is.Equal("incomplete", (<-c).Message)
+}
@@ -0,0 +1,65 @@
+package managed
+
+import (
+	"errors"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// Defaults of the process supervisor.
+const (
+	DefaultStopTimeout = 30 * time.Second // geth flushes its caches to disk on shutdown
+	DataDirName        = "nodes"          // subdir of the app dir; contains the data dirs of managed nodes
+	LogDirName         = "logs"           // subdir of the app dir; contains the log dirs of managed nodes
+	LogFilename        = "geth.log"       // output of the process as JSON lines of log entries; in the log dir of the node
+)
+
+var (
+	ErrNotManaged         = errors.New("node is not managed by zeth")
+	ErrBinaryNotInstalled = errors.New("geth binary is not installed")
+	ErrInvalidConfig      = errors.New("invalid geth config")
+	ErrGenesisRequired    = errors.New("data dir must be initialised with the genesis block of the network")
+	ErrGenesisInitialised = errors.New("data dir is already initialised with a genesis block")
+	ErrRunning            = errors.New("node is running")
+	ErrDataDirNotAllowed  = errors.New("data dir is set by zeth; data dirs of managed nodes are in the app dir")
+)
+
+// State is the state of the process of a managed node.
+type State string
+
+const (
+	StateStopped    State = "stopped"
+	StateRunning    State = "running"
+	StateRestarting State = "restarting" // waiting to be restarted after exiting
+	StateFailed     State = "failed"     // exited with an error and not restarted
+)
+
+// Status is the status of the process of a managed node.
+type Status struct {
+	NodeID    uuid.UUID  `json:"nodeId"`
+	State     State      `json:"state"`
+	PID       int        `json:"pid,omitempty"`
+	StartedAt *time.Time `json:"startedAt,omitempty"`
+	ExitedAt  *time.Time `json:"exitedAt,omitempty"`
+	ExitCode  *int       `json:"exitCode,omitempty"`
+	Restarts  int        `json:"restarts"` // consecutive restarts; reset once the process runs stable
+	Error     string     `json:"error,omitempty"`
+}
+
+// Running returns whether the process is running or about to be restarted.
+func (s Status) Running() bool {
+	return s.State == StateRunning || s.State == StateRestarting
+}
+
+// restartDelay returns the delay before the nth consecutive restart; doubling up to a minute.
+func restartDelay(base time.Duration, restarts int) time.Duration {
+	delay := base
+	for i := 1; i < restarts && delay < time.Minute; i++ {
+		delay *= 2
+	}
+	if delay > time.Minute {
+		delay = time.Minute
+	}
+	return delay
+}
@@ -0,0 +1,607 @@
+package managed
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/rs/zerolog/log"
+	uuid "github.com/satori/go.uuid"
+	"github.com/zees-dev/zeth/pkg/events"
+	"github.com/zees-dev/zeth/pkg/geth"
+	"github.com/zees-dev/zeth/pkg/geth/downloader"
+	"github.com/zees-dev/zeth/pkg/node"
+)
+
+// Default ports of managed nodes; the next free port is allocated for each node.
+const (
+	defaultHTTPPort = 8545
+	defaultWSPort   = 8546
+	defaultP2PPort  = 30303
+)
+
+// stableUptime is the uptime after which the consecutive restarts of a process are reset.
+const stableUptime = time.Minute
+
+// process is a supervised geth process, or the in-process dev chain of a dev node.
+type process struct {
+	cmd      *exec.Cmd
+	chain    *geth.DevChain
+	output   []*lineWriter // stdout and stderr of the process
+	status   Status
+	stopping bool
+	stop     chan struct{} // closed to cancel a pending restart
+	done     chan struct{} // closed once the process exited and is not restarted
+}
+
+// Service runs the geth processes of managed nodes, restarting them according to their restart policy.
+type Service struct {
+	nodes        node.NodeService
+	downloads    *downloader.Downloader
+	events       events.Publisher
+	dir          string // app dir
+	stopTimeout  time.Duration
+	restartDelay time.Duration
+	binary       func(ctx context.Context, version string) (string, error)
+	now          func() time.Time
+	// healthy returns an error if the running node does not serve requests; polled at the health interval on upgrades
+	healthy        func(ctx context.Context, n node.ZethNode) error
+	healthInterval time.Duration
+
+	// upgrades and installs run in the background until the processes are stopped on shutdown
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu       sync.Mutex
+	procs    map[uuid.UUID]*process
+	logs     map[uuid.UUID]*nodeLog
+	upgrades map[uuid.UUID]Upgrade // last upgrade of each node
+}
+
+func NewService(nodes node.NodeService, downloads *downloader.Downloader, publisher events.Publisher, appDir string) *Service {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Service{
+		nodes:          nodes,
+		downloads:      downloads,
+		events:         publisher,
+		dir:            appDir,
+		stopTimeout:    DefaultStopTimeout,
+		restartDelay:   time.Second,
+		now:            time.Now,
+		healthInterval: time.Second,
+		ctx:            ctx,
+		cancel:         cancel,
+		procs:          make(map[uuid.UUID]*process),
+		logs:           make(map[uuid.UUID]*nodeLog),
+		upgrades:       make(map[uuid.UUID]Upgrade),
+	}
+	s.binary = s.binaryPath
+	s.healthy = s.rpcHealthy
+	return s
+}
+
+// binaryPath returns the path of the geth binary of the version; the binary of the bundled geth version is downloaded if missing,
+// until the context is cancelled.
+func (s *Service) binaryPath(ctx context.Context, version string) (string, error) {
+	dir := s.binDir()
+	path := filepath.Join(dir, downloader.GethBinaryFilename(runtime.GOOS, version))
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+	if version != params.Version {
+		return "", fmt.Errorf("%w: v%s", ErrBinaryNotInstalled, version)
+	}
+
+	log.Info().Msgf("Downloading Geth binary for v%s...", version)
+	if _, err := s.downloads.DownloadGethBinary(ctx, dir, version); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// Create registers a managed node; ports which are not set are allocated. The data dir of the node is a dir of the node in the app dir.
+// The geth config is written to the data dir and the node's RPC endpoints are set to the endpoints served by the process.
+func (s *Service) Create(ctx context.Context, name string, cfg node.ManagedConfig) (node.ZethNode, error) {
+	if err := cfg.Validate(); err != nil {
+		return node.ZethNode{}, err
+	}
+	// data dirs are deleted when nodes are purged; other dirs are not managed by zeth
+	if cfg.DataDir != "" {
+		return node.ZethNode{}, ErrDataDirNotAllowed
+	}
+	if cfg.Version == "" {
+		cfg.Version = params.Version
+	}
+	if cfg.RestartPolicy == "" {
+		cfg.RestartPolicy = node.RestartOnFailure
+	}
+	err := s.allocatePorts(ctx,
+		portRequest{&cfg.HTTPPort, defaultHTTPPort, 2},
+		portRequest{&cfg.WSPort, defaultWSPort, 2},
+		portRequest{&cfg.P2PPort, defaultP2PPort, 1},
+	)
+	if err != nil {
+		return node.ZethNode{}, err
+	}
+
+	n, err := s.nodes.Create(ctx, node.ZethNode{
+		Name:      name,
+		Enabled:   true,
+		DateAdded: s.now().UTC(),
+		Managed:   &cfg,
+	})
+	if err != nil {
+		return n, err
+	}
+
+	if err := s.writeInitialConfig(ctx, &n); err != nil {
+		// the node is unusable without its config
+		if err := s.nodes.Delete(ctx, n.ID); err != nil {
+			log.Err(err).Msgf("failed to remove managed node: %s", n.ID)
+		}
+		os.RemoveAll(s.dataDir(n.ID))
+		return node.ZethNode{}, err
+	}
+	return n, nil
+}
+
+// writeInitialConfig writes the default geth config to the data dir of the created node, and stores the RPC endpoints it serves.
+func (s *Service) writeInitialConfig(ctx context.Context, n *node.ZethNode) error {
+	// geth resolves relative paths against its working directory
+	dataDir, err := filepath.Abs(s.dataDir(n.ID))
+	if err != nil {
+		return err
+	}
+	n.Managed.DataDir = dataDir
+
+	gethCfg := DefaultConfig(*n.Managed)
+	if err := geth.WriteConfigFile(gethCfg, ConfigPath(*n.Managed)); err != nil {
+		return err
+	}
+
+	n.RPC = rpcEndpoints(gethCfg, node.RPC{Default: node.DefaultHTTPRPC})
+	return s.nodes.Update(ctx, n.ID, *n)
+}
+
+// dataDir returns the data dir of the managed node.
+func (s *Service) dataDir(id uuid.UUID) string {
+	return filepath.Join(s.dir, DataDirName, id.String())
+}
+
+// ConfigPath returns the path of the geth config file of the managed node.
+func ConfigPath(cfg node.ManagedConfig) string {
+	return filepath.Join(cfg.DataDir, geth.ConfigFilename)
+}
+
+// portRequest is a port to allocate if not set; the next port from the base, in steps, is allocated.
+type portRequest struct {
+	port       *int
+	base, step int
+}
+
+// allocatePorts sets the ports which are not set to the next ports not used by other nodes run by zeth nor in use on the host.
+func (s *Service) allocatePorts(ctx context.Context, requests ...portRequest) error {
+	nodes, err := s.nodes.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	used := usedPorts(nodes)
+	for _, r := range requests {
+		used[*r.port] = true
+	}
+
+	for _, r := range requests {
+		if *r.port != 0 {
+			continue
+		}
+		for port := r.base; port <= 65535 && *r.port == 0; port += r.step {
+			if !used[port] && portAvailable(port) {
+				used[port] = true
+				*r.port = port
+			}
+		}
+		if *r.port == 0 {
+			return fmt.Errorf("no port available from %d", r.base)
+		}
+	}
+	return nil
+}
+
+// usedPorts returns the ports of the nodes run by zeth.
+func usedPorts(nodes []node.ZethNode) map[int]bool {
+	used := map[int]bool{}
+	for _, n := range nodes {
+		if n.Managed != nil {
+			used[n.Managed.HTTPPort] = true
+			used[n.Managed.WSPort] = true
+			used[n.Managed.P2PPort] = true
+		}
+		if n.Dev != nil {
+			used[n.Dev.HTTPPort] = true
+			used[n.Dev.WSPort] = true
+		}
+	}
+	delete(used, 0)
+	return used
+}
+
+func portAvailable(port int) bool {
+	l, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		return false
+	}
+	l.Close()
+	return true
+}
+
+// Start starts the process of the managed node, or the dev chain of the dev node; a no-op if it is already running.
+// The binary of the bundled geth version is downloaded if missing; request handlers check the binary with RequireBinary first.
+func (s *Service) Start(ctx context.Context, id uuid.UUID) (Status, error) {
+	n, err := s.nodes.Get(ctx, id)
+	if err != nil {
+		return Status{}, err
+	}
+	if n.Managed == nil && n.Dev == nil {
+		return Status{}, ErrNotManaged
+	}
+
+	if status := s.Status(id); status.Running() {
+		return status, nil
+	}
+	if n.Dev != nil {
+		return s.startDev(*n)
+	}
+
+	if err := checkGenesis(*n.Managed); err != nil {
+		return s.fail(id, err), err
+	}
+	binary, err := s.binary(ctx, n.Managed.Version)
+	if err != nil {
+		return s.fail(id, err), err
+	}
+
+	p := &process{status: Status{NodeID: id}, stop: make(chan struct{}), done: make(chan struct{})}
+	s.mu.Lock()
+	if prev, ok := s.procs[id]; ok && prev.status.Running() {
+		s.mu.Unlock()
+		return s.Status(id), nil
+	}
+	s.procs[id] = p
+	err = s.spawn(p, binary, *n)
+	status := p.status
+	s.mu.Unlock()
+	if err != nil {
+		close(p.done)
+		return s.fail(id, err), err
+	}
+
+	go s.supervise(p, binary, *n)
+	return status, nil
+}
+
+// checkGenesis returns ErrGenesisRequired if the network of the node requires a genesis block the data dir is not initialised with;
+// geth would initialise the data dir with the mainnet genesis block.
+func checkGenesis(cfg node.ManagedConfig) error {
+	if cfg.Network == nil || !cfg.Network.RequiresGenesis() {
+		return nil
+	}
+	if initialised(cfg) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrGenesisRequired, cfg.Network.Name)
+}
+
+// initialised returns whether the data dir of the node contains a chain database; initialised with a genesis block.
+func initialised(cfg node.ManagedConfig) bool {
+	_, err := os.Stat(filepath.Join(cfg.DataDir, "geth", "chaindata"))
+	return err == nil
+}
+
+// fail records the process of the node as failed to start.
+func (s *Service) fail(id uuid.UUID, err error) Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.procs[id]
+	if !ok {
+		p = &process{status: Status{NodeID: id}, stop: make(chan struct{}), done: make(chan struct{})}
+		close(p.done)
+		s.procs[id] = p
+	}
+	p.status.State = StateFailed
+	p.status.Error = err.Error()
+	return p.status
+}
+
+// spawn starts the geth process of the node with the config in its data dir; the output is captured in the log of the node.
+// note: must be called with the lock held
+func (s *Service) spawn(p *process, binary string, n node.ZethNode) error {
+	cfg := *n.Managed
+	l := s.nodeLog(n.ID)
+	l.setRetention(cfg.LogMaxSize, cfg.LogMaxFiles)
+	stdout, stderr := l.writer("stdout"), l.writer("stderr")
+
+	cmd := exec.Command(binary, append([]string{"--config", ConfigPath(cfg)}, cfg.Args...)...)
+	cmd.Dir = cfg.DataDir
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	now := s.now().UTC()
+	p.cmd = cmd
+	p.output = []*lineWriter{stdout, stderr}
+	p.status = Status{NodeID: n.ID, State: StateRunning, PID: cmd.Process.Pid, StartedAt: &now, Restarts: p.status.Restarts}
+
+	s.events.Publish(events.NodeStarted, n.ID.String(), p.status)
+	return nil
+}
+
+// supervise waits for the process to exit and restarts it according to the restart policy of the node.
+func (s *Service) supervise(p *process, binary string, n node.ZethNode) {
+	defer close(p.done)
+
+	for {
+		err := p.cmd.Wait()
+		for _, w := range p.output {
+			w.flush()
+		}
+
+		s.mu.Lock()
+		now := s.now().UTC()
+		exitCode := p.cmd.ProcessState.ExitCode()
+		p.status.ExitedAt = &now
+		p.status.ExitCode = &exitCode
+		p.status.PID = 0
+		p.status.Error = ""
+		if err != nil {
+			p.status.Error = err.Error()
+		}
+
+		if p.stopping {
+			p.status.State = StateStopped
+			status := p.status
+			s.mu.Unlock()
+			s.events.Publish(events.NodeStopped, n.ID.String(), status)
+			return
+		}
+
+		if p.status.StartedAt != nil && now.Sub(*p.status.StartedAt) >= stableUptime {
+			p.status.Restarts = 0
+		}
+		restart := n.Managed.Restart(err != nil, p.status.Restarts)
+		switch {
+		case restart:
+			p.status.State = StateRestarting
+			p.status.Restarts++
+		case err != nil:
+			p.status.State = StateFailed
+		default:
+			p.status.State = StateStopped
+		}
+		status := p.status
+		s.mu.Unlock()
+
+		log.Warn().Err(err).Msgf("geth process of node %s exited", n.ID)
+		s.events.Publish(events.NodeExited, n.ID.String(), status)
+		if !restart {
+			return
+		}
+
+		select {
+		case <-time.After(restartDelay(s.restartDelay, status.Restarts)):
+		case <-p.stop:
+			s.mu.Lock()
+			p.status.State = StateStopped
+			status = p.status
+			s.mu.Unlock()
+			s.events.Publish(events.NodeStopped, n.ID.String(), status)
+			return
+		}
+
+		s.mu.Lock()
+		if p.stopping {
+			p.status.State = StateStopped
+			s.mu.Unlock()
+			return
+		}
+		err = s.spawn(p, binary, n)
+		if err != nil {
+			p.status.State = StateFailed
+			p.status.Error = err.Error()
+		}
+		s.mu.Unlock()
+		if err != nil {
+			log.Err(err).Msgf("failed to restart geth process of node %s", n.ID)
+			return
+		}
+	}
+}
+
+// Stop stops the process of the managed node gracefully; the process is killed if it does not exit within the stop timeout or
+// before the context is cancelled. The dev chain of a dev node is closed. A no-op if the process is not running.
+func (s *Service) Stop(ctx context.Context, id uuid.UUID) (Status, error) {
+	s.mu.Lock()
+	p, ok := s.procs[id]
+	if !ok || !p.status.Running() {
+		s.mu.Unlock()
+		return s.Status(id), nil
+	}
+	first := !p.stopping
+	if first {
+		p.stopping = true
+		close(p.stop)
+	}
+	cmd := p.cmd
+	s.mu.Unlock()
+
+	if cmd == nil {
+		if first {
+			s.stopDev(p)
+		}
+		<-p.done
+		return s.Status(id), nil
+	}
+
+	// geth shuts down gracefully on interrupt; not supported on windows
+	if err := cmd.Process.Signal(os.Interrupt); err != nil {
+		cmd.Process.Kill()
+	}
+
+	timer := time.NewTimer(s.stopTimeout)
+	defer timer.Stop()
+	select {
+	case <-p.done:
+	case <-timer.C:
+		log.Warn().Msgf("geth process of node %s did not stop within %s; killing it", id, s.stopTimeout)
+		cmd.Process.Kill()
+		<-p.done
+	case <-ctx.Done():
+		cmd.Process.Kill()
+		<-p.done
+	}
+
+	return s.Status(id), nil
+}
+
+// Restart stops and starts the process of the managed node.
+func (s *Service) Restart(ctx context.Context, id uuid.UUID) (Status, error) {
+	if _, err := s.Stop(ctx, id); err != nil {
+		return Status{}, err
+	}
+	return s.Start(ctx, id)
+}
+
+// Status returns the status of the process of the node.
+func (s *Service) Status(id uuid.UUID) Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.procs[id]
+	if !ok {
+		return Status{NodeID: id, State: StateStopped}
+	}
+	return p.status
+}
+
+// StartAll starts the enabled managed nodes which are set to start with zeth, and the enabled dev nodes;
+// the remaining nodes are not started once the context is cancelled.
+func (s *Service) StartAll(ctx context.Context) error {
+	nodes, err := s.nodes.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, n := range nodes {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		autoStart := (n.Managed != nil && n.Managed.AutoStart) || n.Dev != nil
+		if !autoStart || !n.Enabled {
+			continue
+		}
+		if _, err := s.Start(ctx, n.ID); err != nil {
+			log.Err(err).Msgf("failed to start managed node: %s", n.ID)
+		}
+	}
+	return nil
+}
+
+// StopAll stops all running processes concurrently; on shutdown of zeth. Upgrades and installs running in the background are
+// cancelled first; upgraded nodes are rolled back before they are stopped.
+func (s *Service) StopAll(ctx context.Context) {
+	s.mu.Lock()
+	s.cancel()
+	s.mu.Unlock()
+	s.wg.Wait()
+
+	s.mu.Lock()
+	ids := make([]uuid.UUID, 0, len(s.procs))
+	for id, p := range s.procs {
+		if p.status.Running() {
+			ids = append(ids, id)
+		}
+	}
+	s.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, id := range ids {
+		wg.Add(1)
+		go func(id uuid.UUID) {
+			defer wg.Done()
+			s.Stop(ctx, id)
+		}(id)
+	}
+	wg.Wait()
+}
+
+// Remove stops the process of the managed node and forgets it, deleting its logs; the data dir is deleted if purge is set.
+func (s *Service) Remove(ctx context.Context, n node.ZethNode, purge bool) error {
+	if n.Managed == nil && n.Dev == nil {
+		return nil
+	}
+	if _, err := s.Stop(ctx, n.ID); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	delete(s.procs, n.ID)
+	if l, ok := s.logs[n.ID]; ok {
+		l.close()
+		delete(s.logs, n.ID)
+	}
+	s.mu.Unlock()
+
+	if err := os.RemoveAll(s.logDir(n.ID)); err != nil {
+		return err
+	}
+
+	// only the data dir zeth created for the node is removed; the stored data dir is not trusted
+	if purge && n.Managed != nil {
+		return os.RemoveAll(s.dataDir(n.ID))
+	}
+	return nil
+}
+
+// logDir returns the dir of the log files of the node.
+func (s *Service) logDir(id uuid.UUID) string {
+	return filepath.Join(s.dir, LogDirName, id.String())
+}
+
+// nodeLog returns the log of the node; retained across restarts of the process to keep its subscribers.
+// note: must be called with the lock held
+func (s *Service) nodeLog(id uuid.UUID) *nodeLog {
+	l, ok := s.logs[id]
+	if !ok {
+		l = newNodeLog(s.logDir(id), s.now)
+		s.logs[id] = l
+	}
+	return l
+}
+
+// Logs returns the latest log entries of the node matching the query, in chronological order.
+func (s *Service) Logs(id uuid.UUID, q LogQuery) ([]LogEntry, error) {
+	s.mu.Lock()
+	l := s.nodeLog(id)
+	s.mu.Unlock()
+	return l.search(q)
+}
+
+// SubscribeLogs registers the channel for new log entries of the node, and returns the latest entries matching the query;
+// entries are dropped if the channel is full.
+func (s *Service) SubscribeLogs(id uuid.UUID, c chan LogEntry, q LogQuery) ([]LogEntry, func(), error) {
+	s.mu.Lock()
+	l := s.nodeLog(id)
+	s.mu.Unlock()
+	return l.subscribe(c, q)
+}
@@ -0,0 +1,321 @@
+package managed
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/zees-dev/zeth/pkg/datastore/badgerdbtest"
+	"github.com/zees-dev/zeth/pkg/events"
+	"github.com/zees-dev/zeth/pkg/geth"
+	"github.com/zees-dev/zeth/pkg/geth/downloader"
+	"github.com/zees-dev/zeth/pkg/node"
+)
+
+// newTestService returns a service running the script in place of the geth binary.
+func newTestService(t *testing.T, script string) (*Service, *events.Bus) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test binaries are shell scripts")
+	}
+
+	store, cleanup := badgerdbtest.MustNewTestBadgerDB()
+	t.Cleanup(func() { cleanup() })
+
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "geth")
+	if err := ioutil.WriteFile(binary, []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	bus := events.NewBus(events.DefaultBacklog)
+	s := NewService(node.NewService(store, bus), downloader.New(bus), bus, dir)
+	s.binary = func(context.Context, string) (string, error) { return binary, nil }
+	s.restartDelay = time.Millisecond
+	s.stopTimeout = 5 * time.Second
+	return s, bus
+}
+
+func waitForState(t *testing.T, s *Service, n node.ZethNode, state State) Status {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if status := s.Status(n.ID); status.State == state {
+			return status
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("node did not reach state %s; status: %+v", state, s.Status(n.ID))
+	return Status{}
+}
+
+func Test_Create(t *testing.T) {
+	is := assert.New(t)
+
+	s, _ := newTestService(t, "exec sleep 30")
+	ctx := context.Background()
+
+	n, err := s.Create(ctx, "first", node.ManagedConfig{HTTPPort: 18545})
+	is.NoError(err)
+	is.Equal(18545, n.Managed.HTTPPort)
+	is.NotZero(n.Managed.WSPort)
+	is.NotZero(n.Managed.P2PPort)
+	is.Equal(node.RestartOnFailure, n.Managed.RestartPolicy)
+	is.True(filepath.IsAbs(n.Managed.DataDir))
+	is.Equal("http://127.0.0.1:18545", n.RPC.HTTP)
+	is.Equal(filepath.Join(n.Managed.DataDir, "geth.ipc"), n.RPC.IPC)
+	is.FileExists(filepath.Join(n.Managed.DataDir, geth.ConfigFilename))
+
+	stored, err := s.nodes.Get(ctx, n.ID)
+	is.NoError(err)
+	is.Equal(n.RPC, stored.RPC)
+	is.Equal(n.Managed.DataDir, stored.Managed.DataDir)
+
+	// ports of other managed nodes are not reused
+	other, err := s.Create(ctx, "second", node.ManagedConfig{})
+	is.NoError(err)
+	for _, port := range []int{n.Managed.HTTPPort, n.Managed.WSPort, n.Managed.P2PPort} {
+		is.NotContains([]int{other.Managed.HTTPPort, other.Managed.WSPort, other.Managed.P2PPort}, port)
+	}
+
+	_, err = s.Create(ctx, "invalid", node.ManagedConfig{RestartPolicy: "sometimes"})
+	is.Error(err)
+	_, err = s.Create(ctx, "invalid", node.ManagedConfig{Version: "../../tmp/evil"})
+	is.ErrorIs(err, ErrInvalidVersion)
+	_, err = s.Create(ctx, "invalid", node.ManagedConfig{DataDir: t.TempDir()})
+	is.ErrorIs(err, ErrDataDirNotAllowed)
+
+	// the node is not registered if its config can not be written
+	is.NoError(os.RemoveAll(filepath.Join(s.dir, DataDirName)))
+	is.NoError(ioutil.WriteFile(filepath.Join(s.dir, DataDirName), nil, 0644))
+	_, err = s.Create(ctx, "unwritable", node.ManagedConfig{})
+	is.Error(err)
+	nodes, err := s.nodes.GetAll(ctx)
+	is.NoError(err)
+	is.Len(nodes, 2)
+}
+
+func Test_StartStop(t *testing.T) {
+	is := assert.New(t)
+
+	s, bus := newTestService(t, "exec sleep 30")
+	ctx := context.Background()
+
+	c := make(chan events.Event, 10)
+	_, unsubscribe := bus.Subscribe(c, 0, []string{"node.started", "node.stopped", "node.exited"})
+	defer unsubscribe()
+
+	n, err := s.Create(ctx, "test", node.ManagedConfig{})
+	is.NoError(err)
+
+	status, err := s.Start(ctx, n.ID)
+	is.NoError(err)
+	is.Equal(StateRunning, status.State)
+	is.NotZero(status.PID)
+
+	// starting a running node is a no-op
+	again, err := s.Start(ctx, n.ID)
+	is.NoError(err)
+	is.Equal(status.PID, again.PID)
+
+	status, err = s.Stop(ctx, n.ID)
+	is.NoError(err)
+	is.Equal(StateStopped, status.State)
+	is.NotNil(status.ExitedAt)
+
+	types := []events.Type{}
+	for len(c) > 0 {
+		types = append(types, (<-c).Type)
+	}
+	is.Equal([]events.Type{events.NodeStarted, events.NodeStopped}, types)
+
+	// only the data dir of the node in the app dir is purged
+	other := t.TempDir()
+	dataDir := n.Managed.DataDir
+	n.Managed.DataDir = other
+	is.NoError(s.Remove(ctx, n, true))
+	_, err = os.Stat(dataDir)
+	is.True(os.IsNotExist(err))
+	is.DirExists(other)
+
+	// nodes which are not managed can not be started
+	external, err := s.nodes.Create(ctx, node.ZethNode{Name: "external"})
+	is.NoError(err)
+	_, err = s.Start(ctx, external.ID)
+	is.ErrorIs(err, ErrNotManaged)
+}
+
+func Test_RestartOnFailure(t *testing.T) {
+	is := assert.New(t)
+
+	s, _ := newTestService(t, "exit 1")
+	ctx := context.Background()
+
+	n, err := s.Create(ctx, "crashing", node.ManagedConfig{RestartPolicy: node.RestartOnFailure, MaxRestarts: 2})
+	is.NoError(err)
+
+	_, err = s.Start(ctx, n.ID)
+	is.NoError(err)
+
+	status := waitForState(t, s, n, StateFailed)
+	is.Equal(2, status.Restarts)
+	is.Equal(1, *status.ExitCode)
+}
+
+func Test_StopAll(t *testing.T) {
+	is := assert.New(t)
+
+	// the process ignores interrupts; it is killed once the stop timeout expires
+	s, _ := newTestService(t, "trap '' INT\nwhile true; do sleep 1; done")
+	s.stopTimeout = 100 * time.Millisecond
+	ctx := context.Background()
+
+	n, err := s.Create(ctx, "stubborn", node.ManagedConfig{RestartPolicy: node.RestartAlways})
+	is.NoError(err)
+	_, err = s.Start(ctx, n.ID)
+	is.NoError(err)
+
+	s.StopAll(ctx)
+	is.Equal(StateStopped, s.Status(n.ID).State)
+}
+
+func Test_Logs(t *testing.T) {
+	is := assert.New(t)
+
+	s, _ := newTestService(t, `echo 'INFO [10-19|12:34:56.789] Starting Geth on Ethereum mainnet...' >&2
+echo 'WARN [10-19|12:34:56.790] Sanitizing cache to Go GC limits         provided=4096 updated=2618' >&2
+printf 'exiting'`)
+	ctx := context.Background()
+
+	n, err := s.Create(ctx, "logging", node.ManagedConfig{RestartPolicy: node.RestartNever})
+	is.NoError(err)
+
+	c := make(chan LogEntry, 10)
+	backlog, unsub, err := s.SubscribeLogs(n.ID, c, LogQuery{})
+	is.NoError(err)
+	defer unsub()
+	is.Empty(backlog)
+
+	_, err = s.Start(ctx, n.ID)
+	is.NoError(err)
+	waitForState(t, s, n, StateStopped)
+
+	entries, err := s.Logs(n.ID, LogQuery{})
+	is.NoError(err)
+	is.Len(entries, 3)
+	is.Len(c, 3)
+	is.Equal("stdout", entries[2].Stream)
+	is.Equal("exiting", entries[2].Message, "incomplete last line is captured")
+
+	entries, err = s.Logs(n.ID, LogQuery{Level: LogLevelWarn})
+	is.NoError(err)
+	is.Len(entries, 1)
+	is.Equal("stderr", entries[0].Stream)
+	is.Equal("Sanitizing cache to Go GC limits", entries[0].Message)
+	is.Equal(map[string]string{"provided": "4096", "updated": "2618"}, entries[0].Fields)
+
+	// logs are deleted with the node
+	is.DirExists(s.logDir(n.ID))
+	is.NoError(s.Remove(ctx, n, false))
+	is.NoDirExists(s.logDir(n.ID))
+}
+
+func Test_DevNode(t *testing.T) {
+	is := assert.New(t)
+
+	s, bus := newTestService(t, "exit 1")
+	ctx := context.Background()
+
+	n, err := s.CreateDev(ctx, "dev", node.DevChainConfig{})
+	is.NoError(err)
+	is.True(n.IsDev)
+	is.Len(n.Dev.Accounts, node.DefaultDevAccounts)
+	is.Equal(fmt.Sprintf("http://127.0.0.1:%d", n.Dev.HTTPPort), n.RPC.HTTP)
+
+	c := make(chan events.Event, 10)
+	_, unsub := bus.Subscribe(c, 0, []string{string(events.NodeStarted), string(events.NodeStopped)})
+	defer unsub()
+
+	status, err := s.Start(ctx, n.ID)
+	is.NoError(err)
+	is.Equal(StateRunning, status.State)
+	is.Equal(events.NodeStarted, (<-c).Type)
+
+	// the dev chain is served on the node's RPC endpoint, with the accounts prefunded
+	client, err := ethclient.DialContext(ctx, n.RPC.HTTP)
+	is.NoError(err)
+	balance, err := client.BalanceAt(ctx, n.Dev.Accounts[1].Address, nil)
+	client.Close()
+	is.NoError(err)
+	is.Equal(node.DefaultDevBalance, balance)
+
+	status, err = s.Stop(ctx, n.ID)
+	is.NoError(err)
+	is.Equal(StateStopped, status.State)
+	is.Equal(events.NodeStopped, (<-c).Type)
+
+	// a stopped dev chain starts over from its genesis block
+	status, err = s.Restart(ctx, n.ID)
+	is.NoError(err)
+	is.Equal(StateRunning, status.State)
+
+	is.NoError(s.Remove(ctx, n, true))
+	is.Equal(StateStopped, s.Status(n.ID).State)
+}
+
+func Test_InitGenesis(t *testing.T) {
+	is := assert.New(t)
+
+	// geth init --datadir <dir> <genesis.json> creates the chain database
+	s, _ := newTestService(t, `if [ "$1" = init ]; then mkdir -p "$3/geth/chaindata" && cp "$4" "$3/initialised.json"; exit 0; fi
+exec sleep 30`)
+	ctx := context.Background()
+
+	n, err := s.Create(ctx, "private", node.ManagedConfig{})
+	is.NoError(err)
+	defer s.StopAll(ctx)
+
+	signer := common.HexToAddress("0x1")
+	genesis, err := geth.GenesisSpec{ChainID: 1337, Consensus: geth.ConsensusDev, Signers: []common.Address{signer}}.Build()
+	is.NoError(err)
+
+	n, err = s.InitGenesis(ctx, n.ID, genesis)
+	is.NoError(err)
+	is.FileExists(filepath.Join(n.Managed.DataDir, "initialised.json"))
+	is.Equal(uint64(1337), n.Managed.Network.NetworkID)
+	is.Equal(genesis.ToBlock(nil).Hash(), n.Managed.Network.GenesisHash)
+
+	cfg, err := s.Config(ctx, n.ID)
+	is.NoError(err)
+	is.Equal(uint64(1337), cfg.Config.Eth.NetworkId)
+	is.Empty(cfg.Config.Node.P2P.BootstrapNodes)
+	is.Empty(cfg.Config.Eth.EthDiscoveryURLs)
+
+	_, err = s.InitGenesis(ctx, n.ID, genesis)
+	is.ErrorIs(err, ErrGenesisInitialised)
+
+	// the initialised node starts with the network of the genesis
+	_, err = s.Start(ctx, n.ID)
+	is.NoError(err)
+
+	other, err := s.Create(ctx, "running", node.ManagedConfig{})
+	is.NoError(err)
+	_, err = s.Start(ctx, other.ID)
+	is.NoError(err)
+	_, err = s.InitGenesis(ctx, other.ID, genesis)
+	is.ErrorIs(err, ErrRunning)
+}
+
+func Test_RestartDelay(t *testing.T) {
+	is := assert.New(t)
+
+	is.Equal(time.Second, restartDelay(time.Second, 1))
+	is.Equal(4*time.Second, restartDelay(time.Second, 3))
+	is.Equal(time.Minute, restartDelay(time.Second, 20))
+}
@@ -0,0 +1,116 @@
+package network
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	uuid "github.com/satori/go.uuid"
+	"github.com/zees-dev/zeth/pkg/managed"
+	"github.com/zees-dev/zeth/pkg/node"
+)
+
+// Defaults of private networks.
+const (
+	DefaultChainID = 1337
+	DefaultPeriod  = 5 // seconds between blocks
+	MaxNodes       = 16
+)
+
+// Config configures a private network of managed geth nodes; each node is a clique signer.
+type Config struct {
+	Nodes    int    `json:"nodes"`    // number of nodes
+	Version  string `json:"version"`  // geth version of the nodes; defaults to the bundled geth version
+	ChainID  uint64 `json:"chainId"`  // chain and network id; defaults to DefaultChainID
+	Period   uint64 `json:"period"`   // seconds between blocks; defaults to DefaultPeriod
+	GasLimit uint64 `json:"gasLimit"` // block gas limit; defaults to geth.DefaultGenesisGasLimit
+	Accounts int    `json:"accounts"` // number of prefunded accounts; defaults to node.DefaultDevAccounts
+	// Balance is the initial balance in wei of each prefunded account; defaults to node.DefaultDevBalance
+	Balance *big.Int `json:"balance"`
+}
+
+// Validate returns an error if the config is invalid; zero values are defaulted.
+func (cfg Config) Validate() error {
+	if cfg.Nodes < 1 || cfg.Nodes > MaxNodes {
+		return fmt.Errorf("nodes must be between 1 and %d", MaxNodes)
+	}
+	if cfg.Accounts < 0 || cfg.Accounts > node.MaxDevAccounts {
+		return fmt.Errorf("accounts must be between 0 and %d", node.MaxDevAccounts)
+	}
+	if cfg.Balance != nil && cfg.Balance.Sign() < 0 {
+		return fmt.Errorf("balance must not be negative")
+	}
+	return nil
+}
+
+// Member is a managed node of a private network.
+type Member struct {
+	NodeID uuid.UUID      `json:"nodeId"`
+	Enode  string         `json:"enode"`  // the node is statically peered with all other members
+	Signer common.Address `json:"signer"` // clique signer; its key is in the keystore of the data dir of the node
+}
+
+// State is the state of creating a network.
+type State string
+
+const (
+	StateCreating State = "creating" // the members are being created and started
+	StateCreated  State = "created"
+	StateFailed   State = "failed" // the members were removed; the error is set
+)
+
+// Network is a private network of managed geth nodes sharing a genesis block.
+type Network struct {
+	ID          uuid.UUID         `json:"id"`
+	Name        string            `json:"name"`
+	ChainID     uint64            `json:"chainId"`
+	GenesisHash common.Hash       `json:"genesisHash"`
+	Members     []Member          `json:"members"`
+	Accounts    []node.DevAccount `json:"accounts"` // prefunded in the genesis block
+	State       State             `json:"state"`
+	Error       string            `json:"error,omitempty"` // failure to create the network
+	DateAdded   time.Time         `json:"dateAdded"`
+}
+
+// redacted returns the network without the private keys of its accounts; events are delivered to all subscribers.
+func (n Network) redacted() Network {
+	accounts := make([]node.DevAccount, len(n.Accounts))
+	for i, account := range n.Accounts {
+		accounts[i] = node.DevAccount{Address: account.Address, Balance: account.Balance}
+	}
+	n.Accounts = accounts
+	return n
+}
+
+// Head is the head block of a node.
+type Head struct {
+	Number uint64      `json:"number"`
+	Hash   common.Hash `json:"hash"`
+}
+
+// MemberStatus is the status of a member of a network as served by its RPC endpoint.
+type MemberStatus struct {
+	NodeID  uuid.UUID      `json:"nodeId"`
+	Name    string         `json:"name"`
+	Process managed.Status `json:"process"`
+	Peers   int            `json:"peers"`
+	Head    *Head          `json:"head,omitempty"`
+	Error   string         `json:"error,omitempty"` // failure to query the RPC endpoint of the node
+}
+
+// Fork is a branch of the chain at the common block; the members agreeing on the hash of the block.
+type Fork struct {
+	Hash    common.Hash `json:"hash"`
+	Members []uuid.UUID `json:"members"`
+}
+
+// Status is the status of a network; the chain forked if the members disagree on the hash of the common block.
+type Status struct {
+	Network
+	Nodes []MemberStatus `json:"nodes"`
+	// CommonBlock is the highest block reached by all reachable members
+	CommonBlock uint64 `json:"commonBlock"`
+	Forks       []Fork `json:"forks"`
+	Forked      bool   `json:"forked"`
+}
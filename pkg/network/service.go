@@ -0,0 +1,542 @@
+package network
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/rs/zerolog/log"
+	uuid "github.com/satori/go.uuid"
+	"github.com/zees-dev/zeth/pkg/datastore"
+	"github.com/zees-dev/zeth/pkg/events"
+	"github.com/zees-dev/zeth/pkg/geth"
+	"github.com/zees-dev/zeth/pkg/managed"
+	"github.com/zees-dev/zeth/pkg/node"
+)
+
+// queryTimeout is the timeout of querying the status of a member.
+const queryTimeout = 5 * time.Second
+
+// Files written to the data dirs of members; geth reads the node key from the instance dir.
+const (
+	passwordFilename = "password.txt"
+	nodeKeyPath      = "geth/nodekey"
+)
+
+// note: namespace must not share a prefix with other namespaces, since datastore.GetAll iterates by prefix
+var networkKey = []byte("network")
+
+// ErrCreating is returned when a network is removed while it is being created.
+var ErrCreating = errors.New("network is being created")
+
+// Service orchestrates private networks of managed geth nodes; networks are stored in the datastore, their members are
+// registered as managed nodes.
+type Service struct {
+	store   datastore.Store
+	nodes   node.NodeService
+	managed *managed.Service
+	events  events.Publisher
+	now     func() time.Time
+
+	// networks are created in the background until the service is stopped
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+	creating map[uuid.UUID]bool
+}
+
+func NewService(store datastore.Store, nodes node.NodeService, managed *managed.Service, publisher events.Publisher) *Service {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Service{
+		store:    store,
+		nodes:    nodes,
+		managed:  managed,
+		events:   publisher,
+		now:      time.Now,
+		ctx:      ctx,
+		cancel:   cancel,
+		creating: map[uuid.UUID]bool{},
+	}
+}
+
+// Create stores the network and creates it in the background; starting the members may download the geth binary.
+// A genesis block sealed by a signer of each member is generated, and the members are created as managed nodes on distinct
+// ports, statically peered with each other. The network is published once created, or once failed; members created before
+// a failure are removed. The returned network is being created.
+func (s *Service) Create(ctx context.Context, name string, cfg Config) (Network, error) {
+	if err := cfg.Validate(); err != nil {
+		return Network{}, err
+	}
+	if cfg.Version != "" && cfg.Version != params.Version {
+		// only the bundled geth version is downloaded on start
+		if _, err := s.managed.Binary(ctx, cfg.Version); err != nil {
+			return Network{}, err
+		}
+	}
+	if cfg.ChainID == 0 {
+		cfg.ChainID = DefaultChainID
+	}
+	if cfg.Period == 0 {
+		cfg.Period = DefaultPeriod
+	}
+	if cfg.Accounts == 0 {
+		cfg.Accounts = node.DefaultDevAccounts
+	}
+	if cfg.Balance == nil {
+		cfg.Balance = node.DefaultDevBalance
+	}
+
+	accounts, err := node.NewDevAccounts(cfg.Accounts, cfg.Balance)
+	if err != nil {
+		return Network{}, err
+	}
+	network := Network{
+		ID:        uuid.NewV4(),
+		Name:      name,
+		ChainID:   cfg.ChainID,
+		Members:   []Member{},
+		Accounts:  accounts,
+		State:     StateCreating,
+		DateAdded: s.now().UTC(),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.put(network); err != nil {
+		return Network{}, err
+	}
+	s.creating[network.ID] = true
+	s.wg.Add(1)
+	go func(network Network) {
+		defer s.wg.Done()
+		s.create(s.ctx, network, cfg)
+	}(network)
+	return network, nil
+}
+
+// create creates the members of the network, and stores the network as created or failed.
+func (s *Service) create(ctx context.Context, network Network, cfg Config) {
+	err := s.createMembers(ctx, &network, cfg)
+	if err != nil {
+		log.Err(err).Msgf("failed to create network: %s", network.ID)
+		if err := s.removeMembers(context.Background(), network); err != nil {
+			log.Err(err).Msgf("failed to remove members of network: %s", network.ID)
+		}
+		network.Members = []Member{}
+		network.State, network.Error = StateFailed, err.Error()
+	} else {
+		network.State = StateCreated
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.creating, network.ID)
+	if err := s.put(network); err != nil {
+		log.Err(err).Msgf("failed to store network: %s", network.ID)
+	}
+	if network.State == StateFailed {
+		s.events.Publish(events.NetworkFailed, network.ID.String(), network.redacted())
+		return
+	}
+	s.events.Publish(events.NetworkCreated, network.ID.String(), network.redacted())
+}
+
+func (s *Service) put(network Network) error {
+	bodyBytes := new(bytes.Buffer)
+	json.NewEncoder(bodyBytes).Encode(network)
+	return s.store.Set(networkKey, network.ID.Bytes(), bodyBytes.Bytes())
+}
+
+// Stop cancels creating networks and waits for their members to be removed; on shutdown, before managed nodes are stopped.
+func (s *Service) Stop() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+// createMembers creates the members of the network with their keys, initialises their data dirs with the genesis block
+// of the network, and starts them once all members are peered.
+func (s *Service) createMembers(ctx context.Context, network *Network, cfg Config) error {
+	members := make([]node.ZethNode, cfg.Nodes)
+	for i := range members {
+		password, err := newPassword()
+		if err != nil {
+			return err
+		}
+
+		n, err := s.managed.Create(ctx, fmt.Sprintf("%s-%d", network.Name, i+1), node.ManagedConfig{
+			Version:       cfg.Version,
+			RestartPolicy: node.RestartOnFailure,
+		})
+		if n.ID != uuid.Nil {
+			network.Members = append(network.Members, Member{NodeID: n.ID})
+		}
+		if err != nil {
+			return err
+		}
+		members[i] = n
+
+		signer, nodeKey, err := writeKeys(n.Managed.DataDir, password)
+		if err != nil {
+			return err
+		}
+		network.Members[i].Signer = signer
+		network.Members[i].Enode = enode.NewV4(&nodeKey.PublicKey, net.IPv4(127, 0, 0, 1), n.Managed.P2PPort, n.Managed.P2PPort).URLv4()
+
+		// clique seals blocks with the unlocked key of the signer; geth refuses to unlock accounts while HTTP is served otherwise.
+		// the RPC endpoints of members are served on the loopback interface; see peer
+		n.Managed.Args = []string{
+			"--mine",
+			"--unlock", signer.Hex(),
+			"--password", filepath.Join(n.Managed.DataDir, passwordFilename),
+			"--allow-insecure-unlock",
+		}
+		if err := s.nodes.Update(ctx, n.ID, n); err != nil {
+			return err
+		}
+	}
+
+	signers := make([]common.Address, len(network.Members))
+	for i, m := range network.Members {
+		signers[i] = m.Signer
+	}
+	alloc := core.GenesisAlloc{}
+	for _, account := range network.Accounts {
+		alloc[account.Address] = core.GenesisAccount{Balance: account.Balance.ToInt()}
+	}
+	genesis, err := geth.GenesisSpec{
+		ChainID:   cfg.ChainID,
+		Consensus: geth.ConsensusClique,
+		Signers:   signers,
+		Period:    cfg.Period,
+		GasLimit:  cfg.GasLimit,
+		Alloc:     alloc,
+	}.Build()
+	if err != nil {
+		return err
+	}
+	network.GenesisHash = genesis.ToBlock(nil).Hash()
+
+	for i, n := range members {
+		if _, err := s.managed.InitGenesis(ctx, n.ID, genesis); err != nil {
+			return err
+		}
+		if err := s.peer(ctx, *network, i); err != nil {
+			return err
+		}
+	}
+
+	for _, m := range network.Members {
+		if _, err := s.managed.Start(ctx, m.NodeID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// peer configures the member to seal blocks as its signer, and to peer statically with the other members without discovery.
+// The RPC endpoints are served on the loopback interface only, since the signer is unlocked.
+func (s *Service) peer(ctx context.Context, network Network, i int) error {
+	member := network.Members[i]
+	cfg, err := s.managed.Config(ctx, member.NodeID)
+	if err != nil {
+		return err
+	}
+
+	gethCfg := cfg.Config
+	gethCfg.Eth.Miner.Etherbase = member.Signer
+	gethCfg.Node.HTTPHost = "127.0.0.1"
+	gethCfg.Node.WSHost = "127.0.0.1"
+	gethCfg.Node.P2P.NoDiscovery = true
+	gethCfg.Node.P2P.DiscoveryV5 = false
+	gethCfg.Node.P2P.StaticNodes = nil
+	for j, other := range network.Members {
+		if j == i {
+			continue
+		}
+		gethCfg.Node.P2P.StaticNodes = append(gethCfg.Node.P2P.StaticNodes, enode.MustParse(other.Enode))
+	}
+
+	data, err := geth.MarshalConfig(gethCfg)
+	if err != nil {
+		return err
+	}
+	_, err = s.managed.UpdateConfig(ctx, member.NodeID, data, false)
+	return err
+}
+
+// writeKeys writes a new signer key to the keystore and a new node key to the data dir; the keystore is unlocked with the
+// password written to the password file.
+func writeKeys(dataDir, password string) (common.Address, *ecdsa.PrivateKey, error) {
+	if err := ioutil.WriteFile(filepath.Join(dataDir, passwordFilename), []byte(password), 0600); err != nil {
+		return common.Address{}, nil, err
+	}
+	account, err := keystore.StoreKey(filepath.Join(dataDir, "keystore"), password, keystore.LightScryptN, keystore.LightScryptP)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+
+	nodeKey, err := crypto.GenerateKey()
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	path := filepath.Join(dataDir, nodeKeyPath)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return common.Address{}, nil, err
+	}
+	if err := crypto.SaveECDSA(path, nodeKey); err != nil {
+		return common.Address{}, nil, err
+	}
+	return account.Address, nodeKey, nil
+}
+
+func newPassword() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Get returns the network.
+func (s *Service) Get(ctx context.Context, id uuid.UUID) (*Network, error) {
+	b, err := s.store.Get(networkKey, id.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	var network Network
+	if err := json.Unmarshal(b, &network); err != nil {
+		return nil, err
+	}
+	return &network, nil
+}
+
+// GetAll returns all networks ordered by the date they were added.
+func (s *Service) GetAll(ctx context.Context) ([]Network, error) {
+	networksMap, err := s.store.GetAll(networkKey)
+	if err != nil {
+		return nil, err
+	}
+
+	networks := []Network{}
+	for _, b := range networksMap {
+		var network Network
+		if err := json.Unmarshal(b, &network); err != nil {
+			return nil, err
+		}
+		networks = append(networks, network)
+	}
+	sort.Slice(networks, func(i, j int) bool { return networks[i].DateAdded.Before(networks[j].DateAdded) })
+	return networks, nil
+}
+
+// Remove tears the network down; the members are stopped and removed, including their data dirs. ErrCreating if the
+// network is being created.
+func (s *Service) Remove(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	creating := s.creating[id]
+	s.mu.Unlock()
+	if creating {
+		return ErrCreating
+	}
+
+	network, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.removeMembers(ctx, *network); err != nil {
+		return err
+	}
+	if err := s.store.RemovePrefix(networkKey, id.Bytes()); err != nil {
+		return err
+	}
+
+	s.events.Publish(events.NetworkDeleted, id.String(), nil)
+	return nil
+}
+
+// removeMembers stops and removes the members of the network which are still registered; the first failure is returned.
+func (s *Service) removeMembers(ctx context.Context, network Network) error {
+	var firstErr error
+	for _, m := range network.Members {
+		n, err := s.nodes.Get(ctx, m.NodeID)
+		if err != nil {
+			continue
+		}
+		if err := s.managed.Remove(ctx, *n, true); err != nil {
+			log.Err(err).Msgf("failed to remove member of network %s: %s", network.ID, m.NodeID)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if err := s.nodes.Delete(ctx, m.NodeID); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Status queries the peers and head of each member concurrently, and groups the members by their hash of the common block.
+func (s *Service) Status(ctx context.Context, id uuid.UUID) (Status, error) {
+	network, err := s.Get(ctx, id)
+	if err != nil {
+		return Status{}, err
+	}
+
+	status := Status{Network: *network, Nodes: make([]MemberStatus, len(network.Members)), Forks: []Fork{}}
+	nodes := make([]*node.ZethNode, len(network.Members))
+	var wg sync.WaitGroup
+	for i, m := range network.Members {
+		status.Nodes[i] = MemberStatus{NodeID: m.NodeID, Process: s.managed.Status(m.NodeID)}
+		n, err := s.nodes.Get(ctx, m.NodeID)
+		if err != nil {
+			status.Nodes[i].Error = "node is not registered"
+			continue
+		}
+		nodes[i] = n
+		status.Nodes[i].Name = n.Name
+
+		wg.Add(1)
+		go func(ms *MemberStatus, n node.ZethNode) {
+			defer wg.Done()
+			peers, head, err := s.head(ctx, n)
+			if err != nil {
+				ms.Error = err.Error()
+				return
+			}
+			ms.Peers, ms.Head = peers, &head
+		}(&status.Nodes[i], *n)
+	}
+	wg.Wait()
+
+	// the common block is the lowest head of the reachable members
+	reachable := 0
+	for _, ms := range status.Nodes {
+		if ms.Head == nil {
+			continue
+		}
+		if reachable == 0 || ms.Head.Number < status.CommonBlock {
+			status.CommonBlock = ms.Head.Number
+		}
+		reachable++
+	}
+	if reachable == 0 {
+		return status, nil
+	}
+
+	hashes := make([]*common.Hash, len(status.Nodes))
+	for i, ms := range status.Nodes {
+		if ms.Head == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, n node.ZethNode) {
+			defer wg.Done()
+			hash, err := s.blockHash(ctx, n, status.CommonBlock)
+			if err != nil {
+				status.Nodes[i].Error = err.Error()
+				return
+			}
+			hashes[i] = &hash
+		}(i, *nodes[i])
+	}
+	wg.Wait()
+
+	for i, hash := range hashes {
+		if hash == nil {
+			continue
+		}
+		found := false
+		for f := range status.Forks {
+			if status.Forks[f].Hash == *hash {
+				status.Forks[f].Members = append(status.Forks[f].Members, status.Nodes[i].NodeID)
+				found = true
+				break
+			}
+		}
+		if !found {
+			status.Forks = append(status.Forks, Fork{Hash: *hash, Members: []uuid.UUID{status.Nodes[i].NodeID}})
+		}
+	}
+	status.Forked = len(status.Forks) > 1
+	return status, nil
+}
+
+func (s *Service) dial(n node.ZethNode) (*rpc.Client, error) {
+	transport, err := s.nodes.Transport(n)
+	if err != nil {
+		return nil, err
+	}
+	return n.DialRPCWithTransport(transport)
+}
+
+// head returns the number of peers and the head block of the node.
+func (s *Service) head(ctx context.Context, n node.ZethNode) (int, Head, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	c, err := s.dial(n)
+	if err != nil {
+		return 0, Head{}, err
+	}
+	defer c.Close()
+
+	var peers hexutil.Uint
+	if err := c.CallContext(ctx, &peers, "net_peerCount"); err != nil {
+		return 0, Head{}, err
+	}
+	head, err := block(ctx, c, "latest")
+	return int(peers), head, err
+}
+
+// blockHash returns the hash of the block of the node.
+func (s *Service) blockHash(ctx context.Context, n node.ZethNode, number uint64) (common.Hash, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	c, err := s.dial(n)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	defer c.Close()
+
+	b, err := block(ctx, c, hexutil.EncodeUint64(number))
+	return b.Hash, err
+}
+
+// block returns the number and hash of the block; "latest" for the head block.
+func block(ctx context.Context, c *rpc.Client, number string) (Head, error) {
+	var block *struct {
+		Number hexutil.Uint64 `json:"number"`
+		Hash   common.Hash    `json:"hash"`
+	}
+	if err := c.CallContext(ctx, &block, "eth_getBlockByNumber", number, false); err != nil {
+		return Head{}, err
+	}
+	if block == nil {
+		return Head{}, fmt.Errorf("block %s not found", number)
+	}
+	return Head{Number: uint64(block.Number), Hash: block.Hash}, nil
+}
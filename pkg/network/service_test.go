@@ -0,0 +1,228 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/zees-dev/zeth/pkg/datastore/badgerdbtest"
+	"github.com/zees-dev/zeth/pkg/events"
+	"github.com/zees-dev/zeth/pkg/geth/downloader"
+	"github.com/zees-dev/zeth/pkg/managed"
+	"github.com/zees-dev/zeth/pkg/node"
+)
+
+// newTestService returns a service running members with a script in place of the geth binary; geth init creates the
+// chain database.
+func newTestService(t *testing.T) (*Service, node.NodeService, *events.Bus) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test binaries are shell scripts")
+	}
+
+	store, cleanup := badgerdbtest.MustNewTestBadgerDB()
+	t.Cleanup(func() { cleanup() })
+
+	dir := t.TempDir()
+	binary := filepath.Join(dir, downloader.DefaultGethBinaryDir, downloader.GethBinaryFilename(runtime.GOOS, params.Version))
+	script := `#!/bin/sh
+if [ "$1" = init ]; then mkdir -p "$3/geth/chaindata"; exit 0; fi
+exec sleep 30
+`
+	if err := os.MkdirAll(filepath.Dir(binary), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(binary, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	bus := events.NewBus(events.DefaultBacklog)
+	nodes := node.NewService(store, bus)
+	m := managed.NewService(nodes, downloader.New(bus), bus, dir)
+	t.Cleanup(func() { m.StopAll(context.Background()) })
+	s := NewService(store, nodes, m, bus)
+	t.Cleanup(s.Stop)
+	return s, nodes, bus
+}
+
+// create creates the network and waits until it is created; the published network is returned with the stored network.
+func create(t *testing.T, s *Service, bus *events.Bus, name string, cfg Config) (Network, Network) {
+	c := make(chan events.Event, 1)
+	_, unsub := bus.Subscribe(c, 0, []string{string(events.NetworkCreated), string(events.NetworkFailed)})
+	defer unsub()
+
+	network, err := s.Create(context.Background(), name, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if network.State != StateCreating {
+		t.Fatalf("network is %s", network.State)
+	}
+
+	select {
+	case e := <-c:
+		if e.Type != events.NetworkCreated {
+			t.Fatalf("network failed: %v", e.Data)
+		}
+		stored, err := s.Get(context.Background(), network.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return e.Data.(Network), *stored
+	case <-time.After(10 * time.Second):
+		t.Fatal("network was not created")
+	}
+	return Network{}, Network{}
+}
+
+func Test_CreateRemove(t *testing.T) {
+	is := assert.New(t)
+
+	s, nodes, bus := newTestService(t)
+	ctx := context.Background()
+
+	c := make(chan events.Event, 10)
+	_, unsub := bus.Subscribe(c, 0, []string{string(events.NetworkDeleted)})
+	defer unsub()
+
+	_, err := s.Create(ctx, "invalid", Config{Nodes: MaxNodes + 1})
+	is.Error(err)
+	_, err = s.Create(ctx, "missing", Config{Nodes: 1, Version: "1.0.0"})
+	is.ErrorIs(err, managed.ErrBinaryNotInstalled)
+
+	published, network := create(t, s, bus, "private", Config{Nodes: 3, Accounts: 2})
+	is.Equal(StateCreated, network.State)
+	is.Equal(uint64(DefaultChainID), network.ChainID)
+	is.Len(network.Accounts, 2)
+	is.Len(network.Members, 3)
+	for i, account := range network.Accounts {
+		// private keys are only returned by the API
+		is.NotEmpty(account.PrivateKey)
+		is.Equal(account.Address, published.Accounts[i].Address)
+		is.Empty(published.Accounts[i].PrivateKey)
+	}
+
+	ports := map[int]bool{}
+	for i, m := range network.Members {
+		n, err := nodes.Get(ctx, m.NodeID)
+		is.NoError(err)
+		is.Equal(fmt.Sprintf("private-%d", i+1), n.Name)
+		is.Equal(network.GenesisHash, n.Managed.Network.GenesisHash)
+		is.Equal(network.ChainID, n.Managed.Network.NetworkID)
+		is.Equal(managed.StateRunning, s.managed.Status(m.NodeID).State)
+		for _, port := range []int{n.Managed.HTTPPort, n.Managed.WSPort, n.Managed.P2PPort} {
+			is.False(ports[port], "port %d is shared", port)
+			ports[port] = true
+		}
+
+		// members seal blocks as their signer and peer statically with all other members
+		cfg, err := s.managed.Config(ctx, m.NodeID)
+		is.NoError(err)
+		is.Equal(m.Signer, cfg.Config.Eth.Miner.Etherbase)
+		is.True(cfg.Config.Node.P2P.NoDiscovery)
+		is.Len(cfg.Config.Node.P2P.StaticNodes, 2)
+		for _, peer := range cfg.Config.Node.P2P.StaticNodes {
+			is.NotEqual(m.Enode, peer.URLv4())
+		}
+		is.Contains(n.Managed.Args, m.Signer.Hex())
+		is.Equal("127.0.0.1", cfg.Config.Node.HTTPHost)
+		is.Equal("127.0.0.1", cfg.Config.Node.WSHost)
+		is.FileExists(filepath.Join(n.Managed.DataDir, nodeKeyPath))
+		keys, _ := ioutil.ReadDir(filepath.Join(n.Managed.DataDir, "keystore"))
+		is.Len(keys, 1)
+	}
+
+	networks, err := s.GetAll(ctx)
+	is.NoError(err)
+	is.Len(networks, 1)
+
+	is.NoError(s.Remove(ctx, network.ID))
+	is.Equal(events.NetworkDeleted, (<-c).Type)
+	for _, m := range network.Members {
+		_, err := nodes.Get(ctx, m.NodeID)
+		is.Error(err)
+		is.Equal(managed.StateStopped, s.managed.Status(m.NodeID).State)
+	}
+	_, err = s.Get(ctx, network.ID)
+	is.Error(err)
+}
+
+// rpcServer serves the peer count and blocks of a member; the hash of a block is derived from the number and the branch.
+func rpcServer(t *testing.T, peers, head uint64, branch string) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		var result interface{}
+		switch req.Method {
+		case "net_peerCount":
+			result = fmt.Sprintf("0x%x", peers)
+		case "eth_getBlockByNumber":
+			number := head
+			var tag string
+			json.Unmarshal(req.Params[0], &tag)
+			if tag != "latest" {
+				fmt.Sscanf(tag, "0x%x", &number)
+			}
+			result = map[string]string{
+				"number": fmt.Sprintf("0x%x", number),
+				"hash":   common.BytesToHash([]byte(fmt.Sprintf("%s-%d", branch, number))).Hex(),
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func Test_Status(t *testing.T) {
+	is := assert.New(t)
+
+	s, nodes, bus := newTestService(t)
+	ctx := context.Background()
+
+	_, network := create(t, s, bus, "private", Config{Nodes: 3})
+
+	// members are served by stub RPC endpoints; the third member forked
+	servers := []*httptest.Server{rpcServer(t, 2, 10, "a"), rpcServer(t, 2, 12, "a"), rpcServer(t, 1, 11, "b")}
+	for i, m := range network.Members {
+		n, err := nodes.Get(ctx, m.NodeID)
+		is.NoError(err)
+		n.RPC.HTTP = servers[i].URL
+		is.NoError(nodes.Update(ctx, n.ID, *n))
+	}
+
+	status, err := s.Status(ctx, network.ID)
+	is.NoError(err)
+	is.Len(status.Nodes, 3)
+	is.Equal(2, status.Nodes[0].Peers)
+	is.Equal(uint64(12), status.Nodes[1].Head.Number)
+	is.Equal(uint64(10), status.CommonBlock)
+	is.True(status.Forked)
+	is.Len(status.Forks, 2)
+	is.Equal([]uuid.UUID{network.Members[0].NodeID, network.Members[1].NodeID}, status.Forks[0].Members)
+
+	// unreachable members are excluded from the common block
+	servers[2].Close()
+	status, err = s.Status(ctx, network.ID)
+	is.NoError(err)
+	is.NotEmpty(status.Nodes[2].Error)
+	is.Nil(status.Nodes[2].Head)
+	is.False(status.Forked)
+	is.Len(status.Forks, 1)
+}
@@ -0,0 +1,191 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Capability is an optional feature of a node's RPC endpoint.
+type Capability string
+
+const (
+	CapabilityDebug   Capability = "debug"   // debug_* namespace
+	CapabilityTrace   Capability = "trace"   // trace_* namespace; e.g. erigon, nethermind
+	CapabilityTxPool  Capability = "txpool"  // txpool_* namespace
+	CapabilityProof   Capability = "proof"   // eth_getProof
+	CapabilityArchive Capability = "archive" // historical state
+	CapabilityBatch   Capability = "batch"   // batch requests
+)
+
+// AllCapabilities returns all capabilities which are probed.
+func AllCapabilities() []Capability {
+	return []Capability{CapabilityDebug, CapabilityTrace, CapabilityTxPool, CapabilityProof, CapabilityArchive, CapabilityBatch}
+}
+
+// methodProbes are representative calls of each capability; a call is supported unless the method is rejected,
+// e.g. debug_traceTransaction of an unknown transaction fails, but proves the debug namespace is available.
+var methodProbes = []struct {
+	capability Capability
+	method     string
+	params     []interface{}
+}{
+	{CapabilityDebug, "debug_traceTransaction", []interface{}{common.Hash{}, map[string]interface{}{}}},
+	{CapabilityTrace, "trace_transaction", []interface{}{common.Hash{}}},
+	{CapabilityTxPool, "txpool_status", nil},
+	{CapabilityProof, "eth_getProof", []interface{}{common.Address{}, []string{}, "latest"}},
+}
+
+// prunedStateBlocks is the number of recent blocks whose state is retained by pruned nodes; e.g. 128 by geth.
+const prunedStateBlocks = 128
+
+// logRanges are the eth_getLogs block ranges probed, in descending order; providers commonly limit the range.
+var logRanges = []uint64{100000, 10000, 5000, 2000, 1000, 100}
+
+// Capabilities are the supported namespaces, methods and limits of a node; probed via RPC.
+type Capabilities struct {
+	Supported []Capability    `json:"supported"`
+	Methods   map[string]bool `json:"methods"` // probed method; whether it is supported
+	// MaxLogRange is the largest probed eth_getLogs block range accepted; 0 if none were accepted
+	MaxLogRange uint64    `json:"maxLogRange"`
+	ProbedAt    time.Time `json:"probedAt"`
+}
+
+// Has returns whether the capability is supported.
+func (c Capabilities) Has(capability Capability) bool {
+	for _, supported := range c.Supported {
+		if supported == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// Supports returns whether the node has the capability; false if the node has not been probed.
+func (n ZethNode) Supports(capability Capability) bool {
+	return n.Capabilities != nil && n.Capabilities.Has(capability)
+}
+
+// ParseCapabilities parses a comma separated list of capabilities.
+func ParseCapabilities(s string) ([]Capability, error) {
+	var capabilities []Capability
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		valid := false
+		for _, capability := range AllCapabilities() {
+			valid = valid || capability == Capability(name)
+		}
+		if !valid {
+			return nil, fmt.Errorf("unknown capability %q", name)
+		}
+		capabilities = append(capabilities, Capability(name))
+	}
+	return capabilities, nil
+}
+
+// ProbeCapabilities tries representative calls of each capability, queries the state of block 1 to detect an archive node,
+// and determines the largest eth_getLogs block range accepted. Only the latest block number is required.
+// The transport is the dedicated transport of the node, see NodeService.Transport.
+func (n *ZethNode) ProbeCapabilities(ctx context.Context, transport http.RoundTripper) (*Capabilities, error) {
+	c, err := n.DialRPCWithTransport(transport)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	var head hexutil.Uint64
+	if err := c.CallContext(ctx, &head, "eth_blockNumber"); err != nil {
+		return nil, fmt.Errorf("eth_blockNumber: %w", err)
+	}
+
+	capabilities := &Capabilities{Supported: []Capability{}, Methods: map[string]bool{}, ProbedAt: time.Now().UTC()}
+	add := func(capability Capability) {
+		if !capabilities.Has(capability) {
+			capabilities.Supported = append(capabilities.Supported, capability)
+		}
+	}
+
+	for _, probe := range methodProbes {
+		var result interface{}
+		err := c.CallContext(ctx, &result, probe.method, probe.params...)
+		supported := err == nil || methodExists(err)
+		capabilities.Methods[probe.method] = supported
+		if supported {
+			add(probe.capability)
+		}
+	}
+
+	// pruned nodes only retain the state of recent blocks; e.g. geth fails with "missing trie node".
+	// On young chains block 1 is recent, so its state is retained by any node; archive nodes are not detected.
+	if uint64(head) > prunedStateBlocks {
+		var balance hexutil.Big
+		archive := c.CallContext(ctx, &balance, "eth_getBalance", common.Address{}, hexutil.Uint64(1)) == nil
+		capabilities.Methods["eth_getBalance"] = archive
+		if archive {
+			add(CapabilityArchive)
+		}
+	}
+
+	batch := []rpc.BatchElem{
+		{Method: "eth_blockNumber", Result: new(hexutil.Uint64)},
+		{Method: "eth_blockNumber", Result: new(hexutil.Uint64)},
+	}
+	if err := c.BatchCallContext(ctx, batch); err == nil && batch[0].Error == nil && batch[1].Error == nil {
+		add(CapabilityBatch)
+	}
+
+	// the zero address filter keeps the probe cheap; ranges exceeding the chain are not limited by the node
+	for _, r := range logRanges {
+		from := uint64(0)
+		if uint64(head) >= r {
+			from = uint64(head) - r + 1
+		}
+		filter := map[string]interface{}{
+			"fromBlock": hexutil.Uint64(from),
+			"toBlock":   head,
+			"address":   common.Address{},
+		}
+		var logs []interface{}
+		if err := c.CallContext(ctx, &logs, "eth_getLogs", filter); err == nil {
+			capabilities.MaxLogRange = r
+			break
+		}
+	}
+	capabilities.Methods["eth_getLogs"] = capabilities.MaxLogRange > 0
+
+	sort.Slice(capabilities.Supported, func(i, j int) bool { return capabilities.Supported[i] < capabilities.Supported[j] })
+	return capabilities, nil
+}
+
+// methodExists returns whether the call failed for a reason other than the method being unavailable;
+// e.g. invalid params, or an unknown transaction.
+func methodExists(err error) bool {
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) {
+		return false // transport errors; e.g. providers rejecting restricted methods with 4xx status codes
+	}
+	if rpcErr.ErrorCode() == -32601 {
+		return false
+	}
+	msg := strings.ToLower(rpcErr.Error())
+	if !strings.Contains(msg, "method") {
+		return true
+	}
+	for _, unavailable := range []string{"not found", "does not exist", "not available", "not supported", "unsupported", "not allowed", "disabled"} {
+		if strings.Contains(msg, unavailable) {
+			return false
+		}
+	}
+	return true
+}
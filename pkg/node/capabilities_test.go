@@ -0,0 +1,119 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+type testRPCError struct {
+	code    int
+	message string
+}
+
+func (e testRPCError) Error() string  { return e.message }
+func (e testRPCError) ErrorCode() int { return e.code }
+
+var _ rpc.Error = testRPCError{}
+
+func Test_MethodExists(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"method not found code", testRPCError{-32601, "the method trace_transaction does not exist/is not available"}, false},
+		{"unsupported method message", testRPCError{-32600, "Unsupported method: trace_transaction"}, false},
+		{"unknown transaction", testRPCError{-32000, "transaction 0x0000000000000000000000000000000000000000000000000000000000000000 not found"}, true},
+		{"invalid params", testRPCError{-32602, "invalid argument 0: hex string has length 0"}, true},
+		{"transport error", errors.New("403 Forbidden"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := assert.New(t)
+			is.Equal(tt.want, methodExists(tt.err))
+		})
+	}
+}
+
+func Test_ParseCapabilities(t *testing.T) {
+	is := assert.New(t)
+
+	capabilities, err := ParseCapabilities("debug, Archive,")
+	is.NoError(err)
+	is.Equal([]Capability{CapabilityDebug, CapabilityArchive}, capabilities)
+
+	_, err = ParseCapabilities("debug,parity")
+	is.Error(err)
+}
+
+func Test_ProbeCapabilities(t *testing.T) {
+	is := assert.New(t)
+
+	// a pruned geth node behind a provider limiting eth_getLogs to 2000 blocks
+	head := "0xf4240" // 1000000
+	srv := newTestRPCServer(t, func(req testRPCRequest, res map[string]interface{}) {
+		switch req.Method {
+		case "eth_blockNumber":
+			res["result"] = head
+		case "debug_traceTransaction":
+			res["error"] = map[string]interface{}{"code": -32000, "message": "transaction not found"}
+		case "txpool_status":
+			res["result"] = map[string]interface{}{"pending": "0x0", "queued": "0x0"}
+		case "eth_getProof":
+			res["result"] = map[string]interface{}{}
+		case "eth_getBalance":
+			res["error"] = map[string]interface{}{"code": -32000, "message": "missing trie node"}
+		case "eth_getLogs":
+			var filter struct {
+				FromBlock string `json:"fromBlock"`
+				ToBlock   string `json:"toBlock"`
+			}
+			json.Unmarshal(req.Params[0], &filter)
+			from, _ := strconv.ParseUint(filter.FromBlock[2:], 16, 64)
+			to, _ := strconv.ParseUint(filter.ToBlock[2:], 16, 64)
+			if to-from+1 > 2000 {
+				res["error"] = map[string]interface{}{"code": -32005, "message": "block range too large"}
+			} else {
+				res["result"] = []interface{}{}
+			}
+		default:
+			res["error"] = map[string]interface{}{"code": -32601, "message": "the method " + req.Method + " does not exist/is not available"}
+		}
+	})
+	defer srv.Close()
+
+	n := NewNode(srv.URL, "")
+	capabilities, err := n.ProbeCapabilities(context.Background(), http.DefaultTransport)
+	is.NoError(err)
+	is.Equal([]Capability{CapabilityBatch, CapabilityDebug, CapabilityProof, CapabilityTxPool}, capabilities.Supported)
+	is.False(capabilities.Methods["trace_transaction"])
+	is.False(capabilities.Methods["eth_getBalance"])
+	is.True(capabilities.Methods["debug_traceTransaction"])
+	is.Equal(uint64(2000), capabilities.MaxLogRange)
+	is.False(capabilities.ProbedAt.IsZero())
+
+	is.False(n.Supports(CapabilityDebug))
+	n.Capabilities = capabilities
+	is.True(n.Supports(CapabilityDebug))
+	is.False(n.Supports(CapabilityArchive))
+
+	// the state of block 1 is not pruned yet on young chains; archive nodes are not detected
+	head = "0x64" // 100
+	capabilities, err = n.ProbeCapabilities(context.Background(), http.DefaultTransport)
+	is.NoError(err)
+	is.NotContains(capabilities.Methods, "eth_getBalance")
+	is.False(capabilities.Has(CapabilityArchive))
+
+	// the latest block number is required
+	srv.Close()
+	_, err = n.ProbeCapabilities(context.Background(), http.DefaultTransport)
+	is.Error(err)
+}
@@ -0,0 +1,77 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// ChainMetadata describes the chain and client of a node; discovered via RPC.
+type ChainMetadata struct {
+	ChainID       uint64      `json:"chainId"`
+	NetworkID     NetworkID   `json:"networkId,omitempty"`     // net_version; 0 if not supported
+	ClientVersion string      `json:"clientVersion,omitempty"` // web3_clientVersion, e.g. Geth/v1.10.11-stable/linux-amd64/go1.17
+	Client        string      `json:"client,omitempty"`        // client name, e.g. geth
+	GenesisHash   common.Hash `json:"genesisHash"`             // zero if the genesis block is not available
+	DiscoveredAt  time.Time   `json:"discoveredAt"`
+}
+
+// SameChain returns whether both nodes are on the same chain; the genesis hash is compared if known by both.
+func (m ChainMetadata) SameChain(other ChainMetadata) bool {
+	if m.ChainID != other.ChainID {
+		return false
+	}
+	if m.GenesisHash != (common.Hash{}) && other.GenesisHash != (common.Hash{}) {
+		return m.GenesisHash == other.GenesisHash
+	}
+	return true
+}
+
+// ClientName returns the lower case client name of a web3_clientVersion string; e.g. geth for Geth/v1.10.11-stable/linux-amd64/go1.17
+func ClientName(clientVersion string) string {
+	name := strings.SplitN(clientVersion, "/", 2)[0]
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// DiscoverChain queries the chain ID, network ID, client version and genesis block hash of the node.
+// Only the chain ID is required; the remaining methods are not supported by all providers.
+// The transport is the dedicated transport of the node, see NodeService.Transport.
+func (n *ZethNode) DiscoverChain(ctx context.Context, transport http.RoundTripper) (*ChainMetadata, error) {
+	c, err := n.DialRPCWithTransport(transport)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	var chainID hexutil.Big
+	if err := c.CallContext(ctx, &chainID, "eth_chainId"); err != nil {
+		return nil, fmt.Errorf("eth_chainId: %w", err)
+	}
+	metadata := &ChainMetadata{ChainID: chainID.ToInt().Uint64(), DiscoveredAt: time.Now().UTC()}
+
+	var netVersion string
+	if err := c.CallContext(ctx, &netVersion, "net_version"); err == nil {
+		var networkID uint64
+		if _, err := fmt.Sscan(netVersion, &networkID); err == nil {
+			metadata.NetworkID = NetworkID(networkID)
+		}
+	}
+
+	if err := c.CallContext(ctx, &metadata.ClientVersion, "web3_clientVersion"); err == nil {
+		metadata.Client = ClientName(metadata.ClientVersion)
+	}
+
+	var genesis *struct {
+		Hash common.Hash `json:"hash"`
+	}
+	if err := c.CallContext(ctx, &genesis, "eth_getBlockByNumber", "0x0", false); err == nil && genesis != nil {
+		metadata.GenesisHash = genesis.Hash
+	}
+
+	return metadata, nil
+}
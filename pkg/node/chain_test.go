@@ -0,0 +1,135 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ClientName(t *testing.T) {
+	tests := []struct {
+		version string
+		want    string
+	}{
+		{"Geth/v1.10.11-stable-7231b3ef/linux-amd64/go1.17.2", "geth"},
+		{"erigon/2021.11.2/linux-amd64/go1.17.2", "erigon"},
+		{"Nethermind/v1.11.7+1f2c6c5c/linux-x64/dotnet5.0.11", "nethermind"},
+		{"besu", "besu"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			is := assert.New(t)
+			is.Equal(tt.want, ClientName(tt.version))
+		})
+	}
+}
+
+func Test_SameChain(t *testing.T) {
+	genesis := common.HexToHash("0xd4e56740f876aef8c010b86a40d5f56745a118d0906a34e69aec8c0db1cb8fa3")
+	other := common.HexToHash("0x1")
+
+	tests := []struct {
+		name string
+		a, b ChainMetadata
+		want bool
+	}{
+		{"same chain id and genesis", ChainMetadata{ChainID: 1, GenesisHash: genesis}, ChainMetadata{ChainID: 1, GenesisHash: genesis}, true},
+		{"different chain id", ChainMetadata{ChainID: 1}, ChainMetadata{ChainID: 5}, false},
+		{"different genesis", ChainMetadata{ChainID: 1, GenesisHash: genesis}, ChainMetadata{ChainID: 1, GenesisHash: other}, false},
+		{"unknown genesis", ChainMetadata{ChainID: 1, GenesisHash: genesis}, ChainMetadata{ChainID: 1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := assert.New(t)
+			is.Equal(tt.want, tt.a.SameChain(tt.b))
+		})
+	}
+}
+
+// testRPCRequest is a JSON-RPC request received by the test RPC server.
+type testRPCRequest struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+// newTestRPCServer returns a JSON-RPC server; respond sets the result or error of the response to each request,
+// including the requests of a batch.
+func newTestRPCServer(t *testing.T, respond func(req testRPCRequest, res map[string]interface{})) *httptest.Server {
+	response := func(req testRPCRequest) map[string]interface{} {
+		res := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+		respond(req, res)
+		return res
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if len(body) > 0 && body[0] == '[' {
+			var reqs []testRPCRequest
+			if err := json.Unmarshal(body, &reqs); err != nil {
+				t.Error(err)
+				return
+			}
+			results := []map[string]interface{}{}
+			for _, req := range reqs {
+				results = append(results, response(req))
+			}
+			json.NewEncoder(w).Encode(results)
+			return
+		}
+		var req testRPCRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Error(err)
+			return
+		}
+		json.NewEncoder(w).Encode(response(req))
+	}))
+}
+
+func Test_DiscoverChain(t *testing.T) {
+	is := assert.New(t)
+
+	genesis := common.HexToHash("0xd4e56740f876aef8c010b86a40d5f56745a118d0906a34e69aec8c0db1cb8fa3")
+	srv := newTestRPCServer(t, func(req testRPCRequest, res map[string]interface{}) {
+		switch req.Method {
+		case "eth_chainId":
+			res["result"] = "0x1"
+		case "web3_clientVersion":
+			res["result"] = "Geth/v1.10.11-stable/linux-amd64/go1.17"
+		case "eth_getBlockByNumber":
+			res["result"] = map[string]interface{}{"number": "0x0", "hash": genesis}
+		default:
+			// e.g. providers which do not expose net_version
+			res["error"] = map[string]interface{}{"code": -32601, "message": "the method does not exist"}
+		}
+	})
+	defer srv.Close()
+
+	metadata, err := NewNode(srv.URL, "").DiscoverChain(context.Background(), http.DefaultTransport)
+	is.NoError(err)
+	is.Equal(uint64(1), metadata.ChainID)
+	is.Equal(NetworkID(0), metadata.NetworkID)
+	is.Equal("geth", metadata.Client)
+	is.Equal(genesis, metadata.GenesisHash)
+	is.False(metadata.DiscoveredAt.IsZero())
+
+	// the chain ID is required
+	srv.Close()
+	_, err = NewNode(srv.URL, "").DiscoverChain(context.Background(), http.DefaultTransport)
+	is.Error(err)
+}
@@ -0,0 +1,245 @@
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// ChaosFaultType is a fault which can be injected into requests proxied to a node.
+type ChaosFaultType string
+
+const (
+	ChaosLatency        ChaosFaultType = "latency"         // delay the request before it is proxied
+	ChaosRPCError       ChaosFaultType = "rpc_error"       // respond with a JSON-RPC error without proxying the request
+	ChaosHTTPError      ChaosFaultType = "http_error"      // respond with an HTTP 5xx without proxying the request
+	ChaosDropConnection ChaosFaultType = "drop_connection" // close the client connection without responding
+	ChaosStaleBlock     ChaosFaultType = "stale_block"     // report a block number behind the upstream head
+	ChaosTruncate       ChaosFaultType = "truncate"        // truncate the upstream response body
+)
+
+// Default fault parameters; used if not specified in a fault.
+const (
+	defaultChaosLatency      = 1000 // milliseconds
+	defaultChaosErrorCode    = -32603
+	defaultChaosErrorMessage = "chaos: injected error"
+	defaultChaosStatusCode   = http.StatusServiceUnavailable
+	defaultChaosStaleBlocks  = 10
+)
+
+// ChaosFault configures a fault injected into requests at a given rate.
+type ChaosFault struct {
+	Type    ChaosFaultType `json:"type"`
+	Rate    float64        `json:"rate"`              // probability in [0, 1] of the fault being injected per request
+	Methods []string       `json:"methods,omitempty"` // JSON-RPC methods the fault applies to; empty applies to all
+
+	LatencyMs    int64  `json:"latencyMs,omitempty"`    // latency
+	JitterMs     int64  `json:"jitterMs,omitempty"`     // latency; random additional latency in [0, jitter)
+	ErrorCode    int    `json:"errorCode,omitempty"`    // rpc_error
+	ErrorMessage string `json:"errorMessage,omitempty"` // rpc_error
+	StatusCode   int    `json:"statusCode,omitempty"`   // http_error
+	StaleBlocks  uint64 `json:"staleBlocks,omitempty"`  // stale_block
+}
+
+// ChaosConfig configures fault injection for a node's proxy.
+type ChaosConfig struct {
+	Enabled bool         `json:"enabled"`
+	Faults  []ChaosFault `json:"faults"`
+}
+
+// Validate returns an error if any of the faults are invalid.
+func (cfg ChaosConfig) Validate() error {
+	for i, f := range cfg.Faults {
+		switch f.Type {
+		case ChaosLatency, ChaosRPCError, ChaosDropConnection, ChaosStaleBlock, ChaosTruncate:
+		case ChaosHTTPError:
+			if f.StatusCode != 0 && (f.StatusCode < 500 || f.StatusCode > 599) {
+				return fmt.Errorf("fault %d: status code must be 5xx", i)
+			}
+		default:
+			return fmt.Errorf("fault %d: unknown fault type %q", i, f.Type)
+		}
+		if f.Rate < 0 || f.Rate > 1 {
+			return fmt.Errorf("fault %d: rate must be between 0 and 1", i)
+		}
+		if f.LatencyMs < 0 || f.JitterMs < 0 {
+			return fmt.Errorf("fault %d: latency must not be negative", i)
+		}
+	}
+	return nil
+}
+
+// appliesTo returns whether the fault applies to a request with the given methods.
+func (f ChaosFault) appliesTo(methods []string) bool {
+	if len(f.Methods) == 0 {
+		return true
+	}
+	for _, m := range methods {
+		for _, fm := range f.Methods {
+			if m == fm {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Latency returns the latency to inject.
+func (f ChaosFault) Latency(rnd func(int64) int64) time.Duration {
+	latency := f.LatencyMs
+	if latency == 0 {
+		latency = defaultChaosLatency
+	}
+	if f.JitterMs > 0 {
+		latency += rnd(f.JitterMs)
+	}
+	return time.Duration(latency) * time.Millisecond
+}
+
+// HTTPStatusCode returns the status code of an injected HTTP error.
+func (f ChaosFault) HTTPStatusCode() int {
+	if f.StatusCode == 0 {
+		return defaultChaosStatusCode
+	}
+	return f.StatusCode
+}
+
+// RPCErrorResponse returns a JSON-RPC error response to the given request body.
+func (f ChaosFault) RPCErrorResponse(reqBody []byte) []byte {
+	code, message := f.ErrorCode, f.ErrorMessage
+	if code == 0 {
+		code = defaultChaosErrorCode
+	}
+	if message == "" {
+		message = defaultChaosErrorMessage
+	}
+
+	ids, _ := parseRequestIDs(reqBody)
+	responses := make([]jsonrpcResponse, 0, len(ids))
+	for _, id := range ids {
+		responses = append(responses, jsonrpcResponse{
+			JSONRPC: "2.0",
+			ID:      id,
+			Error:   &jsonrpcError{Code: code, Message: message},
+		})
+	}
+	if !isBatchRequest(reqBody) && len(responses) == 1 {
+		return mustMarshal(responses[0])
+	}
+	return mustMarshal(responses)
+}
+
+// StaleBlockNumber rewrites eth_blockNumber results of the response to lag behind by the configured number of blocks.
+// Returns false if the response does not contain any eth_blockNumber results.
+func (f ChaosFault) StaleBlockNumber(reqBody, resBody []byte) ([]byte, bool) {
+	blocks := f.StaleBlocks
+	if blocks == 0 {
+		blocks = defaultChaosStaleBlocks
+	}
+
+	calls, err := parseJSONRPCCalls(reqBody)
+	if err != nil {
+		return nil, false
+	}
+	blockNumberIDs := map[string]bool{}
+	for _, c := range calls {
+		if c.Method == "eth_blockNumber" {
+			blockNumberIDs[string(c.ID)] = true
+		}
+	}
+	if len(blockNumberIDs) == 0 {
+		return nil, false
+	}
+
+	var responses []jsonrpcResponse
+	isBatch := isBatchRequest(resBody)
+	if isBatch {
+		err = json.Unmarshal(resBody, &responses)
+	} else {
+		var res jsonrpcResponse
+		err = json.Unmarshal(resBody, &res)
+		responses = append(responses, res)
+	}
+	if err != nil {
+		return nil, false
+	}
+
+	modified := false
+	for i, res := range responses {
+		if !blockNumberIDs[string(res.ID)] || res.Error != nil {
+			continue
+		}
+		var hex string
+		if err := json.Unmarshal(res.Result, &hex); err != nil {
+			continue
+		}
+		number, err := hexutil.DecodeUint64(hex)
+		if err != nil {
+			continue
+		}
+		if number > blocks {
+			number -= blocks
+		} else {
+			number = 0
+		}
+		responses[i].Result = mustMarshal(hexutil.Uint64(number))
+		modified = true
+	}
+	if !modified {
+		return nil, false
+	}
+
+	if isBatch {
+		return mustMarshal(responses), true
+	}
+	return mustMarshal(responses[0]), true
+}
+
+// TruncateBody returns the first half of the body.
+func TruncateBody(body []byte) []byte {
+	return body[:len(body)/2]
+}
+
+// ChaosInjector decides which faults are injected into a request.
+type ChaosInjector struct {
+	cfg ChaosConfig
+
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+func NewChaosInjector(cfg ChaosConfig) (*ChaosInjector, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &ChaosInjector{
+		cfg: cfg,
+		rnd: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}, nil
+}
+
+// Sample returns the faults to inject into a request with the given methods.
+func (c *ChaosInjector) Sample(methods []string) []ChaosFault {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	faults := []ChaosFault{}
+	for _, f := range c.cfg.Faults {
+		if f.appliesTo(methods) && c.rnd.Float64() < f.Rate {
+			faults = append(faults, f)
+		}
+	}
+	return faults
+}
+
+// Int63n returns a random number in [0, n).
+func (c *ChaosInjector) Int63n(n int64) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rnd.Int63n(n)
+}
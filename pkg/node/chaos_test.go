@@ -0,0 +1,76 @@
+package node
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ChaosInjector_Sample(t *testing.T) {
+	is := assert.New(t)
+
+	injector, err := NewChaosInjector(ChaosConfig{
+		Enabled: true,
+		Faults: []ChaosFault{
+			{Type: ChaosLatency, Rate: 1},
+			{Type: ChaosRPCError, Rate: 0},
+			{Type: ChaosStaleBlock, Rate: 1, Methods: []string{"eth_blockNumber"}},
+		},
+	})
+	is.NoError(err)
+
+	faults := injector.Sample([]string{"eth_chainId"})
+	is.Len(faults, 1)
+	is.Equal(ChaosLatency, faults[0].Type)
+
+	faults = injector.Sample([]string{"eth_chainId", "eth_blockNumber"})
+	is.Len(faults, 2)
+	is.Equal(ChaosStaleBlock, faults[1].Type)
+}
+
+func Test_ChaosConfig_Validate(t *testing.T) {
+	is := assert.New(t)
+
+	is.NoError(ChaosConfig{Faults: []ChaosFault{{Type: ChaosHTTPError, Rate: 0.1, StatusCode: 502}}}.Validate())
+	is.Error(ChaosConfig{Faults: []ChaosFault{{Type: ChaosHTTPError, Rate: 0.1, StatusCode: 404}}}.Validate())
+	is.Error(ChaosConfig{Faults: []ChaosFault{{Type: "unknown", Rate: 0.1}}}.Validate())
+	is.Error(ChaosConfig{Faults: []ChaosFault{{Type: ChaosTruncate, Rate: 2}}}.Validate())
+}
+
+func Test_ChaosFault_StaleBlockNumber(t *testing.T) {
+	is := assert.New(t)
+
+	f := ChaosFault{Type: ChaosStaleBlock, StaleBlocks: 5}
+
+	body, ok := f.StaleBlockNumber(
+		[]byte(`[{"jsonrpc":"2.0","id":1,"method":"eth_chainId"},{"jsonrpc":"2.0","id":2,"method":"eth_blockNumber"}]`),
+		[]byte(`[{"jsonrpc":"2.0","id":1,"result":"0x64"},{"jsonrpc":"2.0","id":2,"result":"0x64"}]`),
+	)
+	is.True(ok)
+
+	var responses []struct {
+		Result string `json:"result"`
+	}
+	is.NoError(json.Unmarshal(body, &responses))
+	is.Equal("0x64", responses[0].Result)
+	is.Equal("0x5f", responses[1].Result)
+
+	_, ok = f.StaleBlockNumber(
+		[]byte(`{"jsonrpc":"2.0","id":1,"method":"eth_chainId"}`),
+		[]byte(`{"jsonrpc":"2.0","id":1,"result":"0x1"}`),
+	)
+	is.False(ok)
+}
+
+func Test_ChaosFault_RPCErrorResponse(t *testing.T) {
+	is := assert.New(t)
+
+	f := ChaosFault{Type: ChaosRPCError, ErrorCode: -32000, ErrorMessage: "boom"}
+
+	var res jsonrpcResponse
+	is.NoError(json.Unmarshal(f.RPCErrorResponse([]byte(`{"jsonrpc":"2.0","id":"abc","method":"eth_chainId"}`)), &res))
+	is.Equal(`"abc"`, string(res.ID))
+	is.Equal(-32000, res.Error.Code)
+	is.Equal("boom", res.Error.Message)
+}
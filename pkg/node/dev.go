@@ -0,0 +1,80 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Defaults of dev chains.
+const (
+	DefaultDevAccounts = 10
+	MaxDevAccounts     = 100
+)
+
+// DefaultDevBalance is the initial balance of the prefunded accounts of dev chains; 10000 ether.
+var DefaultDevBalance = new(big.Int).Mul(big.NewInt(10000), big.NewInt(params.Ether))
+
+// DevAccount is a prefunded account of a dev chain.
+type DevAccount struct {
+	Address common.Address `json:"address"`
+	// PrivateKey is shown to the user to import the account into wallets; dev accounts only hold funds of the dev chain
+	PrivateKey string       `json:"privateKey"`
+	Balance    *hexutil.Big `json:"balance"` // initial balance in wei
+}
+
+// DevChainConfig configures an in-process go-ethereum dev chain run by zeth.
+// The chain is held in memory; it starts from the genesis block whenever the node is started.
+type DevChainConfig struct {
+	Period   uint64 `json:"period"`   // seconds between blocks; blocks are sealed on demand when transactions are pending if 0
+	GasLimit uint64 `json:"gasLimit"` // block gas limit; defaults to the geth dev mode gas limit
+	HTTPPort int    `json:"httpPort"`
+	WSPort   int    `json:"wsPort"`
+	// Accounts are prefunded in the genesis block; the first account seals blocks
+	Accounts []DevAccount `json:"accounts"`
+}
+
+// Validate returns an error if the config is invalid; zero ports are allocated on creation.
+func (cfg DevChainConfig) Validate() error {
+	if cfg.GasLimit != 0 && cfg.GasLimit < params.MinGasLimit {
+		return fmt.Errorf("gasLimit must be at least %d", params.MinGasLimit)
+	}
+	for name, port := range map[string]int{"httpPort": cfg.HTTPPort, "wsPort": cfg.WSPort} {
+		if port < 0 || port > 65535 {
+			return fmt.Errorf("%s must be between 0 and 65535", name)
+		}
+	}
+	if cfg.HTTPPort != 0 && cfg.HTTPPort == cfg.WSPort {
+		return errors.New("httpPort must differ from wsPort")
+	}
+	return nil
+}
+
+// NewDevAccounts generates n accounts prefunded with the balance.
+func NewDevAccounts(n int, balance *big.Int) ([]DevAccount, error) {
+	if n < 1 || n > MaxDevAccounts {
+		return nil, fmt.Errorf("number of accounts must be between 1 and %d", MaxDevAccounts)
+	}
+	if balance == nil || balance.Sign() < 0 {
+		return nil, errors.New("balance must not be negative")
+	}
+
+	accounts := make([]DevAccount, n)
+	for i := range accounts {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			return nil, err
+		}
+		accounts[i] = DevAccount{
+			Address:    crypto.PubkeyToAddress(key.PublicKey),
+			PrivateKey: hexutil.Encode(crypto.FromECDSA(key)),
+			Balance:    (*hexutil.Big)(new(big.Int).Set(balance)),
+		}
+	}
+	return accounts, nil
+}
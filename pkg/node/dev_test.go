@@ -0,0 +1,51 @@
+package node
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DevChainConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     DevChainConfig
+		wantErr bool
+	}{
+		{"defaults", DevChainConfig{}, false},
+		{"period and gas limit", DevChainConfig{Period: 5, GasLimit: 30000000}, false},
+		{"gas limit too low", DevChainConfig{GasLimit: 100}, true},
+		{"port out of range", DevChainConfig{WSPort: -1}, true},
+		{"conflicting ports", DevChainConfig{HTTPPort: 8545, WSPort: 8545}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := assert.New(t)
+			is.Equal(tt.wantErr, tt.cfg.Validate() != nil)
+		})
+	}
+}
+
+func Test_NewDevAccounts(t *testing.T) {
+	is := assert.New(t)
+
+	accounts, err := NewDevAccounts(3, DefaultDevBalance)
+	is.NoError(err)
+	is.Len(accounts, 3)
+	for _, account := range accounts {
+		key, err := crypto.ToECDSA(hexutil.MustDecode(account.PrivateKey))
+		is.NoError(err)
+		is.Equal(account.Address, crypto.PubkeyToAddress(key.PublicKey))
+		is.Equal(DefaultDevBalance, account.Balance.ToInt())
+	}
+	is.NotEqual(accounts[0].Address, accounts[1].Address)
+
+	_, err = NewDevAccounts(0, DefaultDevBalance)
+	is.Error(err)
+	_, err = NewDevAccounts(1, big.NewInt(-1))
+	is.Error(err)
+}
@@ -0,0 +1,128 @@
+package node
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v3"
+	uuid "github.com/satori/go.uuid"
+	"github.com/zees-dev/zeth/pkg/datastore"
+)
+
+// note: namespace must not share a prefix with `nodeKey`, since datastore.GetAll iterates by prefix
+var nodeGroupKey = []byte("group")
+
+// DefaultArchiveDepth is the number of blocks behind head after which state queries are considered historical.
+// Full nodes typically retain the state of the most recent 128 blocks.
+const DefaultArchiveDepth = 128
+
+type NodeGroupService interface {
+	Create(context.Context, NodeGroup) (NodeGroup, error)
+	Get(context.Context, uuid.UUID) (*NodeGroup, error)
+	GetAll(context.Context) ([]NodeGroup, error)
+	Update(context.Context, uuid.UUID, NodeGroup) error
+	Delete(context.Context, uuid.UUID) error
+}
+
+// NodeGroupMode determines how requests proxied to a node group are distributed across its members.
+type NodeGroupMode uint
+
+const (
+	// GroupModeArchiveAware routes historical state queries to archive members and all other queries to full members.
+	GroupModeArchiveAware NodeGroupMode = iota
+	GroupModeEnd
+)
+
+func (mode NodeGroupMode) IsValid() bool {
+	return mode < GroupModeEnd
+}
+
+// NodeGroupMember is a node which belongs to a node group.
+type NodeGroupMember struct {
+	NodeID  uuid.UUID `json:"nodeId"`
+	Archive bool      `json:"archive"`
+}
+
+// NodeGroup is a set of nodes on the same chain which can be addressed via a single RPC endpoint.
+type NodeGroup struct {
+	ID           uuid.UUID         `json:"id"`
+	Name         string            `json:"name"`
+	Mode         NodeGroupMode     `json:"mode"`
+	ArchiveDepth uint64            `json:"archiveDepth"`
+	Members      []NodeGroupMember `json:"members"`
+	DateAdded    time.Time         `json:"dateAdded"`
+}
+
+type nodeGroupService struct {
+	store datastore.Store
+}
+
+func NewGroupService(store datastore.Store) *nodeGroupService {
+	return &nodeGroupService{store: store}
+}
+
+// Create assigns a UUID to the node group and saves it to the database.
+func (gs *nodeGroupService) Create(ctx context.Context, g NodeGroup) (NodeGroup, error) {
+	id := uuid.NewV4()
+
+	// set ID on existing props
+	g.ID = id
+
+	bodyBytes := new(bytes.Buffer)
+	json.NewEncoder(bodyBytes).Encode(g)
+
+	return g, gs.store.Set(nodeGroupKey, id.Bytes(), bodyBytes.Bytes())
+}
+
+func (gs *nodeGroupService) Get(ctx context.Context, id uuid.UUID) (*NodeGroup, error) {
+	dbGroup, err := gs.store.Get(nodeGroupKey, id.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	var group NodeGroup
+	if err := json.Unmarshal(dbGroup, &group); err != nil {
+		return nil, err
+	}
+
+	return &group, nil
+}
+
+func (gs *nodeGroupService) GetAll(ctx context.Context) ([]NodeGroup, error) {
+	results := []NodeGroup{}
+
+	groupsMap, err := gs.store.GetAll(nodeGroupKey)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range groupsMap {
+		var group NodeGroup
+		if err := json.Unmarshal(s, &group); err != nil {
+			return nil, err
+		}
+		results = append(results, group)
+	}
+
+	return results, nil
+}
+
+func (gs *nodeGroupService) Update(ctx context.Context, id uuid.UUID, g NodeGroup) error {
+	ok, err := gs.store.Has(nodeGroupKey, id.Bytes())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return badger.ErrKeyNotFound
+	}
+
+	bodyBytes := new(bytes.Buffer)
+	json.NewEncoder(bodyBytes).Encode(g)
+	return gs.store.Set(nodeGroupKey, id.Bytes(), bodyBytes.Bytes())
+}
+
+func (gs *nodeGroupService) Delete(ctx context.Context, id uuid.UUID) error {
+	return gs.store.RemovePrefix(nodeGroupKey, id.Bytes())
+}
@@ -0,0 +1,83 @@
+package node
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+type jsonrpcCall struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+// parseJSONRPCCalls parses a single or batched JSON-RPC request body.
+func parseJSONRPCCalls(body []byte) ([]jsonrpcCall, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var calls []jsonrpcCall
+		err := json.Unmarshal(body, &calls)
+		return calls, err
+	}
+	var call jsonrpcCall
+	if err := json.Unmarshal(body, &call); err != nil {
+		return nil, err
+	}
+	return []jsonrpcCall{call}, nil
+}
+
+// RequestMethods returns the methods of a single or batched JSON-RPC request body.
+func RequestMethods(body []byte) []string {
+	calls, err := parseJSONRPCCalls(body)
+	if err != nil {
+		return nil
+	}
+	methods := make([]string, 0, len(calls))
+	for _, call := range calls {
+		methods = append(methods, call.Method)
+	}
+	return methods
+}
+
+type jsonrpcError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+type jsonrpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *jsonrpcError   `json:"error,omitempty"`
+}
+
+// parseRequestIDs returns the ids of a single or batched JSON-RPC request.
+func parseRequestIDs(reqBody []byte) ([]json.RawMessage, error) {
+	calls, err := parseJSONRPCCalls(reqBody)
+	if err != nil {
+		return []json.RawMessage{json.RawMessage("null")}, err
+	}
+
+	ids := make([]json.RawMessage, 0, len(calls))
+	for _, c := range calls {
+		if len(c.ID) == 0 {
+			c.ID = json.RawMessage("null")
+		}
+		ids = append(ids, c.ID)
+	}
+	return ids, nil
+}
+
+func isBatchRequest(reqBody []byte) bool {
+	trimmed := bytes.TrimSpace(reqBody)
+	return len(trimmed) > 0 && trimmed[0] == '['
+}
+
+func mustMarshal(v interface{}) []byte {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
@@ -0,0 +1,146 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/zees-dev/zeth/pkg/geth"
+)
+
+// RestartPolicy determines whether the process of a managed node is restarted after it exits.
+type RestartPolicy string
+
+const (
+	RestartNever     RestartPolicy = "never"
+	RestartOnFailure RestartPolicy = "on-failure" // restart if the process exits with an error
+	RestartAlways    RestartPolicy = "always"
+)
+
+// ErrInvalidVersion is returned for versions which are not geth release versions; versions are part of the filenames of binaries.
+var ErrInvalidVersion = errors.New("version must be a geth release version, e.g. 1.10.11")
+
+// ValidVersion returns whether the version is a geth release version.
+func ValidVersion(version string) bool {
+	return geth.ValidVersion(version)
+}
+
+// ErrUnsupportedVersion is returned for geth versions which can not run the network of the node.
+var ErrUnsupportedVersion = errors.New("geth version does not support the network")
+
+// DefaultMaxRestarts is the number of consecutive restarts of a managed node before zeth gives up.
+const DefaultMaxRestarts = 5
+
+// ManagedConfig configures a geth process run and supervised by zeth.
+// The geth configuration is written to config.toml in the data dir of the node.
+type ManagedConfig struct {
+	Version       string        `json:"version"` // geth version, e.g. 1.10.11; the binary is run from the bin dir of zeth
+	DataDir       string        `json:"dataDir"` // set by zeth; a dir of the node in the app dir
+	HTTPPort      int           `json:"httpPort"`
+	WSPort        int           `json:"wsPort"`
+	P2PPort       int           `json:"p2pPort"`
+	RestartPolicy RestartPolicy `json:"restartPolicy"`
+	MaxRestarts   int           `json:"maxRestarts"` // consecutive restarts before giving up; defaults to DefaultMaxRestarts
+	AutoStart     bool          `json:"autoStart"`   // start the node when zeth starts
+	// LogMaxSize is the size in bytes at which the log file of the output is rotated; defaults to 10MiB
+	LogMaxSize int64 `json:"logMaxSize"`
+	// LogMaxFiles is the number of rotated log files retained; defaults to 5
+	LogMaxFiles int `json:"logMaxFiles"`
+	// Network is the preset of the network the node was created with; nil for mainnet
+	Network *geth.Preset `json:"network,omitempty"`
+	// Args are command line flags passed to geth besides the config file; e.g. flags which can not be set in the config file
+	Args []string `json:"args,omitempty"`
+}
+
+// reservedFlags are geth flags which must not be set by args of users; the config file, data dir and IPC endpoint of the node are
+// set by zeth, and accounts are only unlocked by members of private networks, which serve RPC on the loopback interface.
+var reservedFlags = []string{"config", "datadir", "ipcpath", "keystore", "unlock", "password"}
+
+// FlagName returns the name of the flag set by the command line arg; geth accepts flags with one or two leading dashes.
+// Empty if the arg is not a flag; e.g. the value of the previous flag.
+func FlagName(arg string) string {
+	if !strings.HasPrefix(arg, "-") {
+		return ""
+	}
+	name := strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+	if i := strings.Index(name, "="); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// Validate returns an error if the config is invalid; zero ports are allocated on creation, and an empty version defaults to the
+// bundled geth version.
+func (cfg ManagedConfig) Validate() error {
+	if cfg.Version != "" && !ValidVersion(cfg.Version) {
+		return ErrInvalidVersion
+	}
+	for name, port := range map[string]int{"httpPort": cfg.HTTPPort, "wsPort": cfg.WSPort, "p2pPort": cfg.P2PPort} {
+		if port < 0 || port > 65535 {
+			return fmt.Errorf("%s must be between 0 and 65535", name)
+		}
+	}
+	if cfg.HTTPPort != 0 && (cfg.HTTPPort == cfg.WSPort || cfg.HTTPPort == cfg.P2PPort) {
+		return errors.New("httpPort must differ from wsPort and p2pPort")
+	}
+	if cfg.WSPort != 0 && cfg.WSPort == cfg.P2PPort {
+		return errors.New("wsPort must differ from p2pPort")
+	}
+	switch cfg.RestartPolicy {
+	case "", RestartNever, RestartOnFailure, RestartAlways:
+	default:
+		return fmt.Errorf("restartPolicy must be one of %s, %s, %s", RestartNever, RestartOnFailure, RestartAlways)
+	}
+	if cfg.MaxRestarts < 0 {
+		return errors.New("maxRestarts must not be negative")
+	}
+	if cfg.LogMaxSize < 0 || cfg.LogMaxFiles < 0 {
+		return errors.New("logMaxSize and logMaxFiles must not be negative")
+	}
+	if cfg.Network != nil {
+		if err := cfg.Network.Validate(); err != nil {
+			return fmt.Errorf("network: %w", err)
+		}
+		if err := cfg.CheckNetworkVersion(); err != nil {
+			return err
+		}
+	}
+	for _, arg := range cfg.Args {
+		name := FlagName(arg)
+		for _, flag := range reservedFlags {
+			if name == flag {
+				return fmt.Errorf("args must not set --%s", flag)
+			}
+		}
+	}
+	return nil
+}
+
+// CheckNetworkVersion returns ErrUnsupportedVersion if the geth version of the node is older than the network of the node
+// requires; an empty version is the bundled geth version.
+func (cfg ManagedConfig) CheckNetworkVersion() error {
+	if cfg.Network == nil || cfg.Network.MinVersion == "" {
+		return nil
+	}
+	version := cfg.Version
+	if version == "" {
+		version = params.Version
+	}
+	if geth.CompareVersions(version, cfg.Network.MinVersion) < 0 {
+		return fmt.Errorf("%w: the %s network requires geth v%s or newer", ErrUnsupportedVersion, cfg.Network.Name, cfg.Network.MinVersion)
+	}
+	return nil
+}
+
+// Restart returns whether the process should be restarted after exiting; failed is set if it exited with an error.
+func (cfg ManagedConfig) Restart(failed bool, restarts int) bool {
+	max := cfg.MaxRestarts
+	if max == 0 {
+		max = DefaultMaxRestarts
+	}
+	if restarts >= max {
+		return false
+	}
+	return cfg.RestartPolicy == RestartAlways || (cfg.RestartPolicy == RestartOnFailure && failed)
+}
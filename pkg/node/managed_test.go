@@ -0,0 +1,64 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zees-dev/zeth/pkg/geth"
+)
+
+func Test_ManagedConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     ManagedConfig
+		wantErr bool
+	}{
+		{"defaults", ManagedConfig{}, false},
+		{"ports", ManagedConfig{HTTPPort: 8545, WSPort: 8546, P2PPort: 30303}, false},
+		{"port out of range", ManagedConfig{HTTPPort: 70000}, true},
+		{"conflicting ports", ManagedConfig{HTTPPort: 8545, WSPort: 8545}, true},
+		{"invalid restart policy", ManagedConfig{RestartPolicy: "sometimes"}, true},
+		{"negative max restarts", ManagedConfig{MaxRestarts: -1}, true},
+		{"args", ManagedConfig{Args: []string{"--mine", "--miner.threads", "1"}}, false},
+		{"network", ManagedConfig{Version: "1.15.0", Network: &geth.Preset{Name: "testnet", NetworkID: 5, MinVersion: "1.15.0"}}, false},
+		{"network requiring a newer version", ManagedConfig{Network: &geth.Preset{Name: "testnet", NetworkID: 5, MinVersion: "1.15.0"}}, true},
+		{"args overriding config", ManagedConfig{Args: []string{"--config=other.toml"}}, true},
+		{"args overriding data dir", ManagedConfig{Args: []string{"--datadir", "/tmp"}}, true},
+		{"args overriding data dir with one dash", ManagedConfig{Args: []string{"-datadir=/tmp"}}, true},
+		{"args unlocking accounts", ManagedConfig{Args: []string{"--unlock", "0x0000000000000000000000000000000000000001"}}, true},
+		{"args setting password", ManagedConfig{Args: []string{"-password", "pass.txt"}}, true},
+		{"args setting keystore", ManagedConfig{Args: []string{"--keystore=/tmp"}}, true},
+		{"args setting ipc path", ManagedConfig{Args: []string{"--ipcpath", "/tmp/geth.ipc"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := assert.New(t)
+			is.Equal(tt.wantErr, tt.cfg.Validate() != nil)
+		})
+	}
+}
+
+func Test_ManagedConfigRestart(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      ManagedConfig
+		failed   bool
+		restarts int
+		want     bool
+	}{
+		{"never", ManagedConfig{RestartPolicy: RestartNever}, true, 0, false},
+		{"on failure after failure", ManagedConfig{RestartPolicy: RestartOnFailure}, true, 0, true},
+		{"on failure after clean exit", ManagedConfig{RestartPolicy: RestartOnFailure}, false, 0, false},
+		{"always after clean exit", ManagedConfig{RestartPolicy: RestartAlways}, false, 0, true},
+		{"default max restarts reached", ManagedConfig{RestartPolicy: RestartAlways}, true, DefaultMaxRestarts, false},
+		{"max restarts reached", ManagedConfig{RestartPolicy: RestartAlways, MaxRestarts: 1}, true, 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := assert.New(t)
+			is.Equal(tt.want, tt.cfg.Restart(tt.failed, tt.restarts))
+		})
+	}
+}
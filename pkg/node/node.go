@@ -2,6 +2,7 @@ package node
 
 import (
 	"context"
+	"net/http"
 	"time"
 
 	uuid "github.com/satori/go.uuid"
@@ -12,8 +13,13 @@ type NodeService interface {
 	Get(context.Context, uuid.UUID) (*ZethNode, error)
 	GetAll(context.Context) ([]ZethNode, error)
 	Update(context.Context, uuid.UUID, ZethNode) error
+	UpdateCapabilities(context.Context, uuid.UUID, *Capabilities) error
+	UpdateErrorNormalization(context.Context, uuid.UUID, ErrorNormalizationConfig) error
+	UpdateChaos(context.Context, uuid.UUID, ChaosConfig) error
+	UpdateManagedVersion(context.Context, uuid.UUID, string) error
 	Delete(context.Context, uuid.UUID) error
 	ReverseProxyCache() ReverseProxyCache
+	Transport(ZethNode) (*http.Transport, error)
 }
 
 // Following sites can be used to point to free ethereum nodes
@@ -25,25 +31,9 @@ const (
 	DefaultNodeWSRPC   = "wss://main-light.eth.linkpool.io/ws"
 )
 
-// Ethereum mainnet and sidechain Network IDs
+// NetworkID is the network ID of a chain (net_version); chains are described by the chain registry.
 type NetworkID uint64
 
-const (
-	// Mainnet is the default eth network ID
-	Mainnet           NetworkID = 1
-	BinanceSmartChain NetworkID = 56
-	Polygon           NetworkID = 137
-)
-
-func (id NetworkID) IsSupported() bool {
-	switch id {
-	case Mainnet, BinanceSmartChain, Polygon:
-		return true
-	default:
-		return false
-	}
-}
-
 type ZethNode struct {
 	ID          uuid.UUID `json:"id"`
 	Name        string    `json:"name"`
@@ -52,4 +42,38 @@ type ZethNode struct {
 	DateAdded   time.Time `json:"dateAdded"`
 	ExplorerURL string    `json:"explorerUrl"`
 	RPC         RPC       `json:"rpc"`
+
+	// Chain is discovered from the node's RPC endpoint on registration if requested; nil if unknown
+	Chain *ChainMetadata `json:"chain,omitempty"`
+	// Capabilities are probed on registration and on demand; nil if not probed
+	Capabilities *Capabilities `json:"capabilities,omitempty"`
+	// Managed is set if the node is a geth process run by zeth; nil for external nodes
+	Managed *ManagedConfig `json:"managed,omitempty"`
+	// Dev is set if the node is an in-process dev chain run by zeth; nil otherwise
+	Dev *DevChainConfig `json:"dev,omitempty"`
+
+	Transport          TransportConfig          `json:"transport"`
+	ErrorNormalization ErrorNormalizationConfig `json:"errorNormalization"`
+	Chaos              ChaosConfig              `json:"chaos"`
+}
+
+// Summary identifies a node in lifecycle events. The event stream is readable by all of its subscribers, so the summary leaves out
+// the config of the node, which may contain secrets; e.g. API keys in RPC urls, TLS client keys and private keys of dev accounts.
+type Summary struct {
+	ID      uuid.UUID `json:"id"`
+	Name    string    `json:"name"`
+	Enabled bool      `json:"enabled"`
+	IsDev   bool      `json:"isDev"`
+	Managed bool      `json:"managed"` // a geth process run by zeth
+}
+
+// Summary returns the summary of the node published in lifecycle events.
+func (n ZethNode) Summary() Summary {
+	return Summary{ID: n.ID, Name: n.Name, Enabled: n.Enabled, IsDev: n.IsDev, Managed: n.Managed != nil}
+}
+
+// Redacted returns the node without write-only secrets; for API responses.
+func (n ZethNode) Redacted() ZethNode {
+	n.Transport = n.Transport.Redacted()
+	return n
 }
@@ -0,0 +1,287 @@
+package node
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Normalized JSON-RPC error codes; based on EIP-1474 and go-ethereum conventions.
+const (
+	ErrCodeExecutionReverted   = 3
+	ErrCodeBlockNotFound       = -32001
+	ErrCodeStateUnavailable    = -32002
+	ErrCodeRateLimited         = -32005
+	ErrCodeUpstreamUnavailable = -32603
+)
+
+// Normalized error categories.
+const (
+	ErrCategoryExecutionReverted   = "execution_reverted"
+	ErrCategoryBlockNotFound       = "block_not_found" // the block is not known to the node yet, e.g. beyond its head
+	ErrCategoryStateUnavailable    = "state_unavailable"
+	ErrCategoryRateLimited         = "rate_limited"
+	ErrCategoryUpstreamUnavailable = "upstream_unavailable"
+)
+
+// maxUpstreamMessageLength limits the length of non JSON-RPC upstream bodies used as error messages.
+const maxUpstreamMessageLength = 256
+
+// ErrorRule maps a provider-specific error to a normalized JSON-RPC error.
+// All non-empty matchers of a rule must match for the rule to apply.
+type ErrorRule struct {
+	Name           string `json:"name"`
+	HTTPStatus     int    `json:"httpStatus,omitempty"`     // upstream HTTP status code; 0 matches any
+	ErrorCode      *int   `json:"errorCode,omitempty"`      // upstream JSON-RPC error code; nil matches any
+	MessagePattern string `json:"messagePattern,omitempty"` // regex matched against upstream error message (or body)
+	Category       string `json:"category"`
+	Code           int    `json:"code"`
+	Message        string `json:"message,omitempty"` // normalized message; defaults to the upstream message
+}
+
+// ErrorNormalizationConfig configures provider error normalization of a node.
+// Custom rules take precedence over DefaultErrorRules.
+type ErrorNormalizationConfig struct {
+	Enabled bool        `json:"enabled"`
+	Rules   []ErrorRule `json:"rules"`
+}
+
+// DefaultErrorRules are known error shapes of common providers and clients.
+var DefaultErrorRules = []ErrorRule{
+	{
+		Name:       "http-too-many-requests",
+		HTTPStatus: http.StatusTooManyRequests,
+		Category:   ErrCategoryRateLimited,
+		Code:       ErrCodeRateLimited,
+		Message:    "rate limited",
+	},
+	{
+		Name:           "rate-limit-message",
+		MessagePattern: `(?i)rate.?limit|too many requests|request limit|exceeded .*(capacity|quota|limit)|daily request count`,
+		Category:       ErrCategoryRateLimited,
+		Code:           ErrCodeRateLimited,
+		Message:        "rate limited",
+	},
+	{
+		// geth responds with "header not found" for blocks beyond its head; not an error of pruned nodes
+		Name:           "unknown-block",
+		MessagePattern: `(?i)^(header|block) not found$|^unknown block$`,
+		Category:       ErrCategoryBlockNotFound,
+		Code:           ErrCodeBlockNotFound,
+		Message:        "block not found",
+	},
+	{
+		Name:           "pruned-state",
+		MessagePattern: `(?i)missing trie node|historical state .*(not available|unavailable)|state .*(pruned|not available)|old data not available|not an archive node`,
+		Category:       ErrCategoryStateUnavailable,
+		Code:           ErrCodeStateUnavailable,
+		Message:        "state unavailable",
+	},
+	{
+		Name:           "execution-reverted",
+		MessagePattern: `(?i)^(execution reverted|reverted|vm execution error)\b`,
+		Category:       ErrCategoryExecutionReverted,
+		Code:           ErrCodeExecutionReverted,
+	},
+	{
+		Name:       "http-bad-gateway",
+		HTTPStatus: http.StatusBadGateway,
+		Category:   ErrCategoryUpstreamUnavailable,
+		Code:       ErrCodeUpstreamUnavailable,
+		Message:    "upstream unavailable",
+	},
+	{
+		Name:       "http-service-unavailable",
+		HTTPStatus: http.StatusServiceUnavailable,
+		Category:   ErrCategoryUpstreamUnavailable,
+		Code:       ErrCodeUpstreamUnavailable,
+		Message:    "upstream unavailable",
+	},
+	{
+		Name:       "http-gateway-timeout",
+		HTTPStatus: http.StatusGatewayTimeout,
+		Category:   ErrCategoryUpstreamUnavailable,
+		Code:       ErrCodeUpstreamUnavailable,
+		Message:    "upstream unavailable",
+	},
+}
+
+// NormalizedErrorData is the data of a normalized JSON-RPC error.
+type NormalizedErrorData struct {
+	Category        string          `json:"category"`
+	Rule            string          `json:"rule"`
+	UpstreamStatus  int             `json:"upstreamStatus"`
+	UpstreamCode    *int            `json:"upstreamCode,omitempty"`
+	UpstreamMessage string          `json:"upstreamMessage,omitempty"`
+	UpstreamData    json.RawMessage `json:"upstreamData,omitempty"`
+}
+
+// NormalizationResult records the original upstream response of a normalized response.
+type NormalizationResult struct {
+	OriginalStatusCode int                   `json:"originalStatusCode"`
+	OriginalBody       string                `json:"originalBody"`
+	Errors             []NormalizedErrorData `json:"errors"`
+}
+
+type compiledErrorRule struct {
+	ErrorRule
+	pattern *regexp.Regexp
+}
+
+// ErrorNormalizer rewrites provider-specific error responses into consistent JSON-RPC errors.
+type ErrorNormalizer struct {
+	rules []compiledErrorRule
+}
+
+// NewErrorNormalizer compiles the custom rules followed by the default rules.
+func NewErrorNormalizer(custom []ErrorRule) (*ErrorNormalizer, error) {
+	n := &ErrorNormalizer{}
+	for _, rule := range append(append([]ErrorRule{}, custom...), DefaultErrorRules...) {
+		if err := ValidateErrorRule(rule); err != nil {
+			return nil, err
+		}
+		compiled := compiledErrorRule{ErrorRule: rule}
+		if rule.MessagePattern != "" {
+			compiled.pattern = regexp.MustCompile(rule.MessagePattern)
+		}
+		n.rules = append(n.rules, compiled)
+	}
+	return n, nil
+}
+
+// ValidateErrorRule returns an error if the rule cannot be applied.
+func ValidateErrorRule(rule ErrorRule) error {
+	if rule.Name == "" {
+		return fmt.Errorf("error rule name is required")
+	}
+	if rule.HTTPStatus == 0 && rule.ErrorCode == nil && rule.MessagePattern == "" {
+		return fmt.Errorf("error rule %s: at least one of httpStatus, errorCode or messagePattern is required", rule.Name)
+	}
+	if rule.MessagePattern != "" {
+		if _, err := regexp.Compile(rule.MessagePattern); err != nil {
+			return fmt.Errorf("error rule %s: invalid message pattern: %w", rule.Name, err)
+		}
+	}
+	if rule.Category == "" {
+		return fmt.Errorf("error rule %s: category is required", rule.Name)
+	}
+	return nil
+}
+
+func (rule compiledErrorRule) matches(status int, code *int, message string) bool {
+	if rule.HTTPStatus != 0 && rule.HTTPStatus != status {
+		return false
+	}
+	if rule.ErrorCode != nil && (code == nil || *rule.ErrorCode != *code) {
+		return false
+	}
+	if rule.pattern != nil && !rule.pattern.MatchString(message) {
+		return false
+	}
+	return true
+}
+
+// Normalize rewrites the upstream response if any of its errors match a rule.
+// The request body is used to derive response ids when the upstream did not respond with JSON-RPC.
+// Returns the normalized body and the normalization result, or nil if the response was not modified.
+func (n *ErrorNormalizer) Normalize(reqBody []byte, status int, body []byte) ([]byte, *NormalizationResult) {
+	result := &NormalizationResult{OriginalStatusCode: status, OriginalBody: string(body)}
+
+	trimmed := bytes.TrimSpace(body)
+	isBatch := len(trimmed) > 0 && trimmed[0] == '['
+
+	var responses []jsonrpcResponse
+	var err error
+	if isBatch {
+		err = json.Unmarshal(trimmed, &responses)
+	} else {
+		var res jsonrpcResponse
+		if err = json.Unmarshal(trimmed, &res); err == nil && (res.Error != nil || res.Result != nil) {
+			responses = []jsonrpcResponse{res}
+		}
+	}
+
+	// upstream did not respond with JSON-RPC; synthesize an error response per request
+	if err != nil || len(responses) == 0 {
+		if status < http.StatusBadRequest {
+			return nil, nil
+		}
+		message := strings.TrimSpace(string(body))
+		if len(message) > maxUpstreamMessageLength {
+			message = message[:maxUpstreamMessageLength]
+		}
+		if message == "" {
+			message = http.StatusText(status)
+		}
+		rule, ok := n.match(status, nil, message)
+		if !ok {
+			return nil, nil
+		}
+
+		calls, _ := parseRequestIDs(reqBody)
+		for _, id := range calls {
+			res := jsonrpcResponse{JSONRPC: "2.0", ID: id}
+			res.Error = rule.normalize(status, &jsonrpcError{Message: message}, false, result)
+			responses = append(responses, res)
+		}
+		if len(calls) == 1 && !isBatchRequest(reqBody) {
+			return mustMarshal(responses[0]), result
+		}
+		return mustMarshal(responses), result
+	}
+
+	modified := false
+	for i, res := range responses {
+		if res.Error == nil {
+			continue
+		}
+		rule, ok := n.match(status, &res.Error.Code, res.Error.Message)
+		if !ok {
+			continue
+		}
+		responses[i].Error = rule.normalize(status, res.Error, true, result)
+		modified = true
+	}
+	if !modified {
+		return nil, nil
+	}
+
+	if isBatch {
+		return mustMarshal(responses), result
+	}
+	return mustMarshal(responses[0]), result
+}
+
+func (n *ErrorNormalizer) match(status int, code *int, message string) (compiledErrorRule, bool) {
+	for _, rule := range n.rules {
+		if rule.matches(status, code, message) {
+			return rule, true
+		}
+	}
+	return compiledErrorRule{}, false
+}
+
+// normalize builds the normalized error and appends its data to the normalization result.
+func (rule compiledErrorRule) normalize(status int, upstream *jsonrpcError, hasCode bool, result *NormalizationResult) *jsonrpcError {
+	data := NormalizedErrorData{
+		Category:        rule.Category,
+		Rule:            rule.Name,
+		UpstreamStatus:  status,
+		UpstreamMessage: upstream.Message,
+		UpstreamData:    upstream.Data,
+	}
+	if hasCode {
+		code := upstream.Code
+		data.UpstreamCode = &code
+	}
+	result.Errors = append(result.Errors, data)
+
+	message := rule.Message
+	if message == "" {
+		message = upstream.Message
+	}
+	return &jsonrpcError{Code: rule.Code, Message: message, Data: mustMarshal(data)}
+}
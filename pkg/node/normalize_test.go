@@ -0,0 +1,161 @@
+package node
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ErrorNormalizer_Normalize(t *testing.T) {
+	is := assert.New(t)
+
+	infuraCode := -32005
+	normalizer, err := NewErrorNormalizer([]ErrorRule{
+		{
+			Name:      "custom-code",
+			ErrorCode: &infuraCode,
+			Category:  "custom",
+			Code:      -1,
+			Message:   "custom",
+		},
+	})
+	is.NoError(err)
+
+	tests := []struct {
+		name     string
+		reqBody  string
+		status   int
+		body     string
+		modified bool
+		wantCode int
+		wantRule string
+	}{
+		{
+			name:     "success response is not modified",
+			reqBody:  `{"jsonrpc":"2.0","id":1,"method":"eth_chainId","params":[]}`,
+			status:   http.StatusOK,
+			body:     `{"jsonrpc":"2.0","id":1,"result":"0x1"}`,
+			modified: false,
+		},
+		{
+			name:     "unknown error is not modified",
+			reqBody:  `{"jsonrpc":"2.0","id":1,"method":"eth_chainId","params":[]}`,
+			status:   http.StatusOK,
+			body:     `{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"the method does not exist"}}`,
+			modified: false,
+		},
+		{
+			name:     "custom rule takes precedence",
+			reqBody:  `{"jsonrpc":"2.0","id":1,"method":"eth_chainId","params":[]}`,
+			status:   http.StatusOK,
+			body:     `{"jsonrpc":"2.0","id":1,"error":{"code":-32005,"message":"project ID request rate exceeded"}}`,
+			modified: true,
+			wantCode: -1,
+			wantRule: "custom-code",
+		},
+		{
+			name:     "pruned state",
+			reqBody:  `{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0x0","0x1"]}`,
+			status:   http.StatusOK,
+			body:     `{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"missing trie node 1a2b (path )"}}`,
+			modified: true,
+			wantCode: ErrCodeStateUnavailable,
+			wantRule: "pruned-state",
+		},
+		{
+			name:     "revert",
+			reqBody:  `{"jsonrpc":"2.0","id":1,"method":"eth_call","params":[{},"latest"]}`,
+			status:   http.StatusOK,
+			body:     `{"jsonrpc":"2.0","id":1,"error":{"code":-32015,"message":"VM execution error.","data":"Reverted 0x"}}`,
+			modified: true,
+			wantCode: ErrCodeExecutionReverted,
+			wantRule: "execution-reverted",
+		},
+		{
+			name:     "revert reason",
+			reqBody:  `{"jsonrpc":"2.0","id":1,"method":"eth_call","params":[{},"latest"]}`,
+			status:   http.StatusOK,
+			body:     `{"jsonrpc":"2.0","id":1,"error":{"code":3,"message":"execution reverted: insufficient balance"}}`,
+			modified: true,
+			wantCode: ErrCodeExecutionReverted,
+			wantRule: "execution-reverted",
+		},
+		{
+			name:     "messages mentioning reverts are not reverts",
+			reqBody:  `{"jsonrpc":"2.0","id":1,"method":"eth_sendRawTransaction","params":["0x"]}`,
+			status:   http.StatusOK,
+			body:     `{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"cannot estimate gas; transaction may fail or may require manual gas limit, reverted by contract"}}`,
+			modified: false,
+		},
+		{
+			name:     "block beyond head",
+			reqBody:  `{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0x0","0xffffff"]}`,
+			status:   http.StatusOK,
+			body:     `{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"header not found"}}`,
+			modified: true,
+			wantCode: ErrCodeBlockNotFound,
+			wantRule: "unknown-block",
+		},
+		{
+			name:     "non json-rpc rate limit",
+			reqBody:  `{"jsonrpc":"2.0","id":7,"method":"eth_chainId","params":[]}`,
+			status:   http.StatusTooManyRequests,
+			body:     `Too Many Requests`,
+			modified: true,
+			wantCode: ErrCodeRateLimited,
+			wantRule: "http-too-many-requests",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, result := normalizer.Normalize([]byte(tt.reqBody), tt.status, []byte(tt.body))
+			if !tt.modified {
+				is.Nil(body)
+				is.Nil(result)
+				return
+			}
+			is.NotNil(result)
+			is.Equal(tt.body, result.OriginalBody)
+			is.Equal(tt.status, result.OriginalStatusCode)
+			is.Len(result.Errors, 1)
+			is.Equal(tt.wantRule, result.Errors[0].Rule)
+
+			var res jsonrpcResponse
+			is.NoError(json.Unmarshal(body, &res))
+			is.NotNil(res.Error)
+			is.Equal(tt.wantCode, res.Error.Code)
+		})
+	}
+}
+
+func Test_ErrorNormalizer_NormalizeBatch(t *testing.T) {
+	is := assert.New(t)
+
+	normalizer, err := NewErrorNormalizer(nil)
+	is.NoError(err)
+
+	body, result := normalizer.Normalize(
+		[]byte(`[{"jsonrpc":"2.0","id":1,"method":"eth_chainId"},{"jsonrpc":"2.0","id":2,"method":"eth_chainId"}]`),
+		http.StatusServiceUnavailable,
+		[]byte(`<html>503 Service Unavailable</html>`),
+	)
+	is.NotNil(result)
+
+	var responses []jsonrpcResponse
+	is.NoError(json.Unmarshal(body, &responses))
+	is.Len(responses, 2)
+	is.Equal("2", string(responses[1].ID))
+	is.Equal(ErrCodeUpstreamUnavailable, responses[1].Error.Code)
+}
+
+func Test_ValidateErrorRule(t *testing.T) {
+	is := assert.New(t)
+
+	is.Error(ValidateErrorRule(ErrorRule{Name: "no-matcher", Category: "x"}))
+	is.Error(ValidateErrorRule(ErrorRule{Name: "bad-regex", MessagePattern: "(", Category: "x"}))
+	is.Error(ValidateErrorRule(ErrorRule{MessagePattern: "x", Category: "x"}))
+	is.NoError(ValidateErrorRule(ErrorRule{Name: "ok", HTTPStatus: 429, Category: "x"}))
+}
@@ -0,0 +1,281 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	uuid "github.com/satori/go.uuid"
+)
+
+var (
+	ErrNoGroupMembers    = errors.New("node group has no enabled members")
+	ErrInvalidRPCRequest = errors.New("invalid json-rpc request")
+)
+
+// defaultHeadTTL is the duration for which a node group's head block number is cached.
+const defaultHeadTTL = 3 * time.Second
+
+// RoutingTarget is the class of group members a request is routed to.
+type RoutingTarget string
+
+const (
+	RoutingTargetFull    RoutingTarget = "full"
+	RoutingTargetArchive RoutingTarget = "archive"
+)
+
+// RoutingDecision records how a request proxied to a node group was routed.
+type RoutingDecision struct {
+	GroupID  uuid.UUID     `json:"groupId"`
+	NodeID   uuid.UUID     `json:"nodeId"`
+	Target   RoutingTarget `json:"target"`
+	Reason   string        `json:"reason"`
+	Head     uint64        `json:"head,omitempty"`
+	Fallback bool          `json:"fallback,omitempty"` // true if the preferred target had no enabled members
+}
+
+// stateMethodBlockParam maps state methods to the position of their block parameter.
+var stateMethodBlockParam = map[string]int{
+	"eth_getBalance":          1,
+	"eth_getCode":             1,
+	"eth_getTransactionCount": 1,
+	"eth_getStorageAt":        2,
+	"eth_call":                1,
+	"eth_estimateGas":         1,
+	"eth_createAccessList":    1,
+	"eth_getProof":            2,
+}
+
+// blockReference is the block a state query is executed against.
+type blockReference struct {
+	tag    string // one of latest, pending, safe, finalized, earliest
+	number *uint64
+	hash   string
+}
+
+// parseBlockReference parses a block number, tag or EIP-1898 block object.
+func parseBlockReference(param json.RawMessage) (blockReference, error) {
+	var s string
+	if err := json.Unmarshal(param, &s); err == nil {
+		return parseBlockNumberOrTag(s)
+	}
+
+	var obj struct {
+		BlockNumber *string `json:"blockNumber"`
+		BlockHash   *string `json:"blockHash"`
+	}
+	if err := json.Unmarshal(param, &obj); err != nil {
+		return blockReference{}, fmt.Errorf("invalid block parameter: %s", param)
+	}
+	switch {
+	case obj.BlockHash != nil:
+		return blockReference{hash: *obj.BlockHash}, nil
+	case obj.BlockNumber != nil:
+		return parseBlockNumberOrTag(*obj.BlockNumber)
+	}
+	return blockReference{}, fmt.Errorf("invalid block parameter: %s", param)
+}
+
+func parseBlockNumberOrTag(s string) (blockReference, error) {
+	switch s {
+	case "latest", "pending", "safe", "finalized", "earliest":
+		return blockReference{tag: s}, nil
+	}
+	n, err := hexutil.DecodeUint64(s)
+	if err != nil {
+		return blockReference{}, fmt.Errorf("invalid block number %q: %w", s, err)
+	}
+	return blockReference{number: &n}, nil
+}
+
+// classifyCall determines whether a call must be served by an archive node.
+// head is only invoked if the call references a block by number.
+func classifyCall(call jsonrpcCall, depth uint64, head func() (uint64, error)) (bool, string, error) {
+	idx, ok := stateMethodBlockParam[call.Method]
+	if !ok {
+		return false, fmt.Sprintf("%s is not a state method", call.Method), nil
+	}
+	if len(call.Params) <= idx || string(call.Params[idx]) == "null" {
+		return false, fmt.Sprintf("%s at latest block", call.Method), nil
+	}
+
+	ref, err := parseBlockReference(call.Params[idx])
+	if err != nil {
+		return false, "", err
+	}
+
+	switch {
+	case ref.hash != "":
+		return true, fmt.Sprintf("%s at block hash %s", call.Method, ref.hash), nil
+	case ref.tag == "earliest":
+		return true, fmt.Sprintf("%s at earliest block", call.Method), nil
+	case ref.tag != "":
+		return false, fmt.Sprintf("%s at %s block", call.Method, ref.tag), nil
+	}
+
+	h, err := head()
+	if err != nil {
+		return false, "", err
+	}
+	if h > *ref.number && h-*ref.number > depth {
+		return true, fmt.Sprintf("%s at block %d is %d blocks behind head", call.Method, *ref.number, h-*ref.number), nil
+	}
+	return false, fmt.Sprintf("%s at block %d is within %d blocks of head", call.Method, *ref.number, depth), nil
+}
+
+type cachedHead struct {
+	number    uint64
+	fetchedAt time.Time
+}
+
+// GroupRouter selects the group member which serves a request proxied to a node group.
+type GroupRouter struct {
+	nodes   NodeService
+	headTTL time.Duration
+
+	mu    sync.Mutex
+	heads map[uuid.UUID]cachedHead
+	next  map[uuid.UUID]uint64 // round-robin counter per group
+}
+
+func NewGroupRouter(nodes NodeService) *GroupRouter {
+	return &GroupRouter{
+		nodes:   nodes,
+		headTTL: defaultHeadTTL,
+		heads:   make(map[uuid.UUID]cachedHead),
+		next:    make(map[uuid.UUID]uint64),
+	}
+}
+
+// Route returns the routing decision for a request body proxied to the group.
+// Websocket connections cannot be inspected per message and are always routed to full members.
+func (gr *GroupRouter) Route(ctx context.Context, group NodeGroup, body []byte, isWebSocket bool) (RoutingDecision, error) {
+	full, archive, err := gr.members(ctx, group)
+	if err != nil {
+		return RoutingDecision{}, err
+	}
+
+	decision := RoutingDecision{GroupID: group.ID, Target: RoutingTargetFull}
+	if isWebSocket {
+		decision.Reason = "websocket connection"
+	} else {
+		calls, err := parseJSONRPCCalls(body)
+		if err != nil {
+			return RoutingDecision{}, fmt.Errorf("%w: %v", ErrInvalidRPCRequest, err)
+		}
+
+		depth := group.ArchiveDepth
+		if depth == 0 {
+			depth = DefaultArchiveDepth
+		}
+		var headErr error
+		head := func() (uint64, error) {
+			h, err := gr.head(ctx, group.ID, append(full, archive...))
+			decision.Head, headErr = h, err
+			return h, err
+		}
+
+		reasons := []string{}
+		for _, call := range calls {
+			isArchive, reason, err := classifyCall(call, depth, head)
+			if headErr != nil {
+				return RoutingDecision{}, headErr
+			}
+			if err != nil {
+				return RoutingDecision{}, fmt.Errorf("%w: %v", ErrInvalidRPCRequest, err)
+			}
+			if isArchive {
+				decision.Target = RoutingTargetArchive
+				reasons = []string{reason}
+				break
+			}
+			reasons = append(reasons, reason)
+		}
+		decision.Reason = strings.Join(reasons, "; ")
+	}
+
+	candidates := full
+	if decision.Target == RoutingTargetArchive {
+		candidates = archive
+		if len(candidates) == 0 {
+			candidates, decision.Fallback = full, true
+		}
+	} else if len(candidates) == 0 {
+		// archive nodes are able to serve recent state as well
+		candidates = archive
+	}
+
+	decision.NodeID = gr.pick(group.ID, candidates).ID
+	return decision, nil
+}
+
+// members returns the enabled full and archive members of the group.
+func (gr *GroupRouter) members(ctx context.Context, group NodeGroup) ([]ZethNode, []ZethNode, error) {
+	var full, archive []ZethNode
+	for _, m := range group.Members {
+		n, err := gr.nodes.Get(ctx, m.NodeID)
+		if err != nil || !n.Enabled {
+			continue
+		}
+		if m.Archive {
+			archive = append(archive, *n)
+		} else {
+			full = append(full, *n)
+		}
+	}
+	if len(full)+len(archive) == 0 {
+		return nil, nil, ErrNoGroupMembers
+	}
+	return full, archive, nil
+}
+
+// pick selects the next candidate in round-robin order.
+func (gr *GroupRouter) pick(groupID uuid.UUID, candidates []ZethNode) ZethNode {
+	gr.mu.Lock()
+	defer gr.mu.Unlock()
+
+	i := gr.next[groupID]
+	gr.next[groupID] = i + 1
+	return candidates[i%uint64(len(candidates))]
+}
+
+// head returns the latest block number of the group; cached for headTTL.
+func (gr *GroupRouter) head(ctx context.Context, groupID uuid.UUID, members []ZethNode) (uint64, error) {
+	gr.mu.Lock()
+	cached, ok := gr.heads[groupID]
+	gr.mu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < gr.headTTL {
+		return cached.number, nil
+	}
+
+	var lastErr error
+	for _, n := range members {
+		transport, err := gr.nodes.Transport(n)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		client, err := n.DialWithTransport(transport)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		number, err := client.BlockNumber(ctx)
+		client.Close()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		gr.mu.Lock()
+		gr.heads[groupID] = cachedHead{number: number, fetchedAt: time.Now()}
+		gr.mu.Unlock()
+		return number, nil
+	}
+	return 0, fmt.Errorf("failed to retrieve head block of node group: %w", lastErr)
+}
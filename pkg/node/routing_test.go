@@ -0,0 +1,85 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_classifyCall(t *testing.T) {
+	is := assert.New(t)
+
+	const head = uint64(1000)
+
+	tests := []struct {
+		name      string
+		body      string
+		isArchive bool
+	}{
+		{
+			name:      "non state method",
+			body:      `{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`,
+			isArchive: false,
+		},
+		{
+			name:      "balance without block param",
+			body:      `{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0x0000000000000000000000000000000000000000"]}`,
+			isArchive: false,
+		},
+		{
+			name:      "balance at latest",
+			body:      `{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0x0000000000000000000000000000000000000000","latest"]}`,
+			isArchive: false,
+		},
+		{
+			name:      "balance at earliest",
+			body:      `{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0x0000000000000000000000000000000000000000","earliest"]}`,
+			isArchive: true,
+		},
+		{
+			name:      "call within depth",
+			body:      `{"jsonrpc":"2.0","id":1,"method":"eth_call","params":[{},"0x3b6"]}`, // 950
+			isArchive: false,
+		},
+		{
+			name:      "call beyond depth",
+			body:      `{"jsonrpc":"2.0","id":1,"method":"eth_call","params":[{},"0x64"]}`, // 100
+			isArchive: true,
+		},
+		{
+			name:      "storage at block object",
+			body:      `{"jsonrpc":"2.0","id":1,"method":"eth_getStorageAt","params":["0x0000000000000000000000000000000000000000","0x0",{"blockNumber":"0x1"}]}`,
+			isArchive: true,
+		},
+		{
+			name:      "proof at block hash",
+			body:      `{"jsonrpc":"2.0","id":1,"method":"eth_getProof","params":["0x0000000000000000000000000000000000000000",[],{"blockHash":"0xabc"}]}`,
+			isArchive: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls, err := parseJSONRPCCalls([]byte(tt.body))
+			is.NoError(err)
+			is.Len(calls, 1)
+
+			isArchive, reason, err := classifyCall(calls[0], DefaultArchiveDepth, func() (uint64, error) { return head, nil })
+			is.NoError(err)
+			is.NotEmpty(reason)
+			is.Equal(tt.isArchive, isArchive)
+		})
+	}
+}
+
+func Test_parseJSONRPCCalls_batch(t *testing.T) {
+	is := assert.New(t)
+
+	calls, err := parseJSONRPCCalls([]byte(` [{"method":"eth_chainId","params":[]},{"method":"eth_getCode","params":["0x0","0x1"]}]`))
+	is.NoError(err)
+	is.Len(calls, 2)
+	is.Equal("eth_getCode", calls[1].Method)
+
+	_, err = parseJSONRPCCalls([]byte(`not json`))
+	is.Error(err)
+}
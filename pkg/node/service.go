@@ -5,10 +5,13 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"net/http"
+	"reflect"
+	"sync"
 
-	badger "github.com/dgraph-io/badger/v3"
 	uuid "github.com/satori/go.uuid"
 	"github.com/zees-dev/zeth/pkg/datastore"
+	"github.com/zees-dev/zeth/pkg/events"
 )
 
 var nodeKey = []byte("node")
@@ -17,15 +20,21 @@ var ErrUnknownNodeType = errors.New("unknown node type")
 var ErrNodeTypeNotFound = errors.New("node type not found")
 
 type nodeService struct {
-	store datastore.Store
-	cache ReverseProxyCache
+	store      datastore.Store
+	cache      ReverseProxyCache
+	transports *transportCache
+	events     events.Publisher
+
+	mu sync.Mutex // serializes updates of stored nodes
 }
 
-func NewService(store datastore.Store) *nodeService {
+func NewService(store datastore.Store, publisher events.Publisher) *nodeService {
 	rpCache := NewRPCProxyCache(defaultProxyCacheSize)
 	return &nodeService{
-		store: store,
-		cache: rpCache,
+		store:      store,
+		cache:      rpCache,
+		transports: newTransportCache(),
+		events:     publisher,
 	}
 }
 
@@ -41,7 +50,12 @@ func (ns *nodeService) Create(ctx context.Context, n ZethNode) (ZethNode, error)
 
 	json.NewEncoder(bodyBytes).Encode(n)
 
-	return n, ns.store.Set(nodeKey, id.Bytes(), bodyBytes.Bytes())
+	if err := ns.store.Set(nodeKey, id.Bytes(), bodyBytes.Bytes()); err != nil {
+		return n, err
+	}
+
+	ns.events.Publish(events.NodeCreated, id.String(), n.Summary())
+	return n, nil
 }
 
 func (ns *nodeService) Get(ctx context.Context, id uuid.UUID) (*ZethNode, error) {
@@ -59,25 +73,102 @@ func (ns *nodeService) Get(ctx context.Context, id uuid.UUID) (*ZethNode, error)
 }
 
 func (ns *nodeService) Update(ctx context.Context, id uuid.UUID, node ZethNode) error {
-	ns.cache.Delete(id)
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+	return ns.update(ctx, id, node)
+}
+
+// UpdateCapabilities stores the probed capabilities on the node; other fields of the stored node are kept,
+// since the node may be updated while probing.
+func (ns *nodeService) UpdateCapabilities(ctx context.Context, id uuid.UUID, capabilities *Capabilities) error {
+	return ns.modify(ctx, id, func(node *ZethNode) { node.Capabilities = capabilities })
+}
+
+// UpdateErrorNormalization stores the error normalization config of the node; other fields of the stored node are kept.
+func (ns *nodeService) UpdateErrorNormalization(ctx context.Context, id uuid.UUID, cfg ErrorNormalizationConfig) error {
+	return ns.modify(ctx, id, func(node *ZethNode) { node.ErrorNormalization = cfg })
+}
 
-	ok, err := ns.store.Has(nodeKey, id.Bytes())
+// UpdateChaos stores the fault injection config of the node; other fields of the stored node are kept.
+func (ns *nodeService) UpdateChaos(ctx context.Context, id uuid.UUID, cfg ChaosConfig) error {
+	return ns.modify(ctx, id, func(node *ZethNode) { node.Chaos = cfg })
+}
+
+// UpdateManagedVersion stores the geth version of the managed node; other fields of the stored node are kept.
+func (ns *nodeService) UpdateManagedVersion(ctx context.Context, id uuid.UUID, version string) error {
+	return ns.modify(ctx, id, func(node *ZethNode) {
+		if node.Managed != nil {
+			node.Managed.Version = version
+		}
+	})
+}
+
+// modify applies the change to the stored node; the node is read and written under the lock, so concurrent updates are kept.
+func (ns *nodeService) modify(ctx context.Context, id uuid.UUID, change func(*ZethNode)) error {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+
+	node, err := ns.Get(ctx, id)
 	if err != nil {
 		return err
 	}
-	if !ok {
-		return badger.ErrKeyNotFound
+	change(node)
+	return ns.update(ctx, id, *node)
+}
+
+func (ns *nodeService) update(ctx context.Context, id uuid.UUID, node ZethNode) error {
+	prev, err := ns.Get(ctx, id)
+	if err != nil {
+		return err
 	}
 
 	bodyBytes := new(bytes.Buffer)
 	json.NewEncoder(bodyBytes).Encode(node)
-	return ns.store.Set(nodeKey, id.Bytes(), bodyBytes.Bytes())
+	if err := ns.store.Set(nodeKey, id.Bytes(), bodyBytes.Bytes()); err != nil {
+		return err
+	}
+
+	// cached reverse proxies and transports are only rebuilt if their config changed; e.g. not on capability probes
+	if prev.Transport != node.Transport {
+		ns.transports.Delete(id)
+	}
+	if reconfiguresProxy(*prev, node) {
+		ns.cache.Delete(id)
+	}
+
+	ns.events.Publish(events.NodeUpdated, id.String(), node.Summary())
+	if prev.Enabled != node.Enabled {
+		if node.Enabled {
+			ns.events.Publish(events.NodeEnabled, id.String(), node.Summary())
+		} else {
+			ns.events.Publish(events.NodeDisabled, id.String(), node.Summary())
+		}
+	}
+	return nil
+}
+
+// reconfiguresProxy returns true if the change of the node changes the config of its reverse proxy.
+func reconfiguresProxy(prev, node ZethNode) bool {
+	return prev.Name != node.Name ||
+		prev.RPC != node.RPC ||
+		prev.Transport != node.Transport ||
+		!reflect.DeepEqual(prev.ErrorNormalization, node.ErrorNormalization) ||
+		!reflect.DeepEqual(prev.Chaos, node.Chaos)
 }
 
 func (ns *nodeService) Delete(ctx context.Context, id uuid.UUID) error {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+
 	ns.cache.Delete(id)
+	ns.transports.Delete(id)
+
+	if err := ns.store.RemovePrefix(nodeKey, id.Bytes()); err != nil {
+		return err
+	}
 
-	return ns.store.RemovePrefix(nodeKey, id.Bytes())
+	ns.events.Publish(events.NodeDeleted, id.String(), nil)
+	return nil
 }
 
 func (ns *nodeService) GetAll(ctx context.Context) ([]ZethNode, error) {
@@ -103,6 +194,11 @@ func (ns *nodeService) ReverseProxyCache() ReverseProxyCache {
 	return ns.cache
 }
 
+// Transport returns the dedicated HTTP transport of the node; transports are rebuilt when the node is updated.
+func (ns *nodeService) Transport(n ZethNode) (*http.Transport, error) {
+	return ns.transports.Get(n)
+}
+
 func unmarshal(b []byte) (*ZethNode, error) {
 	var node ZethNode
 	if err := json.Unmarshal(b, &node); err != nil {
@@ -0,0 +1,130 @@
+package node
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zees-dev/zeth/pkg/datastore/badgerdbtest"
+	"github.com/zees-dev/zeth/pkg/events"
+)
+
+func Test_ServicePublishesLifecycleEvents(t *testing.T) {
+	is := assert.New(t)
+
+	store, cleanup := badgerdbtest.MustNewTestBadgerDB()
+	defer cleanup()
+
+	bus := events.NewBus(events.DefaultBacklog)
+	ns := NewService(store, bus)
+	ctx := context.Background()
+
+	c := make(chan events.Event, 10)
+	_, unsubscribe := bus.Subscribe(c, 0, []string{"node.*"})
+	defer unsubscribe()
+
+	n, err := ns.Create(ctx, ZethNode{Name: "test", Enabled: true})
+	is.NoError(err)
+
+	n.Name = "renamed"
+	is.NoError(ns.Update(ctx, n.ID, n))
+
+	n.Enabled = false
+	is.NoError(ns.Update(ctx, n.ID, n))
+
+	is.NoError(ns.Delete(ctx, n.ID))
+
+	types := []events.Type{}
+	for len(c) > 0 {
+		ev := <-c
+		is.Equal(n.ID.String(), ev.Subject)
+		// the config of the node, e.g. API keys of RPC urls, is not published
+		if ev.Data != nil {
+			is.IsType(Summary{}, ev.Data)
+		}
+		types = append(types, ev.Type)
+	}
+	is.Equal([]events.Type{
+		events.NodeCreated,
+		events.NodeUpdated,
+		events.NodeUpdated,
+		events.NodeDisabled,
+		events.NodeDeleted,
+	}, types)
+}
+
+func Test_UpdateCapabilities(t *testing.T) {
+	is := assert.New(t)
+
+	store, cleanup := badgerdbtest.MustNewTestBadgerDB()
+	defer cleanup()
+
+	ns := NewService(store, events.NewBus(events.DefaultBacklog))
+	ctx := context.Background()
+
+	n, err := ns.Create(ctx, ZethNode{Name: "test", Enabled: true})
+	is.NoError(err)
+
+	// the node is updated while its capabilities are probed
+	n.Name = "renamed"
+	is.NoError(ns.Update(ctx, n.ID, n))
+	is.NoError(ns.UpdateCapabilities(ctx, n.ID, &Capabilities{Supported: []Capability{CapabilityDebug}}))
+
+	stored, err := ns.Get(ctx, n.ID)
+	is.NoError(err)
+	is.Equal("renamed", stored.Name)
+	is.True(stored.Supports(CapabilityDebug))
+
+	// configs are stored without overwriting other fields
+	is.NoError(ns.UpdateErrorNormalization(ctx, n.ID, ErrorNormalizationConfig{Enabled: true}))
+	is.NoError(ns.UpdateChaos(ctx, n.ID, ChaosConfig{Enabled: true}))
+	stored, err = ns.Get(ctx, n.ID)
+	is.NoError(err)
+	is.True(stored.ErrorNormalization.Enabled)
+	is.True(stored.Chaos.Enabled)
+	is.True(stored.Supports(CapabilityDebug))
+
+	managed, err := ns.Create(ctx, ZethNode{Name: "managed", Managed: &ManagedConfig{Version: "1.10.11"}})
+	is.NoError(err)
+	managed.Name = "renamed"
+	is.NoError(ns.Update(ctx, managed.ID, managed))
+	is.NoError(ns.UpdateManagedVersion(ctx, managed.ID, "1.10.12"))
+	stored, err = ns.Get(ctx, managed.ID)
+	is.NoError(err)
+	is.Equal("1.10.12", stored.Managed.Version)
+	is.Equal("renamed", stored.Name)
+
+	// removed nodes are not stored again
+	is.NoError(ns.Delete(ctx, n.ID))
+	is.Error(ns.UpdateCapabilities(ctx, n.ID, &Capabilities{}))
+	_, err = ns.Get(ctx, n.ID)
+	is.Error(err)
+}
+
+func Test_UpdateEvictsReconfiguredProxies(t *testing.T) {
+	is := assert.New(t)
+
+	store, cleanup := badgerdbtest.MustNewTestBadgerDB()
+	defer cleanup()
+
+	ns := NewService(store, events.NewBus(events.DefaultBacklog))
+	ctx := context.Background()
+
+	n, err := ns.Create(ctx, ZethNode{Name: "test", Enabled: true})
+	is.NoError(err)
+
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	cache := ns.ReverseProxyCache()
+	cache.Set(r, n.ID, http.NotFoundHandler())
+
+	// probing capabilities does not change the proxy
+	is.NoError(ns.UpdateCapabilities(ctx, n.ID, &Capabilities{Supported: []Capability{CapabilityDebug}}))
+	_, ok := cache.Get(r, n.ID)
+	is.True(ok)
+
+	is.NoError(ns.UpdateChaos(ctx, n.ID, ChaosConfig{Enabled: true}))
+	_, ok = cache.Get(r, n.ID)
+	is.False(ok)
+}
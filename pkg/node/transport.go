@@ -0,0 +1,208 @@
+package node
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// Default transport settings; based on http.DefaultTransport.
+const (
+	defaultDialTimeout         = 30 * time.Second
+	defaultKeepAlive           = 30 * time.Second
+	defaultTLSHandshakeTimeout = 10 * time.Second
+	defaultIdleConnTimeout     = 90 * time.Second
+	defaultMaxIdleConns        = 100
+	defaultMaxIdleConnsPerHost = 16
+)
+
+// TransportConfig configures the HTTP transport used to connect to a node's upstream RPC endpoints.
+// Timeouts are in milliseconds; zero values fall back to defaults.
+type TransportConfig struct {
+	DialTimeout           int64 `json:"dialTimeout"`
+	TLSHandshakeTimeout   int64 `json:"tlsHandshakeTimeout"`
+	ResponseHeaderTimeout int64 `json:"responseHeaderTimeout"` // zero means no timeout
+	IdleConnTimeout       int64 `json:"idleConnTimeout"`
+	MaxIdleConns          int   `json:"maxIdleConns"`
+	MaxIdleConnsPerHost   int   `json:"maxIdleConnsPerHost"`
+	MaxConnsPerHost       int   `json:"maxConnsPerHost"` // zero means no limit
+	DisableHTTP2          bool  `json:"disableHttp2"`
+	DisableCompression    bool  `json:"disableCompression"`
+
+	TLS TLSConfig `json:"tls"`
+
+	// ProxyURL is the proxy used for upstream requests; if empty, proxy environment variables are used
+	ProxyURL string `json:"proxyUrl"`
+	NoProxy  bool   `json:"noProxy"` // ignore proxy environment variables
+}
+
+// TLSConfig configures TLS connections to a node's upstream RPC endpoints.
+// Certificates and keys are PEM encoded.
+type TLSConfig struct {
+	CACert             string `json:"caCert,omitempty"`
+	ClientCert         string `json:"clientCert,omitempty"`
+	ClientKey          string `json:"clientKey,omitempty"` // write-only; not returned by the API
+	ServerName         string `json:"serverName,omitempty"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify"` // only intended for lab nodes with self-signed certificates
+}
+
+// Redacted returns the config without the TLS client key; the key is write-only.
+func (cfg TransportConfig) Redacted() TransportConfig {
+	cfg.TLS.ClientKey = ""
+	return cfg
+}
+
+func millisOrDefault(ms int64, def time.Duration) time.Duration {
+	if ms <= 0 {
+		return def
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+func intOrDefault(v, def int) int {
+	if v <= 0 {
+		return def
+	}
+	return v
+}
+
+// Validate returns an error if a transport cannot be created from the config.
+func (cfg TransportConfig) Validate() error {
+	_, err := cfg.NewTransport()
+	return err
+}
+
+// NewDialer returns the dialer used to establish upstream connections.
+func (cfg TransportConfig) NewDialer() *net.Dialer {
+	return &net.Dialer{
+		Timeout:   millisOrDefault(cfg.DialTimeout, defaultDialTimeout),
+		KeepAlive: defaultKeepAlive,
+	}
+}
+
+// NewTLSConfig returns the TLS configuration used to establish upstream connections.
+func (cfg TLSConfig) NewTLSConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+
+	if cfg.CACert != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM([]byte(cfg.CACert)) {
+			return nil, errors.New("failed to parse CA certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.ClientCert != "" || cfg.ClientKey != "" {
+		cert, err := tls.X509KeyPair([]byte(cfg.ClientCert), []byte(cfg.ClientKey))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// NewTransport creates a dedicated HTTP transport from the config.
+func (cfg TransportConfig) NewTransport() (*http.Transport, error) {
+	tlsConfig, err := cfg.TLS.NewTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	proxy := http.ProxyFromEnvironment
+	switch {
+	case cfg.NoProxy:
+		proxy = nil
+	case cfg.ProxyURL != "":
+		proxyURL, err := url.Parse(cfg.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy url: %w", err)
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+
+	transport := &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           cfg.NewDialer().DialContext,
+		TLSClientConfig:       tlsConfig,
+		TLSHandshakeTimeout:   millisOrDefault(cfg.TLSHandshakeTimeout, defaultTLSHandshakeTimeout),
+		ResponseHeaderTimeout: millisOrDefault(cfg.ResponseHeaderTimeout, 0),
+		IdleConnTimeout:       millisOrDefault(cfg.IdleConnTimeout, defaultIdleConnTimeout),
+		MaxIdleConns:          intOrDefault(cfg.MaxIdleConns, defaultMaxIdleConns),
+		MaxIdleConnsPerHost:   intOrDefault(cfg.MaxIdleConnsPerHost, defaultMaxIdleConnsPerHost),
+		MaxConnsPerHost:       cfg.MaxConnsPerHost,
+		DisableCompression:    cfg.DisableCompression,
+		ExpectContinueTimeout: 1 * time.Second,
+		ForceAttemptHTTP2:     !cfg.DisableHTTP2,
+	}
+	if cfg.DisableHTTP2 {
+		// a non-nil, empty map disables HTTP/2 - https://pkg.go.dev/net/http#hdr-HTTP_2
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+
+	return transport, nil
+}
+
+// cachedTransport is the transport of a node and the config it was created from.
+type cachedTransport struct {
+	cfg       TransportConfig
+	transport *http.Transport
+}
+
+// transportCache holds the dedicated transport of each node.
+type transportCache struct {
+	mu         sync.Mutex
+	transports map[uuid.UUID]cachedTransport
+}
+
+func newTransportCache() *transportCache {
+	return &transportCache{transports: make(map[uuid.UUID]cachedTransport)}
+}
+
+// Get returns the transport of the node; creating it if it does not exist, or if the transport config of the node changed,
+// e.g. of a node being updated which is not stored yet.
+func (c *transportCache) Get(n ZethNode) (*http.Transport, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached, ok := c.transports[n.ID]
+	if ok && cached.cfg == n.Transport {
+		return cached.transport, nil
+	}
+
+	t, err := n.Transport.NewTransport()
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		cached.transport.CloseIdleConnections()
+	}
+	c.transports[n.ID] = cachedTransport{cfg: n.Transport, transport: t}
+	return t, nil
+}
+
+// Delete closes the idle connections of the node's transport and removes it, forcing it to be rebuilt.
+func (c *transportCache) Delete(id uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cached, ok := c.transports[id]; ok {
+		cached.transport.CloseIdleConnections()
+		delete(c.transports, id)
+	}
+}
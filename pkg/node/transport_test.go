@@ -0,0 +1,87 @@
+package node
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_TransportConfig_NewTransport(t *testing.T) {
+	is := assert.New(t)
+
+	transport, err := TransportConfig{}.NewTransport()
+	is.NoError(err)
+	is.Equal(defaultIdleConnTimeout, transport.IdleConnTimeout)
+	is.Equal(defaultMaxIdleConnsPerHost, transport.MaxIdleConnsPerHost)
+	is.True(transport.ForceAttemptHTTP2)
+	is.Nil(transport.TLSNextProto)
+
+	transport, err = TransportConfig{
+		ResponseHeaderTimeout: 1500,
+		MaxConnsPerHost:       4,
+		DisableHTTP2:          true,
+		ProxyURL:              "http://proxy.local:3128",
+		TLS:                   TLSConfig{InsecureSkipVerify: true},
+	}.NewTransport()
+	is.NoError(err)
+	is.Equal(1500*time.Millisecond, transport.ResponseHeaderTimeout)
+	is.Equal(4, transport.MaxConnsPerHost)
+	is.False(transport.ForceAttemptHTTP2)
+	is.NotNil(transport.TLSNextProto)
+	is.True(transport.TLSClientConfig.InsecureSkipVerify)
+
+	req, _ := http.NewRequest(http.MethodPost, "https://rpc.example.com", nil)
+	proxyURL, err := transport.Proxy(req)
+	is.NoError(err)
+	is.Equal("proxy.local:3128", proxyURL.Host)
+
+	transport, err = TransportConfig{NoProxy: true}.NewTransport()
+	is.NoError(err)
+	is.Nil(transport.Proxy)
+}
+
+func Test_TransportConfig_Validate(t *testing.T) {
+	is := assert.New(t)
+
+	is.Error(TransportConfig{TLS: TLSConfig{CACert: "not a certificate"}}.Validate())
+	is.Error(TransportConfig{TLS: TLSConfig{ClientCert: "not a certificate"}}.Validate())
+	is.Error(TransportConfig{ProxyURL: "://invalid"}.Validate())
+	is.NoError(TransportConfig{}.Validate())
+}
+
+func Test_transportCache(t *testing.T) {
+	is := assert.New(t)
+
+	c := newTransportCache()
+	n := *NewNode("http://localhost:8545", "")
+
+	first, err := c.Get(n)
+	is.NoError(err)
+	cached, err := c.Get(n)
+	is.NoError(err)
+	is.Same(first, cached)
+
+	// the transport is rebuilt once the config changes
+	n.Transport.MaxConnsPerHost = 4
+	rebuilt, err := c.Get(n)
+	is.NoError(err)
+	is.NotSame(first, rebuilt)
+	is.Equal(4, rebuilt.MaxConnsPerHost)
+
+	c.Delete(n.ID)
+	recreated, err := c.Get(n)
+	is.NoError(err)
+	is.NotSame(rebuilt, recreated)
+}
+
+func Test_ZethNode_Redacted(t *testing.T) {
+	is := assert.New(t)
+
+	n := ZethNode{Transport: TransportConfig{TLS: TLSConfig{ClientCert: "cert", ClientKey: "key"}}}
+	redacted := n.Redacted()
+	is.Empty(redacted.Transport.TLS.ClientKey)
+	is.Equal("cert", redacted.Transport.TLS.ClientCert)
+	is.Equal("key", n.Transport.TLS.ClientKey)
+}
@@ -2,8 +2,10 @@ package node
 
 import (
 	"context"
+	"net/http"
 
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
 	uuid "github.com/satori/go.uuid"
 )
 
@@ -22,6 +24,7 @@ func (defaultRPC DefaultRPC) IsValid() bool {
 type RPC struct {
 	HTTP    string     `json:"http"`
 	WS      string     `json:"ws"`
+	IPC     string     `json:"ipc,omitempty"` // path of the IPC socket; managed nodes only
 	Default DefaultRPC `json:"default"`
 }
 
@@ -37,12 +40,29 @@ func NewNode(httpRPCURL, wsRPCURL string) *ZethNode {
 	}
 }
 
-// TestConnection returns true if the node can be connected to via RPC HTTP endpoint.
-func (n *ZethNode) TestConnection(ctx context.Context) error {
-	client, err := ethclient.Dial(n.RPC.HTTP)
+// DialWithTransport connects to the node's RPC HTTP endpoint using the given transport.
+func (n *ZethNode) DialWithTransport(transport http.RoundTripper) (*ethclient.Client, error) {
+	c, err := n.DialRPCWithTransport(transport)
+	if err != nil {
+		return nil, err
+	}
+	return ethclient.NewClient(c), nil
+}
+
+// DialRPCWithTransport connects a raw RPC client to the node's RPC HTTP endpoint using the given transport;
+// for calls not covered by ethclient, e.g. net_peerCount.
+func (n *ZethNode) DialRPCWithTransport(transport http.RoundTripper) (*rpc.Client, error) {
+	return rpc.DialHTTPWithClient(n.RPC.HTTP, &http.Client{Transport: transport})
+}
+
+// TestConnection returns an error if the node can not be connected to via RPC HTTP endpoint using the given transport;
+// the dedicated transport of the node, see NodeService.Transport.
+func (n *ZethNode) TestConnection(ctx context.Context, transport http.RoundTripper) error {
+	client, err := n.DialWithTransport(transport)
 	if err != nil {
 		return err
 	}
+	defer client.Close()
 
 	// _, err = client.BlockNumber(ctx)
 	_, err = client.HeaderByNumber(ctx, nil)
@@ -0,0 +1,120 @@
+package preset
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"github.com/zees-dev/zeth/pkg/datastore"
+	"github.com/zees-dev/zeth/pkg/events"
+	"github.com/zees-dev/zeth/pkg/geth"
+)
+
+var (
+	ErrNotFound = errors.New("preset not found")
+	ErrBuiltin  = errors.New("builtin presets can not be modified")
+)
+
+// note: namespace must not share a prefix with other namespaces, since datastore.GetAll iterates by prefix
+var presetKey = []byte("preset")
+
+// Service provides the builtin network presets and the presets defined by users; user presets are stored in the datastore.
+type Service struct {
+	store  datastore.Store
+	events events.Publisher
+}
+
+func NewService(store datastore.Store, publisher events.Publisher) *Service {
+	return &Service{
+		store:  store,
+		events: publisher,
+	}
+}
+
+// nameKey returns the key of the preset; terminated since names may prefix each other and presets are removed by prefix.
+func nameKey(name string) []byte {
+	return []byte(name + ".")
+}
+
+// Get returns the builtin or user preset of the name.
+func (s *Service) Get(ctx context.Context, name string) (geth.Preset, error) {
+	if p, ok := geth.BuiltinPreset(name); ok {
+		return p, nil
+	}
+
+	exists, err := s.store.Has(presetKey, nameKey(name))
+	if err != nil {
+		return geth.Preset{}, err
+	}
+	if !exists {
+		return geth.Preset{}, ErrNotFound
+	}
+
+	b, err := s.store.Get(presetKey, nameKey(name))
+	if err != nil {
+		return geth.Preset{}, err
+	}
+	var p geth.Preset
+	if err := json.Unmarshal(b, &p); err != nil {
+		return geth.Preset{}, err
+	}
+	return p, nil
+}
+
+// GetAll returns the builtin presets followed by the user presets ordered by name.
+func (s *Service) GetAll(ctx context.Context) ([]geth.Preset, error) {
+	presetsMap, err := s.store.GetAll(presetKey)
+	if err != nil {
+		return nil, err
+	}
+
+	presets := []geth.Preset{}
+	for _, b := range presetsMap {
+		var p geth.Preset
+		if err := json.Unmarshal(b, &p); err != nil {
+			return nil, err
+		}
+		presets = append(presets, p)
+	}
+	sort.Slice(presets, func(i, j int) bool { return presets[i].Name < presets[j].Name })
+
+	return append(geth.BuiltinPresets(), presets...), nil
+}
+
+// Upsert adds the user preset or replaces the user preset of the same name; builtin presets can not be replaced.
+func (s *Service) Upsert(ctx context.Context, p geth.Preset) error {
+	if _, ok := geth.BuiltinPreset(p.Name); ok {
+		return ErrBuiltin
+	}
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	p.Builtin = false
+
+	bodyBytes := new(bytes.Buffer)
+	json.NewEncoder(bodyBytes).Encode(p)
+	if err := s.store.Set(presetKey, nameKey(p.Name), bodyBytes.Bytes()); err != nil {
+		return err
+	}
+
+	s.events.Publish(events.PresetUpdated, p.Name, p)
+	return nil
+}
+
+// Delete removes the user preset; nodes created with the preset retain their config.
+func (s *Service) Delete(ctx context.Context, name string) error {
+	if _, ok := geth.BuiltinPreset(name); ok {
+		return ErrBuiltin
+	}
+	if _, err := s.Get(ctx, name); err != nil {
+		return err
+	}
+	if err := s.store.RemovePrefix(presetKey, nameKey(name)); err != nil {
+		return err
+	}
+
+	s.events.Publish(events.PresetDeleted, name, nil)
+	return nil
+}
@@ -0,0 +1,58 @@
+package preset
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zees-dev/zeth/pkg/datastore/badgerdbtest"
+	"github.com/zees-dev/zeth/pkg/events"
+	"github.com/zees-dev/zeth/pkg/geth"
+)
+
+func Test_Presets(t *testing.T) {
+	is := assert.New(t)
+
+	store, cleanup := badgerdbtest.MustNewTestBadgerDB()
+	defer cleanup()
+	s := NewService(store, events.NewBus(events.DefaultBacklog))
+	ctx := context.Background()
+
+	presets, err := s.GetAll(ctx)
+	is.NoError(err)
+	is.Equal(geth.BuiltinPresets(), presets)
+
+	sepolia, err := s.Get(ctx, "sepolia")
+	is.NoError(err)
+	is.Equal(uint64(11155111), sepolia.NetworkID)
+
+	// builtin presets can not be replaced or removed
+	is.ErrorIs(s.Upsert(ctx, geth.Preset{Name: "sepolia", NetworkID: 1}), ErrBuiltin)
+	is.ErrorIs(s.Delete(ctx, "mainnet"), ErrBuiltin)
+
+	is.Error(s.Upsert(ctx, geth.Preset{Name: "Invalid Name", NetworkID: 1}))
+	is.Error(s.Upsert(ctx, geth.Preset{Name: "no-network-id"}))
+
+	// names prefixing each other are stored separately
+	is.NoError(s.Upsert(ctx, geth.Preset{Name: "private", NetworkID: 1337, Builtin: true}))
+	is.NoError(s.Upsert(ctx, geth.Preset{Name: "private-2", NetworkID: 1338}))
+
+	private, err := s.Get(ctx, "private")
+	is.NoError(err)
+	is.Equal(uint64(1337), private.NetworkID)
+	is.False(private.Builtin)
+
+	presets, err = s.GetAll(ctx)
+	is.NoError(err)
+	is.Len(presets, len(geth.BuiltinPresets())+2)
+	is.Equal("private", presets[len(presets)-2].Name)
+	is.Equal("private-2", presets[len(presets)-1].Name)
+
+	is.NoError(s.Delete(ctx, "private"))
+	_, err = s.Get(ctx, "private")
+	is.ErrorIs(err, ErrNotFound)
+	_, err = s.Get(ctx, "private-2")
+	is.NoError(err)
+
+	is.ErrorIs(s.Delete(ctx, "private"), ErrNotFound)
+}
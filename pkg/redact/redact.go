@@ -0,0 +1,347 @@
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// Mask replaces redacted values.
+const Mask = "[REDACTED]"
+
+// Defaults; always applied unless redaction is disabled.
+var (
+	DefaultHeaders = []string{
+		"Authorization",
+		"Proxy-Authorization",
+		"Cookie",
+		"Set-Cookie",
+		"X-Api-Key",
+	}
+	DefaultQueryParams = []string{
+		"key",
+		"apikey",
+		"api_key",
+		"token",
+		"access_token",
+		"secret",
+	}
+	DefaultParams = []ParamRule{
+		{Method: "personal_unlockAccount", Positions: []int{1}},
+		{Method: "personal_importRawKey", Positions: []int{0, 1}},
+		{Method: "personal_newAccount", Positions: []int{0}},
+		{Method: "personal_sendTransaction", Positions: []int{1}},
+		{Method: "personal_signTransaction", Positions: []int{1}},
+		{Method: "personal_sign", Positions: []int{2}},
+		{Method: "personal_openWallet", Positions: []int{1}},
+	}
+)
+
+// secretSegment matches URL path segments which look like provider API keys, e.g. https://mainnet.infura.io/v3/<key>
+var secretSegment = regexp.MustCompile(`^[A-Za-z0-9_-]{20,}$`)
+
+// ParamRule redacts the JSON-RPC params at the given positions of requests of matching methods.
+type ParamRule struct {
+	Method    string `json:"method"` // method pattern, e.g. personal_* - https://pkg.go.dev/path#Match
+	Positions []int  `json:"positions"`
+}
+
+// Config configures redaction of sensitive data in captured RPC traffic.
+// Rules are applied in addition to the defaults.
+type Config struct {
+	Disabled    bool        `json:"disabled"`
+	Headers     []string    `json:"headers"`     // header names; case-insensitive
+	QueryParams []string    `json:"queryParams"` // URL query parameter names; case-insensitive
+	Params      []ParamRule `json:"params"`
+	// Patterns are regular expressions matched against all captured data;
+	// if a pattern contains a capturing group only the first group is masked, otherwise the whole match.
+	Patterns []string `json:"patterns"`
+}
+
+// Validate returns an error if a rule of the config is malformed.
+func (cfg Config) Validate() error {
+	for i, p := range cfg.Params {
+		if _, err := path.Match(p.Method, ""); err != nil || p.Method == "" {
+			return fmt.Errorf("params %d: invalid method pattern %q", i, p.Method)
+		}
+		for _, pos := range p.Positions {
+			if pos < 0 {
+				return fmt.Errorf("params %d: position must not be negative", i)
+			}
+		}
+	}
+	for i, p := range cfg.Patterns {
+		if _, err := regexp.Compile(p); err != nil {
+			return fmt.Errorf("patterns %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Redactor redacts sensitive data from captured requests and responses.
+type Redactor struct {
+	disabled    bool
+	headers     map[string]bool // canonical header names
+	queryParams map[string]bool // lower case parameter names
+	params      []ParamRule
+	patterns    []*regexp.Regexp
+}
+
+// New compiles the redaction rules of the config.
+func New(cfg Config) (*Redactor, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	r := &Redactor{
+		disabled:    cfg.Disabled,
+		headers:     map[string]bool{},
+		queryParams: map[string]bool{},
+		params:      append(append([]ParamRule{}, DefaultParams...), cfg.Params...),
+	}
+	for _, h := range append(append([]string{}, DefaultHeaders...), cfg.Headers...) {
+		r.headers[http.CanonicalHeaderKey(h)] = true
+	}
+	for _, q := range append(append([]string{}, DefaultQueryParams...), cfg.QueryParams...) {
+		r.queryParams[strings.ToLower(q)] = true
+	}
+	for _, p := range cfg.Patterns {
+		r.patterns = append(r.patterns, regexp.MustCompile(p))
+	}
+	return r, nil
+}
+
+// String masks the matches of the custom patterns in the string.
+func (r *Redactor) String(s string) string {
+	if r.disabled {
+		return s
+	}
+	for _, p := range r.patterns {
+		s = maskMatches(p, s)
+	}
+	return s
+}
+
+func maskMatches(p *regexp.Regexp, s string) string {
+	matches := p.FindAllStringSubmatchIndex(s, -1)
+	if len(matches) == 0 {
+		return s
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if len(m) > 2 && m[2] >= 0 {
+			start, end = m[2], m[3]
+		}
+		if start == end {
+			continue // empty matches mask nothing
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(Mask)
+		last = end
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// Header returns a copy of the header with the values of sensitive headers masked.
+func (r *Redactor) Header(h http.Header) http.Header {
+	redacted := make(http.Header, len(h))
+	for k, values := range h {
+		masked := make([]string, len(values))
+		for i, v := range values {
+			if !r.disabled && r.headers[http.CanonicalHeaderKey(k)] {
+				masked[i] = Mask
+			} else {
+				masked[i] = r.String(v)
+			}
+		}
+		redacted[k] = masked
+	}
+	return redacted
+}
+
+// HeaderJSON redacts a JSON encoded header; as captured in RPC events.
+func (r *Redactor) HeaderJSON(s string) string {
+	if r.disabled || s == "" {
+		return s
+	}
+	var h http.Header
+	if err := json.Unmarshal([]byte(s), &h); err != nil {
+		return r.String(s)
+	}
+	b, _ := json.Marshal(r.Header(h))
+	return string(b)
+}
+
+// URL masks credentials, secret query parameters and path segments which look like API keys in the URL.
+// Path segments which are UUIDs are retained; they identify zeth resources.
+func (r *Redactor) URL(s string) string {
+	if r.disabled || s == "" {
+		return s
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return r.String(s)
+	}
+
+	if u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), Mask)
+		} else {
+			u.User = url.User(Mask)
+		}
+	}
+
+	segments := strings.Split(u.Path, "/")
+	for i, seg := range segments {
+		if isSecretSegment(seg) {
+			segments[i] = Mask
+		}
+	}
+	u.Path = strings.Join(segments, "/")
+	u.RawPath = ""
+
+	if u.RawQuery != "" {
+		q := u.Query()
+		for k, values := range q {
+			if r.queryParams[strings.ToLower(k)] {
+				for i := range values {
+					values[i] = Mask
+				}
+			}
+		}
+		u.RawQuery = q.Encode()
+	}
+
+	// keep the mask readable rather than percent-encoded
+	redacted := strings.NewReplacer(url.QueryEscape(Mask), Mask, url.PathEscape(Mask), Mask).Replace(u.String())
+	return r.String(redacted)
+}
+
+// isSecretSegment returns whether the path segment looks like an API key; long, mixing letters and digits.
+func isSecretSegment(seg string) bool {
+	if !secretSegment.MatchString(seg) {
+		return false
+	}
+	// canonical UUIDs only; keys such as Infura project IDs are unhyphenated 32 digit hex strings
+	if _, err := uuid.FromString(seg); err == nil && len(seg) == 36 {
+		return false
+	}
+	return strings.ContainsAny(seg, "0123456789") && strings.IndexFunc(seg, isLetter) >= 0
+}
+
+func isLetter(c rune) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// RequestBody masks the params of JSON-RPC requests (single or batch) matching the param rules, and the custom patterns.
+func (r *Redactor) RequestBody(body string) string {
+	if r.disabled {
+		return body
+	}
+
+	trimmed := bytes.TrimSpace([]byte(body))
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var calls []json.RawMessage
+		if err := json.Unmarshal(trimmed, &calls); err == nil {
+			changed := false
+			for i, call := range calls {
+				if redacted, ok := r.redactCall(call); ok {
+					calls[i] = redacted
+					changed = true
+				}
+			}
+			if changed {
+				b, _ := json.Marshal(calls)
+				body = string(b)
+			}
+		}
+	} else if redacted, ok := r.redactCall(trimmed); ok {
+		body = string(redacted)
+	}
+
+	return r.String(body)
+}
+
+// redactCall masks the params of the JSON-RPC call; returns false if nothing was masked.
+func (r *Redactor) redactCall(call json.RawMessage) (json.RawMessage, bool) {
+	var msg map[string]json.RawMessage
+	if err := json.Unmarshal(call, &msg); err != nil {
+		return nil, false
+	}
+	var method string
+	if err := json.Unmarshal(msg["method"], &method); err != nil {
+		return nil, false
+	}
+	var params []json.RawMessage
+	if err := json.Unmarshal(msg["params"], &params); err != nil {
+		return nil, false
+	}
+
+	mask, _ := json.Marshal(Mask)
+	changed := false
+	for _, rule := range r.params {
+		if ok, _ := path.Match(rule.Method, method); !ok {
+			continue
+		}
+		for _, pos := range rule.Positions {
+			if pos < len(params) {
+				params[pos] = mask
+				changed = true
+			}
+		}
+	}
+	if !changed {
+		return nil, false
+	}
+
+	msg["params"], _ = json.Marshal(params)
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return nil, false
+	}
+	return b, true
+}
+
+// Service holds the redactor of the application; it can be reconfigured at runtime.
+type Service struct {
+	mu       sync.RWMutex
+	redactor *Redactor
+}
+
+// NewService returns a service redacting with the default rules.
+func NewService() *Service {
+	r, _ := New(Config{})
+	return &Service{redactor: r}
+}
+
+// Configure replaces the redaction rules.
+func (s *Service) Configure(cfg Config) error {
+	r, err := New(cfg)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.redactor = r
+	s.mu.Unlock()
+	return nil
+}
+
+// Redactor returns the current redactor.
+func (s *Service) Redactor() *Redactor {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.redactor
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 
 	"github.com/zees-dev/zeth/pkg/datastore"
+	"github.com/zees-dev/zeth/pkg/events"
 )
 
 var (
@@ -13,12 +14,14 @@ var (
 )
 
 type settingsService struct {
-	bs datastore.Store
+	bs     datastore.Store
+	events events.Publisher
 }
 
-func NewService(bs datastore.Store) *settingsService {
+func NewService(bs datastore.Store, publisher events.Publisher) *settingsService {
 	return &settingsService{
-		bs: bs,
+		bs:     bs,
+		events: publisher,
 	}
 }
 
@@ -36,8 +39,17 @@ func (ss *settingsService) Get(ctx context.Context) (Setting, error) {
 	return setting, nil
 }
 
+// Update stores the settings; the sections which changed are published, since the settings contain secrets.
 func (ss *settingsService) Update(ctx context.Context, setting Setting) error {
+	// settings are not stored until seeded
+	prev, _ := ss.Get(ctx)
+
 	bodyBytes := new(bytes.Buffer)
 	json.NewEncoder(bodyBytes).Encode(setting)
-	return ss.bs.SetGlobal(settingsKey, bodyBytes.Bytes())
+	if err := ss.bs.SetGlobal(settingsKey, bodyBytes.Bytes()); err != nil {
+		return err
+	}
+
+	ss.events.Publish(events.SettingsUpdated, "", setting.changes(prev))
+	return nil
 }
@@ -1,16 +1,48 @@
 package settings
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/zees-dev/zeth/pkg/datastore/badgerdbtest"
+	"github.com/zees-dev/zeth/pkg/events"
+	"github.com/zees-dev/zeth/pkg/tracing"
 )
 
 const emptyUUIDV4 = "00000000-0000-0000-0000-000000000000"
 
 func Test_SatisfiesSettingsInterface(t *testing.T) {
 	is := assert.New(t)
-	is.Implements((*Settings)(nil), NewService(nil))
+	is.Implements((*Settings)(nil), NewService(nil, nil))
+}
+
+func Test_UpdatePublishesChanges(t *testing.T) {
+	is := assert.New(t)
+
+	store, cleanup := badgerdbtest.MustNewTestBadgerDB()
+	defer cleanup()
+	bus := events.NewBus(events.DefaultBacklog)
+	ss := NewService(store, bus)
+	ctx := context.Background()
+
+	c := make(chan events.Event, 10)
+	_, unsub := bus.Subscribe(c, 0, []string{string(events.SettingsUpdated)})
+	defer unsub()
+
+	s := Setting{}
+	is.NoError(ss.Update(ctx, s))
+	is.Equal(Changes{Sections: []string{}}, (<-c).Data)
+
+	// the headers of the collector are not published
+	s.Tracing = tracing.Config{Enabled: true, Endpoint: "localhost:4318", Headers: map[string]string{"Authorization": "secret"}}
+	is.NoError(ss.Update(ctx, s))
+	is.Equal(Changes{Sections: []string{"tracing"}}, (<-c).Data)
+
+	stored, err := ss.Get(ctx)
+	is.NoError(err)
+	is.Equal("secret", stored.Tracing.Headers["Authorization"])
+	is.Equal(map[string]string{"Authorization": ""}, stored.Redacted().Tracing.Headers)
 }
 
 // func Test_SettingsCrud(t *testing.T) {
@@ -2,8 +2,13 @@ package settings
 
 import (
 	"context"
+	"reflect"
 
 	uuid "github.com/satori/go.uuid"
+	"github.com/zees-dev/zeth/pkg/geth/downloader"
+	"github.com/zees-dev/zeth/pkg/redact"
+	"github.com/zees-dev/zeth/pkg/tracing"
+	"github.com/zees-dev/zeth/pkg/vulnerability"
 )
 
 type (
@@ -16,10 +21,46 @@ type (
 		DefaultNodeID  uuid.UUID         `json:"defaultNodeID"`
 	}
 	Setting struct {
-		NodeSettings NodeSettings `json:"nodeSettings"`
+		NodeSettings    NodeSettings         `json:"nodeSettings"`
+		Tracing         tracing.Config       `json:"tracing"`
+		Redaction       redact.Config        `json:"redaction"`
+		Vulnerabilities vulnerability.Config `json:"vulnerabilities"`
+		Downloads       downloader.Config    `json:"downloads"`
+	}
+	// Changes are the sections of the settings changed by an update; published instead of the settings, since they contain secrets
+	Changes struct {
+		Sections []string `json:"sections"`
 	}
 	Settings interface {
 		Get(ctx context.Context) (Setting, error)
 		Update(ctx context.Context, setting Setting) error
 	}
 )
+
+// Redacted returns the settings without write-only secrets; for API responses.
+func (s Setting) Redacted() Setting {
+	s.Tracing = s.Tracing.Redacted()
+	return s
+}
+
+// changes returns the sections of the settings which differ from the previous settings.
+func (s Setting) changes(prev Setting) Changes {
+	sections := []struct {
+		name       string
+		prev, next interface{}
+	}{
+		{"nodeSettings", prev.NodeSettings, s.NodeSettings},
+		{"tracing", prev.Tracing, s.Tracing},
+		{"redaction", prev.Redaction, s.Redaction},
+		{"vulnerabilities", prev.Vulnerabilities, s.Vulnerabilities},
+		{"downloads", prev.Downloads, s.Downloads},
+	}
+
+	changes := Changes{Sections: []string{}}
+	for _, section := range sections {
+		if !reflect.DeepEqual(section.prev, section.next) {
+			changes.Sections = append(changes.Sections, section.name)
+		}
+	}
+	return changes
+}
@@ -0,0 +1,134 @@
+package tracing
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName is the name of the tracer used for spans created by zeth.
+const InstrumentationName = "github.com/zees-dev/zeth"
+
+// DefaultServiceName is the service name reported to the collector if not configured.
+const DefaultServiceName = "zeth"
+
+// Config configures export of spans to an OpenTelemetry collector via OTLP/HTTP.
+type Config struct {
+	Enabled  bool              `json:"enabled"`
+	Endpoint string            `json:"endpoint"` // collector host:port, e.g. localhost:4318
+	URLPath  string            `json:"urlPath"`  // defaults to /v1/traces
+	Insecure bool              `json:"insecure"` // use http instead of https
+	Headers  map[string]string `json:"headers"`  // sent with exported spans, e.g. for authentication; values are write-only
+}
+
+// Redacted returns the config without the values of the headers; header values are write-only.
+func (cfg Config) Redacted() Config {
+	if cfg.Headers == nil {
+		return cfg
+	}
+	headers := make(map[string]string, len(cfg.Headers))
+	for name := range cfg.Headers {
+		headers[name] = ""
+	}
+	cfg.Headers = headers
+	return cfg
+}
+
+// Service owns the tracer provider of the application.
+// The provider is created once; the span exporter can be reconfigured at runtime.
+type Service struct {
+	provider   *sdktrace.TracerProvider
+	propagator propagation.TextMapPropagator
+
+	mu        sync.Mutex
+	processor sdktrace.SpanProcessor
+}
+
+func NewService() *Service {
+	res := resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceNameKey.String(DefaultServiceName),
+	)
+
+	s := &Service{
+		provider: sdktrace.NewTracerProvider(sdktrace.WithResource(res)),
+		propagator: propagation.NewCompositeTextMapPropagator(
+			propagation.TraceContext{},
+			propagation.Baggage{},
+		),
+	}
+
+	otel.SetTracerProvider(s.provider)
+	otel.SetTextMapPropagator(s.propagator)
+
+	return s
+}
+
+// Provider returns the tracer provider of the application.
+func (s *Service) Provider() trace.TracerProvider {
+	return s.provider
+}
+
+// Propagator returns the propagator used to extract and inject trace context.
+func (s *Service) Propagator() propagation.TextMapPropagator {
+	return s.propagator
+}
+
+// Tracer returns the tracer used for spans created by zeth.
+func (s *Service) Tracer() trace.Tracer {
+	return s.provider.Tracer(InstrumentationName)
+}
+
+// Configure replaces the span exporter; spans are not exported if tracing is disabled.
+func (s *Service) Configure(ctx context.Context, cfg Config) error {
+	var processor sdktrace.SpanProcessor
+	if cfg.Enabled {
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+		if cfg.URLPath != "" {
+			opts = append(opts, otlptracehttp.WithURLPath(cfg.URLPath))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlptracehttp.WithHeaders(cfg.Headers))
+		}
+
+		exporter, err := otlptracehttp.New(ctx, opts...)
+		if err != nil {
+			return errors.Wrap(err, "failed to create otlp exporter")
+		}
+		processor = sdktrace.NewBatchSpanProcessor(exporter)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.processor != nil {
+		s.provider.UnregisterSpanProcessor(s.processor)
+		if err := s.processor.Shutdown(ctx); err != nil {
+			log.Debug().Err(err).Msg("failed to shutdown previous span processor")
+		}
+	}
+	s.processor = processor
+	if processor != nil {
+		s.provider.RegisterSpanProcessor(processor)
+		log.Info().Msgf("exporting traces to %s", cfg.Endpoint)
+	}
+
+	return nil
+}
+
+// Shutdown flushes pending spans and shuts down the tracer provider.
+func (s *Service) Shutdown(ctx context.Context) error {
+	return s.provider.Shutdown(ctx)
+}
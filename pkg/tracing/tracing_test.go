@@ -0,0 +1,61 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func Test_Configure(t *testing.T) {
+	is := assert.New(t)
+
+	s := NewService()
+	defer s.Shutdown(context.Background())
+
+	is.NoError(s.Configure(context.Background(), Config{}))
+	is.Nil(s.processor)
+
+	is.NoError(s.Configure(context.Background(), Config{Enabled: true, Endpoint: "localhost:4318", Insecure: true}))
+	is.NotNil(s.processor)
+	first := s.processor
+
+	// reconfiguring replaces the span processor
+	is.NoError(s.Configure(context.Background(), Config{Enabled: true, Endpoint: "localhost:4319", URLPath: "/traces"}))
+	is.NotNil(s.processor)
+	is.NotEqual(first, s.processor)
+
+	is.NoError(s.Configure(context.Background(), Config{Enabled: false, Endpoint: "localhost:4318"}))
+	is.Nil(s.processor)
+}
+
+func Test_PropagatesTraceContext(t *testing.T) {
+	is := assert.New(t)
+
+	s := NewService()
+	defer s.Shutdown(context.Background())
+
+	recorder := tracetest.NewSpanRecorder()
+	s.provider.RegisterSpanProcessor(recorder)
+
+	// continue an incoming trace
+	incoming := http.Header{}
+	incoming.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := s.Propagator().Extract(context.Background(), propagation.HeaderCarrier(incoming))
+
+	ctx, span := s.Tracer().Start(ctx, "eth_blockNumber")
+	outgoing := http.Header{}
+	s.Propagator().Inject(ctx, propagation.HeaderCarrier(outgoing))
+	span.End()
+
+	is.Contains(outgoing.Get("traceparent"), "4bf92f3577b34da6a3ce929d0e0e4736")
+	is.NotContains(outgoing.Get("traceparent"), "00f067aa0ba902b7")
+
+	spans := recorder.Ended()
+	is.Len(spans, 1)
+	is.Equal("eth_blockNumber", spans[0].Name())
+	is.Equal("00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+}
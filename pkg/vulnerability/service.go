@@ -0,0 +1,305 @@
+package vulnerability
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	uuid "github.com/satori/go.uuid"
+	"github.com/zees-dev/zeth/pkg/datastore"
+	"github.com/zees-dev/zeth/pkg/events"
+	"github.com/zees-dev/zeth/pkg/geth/version"
+	"github.com/zees-dev/zeth/pkg/node"
+)
+
+// queryTimeout is the timeout of querying the client version of a node.
+const queryTimeout = 10 * time.Second
+
+// note: namespace must not share a prefix with other namespaces, since datastore.GetAll iterates by prefix
+var (
+	feedKey       = []byte("vulnfeed")
+	cachedFeedKey = []byte("latest")
+)
+
+// cachedFeed is the last verified copy of the feed; retained for offline operation.
+type cachedFeed struct {
+	URL       string    `json:"url"`
+	Data      []byte    `json:"data"`
+	Signature []byte    `json:"signature"`
+	FetchedAt time.Time `json:"fetchedAt"`
+}
+
+// Service periodically matches the client versions of all enabled nodes against the signed vulnerabilities feed.
+type Service struct {
+	store         datastore.Store
+	nodes         node.NodeService
+	events        events.Publisher
+	interval      time.Duration
+	pubKeys       []string
+	fetch         func(ctx context.Context, url string) (data, sig []byte, err error)
+	clientVersion func(context.Context, node.ZethNode) (string, error)
+	now           func() time.Time
+
+	mu      sync.Mutex
+	cfg     Config
+	feed    *Feed
+	reports map[uuid.UUID]Report // latest report of each node
+}
+
+func NewService(store datastore.Store, nodes node.NodeService, publisher events.Publisher) *Service {
+	s := &Service{
+		store:    store,
+		nodes:    nodes,
+		events:   publisher,
+		interval: DefaultInterval,
+		pubKeys:  version.GethPubKeys,
+		fetch:    version.FetchFeed,
+		now:      time.Now,
+		reports:  make(map[uuid.UUID]Report),
+	}
+	s.clientVersion = s.queryClientVersion
+	return s
+}
+
+// Configure replaces the configuration; applied from the next check.
+func (s *Service) Configure(cfg Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.cfg = cfg
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *Service) config() Config {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cfg
+}
+
+// Start checks all enabled nodes periodically until the context is cancelled; checks are skipped while disabled.
+func (s *Service) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			if !s.config().Disabled {
+				if err := s.CheckAll(ctx); err != nil {
+					log.Err(err).Msg("failed to check nodes for vulnerabilities")
+				}
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// CheckAll loads the feed and checks all enabled nodes concurrently.
+func (s *Service) CheckAll(ctx context.Context) error {
+	vulns, err := s.loadFeed(ctx)
+	if err != nil {
+		return err
+	}
+
+	nodes, err := s.nodes.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	for _, n := range nodes {
+		if !n.Enabled {
+			continue
+		}
+		wg.Add(1)
+		go func(n node.ZethNode) {
+			defer wg.Done()
+			s.record(s.check(ctx, n, vulns))
+		}(n)
+	}
+	wg.Wait()
+	return nil
+}
+
+// loadFeed fetches and verifies the feed, caching the verified copy; the cached copy is used if the feed can not be fetched or verified.
+func (s *Service) loadFeed(ctx context.Context) ([]version.Vulnerability, error) {
+	url := s.config().feedURL()
+	feed := &Feed{URL: url}
+
+	vulns, fetchErr := s.fetchFeed(ctx, url)
+	if fetchErr == nil {
+		feed.FetchedAt = s.now().UTC()
+	} else {
+		feed.Cached = true
+		feed.Error = fetchErr.Error()
+
+		var cached *cachedFeed
+		var err error
+		if cached, err = s.cachedFeed(); err == nil {
+			if vulns, err = version.ParseFeed(s.pubKeys, cached.Data, cached.Signature); err == nil {
+				feed.FetchedAt = cached.FetchedAt
+			}
+		}
+		if err != nil {
+			s.setFeed(feed)
+			return nil, fmt.Errorf("failed to load vulnerabilities feed: %w", fetchErr)
+		}
+		log.Warn().Err(fetchErr).Msg("failed to fetch vulnerabilities feed; using cached copy")
+	}
+
+	feed.Vulnerabilities = len(vulns)
+	s.setFeed(feed)
+	return vulns, nil
+}
+
+// fetchFeed fetches and verifies the feed; the verified copy replaces the cached copy.
+func (s *Service) fetchFeed(ctx context.Context, url string) ([]version.Vulnerability, error) {
+	data, sig, err := s.fetch(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	vulns, err := version.ParseFeed(s.pubKeys, data, sig)
+	if err != nil {
+		return nil, err
+	}
+
+	bodyBytes := new(bytes.Buffer)
+	json.NewEncoder(bodyBytes).Encode(cachedFeed{URL: url, Data: data, Signature: sig, FetchedAt: s.now().UTC()})
+	if err := s.store.Set(feedKey, cachedFeedKey, bodyBytes.Bytes()); err != nil {
+		log.Err(err).Msg("failed to cache vulnerabilities feed")
+	}
+
+	return vulns, nil
+}
+
+func (s *Service) cachedFeed() (*cachedFeed, error) {
+	b, err := s.store.Get(feedKey, cachedFeedKey)
+	if err != nil {
+		return nil, err
+	}
+	var cached cachedFeed
+	if err := json.Unmarshal(b, &cached); err != nil {
+		return nil, err
+	}
+	return &cached, nil
+}
+
+func (s *Service) setFeed(feed *Feed) {
+	s.mu.Lock()
+	s.feed = feed
+	s.mu.Unlock()
+}
+
+// check matches the client version of the node against the vulnerabilities;
+// the discovered client version is used if the node can not be queried.
+func (s *Service) check(ctx context.Context, n node.ZethNode, vulns []version.Vulnerability) Report {
+	report := Report{NodeID: n.ID, Vulnerabilities: []version.Vulnerability{}, CheckedAt: s.now().UTC()}
+
+	clientVersion, err := s.clientVersion(ctx, n)
+	if err != nil {
+		report.Error = err.Error()
+		if n.Chain == nil || n.Chain.ClientVersion == "" {
+			return report
+		}
+		clientVersion = n.Chain.ClientVersion
+	}
+	report.ClientVersion = clientVersion
+
+	matches, err := version.Match(vulns, clientVersion)
+	if err != nil {
+		report.Error = err.Error()
+		return report
+	}
+	report.Vulnerabilities = matches
+	return report
+}
+
+func (s *Service) queryClientVersion(ctx context.Context, n node.ZethNode) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	transport, err := s.nodes.Transport(n)
+	if err != nil {
+		return "", err
+	}
+	c, err := n.DialRPCWithTransport(transport)
+	if err != nil {
+		return "", err
+	}
+	defer c.Close()
+
+	var clientVersion string
+	err = c.CallContext(ctx, &clientVersion, "web3_clientVersion")
+	return clientVersion, err
+}
+
+// record stores the report of the node; publishing an event if vulnerabilities were found which were not reported previously.
+func (s *Service) record(report Report) {
+	s.mu.Lock()
+	prev := s.reports[report.NodeID]
+	s.reports[report.NodeID] = report
+	s.mu.Unlock()
+
+	if len(report.newFindings(prev)) > 0 {
+		s.events.Publish(events.NodeVulnerable, report.NodeID.String(), report)
+	}
+}
+
+// Feed returns the status of the feed of the last check; nil if no check was run.
+func (s *Service) Feed() *Feed {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.feed == nil {
+		return nil
+	}
+	feed := *s.feed
+	return &feed
+}
+
+// Report returns the latest report of the node; nil if the node has not been checked.
+func (s *Service) Report(id uuid.UUID) *Report {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	report, ok := s.reports[id]
+	if !ok {
+		return nil
+	}
+	return &report
+}
+
+// Reports returns the latest report of all checked nodes; vulnerable nodes first.
+func (s *Service) Reports() []Report {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reports := make([]Report, 0, len(s.reports))
+	for _, report := range s.reports {
+		reports = append(reports, report)
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].Vulnerable() != reports[j].Vulnerable() {
+			return reports[i].Vulnerable()
+		}
+		return reports[i].NodeID.String() < reports[j].NodeID.String()
+	})
+	return reports
+}
+
+// Remove forgets the report of the node.
+func (s *Service) Remove(id uuid.UUID) {
+	s.mu.Lock()
+	delete(s.reports, id)
+	s.mu.Unlock()
+}
@@ -0,0 +1,170 @@
+package vulnerability
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zees-dev/zeth/pkg/datastore/badgerdbtest"
+	"github.com/zees-dev/zeth/pkg/events"
+	"github.com/zees-dev/zeth/pkg/geth/version"
+	"github.com/zees-dev/zeth/pkg/node"
+)
+
+// testSigner signs feeds in the minisign format.
+type testSigner struct {
+	keyID [8]byte
+	pub   ed25519.PublicKey
+	priv  ed25519.PrivateKey
+}
+
+func newTestSigner(t *testing.T) testSigner {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testSigner{keyID: [8]byte{1, 2, 3, 4, 5, 6, 7, 8}, pub: pub, priv: priv}
+}
+
+func (s testSigner) publicKey() string {
+	b := append(append([]byte("Ed"), s.keyID[:]...), s.pub...)
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+func (s testSigner) sign(data []byte) []byte {
+	sig := ed25519.Sign(s.priv, data)
+	trusted := "timestamp:1638316800"
+	global := ed25519.Sign(s.priv, append(append([]byte{}, sig...), trusted...))
+	return []byte("untrusted comment: signature from test key\n" +
+		base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), s.keyID[:]...), sig...)) + "\n" +
+		"trusted comment: " + trusted + "\n" +
+		base64.StdEncoding.EncodeToString(global))
+}
+
+var testFeed = []version.Vulnerability{
+	{Name: "Shallow copy bug", Uid: "GETH-2021-02", Severity: "High", CVE: "CVE-2021-39137", Introduced: "v1.10.0", Fixed: "v1.10.8", Check: `Geth\/v1\.10\.(0|1|2|3|4|5|6|7)-.*$`},
+	{Name: "DoS via malicious snap/1 request", Uid: "GETH-2021-03", Severity: "Medium", CVE: "CVE-2021-41173", Introduced: "v1.10.0", Fixed: "v1.10.9", Check: `Geth\/v1\.10\.(0|1|2|3|4|5|6|7|8)-.*$`},
+}
+
+func Test_Config(t *testing.T) {
+	is := assert.New(t)
+
+	is.NoError(Config{}.Validate())
+	is.NoError(Config{FeedURL: "file:///var/lib/zeth/vulnerabilities.json"}.Validate())
+	is.Error(Config{FeedURL: "ftp://example.com/vulnerabilities.json"}.Validate())
+	is.Equal(version.VersionCheckUrl, Config{}.feedURL())
+}
+
+func Test_CheckAll(t *testing.T) {
+	is := assert.New(t)
+
+	store, cleanup := badgerdbtest.MustNewTestBadgerDB()
+	defer cleanup()
+
+	bus := events.NewBus(events.DefaultBacklog)
+	sub := make(chan events.Event, 10)
+	_, unsubscribe := bus.Subscribe(sub, 0, []string{string(events.NodeVulnerable)})
+	defer unsubscribe()
+
+	nodes := node.NewService(store, bus)
+	ctx := context.Background()
+
+	vulnerable, err := nodes.Create(ctx, node.ZethNode{Name: "vulnerable", Enabled: true})
+	is.NoError(err)
+	patched, err := nodes.Create(ctx, node.ZethNode{Name: "patched", Enabled: true})
+	is.NoError(err)
+	offline, err := nodes.Create(ctx, node.ZethNode{Name: "offline", Enabled: true, Chain: &node.ChainMetadata{ClientVersion: "Geth/v1.10.8-stable/linux-amd64/go1.16"}})
+	is.NoError(err)
+
+	signer := newTestSigner(t)
+	data, _ := json.Marshal(testFeed)
+	sig := signer.sign(data)
+
+	s := NewService(store, nodes, bus)
+	s.pubKeys = []string{signer.publicKey()}
+	s.fetch = func(ctx context.Context, url string) ([]byte, []byte, error) { return data, sig, nil }
+	s.clientVersion = func(_ context.Context, n node.ZethNode) (string, error) {
+		switch n.ID {
+		case vulnerable.ID:
+			return "Geth/v1.10.3-stable-991384a7/linux-amd64/go1.16.3", nil
+		case patched.ID:
+			return "Geth/v1.10.11-stable/linux-amd64/go1.17", nil
+		}
+		return "", errors.New("connection refused")
+	}
+
+	is.NoError(s.CheckAll(ctx))
+
+	feed := s.Feed()
+	is.False(feed.Cached)
+	is.Equal(2, feed.Vulnerabilities)
+
+	report := s.Report(vulnerable.ID)
+	is.Len(report.Vulnerabilities, 2)
+	is.Equal("CVE-2021-39137", report.Vulnerabilities[0].CVE)
+	is.Equal("v1.10.8", report.Vulnerabilities[0].Fixed)
+	is.Empty(s.Report(patched.ID).Vulnerabilities)
+
+	// the discovered client version is used if the node can not be queried
+	report = s.Report(offline.ID)
+	is.Equal("connection refused", report.Error)
+	is.Len(report.Vulnerabilities, 1)
+	is.Equal("GETH-2021-03", report.Vulnerabilities[0].Uid)
+
+	reports := s.Reports()
+	is.Len(reports, 3)
+	is.True(reports[0].Vulnerable())
+	is.False(reports[2].Vulnerable())
+
+	published := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		select {
+		case ev := <-sub:
+			published[ev.Subject] = true
+		case <-time.After(time.Second):
+			t.Fatal("expected node.vulnerable event")
+		}
+	}
+	is.True(published[vulnerable.ID.String()])
+	is.True(published[offline.ID.String()])
+
+	// known findings are not published again; the cached copy is used while offline
+	s.fetch = func(ctx context.Context, url string) ([]byte, []byte, error) {
+		return nil, nil, errors.New("no route to host")
+	}
+	is.NoError(s.CheckAll(ctx))
+	is.True(s.Feed().Cached)
+	is.Len(s.Report(vulnerable.ID).Vulnerabilities, 2)
+	select {
+	case ev := <-sub:
+		t.Fatalf("unexpected event: %v", ev)
+	default:
+	}
+
+	s.Remove(vulnerable.ID)
+	is.Nil(s.Report(vulnerable.ID))
+}
+
+func Test_UntrustedFeed(t *testing.T) {
+	is := assert.New(t)
+
+	store, cleanup := badgerdbtest.MustNewTestBadgerDB()
+	defer cleanup()
+
+	bus := events.NewBus(events.DefaultBacklog)
+	s := NewService(store, node.NewService(store, bus), bus)
+
+	// signed by a key which is not trusted; no cached copy to fall back to
+	signer := newTestSigner(t)
+	data, _ := json.Marshal(testFeed)
+	sig := signer.sign(data)
+	s.fetch = func(ctx context.Context, url string) ([]byte, []byte, error) { return data, sig, nil }
+
+	is.Error(s.CheckAll(context.Background()))
+	is.NotEmpty(s.Feed().Error)
+}
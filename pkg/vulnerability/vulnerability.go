@@ -0,0 +1,80 @@
+package vulnerability
+
+import (
+	"errors"
+	"net/url"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/zees-dev/zeth/pkg/geth/version"
+)
+
+// DefaultInterval is the interval nodes are checked against the vulnerabilities feed.
+const DefaultInterval = time.Hour
+
+// Config configures checking the client versions of nodes against the signed geth vulnerabilities feed.
+type Config struct {
+	Disabled bool `json:"disabled"`
+	// FeedURL is the url of the feed; its signature is fetched from <url>.minisig. Defaults to the geth feed.
+	// file:// urls are supported, e.g. for a local copy of the feed and its signature
+	FeedURL string `json:"feedUrl"`
+}
+
+// Validate returns an error if the feed url is malformed.
+func (cfg Config) Validate() error {
+	if cfg.FeedURL == "" {
+		return nil
+	}
+	u, err := url.Parse(cfg.FeedURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "file") {
+		return errors.New("feedUrl must be a http(s) or file url")
+	}
+	return nil
+}
+
+// feedURL returns the url of the feed; the geth feed if not configured.
+func (cfg Config) feedURL() string {
+	if cfg.FeedURL == "" {
+		return version.VersionCheckUrl
+	}
+	return cfg.FeedURL
+}
+
+// Feed is the status of the vulnerabilities feed of the last check.
+type Feed struct {
+	URL             string    `json:"url"`
+	Vulnerabilities int       `json:"vulnerabilities"` // number of entries of the feed
+	FetchedAt       time.Time `json:"fetchedAt"`
+	// Cached is set if the feed could not be fetched and the locally cached copy was used
+	Cached bool   `json:"cached"`
+	Error  string `json:"error,omitempty"` // failure to fetch or verify the feed
+}
+
+// Report is the result of checking the client version of a node against the feed.
+type Report struct {
+	NodeID          uuid.UUID               `json:"nodeId"`
+	ClientVersion   string                  `json:"clientVersion"` // web3_clientVersion
+	Vulnerabilities []version.Vulnerability `json:"vulnerabilities"`
+	CheckedAt       time.Time               `json:"checkedAt"`
+	Error           string                  `json:"error,omitempty"` // failure to query the client version
+}
+
+// Vulnerable returns whether the client version of the node has known vulnerabilities.
+func (r Report) Vulnerable() bool {
+	return len(r.Vulnerabilities) > 0
+}
+
+// newFindings returns the vulnerabilities of the report which were not reported previously.
+func (r Report) newFindings(prev Report) []version.Vulnerability {
+	known := map[string]bool{}
+	for _, v := range prev.Vulnerabilities {
+		known[v.Uid] = true
+	}
+	findings := []version.Vulnerability{}
+	for _, v := range r.Vulnerabilities {
+		if !known[v.Uid] {
+			findings = append(findings, v)
+		}
+	}
+	return findings
+}
@@ -0,0 +1,15 @@
+// Package memsizeui replaces github.com/fjl/memsize/memsizeui, which is only linked by go-ethereum's debug handler.
+// memsize references runtime.stopTheWorld, which go versions since 1.23 refuse to link; heap reports are not available.
+package memsizeui
+
+import "net/http"
+
+// Handler serves memory usage reports of the added roots; no reports are available.
+type Handler struct{}
+
+// Add adds a root to the handler; ignored.
+func (h *Handler) Add(name string, v interface{}) {}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, "memsize is not available", http.StatusNotFound)
+}